@@ -0,0 +1,49 @@
+#!/usr/bin/env bash
+# Exercises stak's local git workflows end to end, without GitHub
+set -euo pipefail
+
+stak="$1"
+work="$(mktemp -d)"
+cd "$work"
+
+git init -q --bare remote.git
+git init -q -b main repo
+cd repo
+git config user.name "stak smoke"
+git config user.email "smoke@example.com"
+git remote add origin ../remote.git
+echo base > file.txt
+git add file.txt
+git commit -qm base
+git push -q origin main
+
+# A two-branch stack
+git checkout -q -b first
+echo first > first.txt
+git add first.txt
+git commit -qm first
+"$stak" track --parent main
+git checkout -q -b second
+echo second > second.txt
+git add second.txt
+git commit -qm second
+"$stak" track --parent first
+"$stak" list
+
+# Rewrite the bottom branch so the top one needs a restack, which conflicts
+git checkout -q first
+echo changed > second.txt
+git add second.txt
+git commit -qm conflict
+git checkout -q second
+if "$stak" restack; then
+  echo "expected a conflict" >&2
+  exit 1
+fi
+echo second > second.txt
+git add second.txt
+"$stak" restack --continue
+git merge-base --is-ancestor first second
+
+"$stak" push
+git ls-remote --exit-code origin refs/heads/first refs/heads/second
@@ -11,6 +11,8 @@ import (
 	"stacking/internal/ui"
 )
 
+var absorbForce bool
+
 var absorbCmd = &cobra.Command{
 	Use:     "absorb",
 	Aliases: []string{"ab"},
@@ -18,20 +20,20 @@ var absorbCmd = &cobra.Command{
 	Long:    `Automatically determine which commits staged changes belong to and amend them appropriately. Requires git-absorb to be installed.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runAbsorb(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
+	absorbCmd.Flags().BoolVar(&absorbForce, "force", false, "Absorb even if the branch is owned by someone else")
 	rootCmd.AddCommand(absorbCmd)
 }
 
 func runAbsorb() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -58,6 +60,10 @@ func runAbsorb() error {
 		return fmt.Errorf("no staged changes to absorb")
 	}
 
+	if err := checkStackOwnership("rewrite and force-push", []string{currentBranch}, absorbForce); err != nil {
+		return err
+	}
+
 	// Check if git-absorb is installed
 	checkCmd := exec.Command("git", "absorb", "--version")
 	if err := checkCmd.Run(); err != nil {
@@ -73,6 +79,19 @@ func runAbsorb() error {
 		return fmt.Errorf("git-absorb is required for this command")
 	}
 
+	// git-absorb is built on libgit2, which can't read a sparse index
+	if git.IsSparseIndex() {
+		ui.Info("Expanding the sparse index for git absorb")
+		if err := git.SetSparseIndex(false); err != nil {
+			return err
+		}
+		defer func() {
+			if err := git.SetSparseIndex(true); err != nil {
+				ui.Warning(err.Error())
+			}
+		}()
+	}
+
 	ui.Info("Running git absorb to distribute staged changes")
 
 	// Run git absorb
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"stacking/internal/git"
+	"stacking/pkg/models"
+)
+
+// branchSync is how far a branch has moved from its parent and from its copy on the remote
+type branchSync struct {
+	ahead, behind             int // Commits the branch has that its parent lacks, and the other way round
+	remoteAhead, remoteBehind int // The same against its copy on the remote, as of the last fetch
+}
+
+// getBranchSync compares a branch with its parent and its copy on the remote. A branch never
+// pushed counts as level with the remote.
+func getBranchSync(branch *models.Branch) branchSync {
+	var state branchSync
+	if branch.Parent != "" {
+		state.ahead, state.behind, _ = git.AheadBehind(branch.Parent, branch.Name)
+	}
+	if git.RemoteTrackingBranchExists(branch.Name) {
+		state.remoteAhead, state.remoteBehind, _ = git.AheadBehind("refs/remotes/"+git.RemoteRef(branch.Name), branch.Name)
+	}
+	return state
+}
+
+// needsRestack reports whether the parent has commits the branch isn't based on, i.e. their
+// merge base isn't the parent's tip
+func (s branchSync) needsRestack() bool {
+	return s.behind > 0
+}
+
+// needsPush reports whether the remote lacks commits of the branch, so its PR is outdated
+func (s branchSync) needsPush() bool {
+	return s.remoteAhead > 0
+}
+
+// String describes the counts, e.g. "↑2 ↓1 · origin ↑1 (needs restack, needs push)", so stale
+// and diverged branches stand out. Counts of zero are left out.
+func (s branchSync) String() string {
+	var parts []string
+	if counts := formatAheadBehind(s.ahead, s.behind); counts != "" {
+		parts = append(parts, counts)
+	}
+	if counts := formatAheadBehind(s.remoteAhead, s.remoteBehind); counts != "" {
+		parts = append(parts, git.Remote+" "+counts)
+	}
+	description := strings.Join(parts, " · ")
+
+	var badges []string
+	if s.needsRestack() {
+		badges = append(badges, "needs restack")
+	}
+	if s.needsPush() {
+		badges = append(badges, "needs push")
+	}
+	if len(badges) > 0 {
+		description += fmt.Sprintf(" (%s)", strings.Join(badges, ", "))
+	}
+	return description
+}
+
+// aheadBehind describes how a branch compares with its parent and its copy on the remote
+func aheadBehind(branch *models.Branch) string {
+	return getBranchSync(branch).String()
+}
+
+func formatAheadBehind(ahead, behind int) string {
+	var counts []string
+	if ahead > 0 {
+		counts = append(counts, fmt.Sprintf("↑%d", ahead))
+	}
+	if behind > 0 {
+		counts = append(counts, fmt.Sprintf("↓%d", behind))
+	}
+	return strings.Join(counts, " ")
+}
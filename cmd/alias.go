@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"stacking/internal/config"
+	"stacking/internal/git"
+)
+
+// isBuiltinCommand reports whether name is a command or command alias of stak itself
+func isBuiltinCommand(name string) bool {
+	// help and completion are only added to the root when it executes
+	rootCmd.InitDefaultHelpCmd()
+	rootCmd.InitDefaultCompletionCmd()
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandAlias replaces a user-defined alias at the start of args with the arguments it stands
+// for, like git aliases. Aliases can use other aliases, but never shadow built-in commands.
+func expandAlias(args []string) ([]string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(args[0]) {
+		return args, nil
+	}
+	aliases := config.Aliases()
+	if len(aliases) == 0 {
+		return args, nil
+	}
+
+	chain := []string{}
+	for {
+		name := args[0]
+		value, ok := aliases[name]
+		if !ok || isBuiltinCommand(name) {
+			return args, nil
+		}
+		chain = append(chain, name)
+		if contains(chain[:len(chain)-1], name) {
+			return nil, fmt.Errorf("alias loop: %s", strings.Join(chain, " → "))
+		}
+
+		expansion, err := git.SplitArgs(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alias %s: %w", name, err)
+		}
+		if len(expansion) == 0 {
+			return nil, fmt.Errorf("alias %s is empty", name)
+		}
+		args = append(expansion, args[1:]...)
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestExpandAlias(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Chdir(t.TempDir())
+	runGit(t, "init", "--quiet")
+	for name, value := range map[string]string{
+		"ss":    "submit --stack",
+		"msg":   `modify -m "fix typo"`,
+		"outer": "inner --draft",
+		"inner": "ss",
+		"ping":  "pong",
+		"pong":  "ping",
+		"empty": "",
+		"bad":   `log "unterminated`,
+		"list":  "log", // A built-in command is never shadowed
+	} {
+		runGit(t, "config", "stack.aliases."+name, value)
+	}
+
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{args: nil, want: nil},
+		{args: []string{"sync"}, want: []string{"sync"}},
+		{args: []string{"unknown", "x"}, want: []string{"unknown", "x"}},
+		{args: []string{"--profile", "ss"}, want: []string{"--profile", "ss"}},
+		{args: []string{"list"}, want: []string{"list"}},
+		{args: []string{"ss", "payments"}, want: []string{"submit", "--stack", "payments"}},
+		{args: []string{"msg"}, want: []string{"modify", "-m", "fix typo"}},
+		{args: []string{"outer", "payments"}, want: []string{"submit", "--stack", "--draft", "payments"}},
+		{args: []string{"ping"}, wantErr: true},
+		{args: []string{"empty"}, wantErr: true},
+		{args: []string{"bad"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := expandAlias(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expandAlias(%q) = %q, want an error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandAlias(%q) failed: %v", tt.args, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandAlias(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// runGit runs a git command for a test's setup, failing the test if it fails
+func runGit(t *testing.T, args ...string) {
+	t.Helper()
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/archive"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	archiveForce    bool
+	archiveNoGitHub bool
+)
+
+var archiveCmd = &cobra.Command{
+	Use:     "archive [branch]",
+	Aliases: []string{"ar"},
+	Short:   "Archive a fully merged stack",
+	Long: `Snapshot the stack containing the current (or given) branch and remove its stack metadata.
+
+The snapshot records each branch's parent, final commit, PR number and when the PR was opened
+and merged. Final commits are kept under refs/stak/archive/<id>/, so they can still be inspected
+after the branches are deleted. Local branches themselves are left alone.
+
+Every PR in the stack must be merged; use --force to archive an abandoned stack anyway.
+Use 'stak archive list' and 'stak archive show <id>' to look at archived stacks.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		branch := ""
+		if len(args) > 0 {
+			branch = args[0]
+		}
+		if err := runArchive(branch); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var archiveListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List archived stacks",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runArchiveList(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var archiveShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the branches, commits and PRs of an archived stack",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runArchiveShow(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	archiveCmd.Flags().BoolVar(&archiveForce, "force", false, "Archive even if some PRs are not merged")
+	archiveCmd.Flags().BoolVar(&archiveNoGitHub, "no-github", false, "Don't look up PRs (implies --force)")
+	archiveCmd.AddCommand(archiveListCmd)
+	archiveCmd.AddCommand(archiveShowCmd)
+	rootCmd.AddCommand(archiveCmd)
+}
+
+func runArchive(branchName string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	if branchName == "" {
+		var err error
+		branchName, err = git.GetCurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	if !ctx.IsTracked(branchName) {
+		return fmt.Errorf("branch %s is not part of a stack", branchName)
+	}
+
+	root := stackRoot(ctx, branchName)
+	snapshot := &archive.Stack{Base: ctx.Parent(root), ArchivedAt: time.Now()}
+	var unmerged []string
+	for _, branch := range append([]string{root}, ctx.Descendants(root)...) {
+		b := archive.Branch{Name: branch, Parent: ctx.Parent(branch), PRNumber: ctx.PRNumber(branch)}
+		// Sync may already have deleted the local branch
+		b.SHA, _ = git.GetCommitSHA(branch)
+
+		if !archiveNoGitHub {
+			if b.PRNumber == 0 {
+				unmerged = append(unmerged, fmt.Sprintf("%s (no PR)", branch))
+			} else if timeline, err := github.GetPRTimeline(b.PRNumber); err != nil {
+				unmerged = append(unmerged, fmt.Sprintf("%s (PR #%d: %v)", branch, b.PRNumber, err))
+			} else {
+				b.PRState = timeline.State
+				b.OpenedAt = timeline.CreatedAt
+				if timeline.State == "MERGED" {
+					b.MergedAt = timeline.ClosedAt
+				} else {
+					unmerged = append(unmerged, fmt.Sprintf("%s (PR #%d is %s)", branch, b.PRNumber, strings.ToLower(timeline.State)))
+				}
+			}
+		}
+		snapshot.Branches = append(snapshot.Branches, b)
+	}
+
+	if len(unmerged) > 0 && !archiveForce {
+		ui.Error("The stack has not fully landed:")
+		for _, reason := range unmerged {
+			fmt.Printf("  - %s\n", reason)
+		}
+		return fmt.Errorf("not archiving %s. Use --force to archive it anyway", root)
+	}
+
+	if err := archive.Add(snapshot); err != nil {
+		return err
+	}
+	for _, b := range snapshot.Branches {
+		if err := stack.DeleteBranchMetadata(b.Name); err != nil {
+			ui.Warning(fmt.Sprintf("Could not delete metadata for %s: %v", b.Name, err))
+		}
+	}
+
+	ui.Success(fmt.Sprintf("Archived %d branch(es) as %s", len(snapshot.Branches), snapshot.ID))
+	fmt.Printf("\nInspect it with: stak archive show %s\n", snapshot.ID)
+	return nil
+}
+
+func runArchiveList() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	stacks, err := archive.List()
+	if err != nil {
+		return err
+	}
+	if len(stacks) == 0 {
+		fmt.Println("No archived stacks.")
+		return nil
+	}
+
+	for _, s := range stacks {
+		line := fmt.Sprintf("%-30s %s  %d branch(es) on %s", s.ID, s.ArchivedAt.Format("2006-01-02"), len(s.Branches), s.Base)
+		if d := s.Duration(); d > 0 {
+			line += ", landed in " + formatDuration(int64(d.Seconds()))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runArchiveShow(id string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	s, err := archive.Get(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Stack %s\n", s.ID)
+	fmt.Printf("  Base:     %s\n", s.Base)
+	fmt.Printf("  Archived: %s\n", s.ArchivedAt.Format("2006-01-02 15:04:05"))
+	if d := s.Duration(); d > 0 {
+		fmt.Printf("  Landed:   %s after the first PR was opened\n", formatDuration(int64(d.Seconds())))
+	}
+
+	for _, b := range s.Branches {
+		fmt.Printf("\n  %s (%s)\n", b.Name, b.Parent)
+		if b.SHA != "" {
+			fmt.Printf("    Commit: %s (%s)\n", b.SHA[:min(len(b.SHA), 12)], s.Ref(b.Name))
+		}
+		if b.PRNumber == 0 {
+			continue
+		}
+		pr := fmt.Sprintf("    PR #%d", b.PRNumber)
+		if b.PRState != "" {
+			pr += " " + strings.ToLower(b.PRState)
+		}
+		if !b.OpenedAt.IsZero() {
+			pr += ", opened " + b.OpenedAt.Local().Format("2006-01-02")
+		}
+		if !b.MergedAt.IsZero() {
+			pr += ", merged " + b.MergedAt.Local().Format("2006-01-02")
+		}
+		fmt.Println(pr)
+	}
+	return nil
+}
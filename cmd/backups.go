@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/ui"
+)
+
+var backupsPruneDays int
+
+var backupsCmd = &cobra.Command{
+	Use:     "backups",
+	Aliases: []string{"bk"},
+	Short:   "List and restore branch heads saved before destructive operations",
+	Long: `Before stak rebases, resets, deletes or force-pushes a branch, it saves the branch's head
+under refs/stak/backup/<timestamp>/<branch>, one timestamp per stak command. A force-push
+also saves the remote head it replaces, as <branch>@origin.
+
+Use 'stak backups list' to see them and 'stak backups restore <branch>' to put a branch back.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runBackupsList(""); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:   "list [branch]",
+	Short: "List saved branch heads, newest first",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		branch := ""
+		if len(args) > 0 {
+			branch = args[0]
+		}
+		if err := runBackupsList(branch); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore <branch> [<timestamp>]",
+	Short: "Point a branch back at a saved head",
+	Long: `Point a branch back at its most recent backup, or the one taken at the given timestamp
+(as shown by 'stak backups list'). The branch is recreated if it was deleted. If it is
+checked out, the working tree is reset too, so it must be clean.
+
+The branch's current head is backed up first, so a restore can itself be undone.
+Stack metadata is not restored; use 'stak move' if the parent changed.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		stamp := ""
+		if len(args) > 1 {
+			stamp = args[1]
+		}
+		if err := runBackupsRestore(args[0], stamp); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var backupsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old backups",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runBackupsPrune(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	backupsPruneCmd.Flags().IntVar(&backupsPruneDays, "days", 90, "Delete backups older than this many days")
+	backupsCmd.AddCommand(backupsListCmd)
+	backupsCmd.AddCommand(backupsRestoreCmd)
+	backupsCmd.AddCommand(backupsPruneCmd)
+	rootCmd.AddCommand(backupsCmd)
+}
+
+func runBackupsList(branch string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	backups, err := git.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, b := range backups {
+		if branch != "" && b.Branch != branch {
+			continue
+		}
+		found = true
+		name := b.Branch
+		if b.Remote != "" {
+			name += fmt.Sprintf(" (%s, before force-push)", b.Remote)
+		}
+		fmt.Printf("%s  %s  %s\n", b.Stamp, b.SHA[:min(len(b.SHA), 8)], name)
+	}
+	if !found {
+		fmt.Println("No backups.")
+	}
+	return nil
+}
+
+func runBackupsRestore(branch, stamp string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	backups, err := git.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	var backup *git.Backup
+	for i, b := range backups {
+		if b.Branch != branch || (stamp != "" && b.Stamp != stamp) {
+			continue
+		}
+		// Prefer the local head over the remote one saved in the same command
+		if backup == nil || (backup.Stamp == b.Stamp && backup.Remote != "" && b.Remote == "") {
+			backup = &backups[i]
+		}
+	}
+	if backup == nil {
+		if stamp != "" {
+			return fmt.Errorf("no backup of %s at %s", branch, stamp)
+		}
+		return fmt.Errorf("no backups of %s", branch)
+	}
+
+	if currentBranch, _ := git.GetCurrentBranch(); currentBranch == branch {
+		dirty, err := git.HasUncommittedChanges()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("cannot restore the checked-out branch %s: %w", branch, git.ErrDirtyTree)
+		}
+	}
+
+	if err := git.RestoreBranch(branch, backup.SHA); err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Restored %s to %s (backup %s)", branch, backup.SHA[:min(len(backup.SHA), 8)], backup.Stamp))
+	fmt.Println("Stack metadata was not changed. Run 'stak push' to update the remote branch.")
+	return nil
+}
+
+func runBackupsPrune() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	backups, err := git.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -backupsPruneDays)
+	pruned := 0
+	for _, b := range backups {
+		if !b.Time.Before(cutoff) {
+			continue
+		}
+		if err := git.DeleteBackup(b); err != nil {
+			return err
+		}
+		pruned++
+	}
+	ui.Success(fmt.Sprintf("Deleted %d backup(s) older than %d days", pruned, backupsPruneDays))
+	return nil
+}
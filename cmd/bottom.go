@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -18,8 +17,7 @@ var bottomCmd = &cobra.Command{
 	Long:    `Navigate to the bottommost branch in the current stack by following the child chain. If multiple children exist at any level, shows a menu to select one.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runBottom(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -31,7 +29,7 @@ func init() {
 func runBottom() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -63,7 +61,7 @@ func runBottom() error {
 				Items: children,
 			}
 
-			_, result, err := prompt.Run()
+			_, result, err := runSelect(&prompt)
 			if err != nil {
 				return fmt.Errorf("branch selection cancelled: %w", err)
 			}
@@ -84,5 +82,6 @@ func runBottom() error {
 	}
 
 	ui.Success(fmt.Sprintf("Now on branch %s (bottom of stack)", bottomBranch))
+	printStackMap(bottomBranch)
 	return nil
 }
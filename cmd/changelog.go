@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	changelogTitle    string
+	changelogPRTitles bool
+	changelogGroup    bool
+)
+
+var changelogCmd = &cobra.Command{
+	Use:     "changelog",
+	Aliases: []string{"cl"},
+	Short:   "Generate a changelog section for the current stack",
+	Long: `Print a Markdown changelog section built from the commit subjects of the current stack,
+from the bottom branch to the top. Entries of branches with a PR link the PR number.
+
+With --pr-titles, uses one entry per branch with its PR title instead (branches without a PR
+fall back to their commit subjects). With --group, entries are grouped by conventional-commit
+type (feat, fix, perf, ...), e.g. "feat(api): add paging" is listed under Features as
+"**api:** add paging". Breaking changes ("feat!:") are marked.
+
+Run from trunk, it covers every stack based on trunk.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runChangelog(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogTitle, "title", "Unreleased", "Heading of the changelog section")
+	changelogCmd.Flags().BoolVar(&changelogPRTitles, "pr-titles", false, "Use PR titles instead of commit subjects")
+	changelogCmd.Flags().BoolVar(&changelogGroup, "group", false, "Group entries by conventional-commit type")
+	rootCmd.AddCommand(changelogCmd)
+}
+
+// conventionalCommit matches "type(scope)!: description"
+var conventionalCommit = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// changelogGroups are the conventional-commit types in the order their sections appear.
+// Types not listed here go under "Other Changes".
+var changelogGroups = []struct {
+	types   []string
+	heading string
+}{
+	{[]string{"feat"}, "Features"},
+	{[]string{"fix"}, "Bug Fixes"},
+	{[]string{"perf"}, "Performance"},
+	{[]string{"refactor"}, "Refactoring"},
+	{[]string{"docs"}, "Documentation"},
+	{[]string{"test"}, "Tests"},
+	{[]string{"build", "ci"}, "Build and CI"},
+	{[]string{"chore", "style"}, "Chores"},
+	{[]string{"revert"}, "Reverts"},
+}
+
+// changelogEntry is one line of the changelog, optionally followed by a description
+type changelogEntry struct {
+	text     string
+	body     string
+	prNumber int
+}
+
+func runChangelog() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	var roots []string
+	if ctx.IsTracked(currentBranch) {
+		roots = []string{stackRoot(ctx, currentBranch)}
+	} else {
+		roots = stacksBasedOn(ctx, currentBranch)
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+
+	var entries []changelogEntry
+	for _, root := range roots {
+		for _, branch := range append([]string{root}, ctx.Descendants(root)...) {
+			branchEntries, err := changelogEntries(branch, ctx.Parent(branch), ctx.PRNumber(branch))
+			if err != nil {
+				return err
+			}
+			entries = append(entries, branchEntries...)
+		}
+	}
+
+	fmt.Print(formatChangelog(changelogTitle, entries, changelogGroup))
+	return nil
+}
+
+// changelogEntries returns the entries of one branch: its PR title with --pr-titles, or
+// its commit subjects, oldest first
+func changelogEntries(branch, parent string, prNumber int) ([]changelogEntry, error) {
+	if changelogPRTitles && prNumber > 0 {
+		details, err := github.GetPRDetails(prNumber)
+		if err == nil {
+			return []changelogEntry{{text: details.Title, prNumber: prNumber}}, nil
+		}
+		ui.Warning(fmt.Sprintf("Could not get the title of PR #%d, using commit subjects: %v", prNumber, err))
+	}
+
+	subjects, err := git.GetCommitSubjects(parent, branch)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]changelogEntry, 0, len(subjects))
+	for _, subject := range subjects {
+		entries = append(entries, changelogEntry{text: subject, prNumber: prNumber})
+	}
+	return entries, nil
+}
+
+// formatChangelog renders entries as a Markdown section, grouped by conventional-commit type if asked
+func formatChangelog(title string, entries []changelogEntry, group bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", title)
+	if len(entries) == 0 {
+		b.WriteString("No changes.\n")
+		return b.String()
+	}
+
+	if !group {
+		for _, entry := range entries {
+			b.WriteString(formatChangelogLine(entry.text, entry.body, entry.prNumber))
+		}
+		return b.String()
+	}
+
+	sections := make(map[string][]string)
+	for _, entry := range entries {
+		heading, text := "Other Changes", entry.text
+		if m := conventionalCommit.FindStringSubmatch(entry.text); m != nil {
+			kind := strings.ToLower(m[1])
+			for _, g := range changelogGroups {
+				for _, t := range g.types {
+					if t == kind {
+						heading = g.heading
+					}
+				}
+			}
+			// Unknown types keep their prefix, since it may not be a type at all
+			if heading != "Other Changes" {
+				text = m[4]
+				if m[2] != "" {
+					text = fmt.Sprintf("**%s:** %s", m[2], text)
+				}
+			}
+			if m[3] != "" {
+				text = "**BREAKING:** " + text
+			}
+		}
+		sections[heading] = append(sections[heading], formatChangelogLine(text, entry.body, entry.prNumber))
+	}
+
+	headings := make([]string, 0, len(changelogGroups)+1)
+	for _, g := range changelogGroups {
+		headings = append(headings, g.heading)
+	}
+	headings = append(headings, "Other Changes")
+
+	first := true
+	for _, heading := range headings {
+		lines := sections[heading]
+		if len(lines) == 0 {
+			continue
+		}
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+		fmt.Fprintf(&b, "### %s\n\n", heading)
+		for _, line := range lines {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// formatChangelogLine renders one list item, with the description indented below it
+func formatChangelogLine(text, body string, prNumber int) string {
+	line := fmt.Sprintf("- %s\n", text)
+	if prNumber > 0 {
+		line = fmt.Sprintf("- %s (#%d)\n", text, prNumber)
+	}
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if body == "" {
+		return line
+	}
+	// A blank line keeps the description a separate paragraph of the item
+	line += "\n"
+	for _, bodyLine := range strings.Split(body, "\n") {
+		if strings.TrimSpace(bodyLine) == "" {
+			line += "\n"
+			continue
+		}
+		line += "  " + bodyLine + "\n"
+	}
+	return line
+}
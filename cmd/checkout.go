@@ -2,22 +2,31 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strconv"
 	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 	"stacking/internal/git"
+	"stacking/internal/github"
 	"stacking/internal/stack"
 	"stacking/internal/ui"
+	"stacking/pkg/models"
 )
 
 var checkoutCmd = &cobra.Command{
-	Use:     "checkout [branch]",
+	Use:     "checkout [branch|pr-number|pr-url]",
 	Aliases: []string{"co"},
 	Short:   "Smart checkout with branch context",
-	Long:    `Switch to a branch with context about its position in the stack. Shows an interactive menu with parent/children information if no branch is specified.`,
-	Args:    cobra.MaximumNArgs(1),
+	Long: `Switch to a branch with context about its position in the stack. If no branch is specified, shows an interactive
+menu of branches grouped by stack: each stack headed by its root, with branches indented by depth, then base and
+untracked branches.
+
+The branch can also be given as a PR number (123 or #123) or PR URL, in which case the PR's head
+branch is checked out. If it isn't a local branch yet, it is fetched and tracked with its stack,
+like stak get does.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeCheckoutBranch,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -25,8 +34,7 @@ var checkoutCmd = &cobra.Command{
 		}
 
 		if err := runCheckout(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -38,7 +46,7 @@ func init() {
 func runCheckout(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -47,6 +55,13 @@ func runCheckout(branchName string) error {
 		return fmt.Errorf("failed to get current branch: %w", err)
 	}
 
+	// A PR reference names the PR's branch, unless a branch is named like it
+	if prNumber, ok := github.ParsePRReference(branchName); ok {
+		if exists, _ := git.BranchExists(branchName); !exists {
+			return checkoutPR(prNumber, currentBranch)
+		}
+	}
+
 	// If branch name provided, checkout directly
 	if branchName != "" {
 		// Check if branch exists
@@ -68,6 +83,7 @@ func runCheckout(branchName string) error {
 		}
 
 		ui.Success(fmt.Sprintf("Switched to branch %s", branchName))
+		printStackMap(branchName)
 		return nil
 	}
 
@@ -75,6 +91,56 @@ func runCheckout(branchName string) error {
 	return selectBranchInteractive(currentBranch)
 }
 
+// checkoutPR switches to the head branch of a PR. A tracked branch recorded with the PR is
+// found without asking GitHub; a branch that isn't local yet is fetched by stak get.
+func checkoutPR(prNumber int, currentBranch string) error {
+	branchName := ""
+	if branches, err := stack.GetAllStackBranches(); err == nil {
+		for _, branch := range branches {
+			if metadata, err := stack.ReadBranchMetadata(branch); err == nil && metadata.PRNumber == prNumber {
+				branchName = branch
+				break
+			}
+		}
+	}
+
+	if branchName == "" {
+		if !github.IsGHAuthenticated() {
+			return fmt.Errorf("no tracked branch has PR #%d, and looking it up needs gh: %w", prNumber, errNotAuthenticated)
+		}
+		details, err := github.GetPRDetails(prNumber)
+		if err != nil {
+			return fmt.Errorf("failed to look up PR #%d: %w", prNumber, err)
+		}
+		if details.IsCrossRepo {
+			// A fork's branch name says nothing about the local branch of that name; get
+			// fetches its head into a branch of its own
+			return runGet(strconv.Itoa(prNumber))
+		}
+		branchName = details.HeadRefName
+		ui.Info(fmt.Sprintf("PR #%d is branch %s", prNumber, branchName))
+
+		exists, err := git.BranchExists(branchName)
+		if err != nil {
+			return fmt.Errorf("failed to check if branch exists: %w", err)
+		}
+		if !exists {
+			return runGet(strconv.Itoa(prNumber))
+		}
+	}
+
+	if branchName == currentBranch {
+		ui.Info(fmt.Sprintf("Already on branch %s (PR #%d)", branchName, prNumber))
+		return nil
+	}
+	if err := git.CheckoutBranch(branchName); err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %w", branchName, err)
+	}
+	ui.Success(fmt.Sprintf("Switched to branch %s (PR #%d)", branchName, prNumber))
+	printStackMap(branchName)
+	return nil
+}
+
 func selectBranchInteractive(currentBranch string) error {
 	// Get all local branches
 	allBranches, err := git.GetAllLocalBranches()
@@ -82,11 +148,9 @@ func selectBranchInteractive(currentBranch string) error {
 		return fmt.Errorf("failed to list branches: %w", err)
 	}
 
-	// Get all tracked branches
-	trackedBranches, err := stack.GetAllStackBranches()
+	ctx, err := stack.LoadContext()
 	if err != nil {
-		// If error, continue with empty tracked list
-		trackedBranches = []string{}
+		return fmt.Errorf("failed to load stack: %w", err)
 	}
 
 	// Build options with context
@@ -96,88 +160,71 @@ func selectBranchInteractive(currentBranch string) error {
 	}
 
 	var options []branchOption
-
-	// 1. Current branch (highlighted)
-	hasMetadata, _ := stack.HasStackMetadata(currentBranch)
-	if hasMetadata {
-		display := fmt.Sprintf("● %s (current)", currentBranch)
-		options = append(options, branchOption{name: currentBranch, display: display})
-	} else {
-		display := fmt.Sprintf("● %s (current, base)", currentBranch)
-		options = append(options, branchOption{name: currentBranch, display: display})
-	}
-
-	// 2. Tracked branches with context
-	for _, branch := range trackedBranches {
+	marker := func(branch string) string {
 		if branch == currentBranch {
-			continue // Already added above
+			return "● "
 		}
-
-		metadata, err := stack.ReadBranchMetadata(branch)
-		if err != nil {
-			continue
-		}
-
-		// Build display with parent and PR info
-		parts := []string{branch}
-		if metadata.Parent != "" {
-			parts = append(parts, fmt.Sprintf("parent: %s", metadata.Parent))
-		}
-		if metadata.PRNumber > 0 {
-			parts = append(parts, fmt.Sprintf("PR #%d", metadata.PRNumber))
-		}
-
-		// Add children count
-		children, _ := stack.GetChildren(branch)
-		if len(children) > 0 {
-			parts = append(parts, fmt.Sprintf("%d child(ren)", len(children)))
-		}
-
-		display := strings.Join(parts, " • ")
-		options = append(options, branchOption{name: branch, display: display})
+		return "  "
+	}
+	listed := make(map[string]bool)
+
+	// 1. Stacks, each headed by its root, with branches indented by depth
+	for _, root := range ctx.Stack.Roots {
+		stack.TraversePreOrder(root, 0, func(b *models.Branch, depth int) {
+			listed[b.Name] = true
+			display := marker(b.Name) + strings.Repeat("  ", depth)
+			if depth > 0 {
+				display += "└ "
+			}
+			display += b.Name
+
+			var details []string
+			if depth == 0 {
+				if info, ok := stack.StackOf(ctx, b.Name); ok && info.Name != "" {
+					details = append(details, fmt.Sprintf("stack %s", info.Name))
+				}
+				if b.Parent != "" {
+					details = append(details, fmt.Sprintf("on %s", b.Parent))
+				} else {
+					details = append(details, "base")
+				}
+			}
+			if b.PRNumber > 0 {
+				details = append(details, fmt.Sprintf("PR #%d", b.PRNumber))
+			}
+			if len(details) > 0 {
+				display += " • " + strings.Join(details, " • ")
+			}
+			options = append(options, branchOption{name: b.Name, display: display})
+		})
 	}
 
-	// 3. Base branches (main, master, etc.)
-	baseBranches := []string{"main", "master", "develop", "development"}
+	// 2. Base branches (main, master, etc.), then other untracked branches
+	baseBranches := stack.BaseBranches()
 	for _, base := range baseBranches {
-		if base == currentBranch {
-			continue
-		}
-		for _, b := range allBranches {
-			if b == base && !contains(trackedBranches, base) {
-				display := fmt.Sprintf("%s (base)", base)
-				options = append(options, branchOption{name: base, display: display})
-				break
-			}
+		if contains(allBranches, base) && !listed[base] {
+			listed[base] = true
+			options = append(options, branchOption{name: base, display: marker(base) + base + " (base)"})
 		}
 	}
-
-	// 4. Other untracked branches
 	for _, branch := range allBranches {
-		if branch == currentBranch {
-			continue
-		}
-		if contains(trackedBranches, branch) {
-			continue
-		}
-		isBase := false
-		for _, base := range baseBranches {
-			if branch == base {
-				isBase = true
-				break
-			}
+		if !listed[branch] {
+			options = append(options, branchOption{name: branch, display: marker(branch) + branch})
 		}
-		if isBase {
-			continue
-		}
-
-		options = append(options, branchOption{name: branch, display: branch})
 	}
 
 	if len(options) <= 1 {
 		return fmt.Errorf("no other branches available")
 	}
 
+	// Start on the current branch
+	cursor := 0
+	for i, opt := range options {
+		if opt.name == currentBranch {
+			cursor = i
+		}
+	}
+
 	// Create display items for promptui
 	displayItems := make([]string, len(options))
 	for i, opt := range options {
@@ -186,9 +233,13 @@ func selectBranchInteractive(currentBranch string) error {
 
 	// Prompt user
 	prompt := promptui.Select{
-		Label: "Select branch to checkout",
-		Items: displayItems,
-		Size:  15,
+		Label:     "Select branch to checkout",
+		Items:     displayItems,
+		Size:      15,
+		CursorPos: cursor,
+		Searcher: func(input string, index int) bool {
+			return strings.Contains(options[index].name, input)
+		},
 		Templates: &promptui.SelectTemplates{
 			Active:   "▸ {{ . | cyan }}",
 			Inactive: "  {{ . }}",
@@ -196,7 +247,7 @@ func selectBranchInteractive(currentBranch string) error {
 		},
 	}
 
-	idx, _, err := prompt.Run()
+	idx, _, err := runSelect(&prompt)
 	if err != nil {
 		return fmt.Errorf("branch selection cancelled")
 	}
@@ -215,5 +266,6 @@ func selectBranchInteractive(currentBranch string) error {
 	}
 
 	ui.Success(fmt.Sprintf("Switched to branch %s", targetBranch))
+	printStackMap(targetBranch)
 	return nil
 }
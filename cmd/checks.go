@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	checksFailed    bool
+	checksAllFailed bool
+)
+
+var checksCmd = &cobra.Command{
+	Use:     "checks",
+	Aliases: []string{"ck"},
+	Short:   "List CI checks for every PR in the stack",
+	Long: `List each CI check for every PR in the current stack with its status, duration and link.
+With --failed, only failing checks are shown.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runChecks(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var checksRerunCmd = &cobra.Command{
+	Use:   "rerun [branch]",
+	Short: "Re-run failed CI checks",
+	Long: `Re-run the failed CI checks on a branch's PR (default: current branch).
+With --all-failed, re-runs failed checks on every PR in the current stack.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completePRBranch,
+	Run: func(cmd *cobra.Command, args []string) {
+		branchName := ""
+		if len(args) > 0 {
+			branchName = args[0]
+		}
+		if err := runChecksRerun(branchName); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	checksCmd.Flags().BoolVar(&checksFailed, "failed", false, "Only show failing checks")
+	checksRerunCmd.Flags().BoolVar(&checksAllFailed, "all-failed", false, "Re-run failed checks on every PR in the stack")
+	checksCmd.AddCommand(checksRerunCmd)
+	rootCmd.AddCommand(checksCmd)
+}
+
+func runChecks() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	// Check if gh CLI is authenticated
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	prBranches, err := currentStackPRBranches()
+	if err != nil {
+		return err
+	}
+	if len(prBranches) == 0 {
+		fmt.Println("No PRs in this stack. Run 'stak submit' to create them.")
+		return nil
+	}
+
+	failedCount := 0
+	for _, branch := range prBranches {
+		metadata, err := stack.ReadBranchMetadata(branch)
+		if err != nil {
+			continue
+		}
+
+		checks, err := github.GetPRChecks(metadata.PRNumber)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("%s: %v", branch, err))
+			continue
+		}
+
+		var shown []github.Check
+		for _, check := range checks {
+			if check.IsFailed() {
+				failedCount++
+			}
+			if checksFailed && !check.IsFailed() {
+				continue
+			}
+			shown = append(shown, check)
+		}
+
+		if checksFailed && len(shown) == 0 {
+			continue
+		}
+
+		fmt.Printf("%s  PR #%d\n", branch, metadata.PRNumber)
+		if len(shown) == 0 {
+			fmt.Println("  No checks")
+		}
+		for _, check := range shown {
+			displayCheck(check)
+		}
+		fmt.Println()
+	}
+
+	if checksFailed && failedCount == 0 {
+		ui.Success("No failing checks in this stack")
+	}
+
+	return nil
+}
+
+func runChecksRerun(branchName string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	// Check if gh CLI is authenticated
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	var branches []string
+	if checksAllFailed {
+		if branchName != "" {
+			return fmt.Errorf("cannot combine a branch with --all-failed")
+		}
+		prBranches, err := currentStackPRBranches()
+		if err != nil {
+			return err
+		}
+		branches = prBranches
+	} else {
+		if branchName == "" {
+			current, err := git.GetCurrentBranch()
+			if err != nil {
+				return fmt.Errorf("failed to get current branch: %w", err)
+			}
+			branchName = current
+		}
+		branches = []string{branchName}
+	}
+
+	total := 0
+	for _, branch := range branches {
+		metadata, err := stack.ReadBranchMetadata(branch)
+		if err != nil || metadata.PRNumber == 0 {
+			if !checksAllFailed {
+				return fmt.Errorf("branch %s has no associated PR", branch)
+			}
+			continue
+		}
+
+		rerun, err := github.RerunFailedChecks(metadata.PRNumber)
+		total += rerun
+		if err != nil {
+			ui.Warning(err.Error())
+			continue
+		}
+		if rerun > 0 {
+			ui.Success(fmt.Sprintf("Re-running %d failed workflow(s) on PR #%d (%s)", rerun, metadata.PRNumber, branch))
+		}
+	}
+
+	if total == 0 {
+		ui.Info("No failed checks to re-run")
+	}
+
+	return nil
+}
+
+func displayCheck(check github.Check) {
+	result := check.Result()
+
+	duration := "-"
+	if d := check.Duration(); d > 0 {
+		duration = d.String()
+	}
+
+	fmt.Printf("  %s %-40s %-8s %-8s %s\n", getCIIcon(result), check.DisplayName(), result, duration, check.URL())
+}
+
+// currentStackPRBranches returns the branches in the current stack that have a PR, bottom to top
+func currentStackPRBranches() ([]string, error) {
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	var branches []string
+	for _, branch := range ctx.FullStack(currentBranch) {
+		if ctx.PRNumber(branch) > 0 {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// waitForChecks polls a PR until none of its checks are running, or the timeout expires
+func waitForChecks(prNumber int, timeout, interval time.Duration) (*github.PRStatus, error) {
+	deadline := time.Now().Add(timeout)
+	announced := false
+
+	for {
+		status, err := github.GetPRStatus(prNumber)
+		if err != nil {
+			return nil, err
+		}
+		if !status.IsCIPending() {
+			return status, nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("timed out after %s waiting for checks on PR #%d", timeout, prNumber)
+		}
+
+		if !announced {
+			ui.Info(fmt.Sprintf("Waiting for checks on PR #%d (timeout %s)", prNumber, timeout))
+			announced = true
+		}
+		time.Sleep(interval)
+	}
+}
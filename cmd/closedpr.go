@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// closedPRChoice is what was done about a PR closed without merging
+type closedPRChoice int
+
+const (
+	closedPRLeft      closedPRChoice = iota // Nothing, the PR stays closed
+	closedPRReopened                        // The PR was reopened
+	closedPRForgotten                       // The branch has no PR, so the next submit opens one
+	closedPRRemoved                         // The branch was untracked or deleted
+)
+
+// handleClosedPR asks what to do with a branch whose PR was closed without merging: reopen
+// the PR, forget it so the next submit opens a new one, untrack the branch or delete it. Its
+// children move onto its parent when it leaves the stack.
+func handleClosedPR(branch string, prNumber int) (closedPRChoice, error) {
+	ui.Warning(fmt.Sprintf("PR #%d for branch %s was closed without merging", prNumber, branch))
+	if requireInteractive("what to do with it") != nil {
+		ui.Info(fmt.Sprintf("Leaving %s as it is. Reopen the PR with 'gh pr reopen %d', or choose what to do when prompts are enabled", branch, prNumber))
+		return closedPRLeft, nil
+	}
+
+	metadata, err := stack.ReadBranchMetadata(branch)
+	if err != nil {
+		return closedPRLeft, fmt.Errorf("failed to read metadata for %s: %w", branch, err)
+	}
+
+	reopen := fmt.Sprintf("Reopen PR #%d", prNumber)
+	newPR := "Open a new PR for it on the next submit"
+	untrack := fmt.Sprintf("Untrack %s, moving its children onto %s", branch, metadata.Parent)
+	remove := fmt.Sprintf("Delete %s, moving its children onto %s", branch, metadata.Parent)
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("What would you like to do with %s?", branch),
+		Items: []string{reopen, newPR, untrack, remove, "Leave it for now"},
+	}
+	_, result, err := runSelect(&prompt)
+	if err != nil {
+		return closedPRLeft, nil
+	}
+
+	switch result {
+	case reopen:
+		if err := github.ReopenPR(prNumber); err != nil {
+			return closedPRLeft, err
+		}
+		ui.Success(fmt.Sprintf("Reopened PR #%d", prNumber))
+		return closedPRReopened, nil
+	case newPR:
+		if err := git.ClearBranchPRNumber(branch); err != nil {
+			return closedPRLeft, err
+		}
+		ui.Success(fmt.Sprintf("Forgot PR #%d; 'stak submit' opens a new PR for %s", prNumber, branch))
+		return closedPRForgotten, nil
+	case untrack, remove:
+		if err := reparentChildren(branch, metadata.Parent); err != nil {
+			return closedPRLeft, err
+		}
+		if err := untrackBranch(branch); err != nil {
+			return closedPRLeft, err
+		}
+		if result == untrack {
+			ui.Success(fmt.Sprintf("Untracked %s", branch))
+			return closedPRRemoved, nil
+		}
+		if current, _ := git.GetCurrentBranch(); current == branch {
+			if err := git.CheckoutBranch(metadata.Parent); err != nil {
+				return closedPRRemoved, fmt.Errorf("failed to checkout %s: %w", metadata.Parent, err)
+			}
+		}
+		// Its commits never landed, so git only deletes it when forced; a backup is kept
+		if err := git.DeleteBranch(branch, true); err != nil {
+			return closedPRRemoved, err
+		}
+		ui.Success(fmt.Sprintf("Deleted %s (restore it with 'stak restore %s')", branch, branch))
+		return closedPRRemoved, nil
+	}
+	return closedPRLeft, nil
+}
+
+// handleClosedPRs checks the PRs of branches with one batched query and handles those closed
+// without merging. It returns the branches to go on submitting, in order: not those that left
+// the stack, nor those whose PR stays closed, as pushing them would update nothing.
+func handleClosedPRs(branches []string) ([]string, error) {
+	prBranches := make(map[string]int)
+	var prNumbers []int
+	for _, branch := range branches {
+		if metadata, err := stack.ReadBranchMetadata(branch); err == nil && metadata.PRNumber > 0 {
+			prBranches[branch] = metadata.PRNumber
+			prNumbers = append(prNumbers, metadata.PRNumber)
+		}
+	}
+	if len(prNumbers) == 0 {
+		return branches, nil
+	}
+	states, err := github.GetPRStates(prNumbers)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not check for closed PRs: %v", err))
+		return branches, nil
+	}
+
+	var kept []string
+	for _, branch := range branches {
+		if prNumber := prBranches[branch]; prNumber > 0 && states[prNumber] == "CLOSED" {
+			choice, err := handleClosedPR(branch, prNumber)
+			if err != nil {
+				return nil, err
+			}
+			if choice == closedPRLeft || choice == closedPRRemoved {
+				continue
+			}
+		}
+		kept = append(kept, branch)
+	}
+	return kept, nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/ui"
+)
+
+// toolFeature is a feature that needs a newer git or gh than stak otherwise does
+type toolFeature struct {
+	tool    string
+	version git.Version
+	feature string
+}
+
+var toolFeatures = []toolFeature{
+	{"git", git.MergeTreeVersion, "sync --dry-run, modify --route, and modify --into without checkouts (checks out instead)"},
+	{"git", git.TrailerVersion, "co-authors as trailers (added to the message instead)"},
+	{"gh", github.FillFirstVersion, "PR titles from the first commit (filled from all commits instead)"},
+}
+
+// explainFailure adds what may lie behind a failed command: a jj repository stak can't reach
+// when there is no git repository, and otherwise a git older than stak supports. These only
+// run once a command has failed, so commands that succeed don't pay for them.
+func explainFailure(err error) {
+	if errors.Is(err, errNotGitRepository) {
+		if git.IsJJOnly() {
+			ui.Warning("This jj repository keeps its git repository inside .jj, where stak can't reach it. Use a colocated repository to run stak, e.g. one made by 'jj git clone --colocate'")
+		}
+		return
+	}
+	if v := git.GetVersion(); !v.AtLeast(git.MinimumVersion) {
+		ui.Warning(fmt.Sprintf("git %s is older than %d.%d, the oldest stak supports, which may be why this failed. Please upgrade git",
+			v, git.MinimumVersion.Major, git.MinimumVersion.Minor))
+	}
+}
+
+// printToolVersions prints the versions of git and gh, and the features they are too old for
+func printToolVersions() {
+	versions := map[string]git.Version{"git": git.GetVersion(), "gh": github.GetVersion()}
+	for _, tool := range []string{"git", "gh"} {
+		if v := versions[tool]; v.Known() {
+			fmt.Printf("%s version %s\n", tool, v)
+		} else {
+			fmt.Printf("%s version unknown\n", tool)
+		}
+	}
+	for _, f := range toolFeatures {
+		if !versions[f.tool].AtLeast(f.version) {
+			ui.Warning(fmt.Sprintf("Needs %s %d.%d or later: %s", f.tool, f.version.Major, f.version.Minor, f.feature))
+		}
+	}
+}
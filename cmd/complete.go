@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+)
+
+// Shell completion for branch arguments and flags. Completions only offer values
+// the command would accept, e.g. --parent never suggests a branch's own descendants.
+// They must stay quiet and fast: on any error they simply offer nothing.
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completionTarget returns the branch a command acts on: its [branch] argument, or the current branch
+func completionTarget(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	current, err := git.GetCurrentBranch()
+	if err != nil {
+		return ""
+	}
+	return current
+}
+
+// completeValues completes a flag with a fixed set of values
+func completeValues(values ...string) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeBranchArg completes a single [branch] argument with tracked branches matching keep
+func completeBranchArg(keep func(ctx *stack.StackContext, branch string) bool) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ctx, err := stack.LoadContext()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var branches []string
+		for _, b := range stack.GetAllBranchesInOrder(ctx.Stack) {
+			if keep == nil || keep(ctx, b.Name) {
+				branches = append(branches, b.Name)
+			}
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeTrackedBranches completes a [branch] argument with any tracked branch
+var completeTrackedBranches = completeBranchArg(nil)
+
+// completeFrozen completes a [branch] argument with tracked branches that are (or aren't) frozen
+func completeFrozen(frozen bool) completionFunc {
+	return completeBranchArg(func(ctx *stack.StackContext, branch string) bool {
+		isFrozen, err := stack.IsBranchFrozen(branch)
+		return err == nil && isFrozen == frozen
+	})
+}
+
+// completeCheckoutBranch completes checkout with tracked branches and the trunk
+func completeCheckoutBranch(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	branches, directive := completeTrackedBranches(cmd, args, toComplete)
+	if len(args) == 0 {
+		branches = append(branches, existingBaseBranches()...)
+	}
+	return branches, directive
+}
+
+// completeUntrackedBranch completes track's [branch] argument with local branches not yet in a stack
+func completeUntrackedBranch(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	local, err := git.GetAllLocalBranches()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var branches []string
+	for _, b := range local {
+		if !ctx.IsTracked(b) && !stack.IsBaseBranch(b) {
+			branches = append(branches, b)
+		}
+	}
+	return branches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeParent completes --parent: tracked branches and the trunk, minus the
+// target branch and its descendants, which would create a cycle
+func completeParent(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	target := completionTarget(args)
+	excluded := map[string]bool{target: true}
+	for _, d := range ctx.Descendants(target) {
+		excluded[d] = true
+	}
+
+	var parents []string
+	for _, base := range existingBaseBranches() {
+		if !excluded[base] {
+			parents = append(parents, base)
+		}
+	}
+	for _, b := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		if !excluded[b.Name] {
+			parents = append(parents, b.Name)
+		}
+	}
+	return parents, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNextTo completes --before and --after: tracked branches other than the target
+// branch and its descendants
+func completeNextTo(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	target := completionTarget(args)
+	excluded := map[string]bool{target: true}
+	for _, d := range ctx.Descendants(target) {
+		excluded[d] = true
+	}
+
+	var branches []string
+	for _, b := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		if !excluded[b.Name] {
+			branches = append(branches, b.Name)
+		}
+	}
+	return branches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStackNames completes a stack argument with the names of named stacks and the
+// root branches of the others
+func completeStackNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, info := range stack.Stacks(ctx) {
+		if info.Name != "" {
+			names = append(names, info.Name)
+		} else {
+			names = append(names, info.Root)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDownstack completes --into with the tracked ancestors of the current branch
+func completeDownstack(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var ancestors []string
+	for _, a := range ctx.Ancestors(completionTarget(nil)) {
+		if ctx.IsTracked(a) {
+			ancestors = append(ancestors, a)
+		}
+	}
+	return ancestors, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeSplitCommit completes split --at with the commits unique to the target branch
+// Each value is "<hash>\t<subject>" so shells that support it show the subject
+func completeSplitCommit(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	target := completionTarget(args)
+	parent, err := stack.GetParent(target)
+	if err != nil || parent == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	output, err := exec.Command("git", "log", "--format=%h\t%s", "--reverse", parent+".."+target).Output()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completePRBranch completes a [branch] argument with tracked branches that have a PR
+var completePRBranch = completeBranchArg(func(ctx *stack.StackContext, branch string) bool {
+	return ctx.PRNumber(branch) > 0
+})
+
+// existingBaseBranches returns the base branches that exist locally
+func existingBaseBranches() []string {
+	var bases []string
+	for _, base := range stack.BaseBranches() {
+		if exists, err := git.BranchExists(base); err == nil && exists {
+			bases = append(bases, base)
+		}
+	}
+	return bases
+}
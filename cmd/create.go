@@ -35,8 +35,7 @@ The new PR will target the current branch as its base.`,
 		}
 
 		if err := runCreate(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -53,12 +52,12 @@ func init() {
 func runCreate(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Check if gh CLI is authenticated
 	if !github.IsGHAuthenticated() {
-		return fmt.Errorf("gh CLI not authenticated. Run: gh auth login")
+		return errNotAuthenticated
 	}
 
 	// Get current branch (will be the parent)
@@ -69,6 +68,9 @@ func runCreate(branchName string) error {
 
 	// Prompt for branch name if not provided
 	if branchName == "" {
+		if err := requireInteractive("branch name"); err != nil {
+			return err
+		}
 		fmt.Print("Enter new branch name: ")
 		reader := bufio.NewReader(os.Stdin)
 		branchName, err = reader.ReadString('\n')
@@ -101,6 +103,10 @@ func runCreate(branchName string) error {
 	if err := stack.WriteBranchMetadata(branchName, parentBranch, 0); err != nil {
 		return fmt.Errorf("failed to store metadata: %w", err)
 	}
+	recordOwner(branchName)
+	if err := stack.RecordParentSHA(branchName, parentBranch); err != nil {
+		ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+	}
 
 	ui.Success(fmt.Sprintf("Created and checked out branch %s", branchName))
 
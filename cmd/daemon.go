@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/restack"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	daemonInterval    time.Duration
+	daemonOnce        bool
+	daemonAutoRestack bool
+	daemonStatusShort bool
+	daemonPorcelain   bool
+	daemonNotify      bool
+)
+
+// daemonPRState remembers each PR as of the previous check, so only changes are notified
+var daemonPRState = make(map[int]prWatchState)
+
+// daemonMergedStacks remembers which stacks (by root branch) were fully merged on the previous check
+var daemonMergedStacks map[string]bool
+
+type prWatchState struct {
+	approved bool
+	ciFailed bool
+}
+
+var daemonCmd = &cobra.Command{
+	Use:     "daemon",
+	Aliases: []string{"dm"},
+	Short:   "Watch the repository and record when stacks need syncing",
+	Long: `Periodically fetch from the remote and detect trunk updates, merged parents, and branches that are behind their parent.
+The result is recorded in git config so 'stak list', 'stak daemon status' and shell prompts can show it instantly.
+With --auto-restack, branches that rebase cleanly onto their local parent are restacked automatically (nothing is pushed).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDaemon(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the sync status recorded by the daemon",
+	Long: `Show whether the stack needs syncing, as last recorded by 'stak daemon'.
+With --short, prints "needs-sync" or nothing, suitable for a shell prompt.
+
+With --porcelain, prints tab-separated lines of <status> <checked-at (RFC 3339)> <reason>,
+where status is needs-sync (one line per reason), up-to-date or unknown.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDaemonStatus(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", 5*time.Minute, "How often to check for updates")
+	daemonCmd.Flags().BoolVar(&daemonOnce, "once", false, "Check once and exit (e.g. from cron)")
+	daemonCmd.Flags().BoolVar(&daemonAutoRestack, "auto-restack", false, "Rebase branches that are behind their parent when it applies cleanly")
+	daemonCmd.Flags().BoolVar(&daemonNotify, "notify", false, "Send desktop notifications for approvals, CI failures and merged stacks")
+	daemonStatusCmd.Flags().BoolVar(&daemonStatusShort, "short", false, "Print only \"needs-sync\" when a sync is needed")
+	daemonStatusCmd.Flags().BoolVar(&daemonPorcelain, "porcelain", false, "Stable tab-separated output for scripts")
+	daemonCmd.AddCommand(daemonStatusCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	if !daemonOnce {
+		ui.Info(fmt.Sprintf("Watching stacks every %s (Ctrl+C to stop)", daemonInterval))
+	}
+
+	for {
+		reasons, branches, err := checkStacksNeedSync()
+		if err != nil {
+			ui.Warning(fmt.Sprintf("[%s] check failed: %v", time.Now().Format("15:04:05"), err))
+		} else {
+			if err := stack.RecordSyncStatus(reasons, branches); err != nil {
+				ui.Warning(fmt.Sprintf("Could not record sync status: %v", err))
+			}
+			if len(reasons) > 0 {
+				ui.Warning(fmt.Sprintf("[%s] stack needs sync:", time.Now().Format("15:04:05")))
+				for _, reason := range reasons {
+					fmt.Printf("  - %s\n", reason)
+				}
+			} else {
+				ui.Info(fmt.Sprintf("[%s] stacks are up to date", time.Now().Format("15:04:05")))
+			}
+		}
+
+		if daemonOnce {
+			return nil
+		}
+		time.Sleep(daemonInterval)
+	}
+}
+
+// checkStacksNeedSync fetches and returns a reason for every branch that needs syncing, and
+// the branch each is about
+func checkStacksNeedSync() ([]string, []string, error) {
+	if err := git.Fetch(); err != nil {
+		return nil, nil, err
+	}
+
+	// Other stak commands may have changed metadata since the last check
+	git.ReloadBranchMetadata()
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	var reasons, branches []string
+	flag := func(branch, reason string) {
+		reasons = append(reasons, reason)
+		branches = append(branches, branch)
+	}
+	stackPRs := make(map[string]int)
+	stackMerged := make(map[string]int)
+	// Pre-order visits parents before children, so restacks apply bottom-up
+	for _, metadata := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		branch := metadata.Name
+		parent := metadata.Parent
+		if parent == "" {
+			continue
+		}
+
+		if metadata.PRNumber > 0 {
+			root := stackRoot(ctx, branch)
+			stackPRs[root]++
+
+			if status, err := github.GetPRStatus(metadata.PRNumber); err == nil {
+				watchPREvents(branch, metadata.PRNumber, status)
+				if status.IsMerged() {
+					stackMerged[root]++
+					flag(branch, fmt.Sprintf("%s was merged (PR #%d)", branch, metadata.PRNumber))
+					continue
+				}
+			}
+		}
+
+		if !ctx.IsTracked(parent) {
+			// Parent is trunk: compare against the remote
+			if git.RemoteTrackingBranchExists(parent) && !git.BranchContainsCommit(branch, git.RemoteRef(parent)) {
+				flag(branch, fmt.Sprintf("%s is behind %s", branch, git.RemoteRef(parent)))
+			}
+			continue
+		}
+
+		if git.BranchContainsCommit(branch, parent) {
+			continue
+		}
+		rewritten := stack.RewrittenParentBase(branch, parent) != ""
+		if daemonAutoRestack && autoRestackBranch(branch, parent) {
+			continue
+		}
+		if rewritten {
+			flag(branch, fmt.Sprintf("%s was rewritten since %s was restacked onto it", parent, branch))
+			continue
+		}
+		flag(branch, fmt.Sprintf("%s is behind its parent %s", branch, parent))
+	}
+
+	// Notify once when every PR in a stack has landed
+	merged := make(map[string]bool)
+	for root, count := range stackPRs {
+		merged[root] = stackMerged[root] == count
+		if merged[root] && daemonMergedStacks != nil && !daemonMergedStacks[root] {
+			notifyEvent(daemonNotify, fmt.Sprintf("Stack %s fully merged", root))
+		}
+	}
+	daemonMergedStacks = merged
+
+	return reasons, branches, nil
+}
+
+// watchPREvents notifies about approvals and CI failures since the previous check
+func watchPREvents(branch string, prNumber int, status *github.PRStatus) {
+	current := prWatchState{
+		approved: status.IsApproved(),
+		ciFailed: !status.IsCIPending() && !status.IsCIPassing(),
+	}
+	previous, seen := daemonPRState[prNumber]
+	daemonPRState[prNumber] = current
+
+	// The first check only establishes a baseline
+	if !seen {
+		return
+	}
+	if current.approved && !previous.approved {
+		notifyEvent(daemonNotify, fmt.Sprintf("PR #%d approved (%s)", prNumber, branch))
+	}
+	if current.ciFailed && !previous.ciFailed {
+		notifyEvent(daemonNotify, fmt.Sprintf("CI failed on branch %s (PR #%d)", branch, prNumber))
+	}
+}
+
+// stackRoot returns the bottom-most tracked branch of the stack containing branch
+func stackRoot(ctx *stack.StackContext, branch string) string {
+	for _, ancestor := range ctx.Ancestors(branch) {
+		if ctx.IsTracked(ancestor) {
+			return ancestor
+		}
+	}
+	return branch
+}
+
+// stacksBasedOn returns the tracked branches whose parent is base, i.e. the bottom branch of
+// every stack built on it. Untracked branches such as trunk have no children in the context.
+func stacksBasedOn(ctx *stack.StackContext, base string) []string {
+	var roots []string
+	for _, branch := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		if branch.Parent == base {
+			roots = append(roots, branch.Name)
+		}
+	}
+	return roots
+}
+
+// autoRestackBranch rebases a branch onto its local parent if that applies cleanly
+// Does nothing (and returns false) when the working tree is busy
+func autoRestackBranch(branch, parent string) bool {
+	if err := restack.Branch(branch, parent, restack.Options{AbortOnConflict: true}); err != nil {
+		return false
+	}
+
+	ui.Success(fmt.Sprintf("Restacked %s onto %s", branch, parent))
+	return true
+}
+
+func runDaemonStatus() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	status, err := stack.ReadSyncStatus()
+	if err != nil {
+		return fmt.Errorf("failed to read sync status: %w", err)
+	}
+
+	if daemonPorcelain {
+		checkedAt := ""
+		if !status.CheckedAt.IsZero() {
+			checkedAt = status.CheckedAt.Format(time.RFC3339)
+		}
+		switch {
+		case status.CheckedAt.IsZero():
+			printPorcelain("unknown", checkedAt, "")
+		case status.NeedsSync():
+			for _, reason := range status.Reasons {
+				printPorcelain("needs-sync", checkedAt, reason)
+			}
+		default:
+			printPorcelain("up-to-date", checkedAt, "")
+		}
+		return nil
+	}
+
+	if daemonStatusShort {
+		if status.NeedsSync() {
+			fmt.Println("needs-sync")
+		}
+		return nil
+	}
+
+	if status.CheckedAt.IsZero() {
+		fmt.Println("No status recorded yet. Start the watcher with: stak daemon")
+		return nil
+	}
+
+	if !status.NeedsSync() {
+		ui.Success(fmt.Sprintf("Stacks up to date (checked %s)", status.CheckedAt.Format(time.RFC1123)))
+		return nil
+	}
+
+	ui.Warning(fmt.Sprintf("Stack needs sync (checked %s):", status.CheckedAt.Format(time.RFC1123)))
+	fmt.Println("  - " + strings.Join(status.Reasons, "\n  - "))
+	ui.Info("Run: stak sync")
+	return nil
+}
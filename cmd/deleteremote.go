@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// githubDeletesBranches caches whether the repository has GitHub delete merged PRs' branches
+var githubDeletesBranches *bool
+
+// deleteMergedRemoteBranch deletes the remote branch of a merged PR for --delete-remote, unless
+// GitHub does it already ("Automatically delete head branches") or the branch isn't there
+func deleteMergedRemoteBranch(branch string) {
+	if githubDeletesBranches == nil {
+		deletes, err := github.DeletesMergedBranches()
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Could not check whether GitHub deletes merged branches: %v", err))
+		} else if deletes {
+			ui.Info("GitHub deletes the branches of merged PRs for this repository, so stak leaves them to it")
+		}
+		githubDeletesBranches = &deletes
+	}
+	if *githubDeletesBranches {
+		return
+	}
+
+	if exists, err := git.RemoteBranchExists(branch); err == nil && !exists {
+		return
+	}
+	// GitHub closes the open PRs based on a deleted branch instead of retargeting them
+	children, _ := stack.GetChildren(branch)
+	for _, child := range children {
+		if metadata, err := stack.ReadBranchMetadata(child); err == nil && metadata.PRNumber > 0 && metadata.Parent == branch {
+			ui.Warning(fmt.Sprintf("Keeping %s on %s: the PR of %s is still based on it. Run 'stak sync' first", branch, git.Remote, child))
+			return
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Deleting %s on %s", branch, git.Remote))
+	if err := git.DeleteRemoteBranch(branch); err != nil {
+		ui.Warning(fmt.Sprintf("Could not delete %s on %s: %v", branch, git.Remote, err))
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// unmetDependency describes what the stack of branch is still waiting for, or returns "" if
+// it doesn't depend on anything or its dependency is merged. A stack dependency that no
+// longer exists was merged and cleaned up.
+func unmetDependency(ctx *stack.StackContext, branch string) (string, error) {
+	info, ok := stack.StackOf(ctx, branch)
+	if !ok || info.DependsOn == "" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(info.DependsOn, "#") {
+		prNumber, err := strconv.Atoi(info.DependsOn[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid stack dependency %q", info.DependsOn)
+		}
+		states, err := github.GetPRStates([]int{prNumber})
+		if err != nil {
+			return "", err
+		}
+		if state := states[prNumber]; state != "MERGED" {
+			return fmt.Sprintf("PR #%d (%s)", prNumber, strings.ToLower(state)), nil
+		}
+		return "", nil
+	}
+
+	other, err := stack.FindStack(ctx, info.DependsOn)
+	if err != nil {
+		return "", nil
+	}
+	unmerged := 0
+	var prNumbers []int
+	for _, b := range other.Branches {
+		if n := ctx.PRNumber(b); n > 0 {
+			prNumbers = append(prNumbers, n)
+		} else {
+			unmerged++
+		}
+	}
+	if len(prNumbers) > 0 {
+		states, err := github.GetPRStates(prNumbers)
+		if err != nil {
+			return "", err
+		}
+		for _, n := range prNumbers {
+			if states[n] != "MERGED" {
+				unmerged++
+			}
+		}
+	}
+	if unmerged > 0 {
+		return fmt.Sprintf("stack %s (%d unmerged branch(es))", other.Name, unmerged), nil
+	}
+	return "", nil
+}
+
+// checkStackDependency refuses to go on while the stack of branch waits for another stack or
+// PR, unless override is set, in which case it only warns
+func checkStackDependency(action, branch string, override bool) error {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	waiting, err := unmetDependency(ctx, branch)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not check the stack's dependency: %v", err))
+		return nil
+	}
+	if waiting == "" {
+		return nil
+	}
+	if override {
+		ui.Warning(fmt.Sprintf("The stack of %s depends on %s, which isn't merged yet", branch, waiting))
+		return nil
+	}
+	return fmt.Errorf("cannot %s: the stack of %s depends on %s, which isn't merged yet", action, branch, waiting)
+}
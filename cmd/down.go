@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/manifoldco/promptui"
@@ -24,13 +23,11 @@ var downCmd = &cobra.Command{
 			var err error
 			steps, err = strconv.Atoi(args[0])
 			if err != nil || steps < 1 {
-				ui.Error("steps must be a positive integer")
-				os.Exit(1)
+				exitWithError(fmt.Errorf("steps must be a positive integer"))
 			}
 		}
 		if err := runDown(steps); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -42,7 +39,7 @@ func init() {
 func runDown(steps int) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -78,7 +75,7 @@ func runDown(steps int) error {
 				Items: children,
 			}
 
-			_, result, err := prompt.Run()
+			_, result, err := runSelect(&prompt)
 			if err != nil {
 				return fmt.Errorf("branch selection cancelled: %w", err)
 			}
@@ -93,6 +90,7 @@ func runDown(steps int) error {
 			return fmt.Errorf("failed to checkout branch %s: %w", targetBranch, err)
 		}
 		ui.Success(fmt.Sprintf("Now on branch %s", targetBranch))
+		printStackMap(targetBranch)
 	}
 
 	return nil
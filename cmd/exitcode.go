@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"stacking/internal/git"
+	"stacking/internal/profile"
+	"stacking/internal/restack"
+	"stacking/internal/ui"
+)
+
+// Exit codes are part of stak's scripting interface: never renumber them,
+// only add new ones. They are documented in the README.
+const (
+	exitError         = 1 // Any other failure
+	exitConflict      = 2 // A rebase stopped on conflicts or is still in progress
+	exitNeedsAuth     = 3 // gh is not logged in
+	exitDirtyTree     = 4 // Uncommitted changes are in the way
+	exitNotRepository = 5 // Not run inside a git repository
+)
+
+var (
+	errNotGitRepository = errors.New("not in a git repository")
+	errNotAuthenticated = errors.New("gh CLI not authenticated. Run: gh auth login")
+	errConflict         = errors.New("rebase conflict")
+	errRebaseInProgress = errors.New("rebase already in progress")
+)
+
+// exitCode maps a command error to its documented exit code
+func exitCode(err error) int {
+	var rebaseConflict *git.RebaseConflictError
+	var restackConflict *restack.ConflictError
+
+	switch {
+	case errors.Is(err, errConflict), errors.Is(err, errRebaseInProgress),
+		errors.Is(err, restack.ErrRebaseInProgress),
+		errors.As(err, &rebaseConflict), errors.As(err, &restackConflict):
+		return exitConflict
+	case errors.Is(err, errNotAuthenticated):
+		return exitNeedsAuth
+	case errors.Is(err, git.ErrDirtyTree):
+		return exitDirtyTree
+	case errors.Is(err, errNotGitRepository):
+		return exitNotRepository
+	default:
+		return exitError
+	}
+}
+
+// exitWithError reports a command's error and exits with its exit code. os.Exit skips the
+// post-run hooks, so the operation is recorded in history here first: a command that fails
+// halfway may already have moved branches. The --profile report is printed here too, as
+// slow failures are worth profiling as much as slow successes.
+func exitWithError(err error) {
+	ui.Error(err.Error())
+	explainFailure(err)
+	finishJournal()
+	profile.Report(os.Stderr)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/interop"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	exportFormat string
+	exportDryRun bool
+	exportPush   bool
+)
+
+// exportFormats are the tools stak export can write stacks for
+var exportFormats = []string{"graphite"}
+
+var exportCmd = &cobra.Command{
+	Use:   "export --format graphite",
+	Short: "Write the stacks stak tracks in another stacking tool's format",
+	Long: `Record every tracked branch's parent, fork point and PR number in another tool's
+metadata, so teammates using it see the same stacks. The opposite of stak import.
+
+--format graphite sets the parent, fork point and PR of the exported branches in the metadata
+the Graphite CLI keeps under refs/branch-metadata/, keeping whatever else it records, and the
+trunk in .git/.graphite_repo_config unless it names one already.
+
+With --push, the exported branches' metadata refs are pushed to the remote too, unless a
+teammate changed them there since they were last fetched. Teammates fetch them with
+stak import --from graphite --fetch, or with
+git fetch origin '+refs/branch-metadata/*:refs/branch-metadata/*' for Graphite.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExport(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "Format to export to: graphite")
+	exportCmd.MarkFlagRequired("format")
+	exportCmd.RegisterFlagCompletionFunc("format", completeValues(exportFormats...))
+	exportCmd.Flags().BoolVar(&exportDryRun, "dry-run", false, "Show what would be exported without changing anything")
+	exportCmd.Flags().BoolVar(&exportPush, "push", false, "Push the exported metadata to the remote")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport() error {
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+	if !contains(exportFormats, exportFormat) {
+		return fmt.Errorf("cannot export to %q: use %s", exportFormat, strings.Join(exportFormats, ", "))
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	trunk, err := stack.Trunk()
+	if err != nil {
+		return err
+	}
+
+	var branches []interop.Branch
+	for _, b := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		parent := ctx.Parent(b.Name)
+		if parent == "" {
+			continue
+		}
+		branch := interop.Branch{Name: b.Name, Parent: parent, PRNumber: ctx.PRNumber(b.Name)}
+		// The recorded fork point survives the parent being rewritten; the merge base doesn't
+		if sha, err := git.GetBranchParentSHA(b.Name); err == nil && sha != "" && git.BranchContainsCommit(b.Name, sha) {
+			branch.ParentRevision = sha
+		} else if sha, err := git.GetMergeBase(b.Name, parent); err == nil {
+			branch.ParentRevision = sha
+		}
+		branches = append(branches, branch)
+	}
+	if len(branches) == 0 {
+		ui.Warning("No stack branches found")
+		return nil
+	}
+
+	if exportDryRun {
+		for _, branch := range branches {
+			description := fmt.Sprintf("%s on %s", branch.Name, branch.Parent)
+			if branch.PRNumber > 0 {
+				description += fmt.Sprintf(" (PR #%d)", branch.PRNumber)
+			}
+			fmt.Printf("  %s\n", description)
+		}
+		ui.Info(fmt.Sprintf("Would export %d branch(es) to Graphite", len(branches)))
+		return nil
+	}
+
+	// What the refs pointed to before, as of the last fetch, is the lease for pushing them
+	before, err := git.ReadRefTargets(interop.GraphiteRefs)
+	if err != nil {
+		return err
+	}
+	if err := interop.WriteGraphite(branches, trunk); err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Exported %d branch(es) to Graphite's metadata", len(branches)))
+
+	if exportPush {
+		var refs []string
+		for _, branch := range branches {
+			refs = append(refs, interop.GraphiteRefs+branch.Name)
+		}
+		ui.Info(fmt.Sprintf("Pushing the metadata of %d branch(es) to %s", len(refs), git.Remote))
+		if err := git.PushRefs(refs, before); err != nil {
+			return fmt.Errorf("%w. If teammates changed it, fetch theirs with 'stak import --from graphite --fetch' and export again", err)
+		}
+		ui.Success("Pushed the metadata. Teammates pick it up with 'stak import --from graphite --fetch'")
+	}
+	return nil
+}
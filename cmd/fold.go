@@ -2,11 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"stacking/internal/config"
 	"stacking/internal/git"
 	"stacking/internal/github"
 	"stacking/internal/stack"
@@ -14,25 +14,50 @@ import (
 )
 
 var (
-	foldSquash bool
-	foldForce  bool
+	foldSquash     bool
+	foldForce      bool
+	foldUp         bool
+	foldPR         string
+	foldCoAuthors  bool
+	foldKeepAuthor bool
 )
 
 var foldCmd = &cobra.Command{
 	Use:     "fold [branch]",
 	Aliases: []string{"fd"},
 	Short:   "Merge branch into its parent",
-	Long:    `Fold a branch into its parent by merging the commits. Updates children to point to the parent and closes/merges the PR.`,
-	Args:    cobra.MaximumNArgs(1),
+	Long: `Fold a branch into its parent by merging the commits. Updates children to point to the parent and closes/merges the PR.
+
+With --up, fold the branch into its only child instead: the child already contains the
+branch's commits, so it is moved onto the branch's parent, its PR is retargeted there, and
+the branch is removed from the middle of the stack. No commits are rewritten.
+
+Folding normally closes the folded branch's PR. If that PR has reviews or comments, fold
+asks whether to close it, merge its description into the surviving PR (whose description
+then links back to the discussion), or keep it by folding the other way round instead.
+--pr makes the choice up front.
+
+When squashing, --co-authors credits the other authors of the folded commits in
+Co-authored-by trailers, and --keep-author keeps the author and date of the first of them.
+The fold-co-authors and fold-keep-author settings turn them on by default.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
 			branchName = args[0]
 		}
 
+		// The authorship settings apply unless the flags are given explicitly
+		if !cmd.Flags().Changed("co-authors") {
+			foldCoAuthors = config.GetBool("fold-co-authors", false)
+		}
+		if !cmd.Flags().Changed("keep-author") {
+			foldKeepAuthor = config.GetBool("fold-keep-author", false)
+		}
+
 		if err := runFold(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -40,13 +65,18 @@ var foldCmd = &cobra.Command{
 func init() {
 	foldCmd.Flags().BoolVar(&foldSquash, "squash", true, "Squash commits when folding")
 	foldCmd.Flags().BoolVarP(&foldForce, "force", "f", false, "Skip confirmation prompts")
+	foldCmd.Flags().BoolVar(&foldUp, "up", false, "Fold into the branch's only child instead of its parent")
+	foldCmd.Flags().BoolVar(&foldCoAuthors, "co-authors", false, "When squashing, credit the other authors of the folded commits as co-authors")
+	foldCmd.Flags().BoolVar(&foldKeepAuthor, "keep-author", false, "When squashing, keep the author and date of the first folded commit")
+	foldCmd.Flags().StringVar(&foldPR, "pr", "", "What to do with the folded branch's PR: close, merge (its description into the surviving PR) or keep")
+	addVerifyFlags(foldCmd)
 	rootCmd.AddCommand(foldCmd)
 }
 
 func runFold(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch
@@ -102,6 +132,10 @@ func runFold(branchName string) error {
 		return fmt.Errorf("failed to get children: %w", err)
 	}
 
+	if foldUp {
+		return runFoldUp(branchName, parent, metadata.PRNumber, children)
+	}
+
 	// Count commits to be folded
 	commitCount, err := getCommitCount(branchName, parent)
 	if err != nil {
@@ -109,6 +143,34 @@ func runFold(branchName string) error {
 		commitCount = 0
 	}
 
+	// Folding the parent up into this branch instead keeps this branch's PR
+	parentMetadata, err := stack.ReadBranchMetadata(parent)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata for %s: %w", parent, err)
+	}
+	swap := ""
+	if parentMetadata.Parent != "" {
+		if parentChildren, err := stack.GetChildren(parent); err == nil && canFoldUpInto(parent, branchName, parentChildren) {
+			swap = parent
+		}
+	}
+	prAction, err := chooseFoldPRAction(branchName, metadata.PRNumber, parent, parentMetadata.PRNumber, swap)
+	if err != nil {
+		return err
+	}
+	switch prAction {
+	case "":
+		ui.Info("Fold cancelled")
+		return nil
+	case foldPRKeep:
+		foldPR = string(foldPRMerge)
+		return runFoldUp(parent, parentMetadata.Parent, parentMetadata.PRNumber, []string{branchName})
+	}
+
+	if err := checkStackOwnership("fold and force-push", append([]string{branchName}, children...), foldForce); err != nil {
+		return err
+	}
+
 	// Show confirmation
 	if !foldForce {
 		ui.Info(fmt.Sprintf("This will:"))
@@ -116,7 +178,9 @@ func runFold(branchName string) error {
 		if len(children) > 0 {
 			ui.Info(fmt.Sprintf("  - Update %d child branch(es) to point to %s", len(children), parent))
 		}
-		if metadata.PRNumber > 0 {
+		if metadata.PRNumber > 0 && prAction == foldPRMerge {
+			ui.Info(fmt.Sprintf("  - Merge the description of PR #%d into PR #%d and close it", metadata.PRNumber, parentMetadata.PRNumber))
+		} else if metadata.PRNumber > 0 {
 			ui.Info(fmt.Sprintf("  - Close PR #%d", metadata.PRNumber))
 		}
 		ui.Info(fmt.Sprintf("  - Delete local branch %s", branchName))
@@ -126,7 +190,7 @@ func runFold(branchName string) error {
 			Items: []string{"Yes", "No"},
 		}
 
-		_, result, err := prompt.Run()
+		_, result, err := runSelect(&prompt)
 		if err != nil || result == "No" {
 			ui.Info("Fold cancelled")
 			return nil
@@ -142,8 +206,14 @@ func runFold(branchName string) error {
 	// Merge branch into parent
 	ui.Info(fmt.Sprintf("Merging %s into %s", branchName, parent))
 	if foldSquash {
+		warnUnsignedRewrite(branchName, parent)
+		authorship, err := git.BranchAuthorship(parent, branchName, foldKeepAuthor, foldCoAuthors)
+		if err != nil {
+			return err
+		}
+
 		// Squash merge
-		cmd := exec.Command("git", "merge", "--squash", branchName)
+		cmd := git.CommitCommand("merge", "--squash", branchName)
 		output, err := cmd.CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("failed to squash merge: %s", string(output))
@@ -151,12 +221,12 @@ func runFold(branchName string) error {
 
 		// Commit the squashed changes
 		commitMsg := fmt.Sprintf("Fold %s into %s", branchName, parent)
-		if err := git.Commit(commitMsg); err != nil {
+		if err := git.CommitAs(commitMsg, authorship); err != nil {
 			return fmt.Errorf("failed to commit squashed changes: %w", err)
 		}
 	} else {
 		// Regular merge
-		cmd := exec.Command("git", "merge", "--no-ff", branchName, "-m", fmt.Sprintf("Merge %s into %s", branchName, parent))
+		cmd := git.CommitCommand("merge", "--no-ff", branchName, "-m", fmt.Sprintf("Merge %s into %s", branchName, parent))
 		output, err := cmd.CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("failed to merge: %s", string(output))
@@ -203,7 +273,7 @@ func runFold(branchName string) error {
 		}
 
 		ui.Info(fmt.Sprintf("Rebasing %s onto %s", child, parent))
-		if err := git.RebaseOnto(parent); err != nil {
+		if _, err := stack.Restack(child, parent); err != nil {
 			ui.Warning(fmt.Sprintf("Failed to rebase %s: %v", child, err))
 			ui.Info("You may need to manually rebase this branch")
 			continue
@@ -220,16 +290,7 @@ func runFold(branchName string) error {
 	}
 
 	// Close PR if exists
-	if metadata.PRNumber > 0 {
-		ui.Info(fmt.Sprintf("Closing PR #%d", metadata.PRNumber))
-		// Close PR by commenting and closing
-		if err := github.ClosePR(metadata.PRNumber); err != nil {
-			ui.Warning(fmt.Sprintf("Could not close PR #%d: %v", metadata.PRNumber, err))
-			ui.Info("You may want to manually close the PR")
-		} else {
-			ui.Success(fmt.Sprintf("Closed PR #%d", metadata.PRNumber))
-		}
-	}
+	closeFoldedPR(metadata.PRNumber, prAction, parentMetadata.PRNumber)
 
 	// Delete local branch
 	ui.Info(fmt.Sprintf("Deleting local branch %s", branchName))
@@ -248,6 +309,121 @@ func runFold(branchName string) error {
 	return nil
 }
 
+// runFoldUp folds a branch into its only child by moving the child onto the branch's parent.
+// The child already contains the branch's commits, so only metadata and PRs change.
+func runFoldUp(branchName, parent string, prNumber int, children []string) error {
+	if len(children) != 1 {
+		return fmt.Errorf("branch %s has %d children; --up needs exactly one", branchName, len(children))
+	}
+	child := children[0]
+
+	if !git.BranchContainsCommit(child, branchName) {
+		return fmt.Errorf("%s does not contain the latest commits of %s. Run 'stak restack' first", child, branchName)
+	}
+
+	childMetadata, err := stack.ReadBranchMetadata(child)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata for %s: %w", child, err)
+	}
+
+	// Folding the child down into this branch instead keeps this branch's PR
+	swap := ""
+	if !git.IsProtected(child) {
+		swap = child
+	}
+	prAction, err := chooseFoldPRAction(branchName, prNumber, child, childMetadata.PRNumber, swap)
+	if err != nil {
+		return err
+	}
+	switch prAction {
+	case "":
+		ui.Info("Fold cancelled")
+		return nil
+	case foldPRKeep:
+		foldUp = false
+		foldPR = string(foldPRMerge)
+		return runFold(child)
+	}
+
+	if err := checkStackOwnership("fold", []string{branchName, child}, foldForce); err != nil {
+		return err
+	}
+
+	commitCount, err := getCommitCount(branchName, parent)
+	if err != nil {
+		ui.Warning("Could not count commits")
+		commitCount = 0
+	}
+
+	// Show confirmation
+	if !foldForce {
+		ui.Info("This will:")
+		ui.Info(fmt.Sprintf("  - Fold %d commit(s) of %s into %s", commitCount, branchName, child))
+		ui.Info(fmt.Sprintf("  - Update %s to point to %s", child, parent))
+		if childMetadata.PRNumber > 0 {
+			ui.Info(fmt.Sprintf("  - Retarget PR #%d to %s", childMetadata.PRNumber, parent))
+		}
+		if prNumber > 0 && prAction == foldPRMerge {
+			ui.Info(fmt.Sprintf("  - Merge the description of PR #%d into PR #%d and close it", prNumber, childMetadata.PRNumber))
+		} else if prNumber > 0 {
+			ui.Info(fmt.Sprintf("  - Close PR #%d", prNumber))
+		}
+		ui.Info(fmt.Sprintf("  - Delete local branch %s", branchName))
+
+		prompt := promptui.Select{
+			Label: "Proceed with fold?",
+			Items: []string{"Yes", "No"},
+		}
+
+		_, result, err := runSelect(&prompt)
+		if err != nil || result == "No" {
+			ui.Info("Fold cancelled")
+			return nil
+		}
+	}
+
+	// Move the child onto the parent
+	ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", child, branchName, parent))
+	if err := stack.WriteBranchMetadata(child, parent, childMetadata.PRNumber); err != nil {
+		return fmt.Errorf("failed to update metadata for %s: %w", child, err)
+	}
+	if err := stack.RecordParentSHA(child, parent); err != nil {
+		ui.Warning(fmt.Sprintf("Could not record parent commit for %s: %v", child, err))
+	}
+
+	// Retarget the child's PR first, so closing the folded PR can't close it with its base
+	if childMetadata.PRNumber > 0 {
+		if err := github.UpdatePRBase(childMetadata.PRNumber, parent); err != nil {
+			ui.Warning(fmt.Sprintf("Could not update PR #%d base: %v", childMetadata.PRNumber, err))
+		} else {
+			ui.Success(fmt.Sprintf("Updated PR #%d base to %s", childMetadata.PRNumber, parent))
+		}
+	}
+
+	closeFoldedPR(prNumber, prAction, childMetadata.PRNumber)
+
+	// Leave the branch before deleting it
+	if currentBranch, _ := git.GetCurrentBranch(); currentBranch == branchName {
+		if err := git.CheckoutBranch(child); err != nil {
+			return fmt.Errorf("failed to checkout %s: %w", child, err)
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Deleting local branch %s", branchName))
+	if err := git.DeleteBranch(branchName, true); err != nil {
+		ui.Warning(fmt.Sprintf("Could not delete branch %s: %v", branchName, err))
+	} else {
+		ui.Success(fmt.Sprintf("Deleted branch %s", branchName))
+	}
+
+	if err := stack.DeleteBranchMetadata(branchName); err != nil {
+		ui.Warning(fmt.Sprintf("Could not delete metadata: %v", err))
+	}
+
+	ui.Success(fmt.Sprintf("Folded %s into %s", branchName, child))
+	return nil
+}
+
 func getCommitCount(branch, base string) (int, error) {
 	cmd := exec.Command("git", "rev-list", "--count", fmt.Sprintf("%s..%s", base, branch))
 	output, err := cmd.Output()
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/ui"
+)
+
+// foldPRAction is what fold does with the PR of the branch that goes away
+type foldPRAction string
+
+const (
+	foldPRClose foldPRAction = "close"
+	foldPRMerge foldPRAction = "merge"
+	foldPRKeep  foldPRAction = "keep"
+)
+
+// chooseFoldPRAction decides what happens to the PR of the folded branch. into is the branch
+// that absorbs it, and swap the branch that could be folded into branch the other way round to
+// keep the PR, or "" if that isn't possible. Without --pr, only a PR with review history
+// prompts, since closing it buries the discussion. An empty action means the user cancelled.
+func chooseFoldPRAction(branch string, prNumber int, into string, intoPR int, swap string) (foldPRAction, error) {
+	if prNumber == 0 {
+		return foldPRClose, nil
+	}
+
+	switch action := foldPRAction(foldPR); action {
+	case "":
+	case foldPRClose:
+		return action, nil
+	case foldPRMerge:
+		if intoPR == 0 {
+			return "", fmt.Errorf("%s has no PR to merge the description of PR #%d into", into, prNumber)
+		}
+		return action, nil
+	case foldPRKeep:
+		if swap == "" {
+			return "", fmt.Errorf("cannot keep PR #%d: no branch can be folded into %s instead", prNumber, branch)
+		}
+		return action, nil
+	default:
+		return "", fmt.Errorf("invalid --pr value %q (use close, merge or keep)", foldPR)
+	}
+
+	if foldForce {
+		return foldPRClose, nil
+	}
+	if hasHistory, err := github.HasReviewHistory(prNumber); err != nil || !hasHistory {
+		return foldPRClose, nil
+	}
+	if intoPR == 0 && swap == "" {
+		return foldPRClose, nil
+	}
+
+	ui.Warning(fmt.Sprintf("PR #%d has reviews or comments that closing it would bury", prNumber))
+	items := []string{fmt.Sprintf("Close PR #%d", prNumber)}
+	actions := []foldPRAction{foldPRClose}
+	if intoPR > 0 {
+		items = append(items, fmt.Sprintf("Merge its description into PR #%d and close it", intoPR))
+		actions = append(actions, foldPRMerge)
+	}
+	if swap != "" {
+		items = append(items, fmt.Sprintf("Keep PR #%d: fold %s into %s instead", prNumber, swap, branch))
+		actions = append(actions, foldPRKeep)
+	}
+
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("What should happen to PR #%d?", prNumber),
+		Items: items,
+	}
+	idx, _, err := runSelect(&prompt)
+	if err != nil {
+		return "", nil
+	}
+	return actions[idx], nil
+}
+
+// canFoldUpInto checks if parent can be folded up into branch, its only child, which keeps
+// branch's PR when folding branch would otherwise close it
+func canFoldUpInto(parent, branch string, parentChildren []string) bool {
+	if len(parentChildren) != 1 || parentChildren[0] != branch || git.IsProtected(parent) {
+		return false
+	}
+	return git.BranchContainsCommit(branch, parent)
+}
+
+// closeFoldedPR closes the PR of a folded branch. With foldPRMerge, its description is first
+// appended to the surviving PR and the closing comment points there, so the review history
+// stays one click away.
+func closeFoldedPR(prNumber int, action foldPRAction, intoPR int) {
+	if prNumber == 0 {
+		return
+	}
+
+	var err error
+	if action == foldPRMerge && intoPR > 0 {
+		if err := mergePRDescription(prNumber, intoPR); err != nil {
+			ui.Warning(fmt.Sprintf("Could not merge the description of PR #%d: %v", prNumber, err))
+		} else {
+			ui.Success(fmt.Sprintf("Merged the description of PR #%d into PR #%d", prNumber, intoPR))
+		}
+		ui.Info(fmt.Sprintf("Closing PR #%d", prNumber))
+		err = github.ClosePRWithComment(prNumber, fmt.Sprintf("Folded into #%d.", intoPR))
+	} else {
+		ui.Info(fmt.Sprintf("Closing PR #%d", prNumber))
+		err = github.ClosePR(prNumber)
+	}
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not close PR #%d: %v", prNumber, err))
+		ui.Info("You may want to manually close the PR")
+	} else {
+		ui.Success(fmt.Sprintf("Closed PR #%d", prNumber))
+	}
+}
+
+// mergePRDescription appends the title and description of PR from to PR into, under a
+// heading that links back to it. Running it twice doesn't add the section again.
+func mergePRDescription(from, into int) error {
+	source, err := github.GetPRContent(from)
+	if err != nil {
+		return err
+	}
+	target, err := github.GetPRContent(into)
+	if err != nil {
+		return err
+	}
+
+	heading := fmt.Sprintf("### Folded from #%d: %s", source.Number, source.Title)
+	body := strings.TrimSpace(target.Body)
+	if strings.Contains(body, heading) {
+		return nil
+	}
+
+	section := heading
+	if description := strings.TrimSpace(source.Body); description != "" {
+		section += "\n\n" + description
+	}
+	if body != "" {
+		body += "\n\n---\n\n"
+	}
+	return github.EditPR(into, "", body+section)
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"stacking/internal/git"
@@ -11,11 +10,12 @@ import (
 )
 
 var freezeCmd = &cobra.Command{
-	Use:     "freeze [branch]",
-	Aliases: []string{"fr"},
-	Short:   "Protect a branch from modifications",
-	Long:    `Mark a branch as frozen to prevent stack operations from modifying it. This is useful for protecting stable branches while working on dependent branches.`,
-	Args:    cobra.MaximumNArgs(1),
+	Use:               "freeze [branch]",
+	Aliases:           []string{"fr"},
+	Short:             "Protect a branch from modifications",
+	Long:              `Mark a branch as frozen to prevent stack operations from modifying it. This is useful for protecting stable branches while working on dependent branches.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeFrozen(false),
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -23,8 +23,7 @@ var freezeCmd = &cobra.Command{
 		}
 
 		if err := runFreeze(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -36,7 +35,7 @@ func init() {
 func runFreeze(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch
@@ -2,9 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"stacking/internal/git"
@@ -16,16 +14,16 @@ import (
 var getUser string
 
 var getCmd = &cobra.Command{
-	Use:     "get <branch>",
+	Use:     "get <branch|pr-number|pr-url>",
 	Aliases: []string{"gt"},
 	Short:   "Download and track a colleague's stack",
-	Long:    `Fetch a remote branch and automatically detect and track its entire stack structure from PR relationships.`,
-	Args:    cobra.ExactArgs(1),
+	Long: `Fetch a remote branch and automatically detect and track its entire stack structure from PR relationships.
+The branch can also be given as a PR number (123 or #123) or PR URL, in which case the PR's head branch is used.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := args[0]
 		if err := runGet(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -38,132 +36,134 @@ func init() {
 func runGet(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Fetch from remote
 	ui.Info("Fetching from remote")
-	cmd := exec.Command("git", "fetch", "origin")
+	cmd := exec.Command("git", "fetch", git.Remote)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to fetch from remote: %w", err)
 	}
 
-	// Check if remote branch exists
-	remoteBranch := "origin/" + branchName
-	cmd = exec.Command("git", "rev-parse", "--verify", remoteBranch)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("remote branch %s does not exist", branchName)
-	}
-
-	// Check if local branch already exists
-	localExists, err := git.BranchExists(branchName)
+	// Load every open PR once; stack discovery below runs against this index
+	ui.Info("Loading open PRs")
+	index, err := github.LoadOpenPRIndex()
 	if err != nil {
-		return fmt.Errorf("failed to check if local branch exists: %w", err)
+		ui.Warning(fmt.Sprintf("Could not list PRs: %v", err))
+		index = github.NewPRIndex(nil)
 	}
 
-	if localExists {
-		ui.Warning(fmt.Sprintf("Local branch %s already exists", branchName))
-		ui.Info("Checking out existing branch")
-		if err := git.CheckoutBranch(branchName); err != nil {
-			return fmt.Errorf("failed to checkout branch: %w", err)
+	// Resolve PR numbers and URLs to the PR's head branch
+	var requestedPR *github.PRSummary
+	if prNumber, ok := github.ParsePRReference(branchName); ok {
+		pr, found := index.ByNumber[prNumber]
+		if !found {
+			// Not open (or not in the first page of results) - look it up directly
+			details, err := github.GetPRDetails(prNumber)
+			if err != nil {
+				return fmt.Errorf("failed to look up PR #%d: %w", prNumber, err)
+			}
+			pr = github.PRSummary{
+				Number:            details.Number,
+				HeadRefName:       details.HeadRefName,
+				BaseRefName:       details.BaseRefName,
+				IsCrossRepository: details.IsCrossRepo,
+			}
 		}
-	} else {
-		// Create local branch tracking remote
-		ui.Info(fmt.Sprintf("Creating local branch %s from %s", branchName, remoteBranch))
-		cmd = exec.Command("git", "checkout", "-b", branchName, "--track", remoteBranch)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create local branch: %w", err)
+		ui.Info(fmt.Sprintf("PR #%d is branch %s", prNumber, pr.HeadRefName))
+		branchName = pr.HeadRefName
+		requestedPR = &pr
+	} else if pr, found := index.ByHead[branchName]; found {
+		requestedPR = &pr
+	}
+
+	if requestedPR != nil && requestedPR.IsCrossRepository {
+		// The fork's branch name may well be taken here, e.g. main, so it gets a name of its own
+		local, err := checkoutForkPR(*requestedPR)
+		if err != nil {
+			return err
 		}
+		branchName = local
+	} else if err := checkoutRemoteBranch(branchName); err != nil {
+		return err
 	}
 
 	// Try to detect PR for this branch
 	ui.Info("Detecting PR and stack structure")
-	prNumber, err := github.GetPRNumberForBranch(branchName)
-	if err != nil {
+	if requestedPR == nil {
 		ui.Warning("Could not find PR for branch - will only track the single branch")
 		ui.Info("Branch checked out successfully")
 		return nil
 	}
 
-	ui.Info(fmt.Sprintf("Found PR #%d for %s", prNumber, branchName))
-
-	// Get PR details to find base branch
-	prDetails, err := github.GetPRDetails(prNumber)
-	if err != nil {
-		ui.Warning(fmt.Sprintf("Could not get PR details: %v", err))
-		ui.Info("Branch checked out successfully")
-		return nil
-	}
-
-	baseBranch := prDetails.BaseRefName
-	ui.Info(fmt.Sprintf("PR base: %s", baseBranch))
+	ui.Info(fmt.Sprintf("Found PR #%d for %s", requestedPR.Number, branchName))
+	ui.Info(fmt.Sprintf("PR base: %s", requestedPR.BaseRefName))
 
-	// Build stack structure by following PR bases
+	// Parent, PR number and owner for every branch we discover, keyed by branch
+	parents := map[string]string{branchName: requestedPR.BaseRefName}
+	prNumbers := map[string]int{branchName: requestedPR.Number}
+	owners := map[string]string{branchName: requestedPR.Author.Login}
 	stackBranches := []string{branchName}
-	currentBase := baseBranch
 
-	// Walk up the stack (find ancestors)
+	// Walk up the stack (find ancestors) by following PR bases
+	currentBase := requestedPR.BaseRefName
 	for {
-		// Check if base branch has a PR
-		remoteBranchExists := false
-		cmd = exec.Command("git", "rev-parse", "--verify", "origin/"+currentBase)
-		if cmd.Run() == nil {
-			remoteBranchExists = true
-		}
-
-		if !remoteBranchExists {
-			// Base is probably main/master, stop here
-			break
-		}
-
-		basePRNumber, err := github.GetPRNumberForBranch(currentBase)
-		if err != nil {
-			// Base doesn't have a PR, stop here
-			break
-		}
-
-		basePRDetails, err := github.GetPRDetails(basePRNumber)
-		if err != nil {
+		basePR, found := index.ByHead[currentBase]
+		if !found || parents[currentBase] != "" {
+			// Base is probably main/master (or we've looped), stop here
 			break
 		}
 
 		// Fetch and track this base branch too
 		localExists, _ := git.BranchExists(currentBase)
 		if !localExists {
-			ui.Info(fmt.Sprintf("Fetching ancestor branch %s (PR #%d)", currentBase, basePRNumber))
-			cmd = exec.Command("git", "checkout", "-b", currentBase, "--track", "origin/"+currentBase)
-			cmd.Run() // Ignore errors
+			ui.Info(fmt.Sprintf("Fetching ancestor branch %s (PR #%d)", currentBase, basePR.Number))
+			git.CreateTrackingBranch(currentBase) // Ignore errors
 		}
 
+		parents[currentBase] = basePR.BaseRefName
+		prNumbers[currentBase] = basePR.Number
+		owners[currentBase] = basePR.Author.Login
 		stackBranches = append([]string{currentBase}, stackBranches...) // Prepend
-		currentBase = basePRDetails.BaseRefName
-	}
-
-	// Also check for descendant branches (children)
-	children, err := findChildBranches(branchName)
-	if err == nil && len(children) > 0 {
-		for _, child := range children {
-			localExists, _ := git.BranchExists(child)
-			if !localExists {
-				ui.Info(fmt.Sprintf("Fetching descendant branch %s", child))
-				cmd = exec.Command("git", "checkout", "-b", child, "--track", "origin/"+child)
-				cmd.Run() // Ignore errors
+		currentBase = basePR.BaseRefName
+	}
+
+	// Also find descendant branches (children, grandchildren, ...)
+	if !requestedPR.IsCrossRepository {
+		queue := []string{branchName}
+		for len(queue) > 0 {
+			parent := queue[0]
+			queue = queue[1:]
+
+			for _, child := range index.ByBase[parent] {
+				if _, seen := parents[child.HeadRefName]; seen {
+					continue
+				}
+				if child.IsCrossRepository {
+					ui.Info(fmt.Sprintf("Skipping descendant PR #%d (%s): opened from a fork", child.Number, child.HeadRefName))
+					continue
+				}
+
+				localExists, _ := git.BranchExists(child.HeadRefName)
+				if !localExists {
+					ui.Info(fmt.Sprintf("Fetching descendant branch %s (PR #%d)", child.HeadRefName, child.Number))
+					git.CreateTrackingBranch(child.HeadRefName) // Ignore errors
+				}
+
+				parents[child.HeadRefName] = parent
+				prNumbers[child.HeadRefName] = child.Number
+				owners[child.HeadRefName] = child.Author.Login
+				stackBranches = append(stackBranches, child.HeadRefName)
+				queue = append(queue, child.HeadRefName)
 			}
-			stackBranches = append(stackBranches, child)
 		}
 	}
 
 	// Track all branches in the stack
 	ui.Info(fmt.Sprintf("\nTracking %d branch(es) in stack:", len(stackBranches)))
-	for i, branch := range stackBranches {
-		var parent string
-		if i == 0 {
-			// First branch - parent is the final base (main, etc.)
-			parent = currentBase
-		} else {
-			// Subsequent branches - parent is previous in list
-			parent = stackBranches[i-1]
-		}
+	for _, branch := range stackBranches {
+		parent := parents[branch]
 
 		// Check if already tracked
 		hasMetadata, _ := stack.HasStackMetadata(branch)
@@ -173,10 +173,10 @@ func runGet(branchName string) error {
 		}
 
 		// Track the branch
-		branchPR, _ := github.GetPRNumberForBranch(branch)
-		if err := stack.WriteBranchMetadata(branch, parent, branchPR); err != nil {
+		if err := stack.WriteBranchMetadata(branch, parent, prNumbers[branch]); err != nil {
 			ui.Warning(fmt.Sprintf("  %s → failed to track: %v", branch, err))
 		} else {
+			stack.SetBranchOwner(branch, owners[branch])
 			ui.Success(fmt.Sprintf("  %s → %s", branch, parent))
 		}
 	}
@@ -192,45 +192,66 @@ func runGet(branchName string) error {
 	return nil
 }
 
-// findChildBranches finds branches whose PRs target the given branch
-func findChildBranches(parentBranch string) ([]string, error) {
-	// List all remote branches
-	cmd := exec.Command("git", "branch", "-r")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
+// checkoutRemoteBranch checks out a branch from the remote, creating a local tracking branch if needed
+func checkoutRemoteBranch(branchName string) error {
+	// Check if remote branch exists
+	remoteBranch := git.RemoteRef(branchName)
+	if !git.RemoteTrackingBranchExists(branchName) {
+		return fmt.Errorf("remote branch %s does not exist", branchName)
 	}
 
-	var children []string
-	branches := strings.Split(string(output), "\n")
+	// Check if local branch already exists
+	localExists, err := git.BranchExists(branchName)
+	if err != nil {
+		return fmt.Errorf("failed to check if local branch exists: %w", err)
+	}
 
-	for _, line := range branches {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.Contains(line, "->") {
-			continue
+	if localExists {
+		ui.Warning(fmt.Sprintf("Local branch %s already exists", branchName))
+		ui.Info("Checking out existing branch")
+		if err := git.CheckoutBranch(branchName); err != nil {
+			return fmt.Errorf("failed to checkout branch: %w", err)
 		}
+		return nil
+	}
 
-		// Extract branch name (remove "origin/" prefix)
-		remoteBranch := strings.TrimPrefix(line, "origin/")
-		if remoteBranch == parentBranch {
-			continue
-		}
+	// Create local branch tracking remote
+	ui.Info(fmt.Sprintf("Creating local branch %s from %s", branchName, remoteBranch))
+	cmd := exec.Command("git", "checkout", "-b", branchName, "--track", remoteBranch)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create local branch: %w", err)
+	}
+	return nil
+}
 
-		// Check if this branch has a PR targeting parent
-		prNumber, err := github.GetPRNumberForBranch(remoteBranch)
-		if err != nil {
-			continue
-		}
+// forkBranchName is the local branch a fork PR's head is fetched into. The fork's own branch
+// name can't be used, as it may be the name of a local branch such as main.
+func forkBranchName(prNumber int) string {
+	return fmt.Sprintf("pr-%d", prNumber)
+}
 
-		prDetails, err := github.GetPRDetails(prNumber)
-		if err != nil {
-			continue
-		}
+// checkoutForkPR fetches a fork PR's head into its local branch (see forkBranchName), checks it
+// out and returns its name
+func checkoutForkPR(pr github.PRSummary) (string, error) {
+	branch := forkBranchName(pr.Number)
+	localExists, err := git.BranchExists(branch)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if local branch exists: %w", err)
+	}
 
-		if prDetails.BaseRefName == parentBranch {
-			children = append(children, remoteBranch)
+	if localExists {
+		ui.Warning(fmt.Sprintf("Local branch %s already exists", branch))
+		ui.Info("Checking out existing branch")
+	} else {
+		ui.Info(fmt.Sprintf("PR #%d is from a fork (%s), fetching pull/%d/head into %s", pr.Number, pr.HeadRefName, pr.Number, branch))
+		if err := git.FetchPullRequest(pr.Number, branch); err != nil {
+			return "", err
 		}
+		ui.Warning(fmt.Sprintf("Fork branches can't be pushed to %s; this branch is for local review only", git.Remote))
 	}
 
-	return children, nil
+	if err := git.CheckoutBranch(branch); err != nil {
+		return "", fmt.Errorf("failed to checkout branch: %w", err)
+	}
+	return branch, nil
 }
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	graftOnto  string
+	graftForce bool
+)
+
+var graftCmd = &cobra.Command{
+	Use:     "graft [branch]",
+	Aliases: []string{"gf"},
+	Short:   "Move a branch and its descendants into another stack",
+	Long: `Detach a branch and all of its descendants from their stack and attach them under a branch
+of another stack, or directly on trunk to start a stack of their own.
+
+The subtree is restacked onto its new parent in one pass, each branch replaying only its own
+commits, so nothing of the old stack comes along. The branch's PR is retargeted, rewritten
+branches are force-pushed, and the stack comments on the PRs of both stacks are refreshed.
+
+Without --onto, pick the new parent from trunk and the branches of the other stacks.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
+	Run: func(cmd *cobra.Command, args []string) {
+		branchName := ""
+		if len(args) > 0 {
+			branchName = args[0]
+		}
+
+		if err := runGraft(branchName); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	graftCmd.Flags().StringVar(&graftOnto, "onto", "", "Branch of another stack, or trunk, to attach the branch to")
+	graftCmd.RegisterFlagCompletionFunc("onto", completeParent)
+	graftCmd.Flags().BoolVarP(&graftForce, "force", "f", false, "Skip confirmation prompts")
+	rootCmd.AddCommand(graftCmd)
+}
+
+func runGraft(branchName string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	if branchName == "" {
+		var err error
+		branchName, err = git.GetCurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	if !ctx.IsTracked(branchName) {
+		return fmt.Errorf("branch %s is not tracked. Use 'stak track' first", branchName)
+	}
+
+	oldParent := ctx.Parent(branchName)
+	oldRoot := stackRoot(ctx, branchName)
+	descendants := ctx.Descendants(branchName)
+
+	target := graftOnto
+	if target == "" {
+		if target, err = selectGraftTarget(ctx, branchName); err != nil {
+			return err
+		}
+	}
+
+	exists, err := git.BranchExists(target)
+	if err != nil {
+		return fmt.Errorf("failed to check if %s exists: %w", target, err)
+	}
+	if !exists {
+		return fmt.Errorf("branch %s does not exist", target)
+	}
+	if target == branchName {
+		return fmt.Errorf("cannot graft %s onto itself", branchName)
+	}
+	for _, descendant := range descendants {
+		if descendant == target {
+			return fmt.Errorf("cannot graft %s onto its own descendant %s", branchName, target)
+		}
+	}
+	if target == oldParent {
+		ui.Info(fmt.Sprintf("%s is already based on %s. Nothing to do.", branchName, target))
+		return nil
+	}
+	if ctx.IsTracked(target) && stackRoot(ctx, target) == oldRoot {
+		return fmt.Errorf("%s is in the same stack as %s. Use 'stak move' to move branches within a stack", target, branchName)
+	}
+
+	if err := checkStackOwnership("graft", append([]string{branchName}, descendants...), graftForce); err != nil {
+		return err
+	}
+
+	// Show confirmation
+	if !graftForce {
+		ui.Info("This will:")
+		ui.Info(fmt.Sprintf("  - Detach %s and %d descendant(s) from %s", branchName, len(descendants), oldParent))
+		ui.Info(fmt.Sprintf("  - Rebase them onto %s, keeping only their own commits", target))
+		if prNumber := ctx.PRNumber(branchName); prNumber > 0 {
+			ui.Info(fmt.Sprintf("  - Retarget PR #%d to %s", prNumber, target))
+		}
+		ui.Info("  - Force push the rewritten branches")
+
+		prompt := promptui.Select{
+			Label: "Proceed with graft?",
+			Items: []string{"Yes", "No"},
+		}
+
+		_, result, err := runSelect(&prompt)
+		if err != nil || result == "No" {
+			ui.Info("Graft cancelled")
+			return nil
+		}
+	}
+
+	if err := moveSubtree(branchName, target, descendants); err != nil {
+		return err
+	}
+
+	// Both stacks changed shape, so their PRs' stack comments are stale
+	if github.IsGHAuthenticated() {
+		if ctx.IsTracked(oldParent) {
+			if err := updateStackComments(oldParent); err != nil {
+				ui.Warning(fmt.Sprintf("Could not update stack comments: %v", err))
+			}
+		}
+		if err := updateStackComments(branchName); err != nil {
+			ui.Warning(fmt.Sprintf("Could not update stack comments: %v", err))
+		}
+	}
+
+	ui.Success(fmt.Sprintf("Grafted %s from %s onto %s", branchName, oldParent, target))
+	return nil
+}
+
+// selectGraftTarget lets the user pick trunk or a branch of another stack as the new parent
+func selectGraftTarget(ctx *stack.StackContext, branchName string) (string, error) {
+	root := stackRoot(ctx, branchName)
+
+	var targets, items []string
+	for _, base := range existingBaseBranches() {
+		if base != ctx.Parent(branchName) {
+			targets = append(targets, base)
+			items = append(items, fmt.Sprintf("%s (trunk)", base))
+		}
+	}
+	for _, branch := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		if branch.Parent == "" || stackRoot(ctx, branch.Name) == root {
+			continue
+		}
+		targets = append(targets, branch.Name)
+		items = append(items, fmt.Sprintf("%s (stack of %s)", branch.Name, stackRoot(ctx, branch.Name)))
+	}
+	if len(targets) == 0 {
+		return "", fmt.Errorf("there is no other stack to graft %s onto", branchName)
+	}
+
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("Graft %s onto", branchName),
+		Items: items,
+		Size:  15,
+	}
+	idx, _, err := runSelect(&prompt)
+	if err != nil {
+		return "", fmt.Errorf("graft cancelled")
+	}
+	return targets[idx], nil
+}
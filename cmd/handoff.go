@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var handoffForce bool
+
+var handoffCmd = &cobra.Command{
+	Use:     "handoff <teammate> [branch]",
+	Aliases: []string{"ho"},
+	Short:   "Hand a stack over to a teammate",
+	Long: `Push every branch in the stack, publish the stack metadata to the PR comments, reassign the PRs to a teammate,
+and print the command they need to pick the stack up.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		branchName := ""
+		if len(args) > 1 {
+			branchName = args[1]
+		}
+		if err := runHandoff(args[0], branchName); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	handoffCmd.Flags().BoolVar(&handoffForce, "force", false, "Hand off even if branches are owned by someone else")
+	rootCmd.AddCommand(handoffCmd)
+}
+
+func runHandoff(teammate, branchName string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	// Check if gh CLI is authenticated
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	teammate = strings.TrimPrefix(teammate, "@")
+	if teammate == "" {
+		return fmt.Errorf("teammate login cannot be empty")
+	}
+
+	// Default to current branch
+	if branchName == "" {
+		current, err := git.GetCurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+		branchName = current
+	}
+
+	hasMetadata, err := stack.HasStackMetadata(branchName)
+	if err != nil {
+		return fmt.Errorf("failed to check metadata: %w", err)
+	}
+	if !hasMetadata {
+		return fmt.Errorf("branch %s is not tracked in a stack", branchName)
+	}
+
+	// Hand off the whole stack, not just this branch
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	var stackBranches []string
+	for _, branch := range ctx.FullStack(branchName) {
+		// The trunk (e.g. main) is an ancestor but not part of the stack
+		if ctx.IsTracked(branch) {
+			stackBranches = append(stackBranches, branch)
+		}
+	}
+
+	if err := checkStackOwnership("hand off", stackBranches, handoffForce); err != nil {
+		return err
+	}
+
+	ui.Info(fmt.Sprintf("Handing off %d branch(es) to @%s", len(stackBranches), teammate))
+
+	// Push everything so the teammate sees exactly what we have locally
+	for _, branch := range stackBranches {
+		ui.Info(fmt.Sprintf("Pushing %s", branch))
+		if err := git.Push(branch, true, true); err != nil {
+			return err
+		}
+	}
+
+	// Record the new owner before publishing so the comment metadata carries it
+	me, _ := github.GetCurrentUser()
+	for _, branch := range stackBranches {
+		if err := stack.SetBranchOwner(branch, teammate); err != nil {
+			ui.Warning(err.Error())
+		}
+	}
+
+	// Publish the stack metadata and reassign the PRs
+	if err := updateStackComments(branchName); err != nil {
+		ui.Warning(fmt.Sprintf("Failed to update stack comments: %v", err))
+	}
+
+	for _, branch := range stackBranches {
+		metadata, err := stack.ReadBranchMetadata(branch)
+		if err != nil || metadata.PRNumber == 0 {
+			ui.Warning(fmt.Sprintf("%s has no PR - run 'stak submit' so your teammate can find it", branch))
+			continue
+		}
+		if err := github.ReassignPR(metadata.PRNumber, teammate, me); err != nil {
+			ui.Warning(err.Error())
+			continue
+		}
+		ui.Success(fmt.Sprintf("Reassigned PR #%d to @%s", metadata.PRNumber, teammate))
+	}
+
+	ui.Success(fmt.Sprintf("\nStack handed off to @%s", teammate))
+	ui.Info("They can pick it up with:")
+	fmt.Printf("  stak get %s\n", stackBranches[0])
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/config"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/interop"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	importFrom   string
+	importDryRun bool
+	importForce  bool
+	importFetch  bool
+)
+
+// importSources are the tools stak import can read stacks from
+var importSources = []string{"graphite", "branchless", "spr"}
+
+// importToolNames are the names of the tools of importSources, for messages
+var importToolNames = map[string]string{
+	"graphite":   "Graphite",
+	"branchless": "git-branchless",
+	"spr":        "spr",
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import --from graphite|branchless|spr",
+	Short: "Track stacks recorded by another stacking tool",
+	Long: `Convert the stacks another tool tracks into stak metadata, keeping each branch's parent
+and PR number, so a team can switch to stak without re-tracking every branch.
+
+--from graphite reads the metadata the Graphite CLI keeps under refs/branch-metadata/,
+with PR numbers it lacks taken from .git/.graphite_pr_info, and the trunk from
+.git/.graphite_repo_config if stak has none set. Graphite's own metadata is left as it is.
+With --fetch, the metadata refs are fetched from the remote first, e.g. after a teammate
+ran stak export --push.
+
+--from branchless rebuilds the stacks of git-branchless from the commit graph, like its
+smartlog: each branch is stacked on the nearest branch below it, or on the main branch.
+
+--from spr turns the commits of the current branch, one per PR, into a branch per PR at
+that commit, named after the PR's branch (spr/<trunk>/<commit-id> for ejoffe/spr) and
+stacked in commit order. Commits spr hasn't marked go with the commit above them.
+
+PR numbers the tool doesn't know are looked up among the open PRs. Branches stak already
+tracks are skipped unless --force is given, as are branches that don't exist locally.
+--dry-run shows what would be imported.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runImport(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Tool to import from: graphite, branchless or spr")
+	importCmd.MarkFlagRequired("from")
+	importCmd.RegisterFlagCompletionFunc("from", completeValues(importSources...))
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without changing anything")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite the parent and PR number of branches stak already tracks")
+	importCmd.Flags().BoolVar(&importFetch, "fetch", false, "Fetch Graphite's metadata from the remote first, e.g. as pushed by stak export --push")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport() error {
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+	if !contains(importSources, importFrom) {
+		return fmt.Errorf("cannot import from %q: use %s", importFrom, strings.Join(importSources, ", "))
+	}
+	tool := importToolNames[importFrom]
+	if importFetch && importFrom != "graphite" {
+		return fmt.Errorf("--fetch only applies to --from graphite")
+	}
+
+	// spr's branches are its PRs' branches; the other tools' PRs are looked up if they lack them
+	authenticated := github.IsGHAuthenticated()
+	if importFrom == "spr" && !authenticated {
+		return errNotAuthenticated
+	}
+	var openPRs []github.PRSummary
+	if authenticated {
+		var err error
+		if openPRs, err = github.ListOpenPRs(""); err != nil && importFrom == "spr" {
+			return err
+		} else if err != nil {
+			ui.Warning(fmt.Sprintf("Could not look up PRs: %v", err))
+		}
+	}
+
+	branches, trunk, err := loadImport(openPRs)
+	if err != nil {
+		return err
+	}
+	if len(branches) == 0 {
+		return fmt.Errorf("no stacks found to import from %s", tool)
+	}
+	for i := range branches {
+		if branches[i].PRNumber > 0 {
+			continue
+		}
+		for _, pr := range openPRs {
+			if pr.HeadRefName == branches[i].Name && !pr.IsCrossRepository {
+				branches[i].PRNumber = pr.Number
+			}
+		}
+	}
+
+	if trunk != "" && config.GetString("trunk", "") == "" {
+		if importDryRun {
+			ui.Info(fmt.Sprintf("Would set the trunk to %s", trunk))
+		} else if err := config.Set("trunk", trunk); err != nil {
+			return err
+		} else {
+			ui.Info(fmt.Sprintf("Set the trunk to %s, like %s", trunk, tool))
+		}
+	}
+
+	// Branches created along the way, for those stacked on them
+	created := make(map[string]bool)
+	count := 0
+	for _, branch := range branches {
+		exists, err := git.BranchExists(branch.Name)
+		if err != nil {
+			return err
+		}
+		if !exists && branch.Commit == "" {
+			ui.Warning(fmt.Sprintf("Skipping %s: not a local branch", branch.Name))
+			continue
+		}
+		if parentExists, err := git.BranchExists(branch.Parent); err != nil || (!parentExists && !created[branch.Parent]) {
+			ui.Warning(fmt.Sprintf("Skipping %s: its parent %s is not a local branch", branch.Name, branch.Parent))
+			continue
+		}
+		tracked, err := stack.HasStackMetadata(branch.Name)
+		if err != nil {
+			return fmt.Errorf("failed to check stack metadata: %w", err)
+		}
+		if tracked && !importForce {
+			ui.Info(fmt.Sprintf("Skipping %s: already tracked (--force to overwrite)", branch.Name))
+			continue
+		}
+
+		description := fmt.Sprintf("%s on %s", branch.Name, branch.Parent)
+		if branch.PRNumber > 0 {
+			description += fmt.Sprintf(" (PR #%d)", branch.PRNumber)
+		}
+		if !exists {
+			description += fmt.Sprintf(", created at %s", shortSHA(branch.Commit))
+			created[branch.Name] = true
+		}
+		count++
+		if importDryRun {
+			fmt.Printf("  %s\n", description)
+			continue
+		}
+
+		if !exists {
+			if err := git.CreateBranchAt(branch.Name, branch.Commit); err != nil {
+				return err
+			}
+		}
+		if err := stack.WriteBranchMetadata(branch.Name, branch.Parent, branch.PRNumber); err != nil {
+			return fmt.Errorf("failed to write metadata: %w", err)
+		}
+		// The tool's record of where the branch forks off beats guessing, while it still holds
+		if branch.ParentRevision != "" && git.BranchContainsCommit(branch.Name, branch.ParentRevision) {
+			err = git.SetBranchParentSHA(branch.Name, branch.ParentRevision)
+		} else {
+			err = stack.RecordParentSHA(branch.Name, branch.Parent)
+		}
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit of %s: %v", branch.Name, err))
+		}
+		ui.Success(fmt.Sprintf("Imported %s", description))
+	}
+
+	switch {
+	case count == 0:
+		ui.Info("Nothing to import")
+	case importDryRun:
+		ui.Info(fmt.Sprintf("Would import %d branch(es) from %s", count, tool))
+	default:
+		ui.Success(fmt.Sprintf("Imported %d branch(es) from %s. Run 'stak list' to see your stacks", count, tool))
+	}
+	return nil
+}
+
+// loadImport reads the branches of the tool --from names, and its trunk if it has one
+func loadImport(openPRs []github.PRSummary) ([]interop.Branch, string, error) {
+	switch importFrom {
+	case "graphite":
+		if importFetch {
+			ui.Info(fmt.Sprintf("Fetching %s from %s", interop.GraphiteRefs, git.Remote))
+			if err := git.FetchRefs(interop.GraphiteRefs); err != nil {
+				return nil, "", err
+			}
+		}
+		branches, trunk, err := interop.LoadGraphite()
+		if err == nil && len(branches) == 0 {
+			err = fmt.Errorf("no Graphite metadata found: there are no refs under %s", interop.GraphiteRefs)
+		}
+		return branches, trunk, err
+	case "branchless":
+		return interop.LoadBranchless()
+	}
+
+	current, err := git.GetCurrentBranch()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	trunk, err := stack.Trunk()
+	if err != nil {
+		return nil, "", err
+	}
+	branches, err := interop.LoadSpr(current, trunk, openPRs)
+	return branches, "", err
+}
@@ -1,49 +1,86 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"stacking/internal/config"
 	"stacking/internal/git"
 	"stacking/internal/github"
+	"stacking/internal/stack"
 	"stacking/internal/ui"
 )
 
+var (
+	initYes      bool
+	initGitAlias []string
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init",
-	Short: "Initialize repository for stack",
-	Long: `Initialize the current repository for using stack.
-Verifies git repository and GitHub CLI authentication.`,
+	Short: "Set up the repository for stak",
+	Long: `Walk through setting up the current repository for stak: confirm the trunk branch and remote,
+pick the default merge method and draft policy, optionally install a git hook and shell completions,
+and write the answers to .stak.toml at the repository root so the whole team shares them.
+
+With --yes, the detected defaults are accepted without prompting.
+
+With --git-alias, only installs a global git alias so stak can also be run as "git stak".
+Pass --git-alias=stak,stack to install "git stack" as well.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runInit(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Accept detected defaults without prompting")
+	initCmd.Flags().StringSliceVar(&initGitAlias, "git-alias", nil, "Install git aliases (default \"stak\") that run stak, then exit")
+	initCmd.Flags().Lookup("git-alias").NoOptDefVal = "stak"
 	rootCmd.AddCommand(initCmd)
 }
 
+// prePushHookMarker identifies hooks written by stak, so they are never confused with user hooks
+const prePushHookMarker = "# Installed by stak init"
+
+const prePushHook = `#!/bin/sh
+` + prePushHookMarker + `: warn before pushing a stack that needs a sync.
+if [ "$(stak daemon status --short 2>/dev/null)" = "needs-sync" ]; then
+	echo "stak: this stack needs a sync before pushing (run: stak sync)" >&2
+fi
+exit 0
+`
+
 func runInit() error {
+	if len(initGitAlias) > 0 {
+		return installGitAliases(initGitAlias)
+	}
+
 	ui.Info("Initializing repository for stack")
 
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository. Run: git init")
+		return fmt.Errorf("%w. Run: git init", errNotGitRepository)
 	}
 	ui.Success("Git repository detected")
 
-	// Check for remote
-	remoteURL, err := git.GetRemoteURL()
+	// Don't prompt when asked not to, or when prompts are disabled (e.g. in CI)
+	interactive := !initYes && requireInteractive("init") == nil
+
+	// Remote
+	remote, err := initChooseRemote(interactive)
 	if err != nil {
-		ui.Warning("No remote repository configured")
-		ui.Info("You can add a remote with: git remote add origin <url>")
-	} else {
-		ui.Success(fmt.Sprintf("Remote repository: %s", remoteURL))
+		return err
 	}
+	git.Remote = remote
 
 	// Check if gh CLI is installed
 	ui.Info("Checking GitHub CLI (gh)")
@@ -54,17 +91,62 @@ func runInit() error {
 		ui.Success("GitHub CLI authenticated")
 	}
 
-	// Get current branch
-	currentBranch, err := git.GetCurrentBranch()
+	// Trunk
+	trunk, err := initChooseTrunk(interactive)
 	if err != nil {
-		ui.Warning("Could not determine current branch")
-	} else {
-		ui.Info(fmt.Sprintf("Current branch: %s", currentBranch))
+		return err
+	}
+
+	// Merge method
+	mergeMethod, err := initChoose(interactive, "Default merge method", []string{"squash", "merge", "rebase"},
+		config.GetString("merge-method", "squash"))
+	if err != nil {
+		return err
+	}
+
+	// Draft policy
+	draftDefault := "ready for review"
+	if config.GetBool("draft", false) {
+		draftDefault = "draft"
+	}
+	draftPolicy, err := initChoose(interactive, "Open new PRs as", []string{"ready for review", "draft"}, draftDefault)
+	if err != nil {
+		return err
+	}
+
+	path, err := config.WriteProjectFile([]config.Setting{
+		{Key: "trunk", Value: trunk},
+		{Key: "remote", Value: remote},
+		{Key: "merge-method", Value: mergeMethod},
+		{Key: "draft", Value: fmt.Sprintf("%t", draftPolicy == "draft")},
+	})
+	if err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Wrote %s (commit it to share these settings with your team)", path))
+
+	// Optional extras are opt-in, so they are skipped unless answered interactively
+	if interactive {
+		if ok, err := initConfirm("Install a pre-push hook that warns when the stack needs a sync"); err != nil {
+			return err
+		} else if ok {
+			if err := installPrePushHook(); err != nil {
+				ui.Warning(err.Error())
+			}
+		}
+
+		if ok, err := initConfirm("Install shell completions"); err != nil {
+			return err
+		} else if ok {
+			if err := installCompletions(); err != nil {
+				ui.Warning(err.Error())
+			}
+		}
 	}
 
 	ui.Success("Repository initialized for stack")
 	fmt.Println("\nNext steps:")
-	fmt.Println("  1. Create a new branch from your base branch")
+	fmt.Printf("  1. Create a new branch from %s\n", trunk)
 	fmt.Println("  2. Make commits and run: stak create --title \"Your PR title\"")
 	fmt.Println("  3. Use 'stak list' to visualize your stack")
 	fmt.Println("  4. Use 'stak sync' to keep branches in sync")
@@ -72,3 +154,230 @@ func runInit() error {
 
 	return nil
 }
+
+// initChoose asks the user to pick one of items, or returns def when not interactive
+func initChoose(interactive bool, label string, items []string, def string) (string, error) {
+	if !interactive {
+		ui.Info(fmt.Sprintf("%s: %s", label, def))
+		return def, nil
+	}
+
+	cursor := 0
+	for i, item := range items {
+		if item == def {
+			cursor = i
+		}
+	}
+
+	prompt := promptui.Select{
+		Label:     label,
+		Items:     items,
+		CursorPos: cursor,
+	}
+	_, result, err := runSelect(&prompt)
+	if err != nil {
+		return "", fmt.Errorf("init cancelled")
+	}
+	return result, nil
+}
+
+// initConfirm asks a yes/no question, defaulting to no
+func initConfirm(label string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+	_, err := runPrompt(&prompt)
+	if errors.Is(err, promptui.ErrAbort) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("init cancelled")
+	}
+	return true, nil
+}
+
+func initChooseRemote(interactive bool) (string, error) {
+	remotes, err := git.GetRemotes()
+	if err != nil {
+		return "", err
+	}
+	if len(remotes) == 0 {
+		ui.Warning("No remote repository configured")
+		ui.Info(fmt.Sprintf("You can add a remote with: git remote add %s <url>", git.Remote))
+		return git.Remote, nil
+	}
+
+	def := git.Remote
+	if !contains(remotes, def) {
+		def = remotes[0]
+	}
+	if len(remotes) == 1 {
+		interactive = false // Nothing to choose
+	}
+
+	remote, err := initChoose(interactive, "Remote", remotes, def)
+	if err != nil {
+		return "", err
+	}
+
+	git.Remote = remote
+	if url, err := git.GetRemoteURL(); err == nil {
+		ui.Success(fmt.Sprintf("Remote repository: %s", url))
+	}
+	return remote, nil
+}
+
+func initChooseTrunk(interactive bool) (string, error) {
+	detected, err := stack.Trunk()
+	if err != nil {
+		detected = ""
+	}
+
+	if !interactive {
+		if detected == "" {
+			return "", err
+		}
+		ui.Info(fmt.Sprintf("Trunk branch: %s", detected))
+		return detected, nil
+	}
+
+	prompt := promptui.Prompt{
+		Label:   "Trunk branch",
+		Default: detected,
+		Validate: func(input string) error {
+			exists, err := git.BranchExists(strings.TrimSpace(input))
+			if err != nil || !exists {
+				return fmt.Errorf("branch %s does not exist", input)
+			}
+			return nil
+		},
+	}
+	trunk, err := runPrompt(&prompt)
+	if err != nil {
+		return "", fmt.Errorf("init cancelled")
+	}
+	return strings.TrimSpace(trunk), nil
+}
+
+// installPrePushHook writes stak's pre-push hook, leaving any hook not written by stak alone
+func installPrePushHook() error {
+	path, err := git.GetGitPath("hooks/pre-push")
+	if err != nil {
+		return err
+	}
+
+	if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), prePushHookMarker) {
+		return fmt.Errorf("%s already exists; not overwriting it", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(prePushHook), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	ui.Success(fmt.Sprintf("Installed pre-push hook at %s", path))
+	return nil
+}
+
+// installCompletions writes the completion script for the user's shell where the shell loads it automatically
+func installCompletions() error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to find home directory: %w", err)
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell != "" {
+		shell = filepath.Base(shell)
+	}
+	var path string
+	var generate func(f *os.File) error
+	switch shell {
+	case "bash":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		path = filepath.Join(dataHome, "bash-completion", "completions", "stak")
+		generate = func(f *os.File) error { return rootCmd.GenBashCompletionV2(f, true) }
+	case "zsh":
+		path = filepath.Join(home, ".zsh", "completions", "_stak")
+		generate = func(f *os.File) error { return rootCmd.GenZshCompletion(f) }
+	case "fish":
+		path = filepath.Join(home, ".config", "fish", "completions", "stak.fish")
+		generate = func(f *os.File) error { return rootCmd.GenFishCompletion(f, true) }
+	case "":
+		if runtime.GOOS == "windows" {
+			// PowerShell has no completions directory, and $SHELL isn't set there
+			return fmt.Errorf("PowerShell completions are loaded from your profile. Add to $PROFILE: stak completion powershell | Out-String | Invoke-Expression")
+		}
+		return fmt.Errorf("$SHELL is not set, so the shell to install completions for is unknown. Run: stak completion --help")
+	default:
+		return fmt.Errorf("don't know where to install completions for shell %q. Run: stak completion --help", shell)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := generate(f); err != nil {
+		return fmt.Errorf("failed to generate completions: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Installed %s completions at %s", shell, path))
+	if shell == "zsh" {
+		ui.Info("Make sure ~/.zsh/completions is in your fpath, e.g. add to ~/.zshrc: fpath=(~/.zsh/completions $fpath)")
+	}
+	return nil
+}
+
+// installGitAliases adds global git aliases (e.g. "git stak") that run stak
+func installGitAliases(names []string) error {
+	// Prefer stak from PATH so the alias keeps working after upgrades or reinstalls
+	command := "stak"
+	if _, err := exec.LookPath("stak"); err != nil {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate stak: %w", err)
+		}
+		// git runs the alias with sh, also on Windows, where the path needs forward slashes
+		command = `"` + filepath.ToSlash(exe) + `"`
+	}
+	alias := "!" + command
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		key := "alias." + name
+
+		existing, err := git.GetGlobalConfig(key)
+		if err != nil {
+			return err
+		}
+		if existing == alias {
+			ui.Info(fmt.Sprintf("git %s already runs stak", name))
+			continue
+		}
+		if existing != "" {
+			ui.Warning(fmt.Sprintf("git %s is already an alias for %q; leaving it alone", name, existing))
+			continue
+		}
+
+		if err := git.SetGlobalConfig(key, alias); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Installed git alias: git %s", name))
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"stacking/internal/config"
+	"stacking/internal/ui"
+)
+
+// requireInteractive fails when prompts are disabled with the no-interactive setting
+// (e.g. STAK_NO_INTERACTIVE=1 in CI), so commands error out instead of hanging on stdin
+func requireInteractive(what string) error {
+	if config.GetBool("no-interactive", false) {
+		return fmt.Errorf("cannot prompt for %s: interactive prompts are disabled (no-interactive). Pass it as an argument or flag instead", what)
+	}
+	return nil
+}
+
+// runSelect runs a selection prompt, unless prompts are disabled
+func runSelect(prompt *promptui.Select) (int, string, error) {
+	if err := requireInteractive(fmt.Sprintf("%q", prompt.Label)); err != nil {
+		// Callers often report a failed prompt as "cancelled", so say why here
+		ui.Warning(err.Error())
+		return 0, "", err
+	}
+	// Run scrolls to the top, which would hide a starting cursor further down
+	size := prompt.Size
+	if size < 1 {
+		size = 5
+	}
+	return prompt.RunCursorAt(prompt.CursorPos, max(0, prompt.CursorPos-size+1))
+}
+
+// runPrompt runs a text or confirmation prompt, unless prompts are disabled
+func runPrompt(prompt *promptui.Prompt) (string, error) {
+	if err := requireInteractive(fmt.Sprintf("%q", prompt.Label)); err != nil {
+		ui.Warning(err.Error())
+		return "", err
+	}
+	return prompt.Run()
+}
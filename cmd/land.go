@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/config"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var landCmd = &cobra.Command{
+	Use:   "land",
+	Short: "Restack, check and merge the whole stack in one go",
+	Long: `Land the current branch and every branch below it: the full happy path of stak merge --all
+in one confirmed command.
+
+land restacks the stack locally, checks that every PR is approved, passing and mergeable, shows
+the merge plan and asks to go ahead. Only then does it push the branches the restack moved, and
+merge the PRs bottom to top, waiting for each one's checks to finish. It rebases and retargets
+what builds on them, deletes the merged branches and prints a summary of what landed.
+
+With --stack, lands the named stack up to its tip instead, e.g. stak land --stack payments.
+Pass --yes to skip the confirmation, e.g. in CI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("delete-remote") {
+			mergeDeleteRemote = config.GetBool("delete-remote", false)
+		}
+		if err := runLand(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	// land drives stak merge, so its flags set merge's options
+	landCmd.Flags().BoolVarP(&mergeYes, "yes", "y", false, "Land without asking for confirmation")
+	landCmd.Flags().StringVar(&mergeStack, "stack", "", "Land the stack with this name, or containing this branch, instead of the current one")
+	landCmd.RegisterFlagCompletionFunc("stack", completeStackNames)
+	landCmd.Flags().StringVar(&mergeMethod, "method", "", "Merge method: squash, merge, or rebase (default: the branch's merge-method, the merge-method setting, or squash)")
+	landCmd.RegisterFlagCompletionFunc("method", completeValues(mergeMethods...))
+	landCmd.Flags().BoolVar(&mergeSkipChecks, "skip-checks", false, "Land even if PRs are not approved or checks fail")
+	landCmd.Flags().BoolVar(&mergeForce, "force", false, "Restack and rebase branches even if they are owned by someone else")
+	landCmd.Flags().DurationVar(&mergeTimeout, "checks-timeout", 30*time.Minute, "How long to wait for each PR's checks")
+	landCmd.Flags().DurationVar(&mergeInterval, "checks-interval", 15*time.Second, "How often to poll checks")
+	landCmd.Flags().BoolVar(&mergeNotify, "notify", false, "Send a desktop notification when the stack has landed or landing stops")
+	landCmd.Flags().BoolVar(&mergeDeleteRemote, "delete-remote", false, "Delete merged branches on the remote too (default: delete-remote setting)")
+	landCmd.Flags().StringVar(&mergeTemplateFlag, "template", "", "Commit message template for squash and merge commits (default: merge-template setting)")
+	rootCmd.AddCommand(landCmd)
+}
+
+func runLand() error {
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+	if !mergeYes {
+		if err := requireInteractive("confirmation to land (--yes)"); err != nil {
+			return err
+		}
+	}
+
+	// The whole chain lands, and each PR's checks are waited for rather than failing on
+	mergeAll = true
+	mergeWaitChecks = true
+	started := time.Now()
+
+	originalBranch, _ := git.GetCurrentBranch()
+	target, err := mergeTarget()
+	if err != nil {
+		return err
+	}
+	branches, err := mergeChain(target)
+	if err != nil {
+		return err
+	}
+	if err := checkCanRestack("land", target); err != nil {
+		return err
+	}
+	if err := checkCanMerge(branches); err != nil {
+		return err
+	}
+
+	// Restack first, so every PR is on top of its parent when it merges. Nothing is pushed
+	// before the plan is confirmed.
+	moved, err := restackForLanding(branches, originalBranch)
+	if err != nil {
+		return err
+	}
+
+	methods, err := resolveMergeMethods(branches)
+	if err != nil {
+		return err
+	}
+	messages, err := mergeMessages(branches, methods)
+	if err != nil {
+		return err
+	}
+	if err := checkMergeReadiness(branches, methods); err != nil {
+		return err
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	base := ctx.Parent(branches[0])
+	rebased := ctx.Children(target)
+	if len(moved) > 0 {
+		fmt.Printf("Restacked locally, force-pushed before merging: %s\n", strings.Join(moved, ", "))
+	}
+	if err := confirmMergePlan(branches, methods); err != nil {
+		if len(moved) > 0 {
+			ui.Info("The restacked branches were not pushed. Run 'stak push' to push them")
+		}
+		return err
+	}
+	if err := pushForLanding(moved); err != nil {
+		return err
+	}
+
+	if err := git.Fetch(); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	merged, err := mergeInOrder(branches, methods, messages)
+	if err != nil {
+		if len(merged) > 0 {
+			ui.Warning(fmt.Sprintf("Landed %d of %d PR(s) before stopping: #%s", len(merged), len(branches), strings.Join(merged, ", #")))
+		}
+		return err
+	}
+
+	notifyEvent(mergeNotify, "Stack landed")
+	ui.Success(fmt.Sprintf("Landed %d PR(s) into %s in %s", len(branches), base, time.Since(started).Round(time.Second)))
+	for i, branch := range branches {
+		fmt.Printf("  #%-6s %s (%s)\n", merged[i], branch, methods[branch])
+	}
+	if len(rebased) > 0 {
+		fmt.Printf("  Now on %s: %s\n", base, strings.Join(rebased, ", "))
+	}
+	fmt.Printf("\nRelease notes: stak release-notes %s\n", strings.Join(merged, " "))
+
+	if originalBranch != "" {
+		if err := returnToOriginalOrAlternative(originalBranch); err != nil {
+			ui.Warning(fmt.Sprintf("Could not return to %s: %v", originalBranch, err))
+		}
+	}
+	return nil
+}
+
+// restackForLanding restacks the stack from the bottom of branches and returns those of them
+// that moved, which pushForLanding pushes once the plan is confirmed
+func restackForLanding(branches []string, original string) ([]string, error) {
+	ui.Info("Restacking the stack")
+	restacked, err := restackSubtrees(branches[:1], original)
+	if err != nil {
+		return nil, err
+	}
+	var moved []string
+	for _, branch := range restacked {
+		if contains(branches, branch) {
+			moved = append(moved, branch)
+		}
+	}
+	return moved, nil
+}
+
+// pushForLanding force-pushes the restacked branches, so their PRs hold what lands. Their
+// checks start over, which merging waits for.
+func pushForLanding(moved []string) error {
+	for _, branch := range moved {
+		ui.Info(fmt.Sprintf("Force pushing %s", branch))
+		if err := git.Push(branch, false, true); err != nil {
+			return fmt.Errorf("failed to push %s: %w", branch, err)
+		}
+	}
+	if len(moved) > 0 {
+		// Give GitHub a moment to register checks for the pushed commits
+		ui.Info(fmt.Sprintf("Pushed %d restacked branch(es); their checks run again", len(moved)))
+		time.Sleep(mergeInterval)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/ui"
+)
+
+// lfsWarned is set once the missing Git LFS warning was shown, so a stack is warned about once
+var lfsWarned bool
+
+// warnLFSForcePush warns before force-pushing a branch with LFS files when Git LFS isn't set
+// up: its pre-push hook then either fails the push or lets rewritten commits reach the remote
+// without the content of their LFS files
+func warnLFSForcePush(branch string) {
+	if lfsWarned || !git.UsesLFS(branch) || git.IsLFSInstalled() {
+		return
+	}
+	lfsWarned = true
+	ui.Warning(fmt.Sprintf("%s has files stored with Git LFS, but Git LFS is not installed. The push may fail, or leave LFS files on the remote without their content", branch))
+	ui.Info("Install Git LFS (https://git-lfs.com) and run 'git lfs install'")
+}
@@ -2,35 +2,54 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"stacking/internal/git"
 	"stacking/internal/stack"
 	"stacking/internal/ui"
+	"stacking/pkg/models"
+)
+
+var (
+	listPorcelain bool
+	listScope     string
 )
 
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List all stacked branches",
-	Long:    `Display a tree visualization of all stacked branches and their relationships.`,
+	Long: `Display a tree visualization of all stacked branches and their relationships.
+
+Each branch shows how many commits it is ahead (↑) and behind (↓) its parent, and its copy on
+the remote, as of the last fetch. Branches whose parent moved on are badged as needing a
+restack, and those with commits the remote lacks as needing a push.
+
+With --porcelain, prints one tab-separated line per branch, parents before children:
+  <branch> <parent> <pr-number, 0 if none> <depth> <current: true|false>
+
+With --scope, only lists the stacks relevant to a directory of a monorepo: the ones tagged
+with 'stak stacks scope' for it, and untagged ones that change files inside it.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runList(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
+	listCmd.Flags().BoolVar(&listPorcelain, "porcelain", false, "Stable tab-separated output for scripts")
+	listCmd.Flags().StringVar(&listScope, "scope", "", "Only list stacks relevant to this directory")
+	listCmd.MarkFlagDirname("scope")
 	rootCmd.AddCommand(listCmd)
 }
 
 func runList() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -45,8 +64,87 @@ func runList() error {
 		return fmt.Errorf("failed to build stack: %w", err)
 	}
 
+	if listScope != "" {
+		scope, err := resolveScope(listScope)
+		if err != nil {
+			return err
+		}
+		ctx := &stack.StackContext{Stack: s}
+		s = stack.FilterStacks(ctx, func(info stack.StackInfo) bool {
+			return stack.InScope(ctx, info, scope)
+		})
+		if len(s.Roots) == 0 && !listPorcelain {
+			ui.Info(fmt.Sprintf("No stacks in %s/", scope))
+			return nil
+		}
+	}
+
+	if listPorcelain {
+		for _, root := range s.Roots {
+			stack.TraversePreOrder(root, 0, func(b *models.Branch, depth int) {
+				printPorcelain(b.Name, b.Parent, strconv.Itoa(b.PRNumber), strconv.Itoa(depth), porcelainBool(b.Name == currentBranch))
+			})
+		}
+		return nil
+	}
+
 	// Display the stack
-	ui.DisplayStack(s, currentBranch)
+	var needRestack, needPush []string
+	ui.DisplayStack(s, currentBranch, func(b *models.Branch) string {
+		state := getBranchSync(b)
+		if state.needsRestack() {
+			needRestack = append(needRestack, b.Name)
+		}
+		if state.needsPush() {
+			needPush = append(needPush, b.Name)
+		}
+		return state.String()
+	})
+	if len(needRestack) > 0 || len(needPush) > 0 {
+		fmt.Println()
+	}
+	if len(needRestack) > 0 {
+		ui.Info(fmt.Sprintf("%d branch(es) need restacking: run 'stak restack' or 'stak sync'", len(needRestack)))
+	}
+	if len(needPush) > 0 {
+		ui.Info(fmt.Sprintf("%d branch(es) have commits the remote lacks: run 'stak submit'", len(needPush)))
+	}
+
+	// Stacks that must wait for another stack or PR to land
+	var dependencies []string
+	for _, info := range stack.Stacks(&stack.StackContext{Stack: s}) {
+		if info.DependsOn == "" {
+			continue
+		}
+		name := info.Name
+		if name == "" {
+			name = "stack of " + info.Root
+		}
+		dependencies = append(dependencies, fmt.Sprintf("  %s → %s", name, info.DependsOn))
+	}
+	if len(dependencies) > 0 {
+		fmt.Println()
+		fmt.Println("Depends on:")
+		for _, line := range dependencies {
+			fmt.Println(line)
+		}
+	}
+
+	// Branches whose parent was amended or rebased outside stak have silently diverged
+	if rewritten, err := stack.RewrittenParents(&stack.StackContext{Stack: s}); err == nil && len(rewritten) > 0 {
+		fmt.Println()
+		for _, b := range rewritten {
+			ui.Warning(fmt.Sprintf("%s was rewritten since %s was restacked onto it", b.Parent, b.Name))
+		}
+		ui.Info("Run: stak sync")
+	}
+
+	// Surface what 'stak daemon' last found, if it is running
+	if status, err := stack.ReadSyncStatus(); err == nil && status.NeedsSync() {
+		fmt.Println()
+		ui.Warning(fmt.Sprintf("Stack needs sync: %s", strings.Join(status.Reasons, "; ")))
+		ui.Info("Run: stak sync")
+	}
 
 	return nil
 }
@@ -2,46 +2,67 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"stacking/internal/git"
 	"stacking/internal/github"
 	"stacking/internal/stack"
-	"stacking/internal/ui"
 	"stacking/pkg/models"
 )
 
 var (
-	logShort bool
+	logShort     bool
+	logPorcelain bool
+	logGraph     bool
+	logCommits   bool
 )
 
 var logCmd = &cobra.Command{
 	Use:     "log",
 	Aliases: []string{"lg"},
 	Short:   "Show detailed information about stack branches",
-	Long:    `Display detailed information about all branches in the stack, including PR status, reviews, CI checks, and commit counts.`,
+	Long: `Display detailed information about all branches in the stack, including PR status, reviews, CI checks, and commit counts.
+
+With --porcelain, prints one tab-separated line per branch, parents before children:
+  <branch> <parent> <pr-number, 0 if none> <state> <draft> <review-decision> <ci> <commits>
+  <current: true|false>
+For branches without a PR, the other PR fields are empty.
+
+With --graph, shows the commits of each branch under it instead of its PR, newest first like
+git log --graph, so you can see which commits live in which layer of the stack. Only git is
+consulted, so it works offline. --show-commits adds the same commits under each branch's PR
+details.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runLog(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
 	logCmd.Flags().BoolVarP(&logShort, "short", "s", false, "Show short format (same as list)")
+	logCmd.Flags().BoolVar(&logPorcelain, "porcelain", false, "Stable tab-separated output for scripts")
+	logCmd.Flags().BoolVarP(&logGraph, "graph", "g", false, "Show the commits of each branch")
+	logCmd.MarkFlagsMutuallyExclusive("graph", "short")
+	logCmd.MarkFlagsMutuallyExclusive("graph", "porcelain")
+	logCmd.Flags().BoolVar(&logCommits, "show-commits", false, "List the commits of each branch under its PR details")
+	logCmd.MarkFlagsMutuallyExclusive("show-commits", "graph")
+	logCmd.MarkFlagsMutuallyExclusive("show-commits", "short")
+	logCmd.MarkFlagsMutuallyExclusive("show-commits", "porcelain")
 	rootCmd.AddCommand(logCmd)
 }
 
 func runLog() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// If short mode, just run list
 	if logShort {
+		listPorcelain = logPorcelain
 		return runList()
 	}
 
@@ -57,6 +78,18 @@ func runLog() error {
 		return fmt.Errorf("failed to build stack: %w", err)
 	}
 
+	if logPorcelain {
+		for _, branch := range stack.GetAllBranchesInOrder(s) {
+			printLogPorcelain(branch, currentBranch)
+		}
+		return nil
+	}
+
+	if logGraph {
+		displayCommitGraph(s, currentBranch)
+		return nil
+	}
+
 	// Display detailed stack information
 	displayDetailedStack(s, currentBranch)
 
@@ -96,6 +129,9 @@ func displayBranchDetailed(branch *models.Branch, prefix string, currentBranch s
 	if branch.Parent != "" {
 		branchLine += fmt.Sprintf(" (%s)", branch.Parent)
 	}
+	if counts := aheadBehind(branch); counts != "" {
+		branchLine += "  " + counts
+	}
 	fmt.Println(branchLine)
 
 	// Get PR details if available
@@ -113,6 +149,9 @@ func displayBranchDetailed(branch *models.Branch, prefix string, currentBranch s
 		detailPrefix := getDetailPrefix(prefix, isLast, false)
 		fmt.Printf("%s  No PR\n", detailPrefix)
 	}
+	if logCommits && branch.Parent != "" {
+		displayBranchCommits(branch, getDetailPrefix(prefix, isLast, false))
+	}
 
 	// Display children recursively
 	for i, child := range branch.Children {
@@ -129,6 +168,81 @@ func displayBranchDetailed(branch *models.Branch, prefix string, currentBranch s
 	}
 }
 
+func displayCommitGraph(s *models.Stack, currentBranch string) {
+	if len(s.Roots) == 0 {
+		fmt.Println("No stacked branches found.")
+		return
+	}
+
+	for _, root := range s.Roots {
+		displayBranchGraph(root, "", currentBranch, true)
+	}
+}
+
+// displayBranchGraph prints a branch with its own commits, those after its parent, and then
+// its children
+func displayBranchGraph(branch *models.Branch, prefix string, currentBranch string, isLast bool) {
+	indicator := " "
+	if branch.Name == currentBranch {
+		indicator = "●"
+	}
+	connector := "├─"
+	if isLast {
+		connector = "└─"
+	}
+	if prefix == "" {
+		connector = ""
+	}
+
+	branchLine := fmt.Sprintf("%s%s %s %s", prefix, connector, indicator, branch.Name)
+	if branch.PRNumber > 0 {
+		branchLine += fmt.Sprintf(" #%d", branch.PRNumber)
+	}
+	if counts := aheadBehind(branch); counts != "" {
+		branchLine += "  " + counts
+	}
+	fmt.Println(branchLine)
+
+	if branch.Parent != "" {
+		displayBranchCommits(branch, getDetailPrefix(prefix, isLast, false))
+	}
+
+	for i, child := range branch.Children {
+		childIsLast := i == len(branch.Children)-1
+		childPrefix := prefix
+		if prefix == "" {
+			childPrefix = " "
+		} else if isLast {
+			childPrefix = prefix + "   "
+		} else {
+			childPrefix = prefix + "│  "
+		}
+		displayBranchGraph(child, childPrefix, currentBranch, childIsLast)
+	}
+}
+
+// displayBranchCommits prints the commits of a branch, newest first. If its parent was
+// rewritten since the branch was restacked, they start after the parent commit recorded.
+func displayBranchCommits(branch *models.Branch, detailPrefix string) {
+	base := branch.Parent
+	if recorded := stack.RewrittenParentBase(branch.Name, branch.Parent); recorded != "" {
+		base = recorded
+	}
+	commits, err := git.GetCommitMessages(base, branch.Name)
+	if err != nil {
+		fmt.Printf("%s  (error: %v)\n", detailPrefix, err)
+		return
+	}
+	if len(commits) == 0 {
+		fmt.Printf("%s  (no commits)\n", detailPrefix)
+		return
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		subject, _, _ := strings.Cut(commits[i].Message, "\n")
+		fmt.Printf("%s  * %s %s\n", detailPrefix, shortSHA(commits[i].SHA), subject)
+	}
+}
+
 func displayPRDetails(details *github.PRDetails, prefix string, isLast bool) {
 	detailPrefix := getDetailPrefix(prefix, isLast, true)
 
@@ -159,6 +273,25 @@ func displayPRDetails(details *github.PRDetails, prefix string, isLast bool) {
 	fmt.Printf("%s  %d commit(s)\n", detailPrefix, details.Commits.TotalCount)
 }
 
+func printLogPorcelain(branch *models.Branch, currentBranch string) {
+	current := porcelainBool(branch.Name == currentBranch)
+	if branch.PRNumber == 0 {
+		printPorcelain(branch.Name, branch.Parent, "0", "", "", "", "", "", current)
+		return
+	}
+
+	details, err := github.GetPRDetails(branch.PRNumber)
+	if err != nil {
+		// Keep the record so scripts still see the branch; state is unknown
+		printPorcelain(branch.Name, branch.Parent, strconv.Itoa(branch.PRNumber), "", "", "", "", "", current)
+		return
+	}
+
+	printPorcelain(branch.Name, branch.Parent, strconv.Itoa(branch.PRNumber),
+		details.State, porcelainBool(details.IsDraft), details.ReviewDecision, details.GetCIStatus(),
+		strconv.Itoa(details.Commits.TotalCount), current)
+}
+
 func getDetailPrefix(prefix string, isLast bool, hasMore bool) string {
 	if prefix == "" {
 		return ""
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+)
+
+// validatePatterns checks --match globs up front, so a typo fails loudly instead of matching nothing
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --match pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether a branch matches one of the glob patterns.
+// An empty pattern list matches every branch. As in shell globs, * does not match /.
+func matchesAny(branch string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterBranches keeps the branches matching the glob patterns, preserving order
+func filterBranches(branches []string, patterns []string) []string {
+	if len(patterns) == 0 {
+		return branches
+	}
+	var matched []string
+	for _, branch := range branches {
+		if matchesAny(branch, patterns) {
+			matched = append(matched, branch)
+		}
+	}
+	return matched
+}
@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"stacking/internal/config"
 	"stacking/internal/git"
 	"stacking/internal/github"
 	"stacking/internal/stack"
@@ -12,9 +16,19 @@ import (
 )
 
 var (
-	mergeAll        bool
-	mergeMethod     string
-	mergeSkipChecks bool
+	mergeAll          bool
+	mergeMethod       string
+	mergeSkipChecks   bool
+	mergeIgnoreDeps   bool
+	mergeForce        bool
+	mergeWaitChecks   bool
+	mergeTimeout      time.Duration
+	mergeInterval     time.Duration
+	mergeNotify       bool
+	mergeStack        string
+	mergeTemplateFlag string
+	mergeDeleteRemote bool
+	mergeYes          bool
 )
 
 var mergeCmd = &cobra.Command{
@@ -22,94 +36,284 @@ var mergeCmd = &cobra.Command{
 	Aliases: []string{"mg"},
 	Short:   "Merge PRs in the stack",
 	Long: `Merge approved PRs in the correct order (bottom to top).
-After each merge, updates dependent PRs to point to the new base and rebases children.`,
+After each merge, updates dependent PRs to point to the new base and rebases children.
+
+With --stack, merges a stack other than the current one without checking it out: its bottom
+PR, or with --all every PR up to its tip, e.g. stak merge --stack payments --all.
+
+With --template, or the merge-template setting, the squash or merge commit message is built
+from the PR instead of left to GitHub. The first line is the subject; {title}, {number},
+{body}, {branch}, {stack} and {co-authors} are replaced, and "default" stands for
+"{title} (#{number})", then the body, the stack's PRs and the co-authors.
+
+Before merging more than one PR, merge prints the plan: the PRs in order, the PR bases that
+change and the branches deleted, and asks to go ahead. --yes skips the question.
+
+A stack that depends on another stack or PR (see 'stak stacks depend') isn't merged until
+that lands; --ignore-dependencies merges it anyway.
+
+--delete-remote, or the delete-remote setting, deletes each merged branch on the remote too,
+unless GitHub is set to delete the branches of merged PRs itself.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// The delete-remote setting applies unless --delete-remote is given explicitly
+		if !cmd.Flags().Changed("delete-remote") {
+			mergeDeleteRemote = config.GetBool("delete-remote", false)
+		}
 		if err := runMerge(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
 	mergeCmd.Flags().BoolVar(&mergeAll, "all", false, "Merge entire stack from current branch")
-	mergeCmd.Flags().StringVar(&mergeMethod, "method", "squash", "Merge method: squash, merge, or rebase")
+	mergeCmd.Flags().StringVar(&mergeMethod, "method", "", "Merge method: squash, merge, or rebase (default: the branch's merge-method, the merge-method setting, or squash)")
+	mergeCmd.RegisterFlagCompletionFunc("method", completeValues("squash", "merge", "rebase"))
 	mergeCmd.Flags().BoolVar(&mergeSkipChecks, "skip-checks", false, "Skip approval and CI checks")
+	mergeCmd.Flags().BoolVar(&mergeIgnoreDeps, "ignore-dependencies", false, "Merge even if the stack depends on a stack or PR that isn't merged yet")
+	mergeCmd.Flags().BoolVar(&mergeForce, "force", false, "Rebase children even if they are owned by someone else")
+	mergeCmd.Flags().BoolVar(&mergeWaitChecks, "wait-checks", false, "Wait for running CI checks to finish before merging")
+	mergeCmd.Flags().DurationVar(&mergeTimeout, "checks-timeout", 30*time.Minute, "How long to wait for checks with --wait-checks")
+	mergeCmd.Flags().DurationVar(&mergeInterval, "checks-interval", 15*time.Second, "How often to poll checks with --wait-checks")
+	mergeCmd.Flags().BoolVar(&mergeNotify, "notify", false, "Send desktop notifications as PRs merge or fail to")
+	mergeCmd.Flags().StringVar(&mergeStack, "stack", "", "Merge the stack with this name, or containing this branch, instead of the current one")
+	mergeCmd.RegisterFlagCompletionFunc("stack", completeStackNames)
+	mergeCmd.Flags().BoolVarP(&mergeYes, "yes", "y", false, "Merge several PRs without asking for confirmation of the plan")
+	mergeCmd.Flags().BoolVar(&mergeDeleteRemote, "delete-remote", false, "Delete merged branches on the remote too (default: delete-remote setting)")
+	mergeCmd.Flags().StringVar(&mergeTemplateFlag, "template", "", "Commit message template for squash and merge commits, \"default\", or \"github\" for GitHub's message (default: merge-template setting)")
 	rootCmd.AddCommand(mergeCmd)
 }
 
 func runMerge() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Check if gh CLI is authenticated
 	if !github.IsGHAuthenticated() {
-		return fmt.Errorf("gh CLI not authenticated. Run: gh auth login")
+		return errNotAuthenticated
+	}
+
+	originalBranch, _ := git.GetCurrentBranch()
+
+	// Get current branch, or with --stack the branch of that stack to merge up to
+	currentBranch, err := mergeTarget()
+	if err != nil {
+		return err
+	}
+
+	branchesToMerge, err := mergeChain(currentBranch)
+	if err != nil {
+		return err
+	}
+	if err := checkCanMerge(branchesToMerge); err != nil {
+		return err
+	}
+	if len(branchesToMerge) > 1 && !mergeYes {
+		if err := requireInteractive("confirmation of the merge plan (--yes)"); err != nil {
+			return err
+		}
+	}
+
+	methods, err := resolveMergeMethods(branchesToMerge)
+	if err != nil {
+		return err
+	}
+
+	messages, err := mergeMessages(branchesToMerge, methods)
+	if err != nil {
+		return err
+	}
+
+	if mergeAll {
+		if err := checkMergeReadiness(branchesToMerge, methods); err != nil {
+			return err
+		}
+	}
+	if len(branchesToMerge) > 1 {
+		if err := confirmMergePlan(branchesToMerge, methods); err != nil {
+			return err
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Merging %d PR(s)", len(branchesToMerge)))
+
+	// Fetch latest
+	if err := git.Fetch(); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
 	}
 
-	// Get current branch
-	currentBranch, err := git.GetCurrentBranch()
+	mergedPRs, err := mergeInOrder(branchesToMerge, methods, messages)
 	if err != nil {
-		return fmt.Errorf("failed to get current branch: %w", err)
+		return err
+	}
+
+	if mergeAll {
+		notifyEvent(mergeNotify, "Stack fully merged")
+	} else {
+		notifyEvent(mergeNotify, fmt.Sprintf("Merged %s", branchesToMerge[0]))
+	}
+	ui.Success("All PRs merged successfully")
+	if mergeAll {
+		fmt.Printf("\nRelease notes: stak release-notes %s\n", strings.Join(mergedPRs, " "))
+	}
+
+	// Rebasing the children checked them out, so go back to where --stack was run from
+	if mergeStack != "" && originalBranch != "" {
+		if err := returnToOriginalOrAlternative(originalBranch); err != nil {
+			ui.Warning(fmt.Sprintf("Could not return to %s: %v", originalBranch, err))
+		}
 	}
+	return nil
+}
 
+// mergeChain returns the branches merge lands, bottom first: branch, and with --all the tracked
+// branches below it
+func mergeChain(currentBranch string) ([]string, error) {
 	// Check if branch has stack metadata
 	hasMetadata, err := stack.HasStackMetadata(currentBranch)
 	if err != nil {
-		return fmt.Errorf("failed to check stack metadata: %w", err)
+		return nil, fmt.Errorf("failed to check stack metadata: %w", err)
 	}
 
 	if !hasMetadata {
-		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+		return nil, fmt.Errorf("branch %s is not part of a stack", currentBranch)
 	}
 
 	// Get branch metadata
 	metadata, err := stack.ReadBranchMetadata(currentBranch)
 	if err != nil {
-		return fmt.Errorf("failed to read metadata: %w", err)
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
 	}
 
 	if metadata.PRNumber == 0 {
-		return fmt.Errorf("branch %s has no associated PR", currentBranch)
+		return nil, fmt.Errorf("branch %s has no associated PR", currentBranch)
 	}
 
 	// Build ancestor chain
 	ancestors, err := stack.GetAncestors(currentBranch)
 	if err != nil {
-		return fmt.Errorf("failed to get ancestors: %w", err)
+		return nil, fmt.Errorf("failed to get ancestors: %w", err)
 	}
 
 	// Build list of branches to merge
 	var branchesToMerge []string
 	if mergeAll {
-		// Merge entire chain: ancestors + current
-		branchesToMerge = append(ancestors, currentBranch)
+		// Merge entire chain: ancestors + current. Ancestors include the trunk, which has no PR
+		for _, ancestor := range ancestors {
+			if tracked, _ := stack.HasStackMetadata(ancestor); tracked {
+				branchesToMerge = append(branchesToMerge, ancestor)
+			}
+		}
+		branchesToMerge = append(branchesToMerge, currentBranch)
 	} else {
 		// Merge only current branch
 		branchesToMerge = []string{currentBranch}
 	}
+	return branchesToMerge, nil
+}
 
-	ui.Info(fmt.Sprintf("Merging %d PR(s)", len(branchesToMerge)))
+// checkCanMerge refuses to merge branches whose children belong to someone else, or whose
+// stack depends on one that hasn't landed
+func checkCanMerge(branchesToMerge []string) error {
+	// Merging rebases and force-pushes the children of every merged branch
+	affected, err := stack.GetDescendants(branchesToMerge[0])
+	if err != nil {
+		return fmt.Errorf("failed to get descendants: %w", err)
+	}
+	if err := checkStackOwnership("rebase and force-push", affected, mergeForce); err != nil {
+		return err
+	}
 
-	// Fetch latest
-	if err := git.Fetch(); err != nil {
-		return fmt.Errorf("failed to fetch: %w", err)
+	// A stack that depends on another stack or PR can't land before it
+	if err := checkStackDependency("merge", branchesToMerge[0], mergeIgnoreDeps); err != nil {
+		return fmt.Errorf("%w. Merge it first, or use --ignore-dependencies", err)
 	}
+	return nil
+}
 
-	// Merge each branch in order
-	for _, branch := range branchesToMerge {
-		if err := mergeBranch(branch); err != nil {
-			return err
+// confirmMergePlan prints what merging branches does, step by step: the PR merged, the PRs
+// retargeted onto the base and the branches deleted. It then asks to go ahead, unless --yes.
+func confirmMergePlan(branches []string, methods map[string]string) error {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	base := ctx.Parent(branches[0])
+
+	fmt.Printf("Merge plan, into %s:\n", base)
+	fmt.Printf("  %-5s %-7s %-25s %-7s %-35s %s\n", "Step", "PR", "Branch", "Method", "Retargets", "Deletes")
+	for i, branch := range branches {
+		var retargets []string
+		for _, child := range ctx.Children(branch) {
+			retargets = append(retargets, fmt.Sprintf("%s: %s → %s", child, branch, base))
 		}
+		if len(retargets) == 0 {
+			retargets = []string{"-"}
+		}
+		deletes := branch
+		if mergeDeleteRemote {
+			deletes += ", " + git.RemoteRef(branch)
+		}
+		fmt.Printf("  %-5d %-7s %-25s %-7s %-35s %s\n", i+1, fmt.Sprintf("#%d", ctx.Metadata(branch).PRNumber),
+			branch, methods[branch], strings.Join(retargets, ", "), deletes)
 	}
+	fmt.Println()
 
-	ui.Success("All PRs merged successfully")
+	if mergeYes {
+		return nil
+	}
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Merge %d PR(s) into %s", len(branches), base),
+		IsConfirm: true,
+	}
+	if _, err := runPrompt(&prompt); err != nil {
+		return fmt.Errorf("merge cancelled, nothing was merged")
+	}
 	return nil
 }
 
-func mergeBranch(branch string) error {
+// mergeInOrder merges the branches' PRs bottom first and returns their numbers
+func mergeInOrder(branchesToMerge []string, methods map[string]string, messages map[string]mergeMessage) ([]string, error) {
+	// Merge each branch in order, remembering the PRs since merged branches lose their metadata
+	var mergedPRs []string
+	for _, branch := range branchesToMerge {
+		prNumber := 0
+		if metadata, err := stack.ReadBranchMetadata(branch); err == nil {
+			prNumber = metadata.PRNumber
+		}
+		if err := mergeBranch(branch, methods[branch], messages[branch]); err != nil {
+			notifyEvent(mergeNotify, fmt.Sprintf("Merge stopped at %s: %v", branch, err))
+			return mergedPRs, err
+		}
+		mergedPRs = append(mergedPRs, strconv.Itoa(prNumber))
+	}
+	return mergedPRs, nil
+}
+
+// mergeTarget returns the branch merge works from: the current branch, or with --stack the
+// bottom of that stack, or its tip with --all
+func mergeTarget() (string, error) {
+	if mergeStack == "" {
+		currentBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current branch: %w", err)
+		}
+		return currentBranch, nil
+	}
+
+	ctx, info, err := findStackFlag(mergeStack)
+	if err != nil {
+		return "", err
+	}
+	if !mergeAll {
+		return info.Root, nil
+	}
+	return selectStackTip(ctx, info)
+}
+
+func mergeBranch(branch, method string, message mergeMessage) error {
 	ui.Info(fmt.Sprintf("Processing branch %s", branch))
 
 	// Get branch metadata
@@ -144,18 +348,32 @@ func mergeBranch(branch string) error {
 
 	// Verify approval and CI unless skipping checks
 	if !mergeSkipChecks {
+		if err := checkMergeProtection(prNumber, metadata.Parent, status, method); err != nil {
+			return err
+		}
+
 		if !status.IsApproved() {
 			return fmt.Errorf("PR #%d is not approved", prNumber)
 		}
 
+		if status.IsCIPending() {
+			if !mergeWaitChecks {
+				return fmt.Errorf("PR #%d has CI checks still running. Use --wait-checks to wait for them", prNumber)
+			}
+			status, err = waitForChecks(prNumber, mergeTimeout, mergeInterval)
+			if err != nil {
+				return err
+			}
+		}
+
 		if !status.IsCIPassing() {
 			return fmt.Errorf("PR #%d has failing CI checks", prNumber)
 		}
 	}
 
 	// Merge the PR
-	ui.Info(fmt.Sprintf("Merging PR #%d", prNumber))
-	if err := github.MergePR(prNumber, mergeMethod); err != nil {
+	ui.Info(fmt.Sprintf("Merging PR #%d (%s)", prNumber, method))
+	if err := github.MergePRWithMessage(prNumber, method, message.Subject, message.Body); err != nil {
 		return fmt.Errorf("failed to merge PR #%d: %w", prNumber, err)
 	}
 
@@ -177,6 +395,11 @@ func mergeBranch(branch string) error {
 		}
 	}
 
+	// The children no longer build on the branch, so it can go on the remote too
+	if mergeDeleteRemote {
+		deleteMergedRemoteBranch(branch)
+	}
+
 	// Delete local branch
 	ui.Info(fmt.Sprintf("Deleting local branch %s", branch))
 	currentBranch, _ := git.GetCurrentBranch()
@@ -216,9 +439,9 @@ func updateChildAfterMerge(child, oldParent, newParent string) error {
 	}
 
 	// Rebase onto new parent
-	ui.Info(fmt.Sprintf("Rebasing %s onto origin/%s", child, newParent))
-	onto := fmt.Sprintf("origin/%s", newParent)
-	if err := git.RebaseOnto(onto); err != nil {
+	onto := git.RemoteRef(newParent)
+	ui.Info(fmt.Sprintf("Rebasing %s onto %s", child, onto))
+	if _, err := stack.Restack(child, onto); err != nil {
 		if conflictErr, ok := err.(*git.RebaseConflictError); ok {
 			return handleRebaseConflict(child, conflictErr)
 		}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"stacking/internal/config"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+)
+
+// defaultMergeTemplate is the template merge-template = "default" stands for
+const defaultMergeTemplate = "{title} (#{number})\n\n{body}\n\n{stack}\n\n{co-authors}"
+
+// mergeMessage is the commit subject and body a PR is squashed or merged with
+type mergeMessage struct {
+	Subject string
+	Body    string
+}
+
+// mergeTemplate returns the template of merge commit messages from --template or the
+// merge-template setting, or "" to leave the message to GitHub
+func mergeTemplate() string {
+	template := mergeTemplateFlag
+	if template == "" {
+		template = config.GetString("merge-template", "")
+	}
+	switch template {
+	case "default":
+		return defaultMergeTemplate
+	case "github":
+		return ""
+	}
+	return template
+}
+
+// mergeMessages builds the commit message of each branch's PR from the merge template, for
+// the methods that create a commit. Without a template, none are built and GitHub's are used.
+func mergeMessages(branches []string, methods map[string]string) (map[string]mergeMessage, error) {
+	template := mergeTemplate()
+	messages := make(map[string]mergeMessage)
+	if template == "" {
+		return messages, nil
+	}
+
+	// The stack is described as it is before anything merges and its branches go away
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+	reference := stackReference(ctx, branches[0])
+
+	for _, branch := range branches {
+		if methods[branch] == "rebase" {
+			continue
+		}
+		prNumber := ctx.Metadata(branch).PRNumber
+		content, err := github.GetPRContent(prNumber)
+		if err != nil {
+			return nil, err
+		}
+		var coAuthors []string
+		if strings.Contains(template, "{co-authors}") {
+			if coAuthors, err = github.GetPRCoAuthors(prNumber); err != nil {
+				return nil, err
+			}
+		}
+		for i, coAuthor := range coAuthors {
+			coAuthors[i] = "Co-authored-by: " + coAuthor
+		}
+
+		message := strings.NewReplacer(
+			"{title}", content.Title,
+			"{number}", fmt.Sprint(prNumber),
+			"{body}", strings.TrimSpace(strings.ReplaceAll(content.Body, "\r\n", "\n")),
+			"{branch}", branch,
+			"{stack}", reference,
+			"{co-authors}", strings.Join(coAuthors, "\n"),
+		).Replace(template)
+		messages[branch] = splitMergeMessage(message)
+	}
+	return messages, nil
+}
+
+// blankLines matches the run of blank lines that empty placeholders leave behind
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// splitMergeMessage splits a rendered template into its first line, the subject, and the rest
+func splitMergeMessage(message string) mergeMessage {
+	message = blankLines.ReplaceAllString(strings.TrimSpace(message), "\n\n")
+	subject, body, _ := strings.Cut(message, "\n")
+	return mergeMessage{Subject: strings.TrimSpace(subject), Body: strings.TrimSpace(body)}
+}
+
+// stackReference describes the stack branch belongs to for a commit message, e.g.
+// "Part of stack payments: #41, #42, #43", or "" if its PRs are alone
+func stackReference(ctx *stack.StackContext, branch string) string {
+	info, ok := stack.StackOf(ctx, branch)
+	if !ok {
+		return ""
+	}
+	var prs []string
+	for _, b := range info.Branches {
+		if prNumber := ctx.Metadata(b).PRNumber; prNumber > 0 {
+			prs = append(prs, fmt.Sprintf("#%d", prNumber))
+		}
+	}
+	if len(prs) < 2 {
+		return ""
+	}
+	if info.Name != "" {
+		return fmt.Sprintf("Part of stack %s: %s", info.Name, strings.Join(prs, ", "))
+	}
+	return "Part of a stack: " + strings.Join(prs, ", ")
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/config"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	mergeMethodBranch string
+	mergeMethodUnset  bool
+)
+
+// mergeMethods are the values merge-method and --method take
+var mergeMethods = []string{"squash", "merge", "rebase"}
+
+var mergeMethodCmd = &cobra.Command{
+	Use:   "merge-method [squash|merge|rebase]",
+	Short: "Show or set how a branch's PR is merged",
+	Long: `Show or set the method stak merge uses for a branch's PR.
+
+The method is the first of: --method of stak merge, the branch's own method set with this
+command, the merge-method setting (git config or .stak.toml), and squash. A branch's own
+method must be one the repository allows; a default falls back to one that is.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeValues(mergeMethods...),
+	Run: func(cmd *cobra.Command, args []string) {
+		method := ""
+		if len(args) > 0 {
+			method = args[0]
+		}
+		if err := runMergeMethod(method); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	mergeMethodCmd.Flags().StringVar(&mergeMethodBranch, "branch", "", "Branch to show or set the method of, instead of the current one")
+	mergeMethodCmd.RegisterFlagCompletionFunc("branch", completeTrackedBranches)
+	mergeMethodCmd.Flags().BoolVar(&mergeMethodUnset, "unset", false, "Go back to the default method")
+	rootCmd.AddCommand(mergeMethodCmd)
+}
+
+func runMergeMethod(method string) error {
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	branch := mergeMethodBranch
+	if branch == "" {
+		var err error
+		if branch, err = git.GetCurrentBranch(); err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+	}
+	if tracked, _ := stack.HasStackMetadata(branch); !tracked {
+		return fmt.Errorf("branch %s is not part of a stack", branch)
+	}
+
+	switch {
+	case mergeMethodUnset:
+		if err := git.SetBranchMergeMethod(branch, ""); err != nil {
+			return err
+		}
+	case method != "":
+		if !contains(mergeMethods, method) {
+			return fmt.Errorf("invalid merge method %q: use %s", method, strings.Join(mergeMethods, ", "))
+		}
+		if err := git.SetBranchMergeMethod(branch, method); err != nil {
+			return err
+		}
+	}
+
+	method, source := branchMergeMethod(branch)
+	ui.Success(fmt.Sprintf("%s merges with %s (%s)", branch, method, source))
+	return nil
+}
+
+// branchMergeMethod returns the method branch's PR merges with, leaving out --method, and
+// where it comes from
+func branchMergeMethod(branch string) (method, source string) {
+	if method, _ := git.GetBranchMergeMethod(branch); method != "" {
+		return method, "set for the branch"
+	}
+	if method := config.GetString("merge-method", ""); method != "" {
+		return method, "merge-method setting"
+	}
+	return "squash", "default"
+}
+
+// resolveMergeMethods works out the merge method of each branch and checks it against those
+// the repository allows. A method given with --method or set for the branch must be allowed;
+// a default that isn't is replaced by one that is.
+func resolveMergeMethods(branches []string) (map[string]string, error) {
+	allowed, err := github.GetMergeMethods()
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not check the repository's allowed merge methods: %v", err))
+		allowed = nil
+	}
+
+	methods := make(map[string]string)
+	for _, branch := range branches {
+		method, source := branchMergeMethod(branch)
+		if mergeMethod != "" {
+			method, source = mergeMethod, "--method"
+		}
+		if !contains(mergeMethods, method) {
+			return nil, fmt.Errorf("invalid merge method %q for %s (%s): use %s", method, branch, source, strings.Join(mergeMethods, ", "))
+		}
+
+		if allowed != nil && !allowed.Allows(method) {
+			options := allowed.Allowed()
+			if len(options) == 0 {
+				return nil, fmt.Errorf("the repository doesn't allow any merge method stak can use")
+			}
+			if source == "--method" || source == "set for the branch" {
+				return nil, fmt.Errorf("%s merges with %s (%s), which the repository doesn't allow. Allowed: %s",
+					branch, method, source, strings.Join(options, ", "))
+			}
+			ui.Info(fmt.Sprintf("The repository doesn't allow %s merges; merging %s with %s", method, branch, options[0]))
+			method = options[0]
+		}
+		methods[branch] = method
+	}
+	return methods, nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/manifoldco/promptui"
+	"stacking/internal/config"
+	"stacking/internal/git"
+	"stacking/internal/ui"
+)
+
+// useMergetool reports whether conflicts should be resolved with git mergetool, from the
+// command's --mergetool flag or the mergetool setting. Never when prompts are disabled.
+func useMergetool(flag bool) bool {
+	if !flag && !config.GetBool("mergetool", false) {
+		return false
+	}
+	return requireInteractive("mergetool") == nil
+}
+
+// resolveWithMergetool runs git mergetool on the conflicted files of the rebase in progress
+// and continues the rebase whenever the index is clean, until the rebase finishes.
+// It returns false, with the rebase still in progress, if the user chose to resolve by hand.
+func resolveWithMergetool(branch string) (bool, error) {
+	for {
+		files, err := git.GetConflictedFiles()
+		if err != nil {
+			return false, err
+		}
+
+		if len(files) > 0 {
+			ui.Info(fmt.Sprintf("Resolving %d conflicted file(s) on %s with git mergetool", len(files), branch))
+			outside := make(map[string]bool)
+			for _, file := range git.OutsideSparseCheckout(files) {
+				outside[file] = true
+			}
+			for _, file := range files {
+				// The tool's exit status only says whether this file was resolved; the index is checked below
+				if runMergetool(file) == nil && outside[file] {
+					// git mergetool can't stage files outside a sparse checkout by itself
+					if err := git.StageFiles([]string{file}); err != nil {
+						ui.Warning(err.Error())
+					}
+				}
+			}
+
+			remaining, err := git.GetConflictedFiles()
+			if err != nil {
+				return false, err
+			}
+			if len(remaining) > 0 {
+				ui.Warning("Conflicts remain in:")
+				for _, file := range remaining {
+					fmt.Printf("  - %s\n", file)
+				}
+
+				prompt := promptui.Select{
+					Label: "What would you like to do?",
+					Items: []string{"Run mergetool again", "Stop and resolve manually", "Abort the rebase"},
+				}
+				_, result, err := runSelect(&prompt)
+				if err != nil || result == "Stop and resolve manually" {
+					return false, nil
+				}
+				if result == "Abort the rebase" {
+					if err := git.AbortRebase(); err != nil {
+						return false, err
+					}
+					return false, fmt.Errorf("rebase of %s aborted", branch)
+				}
+				continue
+			}
+		}
+
+		// The index is clean: move on to the next commit, which may conflict in turn
+		ui.Info("Conflicts resolved, continuing rebase")
+		continueErr := git.ContinueRebase()
+
+		inProgress, err := git.IsRebaseInProgress()
+		if err != nil {
+			return false, err
+		}
+		if !inProgress {
+			ui.Success(fmt.Sprintf("Rebased %s", branch))
+			return true, nil
+		}
+		if hasConflicts, _ := git.HasMergeConflicts(); !hasConflicts {
+			// Stopped for another reason (e.g. a commit became empty); leave it to the user
+			if continueErr != nil {
+				ui.Warning(continueErr.Error())
+			}
+			return false, nil
+		}
+	}
+}
+
+// runMergetool opens the configured merge tool for one file, given relative to the repository
+// root, attached to the terminal
+func runMergetool(file string) error {
+	cmd := exec.Command("git", "mergetool", "--no-prompt", "--", file)
+	if root, err := git.GetRepoRoot(); err == nil {
+		cmd.Dir = root
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
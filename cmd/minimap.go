@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"stacking/internal/config"
+	"stacking/internal/stack"
+	"stacking/pkg/models"
+)
+
+// printStackMap prints a compact view of the stack of branch, its base first and then its
+// branches indented by depth, with an arrow at branch. Navigation commands print it after
+// switching, so you know where you landed without running stak list. Nothing is printed for
+// untracked branches, or with the minimap setting false.
+func printStackMap(branch string) {
+	if !config.GetBool("minimap", true) {
+		return
+	}
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return
+	}
+	info, ok := stack.StackOf(ctx, branch)
+	if !ok {
+		return
+	}
+	root := ctx.Stack.GetBranch(info.Root)
+	if root == nil {
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("   %s\n", info.Base)
+	printStackMapBranch(root, "", true, branch)
+}
+
+// printStackMapBranch prints a branch of the stack map and the branches on it
+func printStackMapBranch(b *models.Branch, indent string, isLast bool, current string) {
+	arrow, connector := " ", "├"
+	if b.Name == current {
+		arrow = "→"
+	}
+	if isLast {
+		connector = "└"
+	}
+	line := fmt.Sprintf(" %s %s%s %s", arrow, indent, connector, b.Name)
+	if b.PRNumber > 0 {
+		line += fmt.Sprintf(" #%d", b.PRNumber)
+	}
+	fmt.Println(line)
+
+	childIndent := indent + "│ "
+	if isLast {
+		childIndent = indent + "  "
+	}
+	for i, child := range b.Children {
+		printStackMapBranch(child, childIndent, i == len(b.Children)-1, current)
+	}
+}
@@ -23,19 +23,44 @@ var (
 	modifyPush       bool
 	modifyCommit     bool
 	modifyInto       string
+	modifyForce      bool
+	modifyReword     string
+	modifyMessage    string
+	modifySplit      bool
+	modifyAll        bool
+	modifyPaths      []string
+	modifyRoute      bool
 )
 
 var modifyCmd = &cobra.Command{
-	Use:     "modify",
+	Use:     "modify [-- <path>...]",
 	Aliases: []string{"m"},
 	Short:   "Modify current branch (commits only, no push)",
 	Long: `Modify the current branch by creating or amending commits locally.
 By default, this command does NOT push changes - it only creates commits.
-Use --push flag if you want to push and sync children after committing.`,
+Use --push flag if you want to push and sync children after committing.
+
+--reword changes only the message of the last commit, or of the commit given as
+--reword=<commit> among the branch's own commits, and restacks the branches above.
+Pass the new message with -m, or edit it in the editor.
+
+--split-commit undoes the last commit and walks through git add --patch to commit its
+changes again as several commits, e.g. before splitting the branch with 'stak split'.
+
+-a commits all changes to tracked files, and paths after -- commit only the changes to
+those paths, leaving anything else staged out of the commit. Both skip the menu, so
+'stak modify -a -m "Fix"' runs without prompting.
+
+--into commits the staged changes, or if none are staged all changes to tracked files, to
+the given downstack branch without checking it out, then restacks the branches above.
+
+--route sends each staged hunk to the downstack branch that wrote the code it changes,
+found with git blame, amending that branch's last commit and restacking the branches
+above. Hunks changing the current branch's code, or the trunk's, stay staged.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		modifyPaths = args
 		if err := runModify(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -48,14 +73,23 @@ func init() {
 	modifyCmd.Flags().StringVar(&modifyBody, "body", "", "New PR body")
 	modifyCmd.Flags().BoolVarP(&modifyPush, "push", "p", false, "Push changes after committing")
 	modifyCmd.Flags().BoolVarP(&modifyCommit, "commit", "c", false, "Create a fresh commit instead of amending")
-	modifyCmd.Flags().StringVar(&modifyInto, "into", "", "Apply changes to downstack branch")
+	modifyCmd.Flags().StringVar(&modifyReword, "reword", "", "Only change the message of the last commit, or of the given commit of the branch")
+	modifyCmd.Flags().Lookup("reword").NoOptDefVal = "HEAD"
+	modifyCmd.Flags().BoolVar(&modifySplit, "split-commit", false, "Split the last commit into several by selecting the changes of each")
+	modifyCmd.Flags().BoolVarP(&modifyAll, "all", "a", false, "Commit all changes to tracked files, without the menu")
+	modifyCmd.Flags().StringVarP(&modifyMessage, "message", "m", "", "Commit message for --reword, --commit or --amend")
+	modifyCmd.Flags().StringVar(&modifyInto, "into", "", "Commit the staged changes to a downstack branch, without checking it out")
+	modifyCmd.Flags().BoolVar(&modifyRoute, "route", false, "Amend each staged hunk into the downstack branch whose code it changes")
+	modifyCmd.RegisterFlagCompletionFunc("into", completeDownstack)
+	modifyCmd.Flags().BoolVar(&modifyForce, "force", false, "Push even if the branch is owned by someone else")
+	addVerifyFlags(modifyCmd)
 	rootCmd.AddCommand(modifyCmd)
 }
 
 func runModify() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -73,20 +107,44 @@ func runModify() error {
 		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
 	}
 
+	// The commit of --reword is optional, so in "--reword abc123" abc123 is taken as a path
+	if modifyReword != "" && len(modifyPaths) > 0 {
+		return fmt.Errorf("--reword doesn't take paths. To reword a commit other than the last, use --reword=<commit>, e.g. --reword=%s", modifyPaths[0])
+	}
+	selectsChanges := modifyAll || len(modifyPaths) > 0
+	if modifyAll && len(modifyPaths) > 0 {
+		return fmt.Errorf("-a can't be combined with paths; use one or the other")
+	}
+	if selectsChanges && (modifyInto != "" || modifyRoute || modifyReword != "" || modifySplit || modifyRebaseNum > 0) {
+		return fmt.Errorf("-a and paths only apply to --commit and --amend")
+	}
+	if len(modifyPaths) > 0 {
+		// Stage the paths first so new files can be committed too
+		args := append([]string{"add", "--all", "--"}, modifyPaths...)
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to stage %s: %s", strings.Join(modifyPaths, " "), strings.TrimSpace(string(output)))
+		}
+	}
+
 	// Handle --into flag (apply changes to downstack branch)
 	if modifyInto != "" {
 		return applyToDownstack(currentBranch, modifyInto)
 	}
 
+	// Handle --route flag (send each staged hunk to the branch it belongs to)
+	if modifyRoute {
+		return routeStagedChanges(currentBranch)
+	}
+
 	// If no flags provided, show interactive menu when there are no staged changes
-	if !modifyAmend && modifyRebaseNum == 0 && !modifyEditPR && modifyTitle == "" && modifyBody == "" && !modifyCommit {
+	if !modifyAmend && modifyRebaseNum == 0 && !modifyEditPR && modifyTitle == "" && modifyBody == "" && !modifyCommit && modifyReword == "" && !modifySplit {
 		// Check if there are any staged changes specifically
 		hasStagedChanges, err := git.HasStagedChanges()
 		if err != nil {
 			return fmt.Errorf("failed to check for staged changes: %w", err)
 		}
 
-		if !hasStagedChanges {
+		if !hasStagedChanges && !selectsChanges {
 			// No staged changes - show interactive menu
 			choice, err := showModifyMenu()
 			if err != nil {
@@ -103,13 +161,13 @@ func runModify() error {
 					return err
 				}
 			case "Just edit the commit message":
-				modifyAmend = true
+				modifyReword = "HEAD"
 			case "Abort this operation":
 				ui.Info("Operation aborted")
 				return nil
 			}
 		} else {
-			// Has staged changes but no explicit flags
+			// Has staged changes, or -a or paths select them, but no explicit flags
 			// Check if there are commits on this branch - if yes, amend by default
 			hasCommits, err := branchHasCommits(currentBranch)
 			if err != nil {
@@ -128,10 +186,24 @@ func runModify() error {
 		}
 	}
 
+	// Handle reword (message only)
+	if modifyReword != "" {
+		if err := rewordCommit(currentBranch, modifyReword, modifyMessage); err != nil {
+			return err
+		}
+	}
+
+	// Handle split of the last commit
+	if modifySplit {
+		if err := splitTipCommit(currentBranch); err != nil {
+			return err
+		}
+	}
+
 	// Handle commit (fresh commit)
 	if modifyCommit {
 		ui.Info("Creating new commit")
-		cmd := exec.Command("git", "commit")
+		cmd := git.CommitCommand(withSelection(withMessage([]string{"commit"}))...)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -143,7 +215,7 @@ func runModify() error {
 	// Handle amend
 	if modifyAmend {
 		ui.Info("Amending last commit")
-		cmd := exec.Command("git", "commit", "--amend", "--no-edit")
+		cmd := git.CommitCommand(withSelection(withMessage([]string{"commit", "--amend", "--no-edit"}))...)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -155,7 +227,13 @@ func runModify() error {
 	// Handle interactive rebase
 	if modifyRebaseNum > 0 {
 		ui.Info(fmt.Sprintf("Starting interactive rebase for last %d commits", modifyRebaseNum))
-		cmd := exec.Command("git", "rebase", "-i", fmt.Sprintf("HEAD~%d", modifyRebaseNum))
+		if err := git.CheckNotProtected(currentBranch, "rebase"); err != nil {
+			return err
+		}
+		if err := git.BackupCurrentBranch(); err != nil {
+			return err
+		}
+		cmd := git.CommitCommand("rebase", "-i", fmt.Sprintf("HEAD~%d", modifyRebaseNum))
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -169,9 +247,12 @@ func runModify() error {
 		// Determine if force push is needed
 		// Force push only if we amended or rebased (which rewrites history)
 		// Fresh commits with -c don't need force push
-		needsForcePush := modifyAmend || modifyRebaseNum > 0
+		needsForcePush := modifyAmend || modifyRebaseNum > 0 || modifyReword != "" || modifySplit
 
 		if needsForcePush {
+			if err := checkStackOwnership("force-push", []string{currentBranch}, modifyForce); err != nil {
+				return err
+			}
 			ui.Info(fmt.Sprintf("Force pushing %s", currentBranch))
 		} else {
 			ui.Info(fmt.Sprintf("Pushing %s", currentBranch))
@@ -237,6 +318,33 @@ func runModify() error {
 	return nil
 }
 
+// withMessage adds the -m message to a git commit command. It replaces --no-edit, which
+// keeps the message of an amended commit.
+func withMessage(args []string) []string {
+	if modifyMessage == "" {
+		return args
+	}
+	var result []string
+	for _, arg := range args {
+		if arg != "--no-edit" {
+			result = append(result, arg)
+		}
+	}
+	return append(result, "-m", modifyMessage)
+}
+
+// withSelection limits a git commit command to the changes -a or the paths select. Paths are
+// committed with git's --only semantics: other staged changes stay staged.
+func withSelection(args []string) []string {
+	if modifyAll {
+		args = append(args, "--all")
+	}
+	if len(modifyPaths) > 0 {
+		args = append(append(args, "--only", "--"), modifyPaths...)
+	}
+	return args
+}
+
 // showModifyMenu displays an interactive menu for modify options
 func showModifyMenu() (string, error) {
 	prompt := promptui.Select{
@@ -249,7 +357,7 @@ func showModifyMenu() (string, error) {
 		},
 	}
 
-	_, result, err := prompt.Run()
+	_, result, err := runSelect(&prompt)
 	if err != nil {
 		return "", fmt.Errorf("prompt failed: %w", err)
 	}
@@ -274,7 +382,7 @@ func commitAllChanges() error {
 	if hasCommits {
 		// Amend existing commit
 		ui.Info("Amending last commit with all changes")
-		cmd := exec.Command("git", "commit", "--all", "--amend", "--no-edit")
+		cmd := git.CommitCommand("commit", "--all", "--amend", "--no-edit")
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -284,7 +392,7 @@ func commitAllChanges() error {
 	} else {
 		// Create first commit
 		ui.Info("Creating first commit with all changes")
-		cmd := exec.Command("git", "commit", "--all")
+		cmd := git.CommitCommand("commit", "--all")
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -325,11 +433,11 @@ func commitPatchChanges() error {
 	if hasCommits {
 		// Amend existing commit
 		ui.Info("Amending last commit with selected changes")
-		commitCmd = exec.Command("git", "commit", "--amend", "--no-edit")
+		commitCmd = git.CommitCommand("commit", "--amend", "--no-edit")
 	} else {
 		// Create first commit
 		ui.Info("Creating first commit with selected changes")
-		commitCmd = exec.Command("git", "commit")
+		commitCmd = git.CommitCommand("commit")
 	}
 
 	commitCmd.Stdin = os.Stdin
@@ -342,7 +450,11 @@ func commitPatchChanges() error {
 	return nil
 }
 
-// applyToDownstack applies current changes to a downstack (ancestor) branch
+// applyToDownstack commits the staged changes, or if none are staged all changes to tracked
+// files, to a downstack (ancestor) branch. The commit is made on the branch ref directly, with
+// a three-way merge of the changes into its tree, and the branches above are restacked in
+// memory, bringing the changes back into HEAD. Nothing is checked out, so the working tree
+// stays as it is.
 func applyToDownstack(currentBranch, targetBranch string) error {
 	// Validate target branch exists
 	exists, err := git.BranchExists(targetBranch)
@@ -358,80 +470,112 @@ func applyToDownstack(currentBranch, targetBranch string) error {
 	if err != nil {
 		return fmt.Errorf("failed to check branch relationship: %w", err)
 	}
-	if !isAncestor {
+	if !isAncestor || targetBranch == currentBranch {
 		return fmt.Errorf("target branch %s is not an ancestor of %s", targetBranch, currentBranch)
 	}
-
-	// Check for uncommitted changes
-	hasChanges, err := git.HasUncommittedChanges()
+	if err := git.CheckNotProtected(targetBranch, "commit to"); err != nil {
+		return err
+	}
+	inProgress, err := git.IsRebaseInProgress()
 	if err != nil {
-		return fmt.Errorf("failed to check for changes: %w", err)
+		return fmt.Errorf("failed to check rebase status: %w", err)
 	}
-	if !hasChanges {
-		return fmt.Errorf("no changes to apply")
+	if inProgress {
+		return fmt.Errorf("a rebase is in progress. Finish it with 'stak restack --continue' or abort it first")
 	}
-
-	ui.Info(fmt.Sprintf("Applying changes from %s to %s", currentBranch, targetBranch))
-
-	// Stash current changes
-	ui.Info("Stashing changes")
-	stashCmd := exec.Command("git", "stash", "push", "-m", fmt.Sprintf("stak-modify-into-%s", targetBranch))
-	if err := stashCmd.Run(); err != nil {
-		return fmt.Errorf("failed to stash changes: %w", err)
+	// pre-commit checks the working tree and index, which don't hold the commit made here
+	if !git.NoVerify && git.HasHook("pre-commit") {
+		return fmt.Errorf("--into can't run the pre-commit hook, as %s isn't checked out. Commit with --no-verify to skip it, or check out %s and commit there", targetBranch, targetBranch)
 	}
 
-	// Checkout target branch
-	ui.Info(fmt.Sprintf("Switching to %s", targetBranch))
-	if err := git.CheckoutBranch(targetBranch); err != nil {
-		return fmt.Errorf("failed to checkout target branch: %w", err)
+	// The changes as a commit on HEAD: the index, or git stash create's snapshot of the
+	// tracked files
+	hasStaged, err := git.HasStagedChanges()
+	if err != nil {
+		return fmt.Errorf("failed to check for staged changes: %w", err)
 	}
-
-	// Apply stash
-	ui.Info("Applying changes")
-	popCmd := exec.Command("git", "stash", "pop")
-	popCmd.Stdout = os.Stdout
-	popCmd.Stderr = os.Stderr
-	if err := popCmd.Run(); err != nil {
-		ui.Warning("Failed to apply stash cleanly. You may need to resolve conflicts.")
-		return fmt.Errorf("stash apply failed: %w", err)
+	var changes string
+	if hasStaged {
+		tree, err := git.WriteIndexTree()
+		if err != nil {
+			return err
+		}
+		if changes, err = git.CommitTree(tree, "HEAD"); err != nil {
+			return err
+		}
+	} else {
+		if changes, err = git.StashCreate(); err != nil {
+			return err
+		}
+		if changes == "" {
+			return fmt.Errorf("no changes to apply")
+		}
 	}
 
-	// Prompt for commit
-	ui.Info("Changes applied. Creating commit...")
-	commitCmd := exec.Command("git", "commit")
-	commitCmd.Stdin = os.Stdin
-	commitCmd.Stdout = os.Stdout
-	commitCmd.Stderr = os.Stderr
-	if err := commitCmd.Run(); err != nil {
-		ui.Warning("Commit cancelled or failed. Changes are still staged.")
-		return fmt.Errorf("failed to commit: %w", err)
+	// HEAD is the merge base, so what the branches in between changed is left out
+	ours, err := git.CommitTree(targetBranch+"^{tree}", targetBranch, "HEAD")
+	if err != nil {
+		return err
 	}
-
-	ui.Success(fmt.Sprintf("Changes committed to %s", targetBranch))
-
-	// Push target branch
-	ui.Info(fmt.Sprintf("Pushing %s", targetBranch))
-	if err := git.Push(targetBranch, false, false); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
+	merge := git.MergeTree
+	if !git.GetVersion().AtLeast(git.MergeTreeVersion) {
+		merge = git.MergeInWorktree
 	}
-
-	// Rebase all descendants of target (including original current branch)
-	ui.Info("Syncing descendant branches")
-	children, err := stack.GetChildren(targetBranch)
+	tree, conflicts, err := merge(ours, changes)
 	if err != nil {
-		return fmt.Errorf("failed to get children: %w", err)
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("the changes conflict with %s in %s; nothing was changed", targetBranch, strings.Join(conflicts, ", "))
 	}
 
-	for _, child := range children {
-		if err := syncBranchRecursive(child); err != nil {
+	message := strings.TrimSpace(modifyMessage)
+	if message == "" {
+		if err := requireInteractive("a commit message (-m)"); err != nil {
 			return err
 		}
+		template := fmt.Sprintf("\n# Please enter the commit message for your changes to %s. Lines starting\n# with '#' will be ignored, and an empty message aborts the commit.\n", targetBranch)
+		if message, err = git.EditCommitMessage(template); err != nil {
+			return fmt.Errorf("commit cancelled: %w", err)
+		}
+	}
+	if message, err = git.RunCommitMsgHook(message); err != nil {
+		return err
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	if err := git.BackupBranch(targetBranch); err != nil {
+		return err
+	}
+	commit, err := git.CommitOnBranch(targetBranch, tree, message)
+	if err != nil {
+		return err
+	}
+	restacked, err := restackKeepingChanges(map[string]string{targetBranch: commit}, ctx.Children(targetBranch), "")
+	if err != nil {
+		return err
 	}
+	ui.Success(fmt.Sprintf("Changes committed to %s", targetBranch))
 
-	// Return to original branch
-	ui.Info(fmt.Sprintf("Returning to %s", currentBranch))
-	if err := git.CheckoutBranch(currentBranch); err != nil {
-		return fmt.Errorf("failed to return to original branch: %w", err)
+	if modifyPush {
+		if err := checkStackOwnership("force-push", restacked, modifyForce); err != nil {
+			return err
+		}
+		ui.Info(fmt.Sprintf("Pushing %s", targetBranch))
+		if err := git.Push(targetBranch, false, false); err != nil {
+			return fmt.Errorf("failed to push: %w", err)
+		}
+		for _, branch := range restacked {
+			ui.Info(fmt.Sprintf("Force pushing %s", branch))
+			if err := git.Push(branch, false, true); err != nil {
+				return fmt.Errorf("failed to push %s: %w", branch, err)
+			}
+		}
+	} else {
+		ui.Info("Use 'stak submit' to push the updated branches")
 	}
 
 	ui.Success("Successfully applied changes to downstack branch")
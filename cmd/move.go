@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -14,14 +13,27 @@ import (
 
 var (
 	moveParent string
+	moveForce  bool
+	moveBefore string
+	moveAfter  string
 )
 
 var moveCmd = &cobra.Command{
 	Use:     "move [branch]",
 	Aliases: []string{"mv"},
 	Short:   "Change a branch's parent",
-	Long:    `Move a branch to a different parent in the stack. This rebases the branch onto the new parent and updates all metadata and PR bases.`,
-	Args:    cobra.MaximumNArgs(1),
+	Long: `Move a branch to a different parent in the stack. The branch moves together with all of its
+descendants: the whole subtree is restacked in one pass, each branch replaying only its own
+commits, so its structure is kept and nothing of the old parent comes along. Metadata and
+PR bases are updated, the rewritten branches that were already pushed are force pushed, and
+the moved branch is checked out.
+
+With --before or --after, the branch is inserted next to another branch instead, e.g. a
+sibling: --before puts it between that branch and its parent, --after between that branch
+and its children. The branch's own children come along, and everything affected is
+restacked and has its PR retargeted in one go.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -29,21 +41,26 @@ var moveCmd = &cobra.Command{
 		}
 
 		if err := runMove(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
 	moveCmd.Flags().StringVar(&moveParent, "parent", "", "New parent branch")
+	moveCmd.RegisterFlagCompletionFunc("parent", completeParent)
+	moveCmd.Flags().StringVar(&moveBefore, "before", "", "Insert the branch below this branch, between it and its parent")
+	moveCmd.RegisterFlagCompletionFunc("before", completeNextTo)
+	moveCmd.Flags().StringVar(&moveAfter, "after", "", "Insert the branch above this branch, between it and its children")
+	moveCmd.RegisterFlagCompletionFunc("after", completeNextTo)
+	moveCmd.Flags().BoolVar(&moveForce, "force", false, "Move even if branches are owned by someone else")
 	rootCmd.AddCommand(moveCmd)
 }
 
 func runMove(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch
@@ -82,6 +99,10 @@ func runMove(branchName string) error {
 	currentParent := metadata.Parent
 	ui.Info(fmt.Sprintf("Current parent: %s", currentParent))
 
+	if moveBefore != "" || moveAfter != "" {
+		return runMoveNextTo(branchName)
+	}
+
 	// Determine new parent
 	var newParent string
 	if moveParent != "" {
@@ -124,61 +145,87 @@ func runMove(branchName string) error {
 		return fmt.Errorf("cannot move: would create circular dependency")
 	}
 
-	// Checkout the branch
-	currentBranch, _ := git.GetCurrentBranch()
-	if currentBranch != branchName {
-		ui.Info(fmt.Sprintf("Checking out %s", branchName))
+	// Moving rewrites the branch and all of its descendants
+	affected, err := stack.GetDescendants(branchName)
+	if err != nil {
+		return fmt.Errorf("failed to get descendants: %w", err)
+	}
+	if err := checkStackOwnership("move", append([]string{branchName}, affected...), moveForce); err != nil {
+		return err
+	}
+
+	if err := moveSubtree(branchName, newParent, affected); err != nil {
+		return err
+	}
+
+	// Leave the user on the branch they moved
+	if current, _ := git.GetCurrentBranch(); current != branchName {
 		if err := git.CheckoutBranch(branchName); err != nil {
-			return fmt.Errorf("failed to checkout branch: %w", err)
+			return fmt.Errorf("failed to checkout %s: %w", branchName, err)
 		}
 	}
 
-	// Rebase onto new parent
-	ui.Info(fmt.Sprintf("Rebasing %s onto %s", branchName, newParent))
-	if err := git.RebaseOnto(newParent); err != nil {
-		return fmt.Errorf("failed to rebase: %w", err)
+	ui.Success(fmt.Sprintf("Moved %s from %s to %s", branchName, currentParent, newParent))
+	return nil
+}
+
+// moveSubtree moves a branch and its descendants onto newParent as a unit: each replays only
+// its own commits onto its parent, so the subtree keeps its shape and nothing of the old
+// parent comes along. The PR base is updated and rewritten branches already pushed are pushed.
+func moveSubtree(branchName, newParent string, descendants []string) error {
+	if err := checkCanRestack("move", branchName); err != nil {
+		return err
+	}
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	recordRestackUpstreams(ctx, append([]string{branchName}, descendants...))
+	if len(descendants) > 0 {
+		ui.Info(fmt.Sprintf("Moving %s with its %d descendant(s)", branchName, len(descendants)))
 	}
 
 	// Update metadata
-	if err := stack.WriteBranchMetadata(branchName, newParent, metadata.PRNumber); err != nil {
+	if err := stack.WriteBranchMetadata(branchName, newParent, ctx.PRNumber(branchName)); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
 
-	// Push changes
-	ui.Info(fmt.Sprintf("Force pushing %s", branchName))
-	if err := git.Push(branchName, false, true); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
+	// The subtree takes the name, dependency and scope of the stack it joins, or none if it
+	// becomes a stack of its own
+	joined := ""
+	if _, ok := stack.StackOf(ctx, newParent); ok {
+		joined = newParent
+	}
+	for _, branch := range append([]string{branchName}, descendants...) {
+		if err := git.CopyStackFields(branch, joined); err != nil {
+			ui.Warning(fmt.Sprintf("Could not update the stack fields of %s: %v", branch, err))
+		}
 	}
 
 	// Update PR base if PR exists
-	if metadata.PRNumber > 0 {
-		ui.Info(fmt.Sprintf("Updating PR #%d base to %s", metadata.PRNumber, newParent))
-		if err := github.UpdatePRBase(metadata.PRNumber, newParent); err != nil {
+	if prNumber := ctx.PRNumber(branchName); prNumber > 0 {
+		ui.Info(fmt.Sprintf("Updating PR #%d base to %s", prNumber, newParent))
+		if err := github.UpdatePRBase(prNumber, newParent); err != nil {
 			return fmt.Errorf("failed to update PR base: %w", err)
 		}
 	}
 
-	// Rebase all children onto the moved branch
-	children, err := stack.GetChildren(branchName)
+	// Restack the whole subtree in one pass, parents first
+	restacked, err := restackSubtrees([]string{branchName}, currentBranch)
 	if err != nil {
-		return fmt.Errorf("failed to get children: %w", err)
+		ui.Info("After the restack, run 'stak push' to update the PRs")
+		return err
 	}
 
-	if len(children) > 0 {
-		ui.Info(fmt.Sprintf("Syncing %d child branch(es)", len(children)))
-		for _, child := range children {
-			if err := syncBranchRecursive(child); err != nil {
-				return fmt.Errorf("failed to sync child %s: %w", child, err)
-			}
-		}
-
-		// Return to the branch we moved
-		if err := git.CheckoutBranch(branchName); err != nil {
-			return fmt.Errorf("failed to return to branch: %w", err)
-		}
+	if err := pushRestacked(restacked); err != nil {
+		return err
 	}
 
-	ui.Success(fmt.Sprintf("Moved %s from %s to %s", branchName, currentParent, newParent))
 	return nil
 }
 
@@ -199,7 +246,7 @@ func selectNewParent(branch, currentParent string) (string, error) {
 	var options []string
 
 	// Base branches (main, master, develop)
-	baseBranches := []string{"main", "master", "develop", "development"}
+	baseBranches := stack.BaseBranches()
 	for _, base := range baseBranches {
 		for _, b := range allBranches {
 			if b == base && b != branch {
@@ -270,7 +317,7 @@ func selectNewParent(branch, currentParent string) (string, error) {
 		Size:  10,
 	}
 
-	_, result, err := prompt.Run()
+	_, result, err := runSelect(&prompt)
 	if err != nil {
 		return "", fmt.Errorf("parent selection cancelled")
 	}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// runMoveNextTo inserts a branch directly below (--before) or above (--after) another branch,
+// usually a sibling. The branch keeps its own children, and takes over the other branch
+// (--before) or its children (--after). Everything affected is restacked in one pass.
+func runMoveNextTo(branchName string) error {
+	if moveParent != "" || (moveBefore != "" && moveAfter != "") {
+		return fmt.Errorf("use only one of --parent, --before and --after")
+	}
+	target := moveBefore
+	if target == "" {
+		target = moveAfter
+	}
+	if target == branchName {
+		return fmt.Errorf("cannot move %s next to itself", branchName)
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	if !ctx.IsTracked(target) {
+		return fmt.Errorf("branch %s is not tracked", target)
+	}
+	for _, descendant := range ctx.Descendants(branchName) {
+		if descendant == target {
+			return fmt.Errorf("cannot move: %s is a descendant of %s", target, branchName)
+		}
+	}
+
+	// The branches that end up on top of the moved branch
+	var newParent string
+	var above []string
+	if moveBefore != "" {
+		newParent = ctx.Parent(target)
+		above = []string{target}
+	} else {
+		newParent = target
+		for _, child := range ctx.Children(target) {
+			if child != branchName {
+				above = append(above, child)
+			}
+		}
+	}
+	if newParent == ctx.Parent(branchName) && len(above) == 0 {
+		ui.Info(fmt.Sprintf("%s is already right after %s. Nothing to do.", branchName, target))
+		return nil
+	}
+
+	for _, branch := range append([]string{branchName}, above...) {
+		if err := git.CheckNotProtected(branch, "move"); err != nil {
+			return err
+		}
+	}
+	if err := checkCanRestack("move", branchName); err != nil {
+		return err
+	}
+
+	affected := append([]string{branchName}, ctx.Descendants(branchName)...)
+	for _, branch := range above {
+		affected = append(affected, branch)
+		affected = append(affected, ctx.Descendants(branch)...)
+	}
+	if err := checkStackOwnership("move", affected, moveForce); err != nil {
+		return err
+	}
+
+	recordRestackUpstreams(ctx, affected)
+
+	ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", branchName, ctx.Parent(branchName), newParent))
+	if err := stack.WriteBranchMetadata(branchName, newParent, ctx.PRNumber(branchName)); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+	for _, branch := range above {
+		ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", branch, ctx.Parent(branch), branchName))
+		if err := stack.WriteBranchMetadata(branch, branchName, ctx.PRNumber(branch)); err != nil {
+			return fmt.Errorf("failed to update metadata for %s: %w", branch, err)
+		}
+	}
+
+	retargetPR(ctx.PRNumber(branchName), newParent)
+	for _, branch := range above {
+		retargetPR(ctx.PRNumber(branch), branchName)
+	}
+
+	restacked, err := restackSubtrees([]string{branchName}, currentBranch)
+	if err != nil {
+		ui.Info("After the restack, run 'stak push' to update the PRs")
+		return err
+	}
+
+	if err := pushRestacked(restacked); err != nil {
+		return err
+	}
+
+	if moveBefore != "" {
+		ui.Success(fmt.Sprintf("Moved %s before %s: %s → %s → %s", branchName, target, newParent, branchName, target))
+	} else {
+		ui.Success(fmt.Sprintf("Moved %s after %s: %s → %s", branchName, target, target, branchName))
+	}
+	return nil
+}
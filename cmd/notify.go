@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"stacking/internal/config"
+	"stacking/internal/notify"
+)
+
+// notifyEvent sends a desktop notification if requested by flag or the stack.notifications setting
+func notifyEvent(requested bool, message string) {
+	if !requested && !config.GetBool("notifications", false) {
+		return
+	}
+	// Notifications are best-effort; never fail a command because of them
+	notify.Send("stak", message)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// syncSkipped are branches sync must not rebase and push, e.g. because that would drop
+// changes merged into them on GitHub
+var syncSkipped = make(map[string]bool)
+
+// openParentPR returns the number of parent's PR if parent is a tracked branch whose PR is
+// still open, or 0
+func openParentPR(parent string) int {
+	metadata, err := stack.ReadBranchMetadata(parent)
+	if err != nil || metadata.PRNumber == 0 {
+		return 0
+	}
+	status, err := github.GetPRStatus(metadata.PRNumber)
+	if err != nil || !status.IsOpen() {
+		return 0
+	}
+	return metadata.PRNumber
+}
+
+// repairOutOfOrderMerge handles a branch whose PR was merged on GitHub while its parent's is
+// still open. If it was merged into the parent's branch, the parent now holds its changes on
+// the remote, so the local parent is brought up to date before sync pushes it. If it was
+// merged somewhere else, e.g. into trunk after being retargeted, it took the parent's changes
+// along. Either way its children move to where it landed. It returns that branch, and false
+// if the user would rather leave the stack as it is for now.
+func repairOutOfOrderMerge(branch string, prNumber int, parent string, parentPR int) (string, bool) {
+	base := parent
+	if details, err := github.GetPRDetails(prNumber); err == nil && details.BaseRefName != "" {
+		base = details.BaseRefName
+	}
+
+	ui.Warning(fmt.Sprintf("PR #%d (%s) was merged into %s before PR #%d (%s) it builds on", prNumber, branch, base, parentPR, parent))
+	if base == parent {
+		fmt.Printf("  Its changes are now part of %s. Repairing updates %s from %s, moves the\n", git.RemoteRef(parent), parent, git.RemoteRef(parent))
+		fmt.Printf("  branches on %s onto %s and retargets their PRs.\n", branch, parent)
+	} else {
+		fmt.Printf("  It took the changes of %s into %s along with its own, so PR #%d may have nothing\n", parent, base, parentPR)
+		fmt.Printf("  left to merge. Repairing moves the branches on %s onto %s and retargets their PRs.\n", branch, base)
+	}
+
+	// Without prompts, e.g. in CI, repair: leaving the stack as it is loses changes on the next push
+	if requireInteractive("how to repair the stack") == nil {
+		prompt := promptui.Select{
+			Label: "Repair the stack?",
+			Items: []string{"Repair the stack", "Leave it for now"},
+		}
+		if _, result, err := runSelect(&prompt); err != nil || result != "Repair the stack" {
+			ui.Info(fmt.Sprintf("Leaving %s and %s as they are; the next sync asks again", branch, parent))
+			syncSkipped[parent] = true
+			syncSkipped[branch] = true
+			return "", false
+		}
+	}
+
+	if base == parent {
+		moved, err := git.FastForwardToRemote(parent)
+		switch {
+		case errors.Is(err, git.ErrDiverged):
+			// Pushing the local parent would replace the merged changes on the remote
+			ui.Warning(fmt.Sprintf("%s has commits %s lacks, so it is not synced. Rebase it onto %s, then run 'stak sync' again",
+				parent, git.RemoteRef(parent), git.RemoteRef(parent)))
+			syncSkipped[parent] = true
+		case err != nil:
+			ui.Warning(fmt.Sprintf("Could not update %s from %s: %v", parent, git.RemoteRef(parent), err))
+			syncSkipped[parent] = true
+		case moved > 0:
+			ui.Info(fmt.Sprintf("Updated %s with the %d commit(s) merged into it on GitHub", parent, moved))
+		}
+	} else {
+		ui.Info(fmt.Sprintf("Check PR #%d: once %s is synced onto %s it may be empty, and can be closed", parentPR, parent, base))
+	}
+	return base, true
+}
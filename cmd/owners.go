@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/codeowners"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var ownersRequest bool
+
+var ownersCmd = &cobra.Command{
+	Use:     "owners",
+	Aliases: []string{"ow"},
+	Short:   "Suggest reviewers for each branch of the stack from CODEOWNERS",
+	Long: `Match the files each branch of the current stack changes (against its parent, not the
+trunk) with the repository's CODEOWNERS file, and list the owners of each layer with how
+many of its files they own. You are left out of your own suggestions.
+
+With --request, reviews are requested from those owners on each branch's PR. GitHub only
+requests code owners automatically for PRs into protected branches, so without this the
+upper layers of a stack often go to nobody.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runOwners(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	ownersCmd.Flags().BoolVar(&ownersRequest, "request", false, "Request reviews from the owners on each PR")
+	rootCmd.AddCommand(ownersCmd)
+}
+
+func runOwners() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	if ownersRequest && !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	file, err := codeowners.Load(root)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return fmt.Errorf("no CODEOWNERS file found (looked in %s)", strings.Join(codeowners.Locations, ", "))
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	if !ctx.IsTracked(currentBranch) {
+		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+
+	// Nobody reviews their own PR; without gh, owners are shown as they are
+	me, _ := github.GetCurrentUser()
+
+	for _, branch := range ctx.FullStack(currentBranch) {
+		if !ctx.IsTracked(branch) {
+			continue
+		}
+
+		files, err := git.GetChangedFiles(ctx.Parent(branch), branch)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("%s: %v", branch, err))
+			continue
+		}
+
+		counts := make(map[string]int)
+		unowned := 0
+		for _, path := range files {
+			owners := file.Owners(path)
+			if len(owners) == 0 {
+				unowned++
+			}
+			for _, owner := range owners {
+				if me == "" || !strings.EqualFold(strings.TrimPrefix(owner, "@"), me) {
+					counts[owner]++
+				}
+			}
+		}
+
+		owners := make([]string, 0, len(counts))
+		for owner := range counts {
+			owners = append(owners, owner)
+		}
+		// Owners of the most files first
+		sort.Slice(owners, func(i, j int) bool {
+			if counts[owners[i]] != counts[owners[j]] {
+				return counts[owners[i]] > counts[owners[j]]
+			}
+			return owners[i] < owners[j]
+		})
+
+		prNumber := ctx.PRNumber(branch)
+		if prNumber > 0 {
+			fmt.Printf("%s  PR #%d\n", branch, prNumber)
+		} else {
+			fmt.Println(branch)
+		}
+		if len(files) == 0 {
+			fmt.Println("  No changes")
+		}
+		for _, owner := range owners {
+			fmt.Printf("  %-30s %d file(s)\n", owner, counts[owner])
+		}
+		if unowned > 0 {
+			fmt.Printf("  %d file(s) without owners\n", unowned)
+		}
+
+		if ownersRequest {
+			requestOwnerReviews(branch, prNumber, owners)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// requestOwnerReviews requests reviews from the GitHub users and teams among owners.
+// Owners given as email addresses can't be requested and are skipped.
+func requestOwnerReviews(branch string, prNumber int, owners []string) {
+	if prNumber == 0 {
+		ui.Warning(fmt.Sprintf("%s has no PR. Run 'stak submit' first", branch))
+		return
+	}
+
+	var reviewers []string
+	for _, owner := range owners {
+		if strings.HasPrefix(owner, "@") {
+			reviewers = append(reviewers, strings.TrimPrefix(owner, "@"))
+		}
+	}
+	if len(reviewers) == 0 {
+		return
+	}
+
+	if err := github.RequestReviewers(prNumber, reviewers); err != nil {
+		ui.Warning(err.Error())
+		return
+	}
+	ui.Success(fmt.Sprintf("Requested reviews on PR #%d from %s", prNumber, strings.Join(reviewers, ", ")))
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"stacking/internal/config"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// checkStackOwnership warns before rewriting branches owned by another GitHub user.
+// The stack.ownership setting controls the behavior:
+//   - warn (default): print a warning and continue
+//   - strict: refuse unless force is set
+//   - off: skip the check entirely
+func checkStackOwnership(action string, branches []string, force bool) error {
+	policy := config.GetString("ownership", "warn")
+	if policy == "off" {
+		return nil
+	}
+
+	me, err := github.GetCurrentUser()
+	if err != nil {
+		// Without knowing who we are we can't tell whose stack this is
+		return nil
+	}
+
+	var foreign []string
+	for _, branch := range branches {
+		owner, err := git.GetBranchOwner(branch)
+		if err != nil || owner == "" || strings.EqualFold(owner, me) {
+			continue
+		}
+		foreign = append(foreign, fmt.Sprintf("%s (owned by @%s)", branch, owner))
+	}
+
+	if len(foreign) == 0 {
+		return nil
+	}
+
+	ui.Warning(fmt.Sprintf("About to %s branch(es) owned by someone else:", action))
+	for _, f := range foreign {
+		fmt.Printf("  - %s\n", f)
+	}
+
+	if force {
+		return nil
+	}
+	if policy == "strict" {
+		return fmt.Errorf("refusing to %s another user's branches. Re-run with --force to override", action)
+	}
+	return nil
+}
+
+// recordOwner marks the current GitHub user as the owner of a branch if it has none
+func recordOwner(branch string) {
+	owner, err := git.GetBranchOwner(branch)
+	if err != nil || owner != "" {
+		return
+	}
+	me, err := github.GetCurrentUser()
+	if err != nil {
+		return
+	}
+	stack.SetBranchOwner(branch, me)
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+)
+
+// pluginPrefix is the executable name prefix for external subcommands, git-style:
+// "stak foo" runs "stak-foo" from PATH when foo is not a built-in command
+const pluginPrefix = "stak-"
+
+var pluginsCmd = &cobra.Command{
+	Use:     "plugins",
+	Aliases: []string{"pl"},
+	Short:   "List plugins found on PATH",
+	Long: `List executables named stak-<name> on PATH. Each one can be run as "stak <name>".
+
+Plugins receive the repository and current branch in STAK_* environment variables,
+and a JSON dump of all tracked branches on stdin.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPlugins(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+}
+
+func runPlugins() error {
+	plugins := findPlugins()
+	if len(plugins) == 0 {
+		fmt.Println("No plugins found. Add an executable named stak-<name> to your PATH.")
+		return nil
+	}
+
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("  %-20s %s\n", name, plugins[name])
+	}
+	return nil
+}
+
+// findPlugins returns every stak-<name> executable on PATH, keyed by name
+// Earlier PATH entries win, matching how the plugin would be resolved when run
+func findPlugins() map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), pluginPrefix) || entry.IsDir() {
+				continue
+			}
+			name := strings.TrimPrefix(entry.Name(), pluginPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if name == "" || plugins[name] != "" {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if _, err := exec.LookPath(path); err != nil {
+				continue // Not executable
+			}
+			plugins[name] = path
+		}
+	}
+	return plugins
+}
+
+// lookupPlugin returns the plugin to run for args, if the first argument is not a built-in command
+func lookupPlugin(args []string) (string, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(args[0]) {
+		return "", false
+	}
+
+	path, err := exec.LookPath(pluginPrefix + args[0])
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// pluginBranch is one tracked branch in the JSON stack dump sent to plugins
+type pluginBranch struct {
+	Name     string   `json:"name"`
+	Parent   string   `json:"parent"`
+	PRNumber int      `json:"pr,omitempty"`
+	Owner    string   `json:"owner,omitempty"`
+	Frozen   bool     `json:"frozen,omitempty"`
+	Children []string `json:"children"`
+}
+
+// pluginStack is the JSON document written to a plugin's stdin
+type pluginStack struct {
+	Version       string         `json:"version"`
+	RepoRoot      string         `json:"repo_root,omitempty"`
+	CurrentBranch string         `json:"current_branch,omitempty"`
+	Branches      []pluginBranch `json:"branches"`
+}
+
+// runPlugin runs an external subcommand and returns its exit code
+func runPlugin(path string, args []string) (int, error) {
+	env := append(os.Environ(), "STAK_VERSION="+appVersion)
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, "STAK_BIN="+exe)
+	}
+
+	dump := pluginStack{Version: appVersion, Branches: []pluginBranch{}}
+	if git.IsGitRepository() {
+		if root, err := git.GetRepoRoot(); err == nil {
+			dump.RepoRoot = root
+			env = append(env, "STAK_REPO_ROOT="+root)
+		}
+
+		ctx, err := stack.LoadContext()
+		if err != nil {
+			return 1, fmt.Errorf("failed to load stack: %w", err)
+		}
+
+		if current, err := git.GetCurrentBranch(); err == nil {
+			dump.CurrentBranch = current
+			env = append(env, "STAK_CURRENT_BRANCH="+current)
+			if parent := ctx.Parent(current); parent != "" {
+				env = append(env, "STAK_PARENT_BRANCH="+parent)
+			}
+			if pr := ctx.PRNumber(current); pr > 0 {
+				env = append(env, "STAK_PR_NUMBER="+strconv.Itoa(pr))
+			}
+		}
+
+		for _, branch := range stack.GetAllBranchesInOrder(ctx.Stack) {
+			frozen, _ := stack.IsBranchFrozen(branch.Name)
+			dump.Branches = append(dump.Branches, pluginBranch{
+				Name:     branch.Name,
+				Parent:   branch.Parent,
+				PRNumber: branch.PRNumber,
+				Owner:    branch.Owner,
+				Frozen:   frozen,
+				Children: ctx.Children(branch.Name),
+			})
+		}
+	}
+
+	input, err := json.Marshal(dump)
+	if err != nil {
+		return 1, fmt.Errorf("failed to encode stack for plugin: %w", err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Env = env
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, fmt.Errorf("failed to run plugin %s: %w", filepath.Base(path), err)
+	}
+	return 0, nil
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
 
 	"github.com/manifoldco/promptui"
@@ -14,16 +13,22 @@ import (
 )
 
 var (
-	popKeep  bool
-	popForce bool
+	popKeep      bool
+	popForce     bool
+	popNoRestack bool
 )
 
 var popCmd = &cobra.Command{
 	Use:     "pop [branch]",
 	Aliases: []string{"pp"},
 	Short:   "Remove branch from stack, keeping changes",
-	Long:    `Pop a branch from the stack, preserving its changes locally. The changes are stashed and can be applied to the parent branch or discarded.`,
-	Args:    cobra.MaximumNArgs(1),
+	Long: `Pop a branch from the stack, preserving its changes locally. The changes are stashed and can be applied to the parent branch or discarded.
+
+Children of the popped branch are moved onto its parent and rebased there with their
+descendants, leaving the popped branch's commits behind. If a rebase conflicts, resolve it
+and run 'stak restack --continue'.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -31,8 +36,7 @@ var popCmd = &cobra.Command{
 		}
 
 		if err := runPop(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -40,13 +44,14 @@ var popCmd = &cobra.Command{
 func init() {
 	popCmd.Flags().BoolVar(&popKeep, "keep", false, "Keep the branch (don't delete it)")
 	popCmd.Flags().BoolVarP(&popForce, "force", "f", false, "Skip confirmation prompts")
+	popCmd.Flags().BoolVar(&popNoRestack, "no-restack", false, "Only re-parent children, without rebasing them")
 	rootCmd.AddCommand(popCmd)
 }
 
 func runPop(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch
@@ -84,7 +89,10 @@ func runPop(branchName string) error {
 
 	parent := metadata.Parent
 	if parent == "" {
-		parent = "main" // fallback
+		parent, err = stack.Trunk() // fallback
+		if err != nil {
+			return err
+		}
 	}
 
 	// Get children
@@ -100,6 +108,9 @@ func runPop(branchName string) error {
 		ui.Info(fmt.Sprintf("  - Switch to %s", parent))
 		if len(children) > 0 {
 			ui.Info(fmt.Sprintf("  - Update %d child branch(es) to point to %s", len(children), parent))
+			if !popNoRestack {
+				ui.Info(fmt.Sprintf("  - Rebase them and their descendants onto %s", parent))
+			}
 		}
 		if !popKeep {
 			ui.Info(fmt.Sprintf("  - Delete local branch %s", branchName))
@@ -114,7 +125,7 @@ func runPop(branchName string) error {
 			Items: []string{"Yes", "No"},
 		}
 
-		_, result, err := prompt.Run()
+		_, result, err := runSelect(&prompt)
 		if err != nil || result == "No" {
 			ui.Info("Pop cancelled")
 			return nil
@@ -154,6 +165,14 @@ func runPop(branchName string) error {
 		return fmt.Errorf("failed to checkout parent: %w", err)
 	}
 
+	if !popNoRestack {
+		if ctx, err := stack.LoadContext(); err != nil {
+			ui.Warning(fmt.Sprintf("Could not load stack: %v", err))
+		} else {
+			recordRestackUpstreams(ctx, ctx.Descendants(branchName))
+		}
+	}
+
 	// Update children to point to parent
 	for _, child := range children {
 		ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", child, branchName, parent))
@@ -205,6 +224,15 @@ func runPop(branchName string) error {
 		ui.Warning(fmt.Sprintf("Could not delete metadata: %v", err))
 	}
 
+	ui.Success(fmt.Sprintf("Popped %s from stack", branchName))
+
+	var restackErr error
+	if len(children) > 0 && !popNoRestack {
+		if _, restackErr = restackSubtrees(children, parent); restackErr == nil {
+			ui.Info("Run 'stak push' to update their PRs")
+		}
+	}
+
 	// Inform about stashed changes
 	if stashCreated {
 		ui.Info("")
@@ -215,7 +243,5 @@ func runPop(branchName string) error {
 		ui.Info("To discard them:")
 		ui.Info("  git stash drop")
 	}
-
-	ui.Success(fmt.Sprintf("Popped %s from stack", branchName))
-	return nil
+	return restackErr
 }
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Porcelain output is for scripts: one record per line, tab-separated fields,
+// no colors or decoration. Field order is stable; new fields are only ever
+// appended, so scripts should ignore fields they don't know about. A missing PR number is
+// always 0, in every command.
+
+// printPorcelain prints one tab-separated record
+func printPorcelain(fields ...string) {
+	for i, field := range fields {
+		// Keep every record on one line with the expected number of fields
+		fields[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(field)
+	}
+	fmt.Println(strings.Join(fields, "\t"))
+}
+
+// porcelainBool formats a boolean field
+func porcelainBool(b bool) string {
+	return strconv.FormatBool(b)
+}
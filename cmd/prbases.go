@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// canStackOn reports whether branch can be given base as its parent: base is a local branch
+// that is tracked or a base branch, and not built on branch
+func canStackOn(branch, base string) bool {
+	if exists, err := git.BranchExists(base); err != nil || !exists {
+		return false
+	}
+	if tracked, err := stack.HasStackMetadata(base); err != nil || (!tracked && !stack.IsBaseBranch(base)) {
+		return false
+	}
+	cycle, err := stack.WouldCreateCycle(branch, base)
+	return err == nil && !cycle
+}
+
+// convergePRBases keeps parents and PR bases in step for the parents-from-prs setting. Each
+// branch remembers the base its PR had when sync last saw it: if the PR's base changed on
+// GitHub since, e.g. by a teammate in the web UI, the branch follows it onto the new base;
+// if the local parent changed instead, the PR is retargeted to it. When both changed, or
+// nothing was recorded yet, GitHub wins.
+func convergePRBases(branches []string) {
+	prBranches := make(map[int]string)
+	var prNumbers []int
+	for _, branch := range branches {
+		if exists, err := git.BranchExists(branch); err != nil || !exists {
+			continue
+		}
+		if metadata, err := stack.ReadBranchMetadata(branch); err == nil && metadata.PRNumber > 0 {
+			prBranches[metadata.PRNumber] = branch
+			prNumbers = append(prNumbers, metadata.PRNumber)
+		}
+	}
+	if len(prNumbers) == 0 {
+		return
+	}
+	bases, err := github.GetOpenPRBases(prNumbers)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not check PR bases: %v", err))
+		return
+	}
+
+	for _, prNumber := range prNumbers {
+		branch, base := prBranches[prNumber], bases[prNumber]
+		if base == "" {
+			continue
+		}
+		parent, err := stack.GetParent(branch)
+		if err != nil {
+			continue
+		}
+		recorded, _ := git.GetBranchPRBase(branch)
+
+		switch {
+		case base == parent:
+		case recorded == base:
+			if syncNoPush {
+				ui.Info(fmt.Sprintf("Not updating PR #%d base to %s (--no-push)", prNumber, parent))
+				continue
+			}
+			if err := github.UpdatePRBase(prNumber, parent); err != nil {
+				ui.Warning(fmt.Sprintf("Could not update PR #%d base: %v", prNumber, err))
+				continue
+			}
+			ui.Info(fmt.Sprintf("Updated PR #%d base to %s, the parent of %s", prNumber, parent, branch))
+			base = parent
+		case canStackOn(branch, base):
+			if err := followPRBase(branch, base); err != nil {
+				ui.Warning(fmt.Sprintf("Could not move %s onto %s: %v", branch, base, err))
+				continue
+			}
+			ui.Info(fmt.Sprintf("PR #%d was retargeted to %s on GitHub, so %s now stacks on %s (was %s)", prNumber, base, branch, base, parent))
+		default:
+			ui.Warning(fmt.Sprintf("PR #%d of %s is based on %s, which %s can't be stacked on. Run 'stak reconcile' to fix it", prNumber, branch, base, branch))
+			continue
+		}
+
+		if err := git.SetBranchPRBase(branch, base); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record the base of PR #%d: %v", prNumber, err))
+		}
+	}
+}
+
+// followPRBase makes base the parent of branch in the metadata; sync then rebases only its
+// own commits there. Like stak move, the subtree takes the stack fields of the stack it joins.
+func followPRBase(branch, base string) error {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	recordRestackUpstreams(ctx, []string{branch})
+	if err := git.SetBranchParent(branch, base); err != nil {
+		return err
+	}
+	if ctx, err = stack.LoadContext(); err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	joined := ""
+	if _, ok := stack.StackOf(ctx, base); ok {
+		joined = base
+	}
+	for _, b := range append([]string{branch}, ctx.Descendants(branch)...) {
+		if err := git.CopyStackFields(b, joined); err != nil {
+			return err
+		}
+	}
+	return nil
+}
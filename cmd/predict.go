@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// predictSyncConflicts simulates the rebases sync would run for the selected branches, parents
+// first, and reports which would conflict and in which files. Each child is simulated on top
+// of its parent's simulated result, so nothing is checked out, rebased or pushed.
+func predictSyncConflicts(selected []string) error {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	isSelected := make(map[string]bool)
+	for _, branch := range selected {
+		isSelected[branch] = true
+	}
+
+	ui.Info(fmt.Sprintf("Predicting conflicts for %d branch(es). Nothing will be changed", len(selected)))
+
+	// Simulated result of each branch, which its children are rebased onto
+	predicted := make(map[string]string)
+	// Branches that conflict, or whose parent does: their children can't be predicted
+	blocked := make(map[string]bool)
+	var conflicting []string
+	for _, metadata := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		branch, parent := metadata.Name, metadata.Parent
+		if !isSelected[branch] || parent == "" {
+			continue
+		}
+		if exists, _ := git.BranchExists(branch); !exists {
+			continue
+		}
+
+		if blocked[parent] {
+			ui.Warning(fmt.Sprintf("%s: not predicted until the conflicts on %s are resolved", branch, parent))
+			blocked[branch] = true
+			continue
+		}
+
+		// Same choice of target as syncBranch: the parent as sync will have pushed it
+		parentRef, ontoName, onto := parent, git.RemoteRef(parent), git.RemoteRef(parent)
+		if result, ok := predicted[parent]; ok {
+			ontoName, onto = parent, result
+		} else if ctx.IsTracked(parent) && syncNoPush {
+			ontoName, onto = parent, parent
+		} else {
+			if !git.RemoteTrackingBranchExists(parent) {
+				ui.Warning(fmt.Sprintf("%s: %s does not exist, sync will skip it", branch, ontoName))
+				continue
+			}
+			if !ctx.IsTracked(parent) {
+				parentRef = ontoName
+			}
+		}
+		if onto == ontoName && git.BranchContainsCommit(branch, onto) {
+			ui.Success(fmt.Sprintf("%s: already up to date with %s", branch, ontoName))
+			predicted[branch] = branch
+			continue
+		}
+
+		result, conflicts, err := stack.PredictRestack(branch, parentRef, onto)
+		if err != nil {
+			return fmt.Errorf("failed to predict rebase of %s: %w", branch, err)
+		}
+		predicted[branch] = result
+
+		if len(conflicts) == 0 {
+			ui.Success(fmt.Sprintf("%s: rebases cleanly onto %s", branch, ontoName))
+			continue
+		}
+		ui.Error(fmt.Sprintf("%s: will conflict when rebased onto %s", branch, ontoName))
+		for _, file := range conflicts {
+			fmt.Printf("  - %s\n", file)
+		}
+		blocked[branch] = true
+		conflicting = append(conflicting, branch)
+	}
+
+	fmt.Println()
+	if len(conflicting) == 0 {
+		ui.Success("No conflicts expected. Run stak sync to apply")
+		return nil
+	}
+	ui.Warning(fmt.Sprintf("%d branch(es) will conflict, in this order: %s", len(conflicting), strings.Join(conflicting, ", ")))
+	fmt.Println("The prediction replays each branch in one step, so a conflict inside a branch's own")
+	fmt.Println("commits may still come up, and one reported here may resolve itself commit by commit.")
+	return nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+)
+
+var promptFormat string
+
+// defaultPromptFormat renders e.g. "stack:payments 3/5 ↻"
+const defaultPromptFormat = "stack:{stack} {position}/{total}{sync}"
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print the stack status for a shell prompt",
+	Long: `Print a compact status of the current branch's stack, e.g. "stack:payments 3/5 ↻", for
+PS1, starship or other shell prompts. It only reads stack metadata and what 'stak daemon'
+last recorded, never the network, so it is fast enough to run on every prompt.
+
+Prints nothing, and exits 0, outside a repository or on a branch that isn't in a stack.
+
+--format sets the output, with these placeholders:
+  {stack}     Stack name, or the name of its root branch if it has none
+  {branch}    Current branch
+  {position}  Position of the branch in the stack, counting from its root
+  {total}     Length of the stack: the branch's line from the root to its last descendant
+  {pr}        PR number of the branch, or nothing
+  {sync}      " ↻" if the daemon found that the stack needs syncing, or nothing
+
+For starship, add a custom module:
+  [custom.stak]
+  command = "stak prompt"
+  when = "git rev-parse --git-dir"`,
+	// Prompts run this constantly: skip the version checks and operation history of other
+	// commands, and anything that could print around the status
+	PersistentPreRun:  func(cmd *cobra.Command, args []string) { changeToRepo() },
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		if status := promptStatus(promptFormat); status != "" {
+			fmt.Println(status)
+		}
+	},
+}
+
+func init() {
+	promptCmd.Flags().StringVar(&promptFormat, "format", defaultPromptFormat, "Output format, with {stack}, {branch}, {position}, {total}, {pr} and {sync} placeholders")
+	rootCmd.AddCommand(promptCmd)
+}
+
+// promptStatus renders format for the current branch, or returns "" if it isn't in a stack
+func promptStatus(format string) string {
+	if !git.IsGitRepository() {
+		return ""
+	}
+	branch, err := git.GetCurrentBranch()
+	if err != nil {
+		return ""
+	}
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return ""
+	}
+	info, ok := stack.StackOf(ctx, branch)
+	if !ok {
+		return ""
+	}
+
+	name := info.Name
+	if name == "" {
+		name = info.Root
+	}
+	// Position and total follow the branch's own line through the stack, from the root to
+	// the end of its longest line of descendants
+	position := 1
+	for parent := ctx.Parent(branch); contains(info.Branches, parent); parent = ctx.Parent(parent) {
+		position++
+	}
+	total := position + stackHeight(ctx, branch)
+	pr := ""
+	if number := ctx.PRNumber(branch); number > 0 {
+		pr = strconv.Itoa(number)
+	}
+	sync := ""
+	if status, err := stack.ReadSyncStatus(); err == nil && status.NeedsSync() {
+		sync = " ↻"
+	}
+
+	return strings.NewReplacer(
+		"{stack}", name,
+		"{branch}", branch,
+		"{position}", strconv.Itoa(position),
+		"{total}", strconv.Itoa(total),
+		"{pr}", pr,
+		"{sync}", sync,
+	).Replace(format)
+}
+
+// stackHeight returns the number of branches in the longest line of descendants of branch
+func stackHeight(ctx *stack.StackContext, branch string) int {
+	height := 0
+	for _, child := range ctx.Children(branch) {
+		height = max(height, 1+stackHeight(ctx, child))
+	}
+	return height
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/ui"
+)
+
+// githubEnabled is cleared by commands that promise not to contact GitHub, such as restack
+// and sync --no-github, so checks that only inform don't look anything up behind their back
+var githubEnabled = true
+
+// githubProtection caches protection rules per branch for the duration of a command
+var githubProtection = make(map[string]*github.BranchProtection)
+
+// githubUnreachable is set once protection rules couldn't be read, e.g. offline or with a
+// remote that isn't on GitHub, so the other branches aren't looked up in vain
+var githubUnreachable bool
+
+// getGitHubProtection returns the protection rules of a branch on GitHub, or nil if they
+// can't be read (e.g. offline); GitHub still enforces them on push
+func getGitHubProtection(branch string) *github.BranchProtection {
+	if protection, ok := githubProtection[branch]; ok {
+		return protection
+	}
+	if githubUnreachable {
+		return nil
+	}
+	protection, err := github.GetBranchProtection(branch)
+	if err != nil {
+		protection = nil
+		githubUnreachable = true
+	}
+	githubProtection[branch] = protection
+	return protection
+}
+
+// checkForcePush is run by git.Push before every force-push
+func checkForcePush(branch string) error {
+	warnLFSForcePush(branch)
+	return checkGitHubForcePush(branch)
+}
+
+// checkGitHubForcePush refuses to force-push a branch whose GitHub protection rules don't
+// allow it, instead of letting the push fail with a generic rejection. Commands that don't
+// contact GitHub leave that to the push.
+func checkGitHubForcePush(branch string) error {
+	if !githubEnabled {
+		return nil
+	}
+	protection := getGitHubProtection(branch)
+	if protection == nil || protection.AllowsForcePushes {
+		return nil
+	}
+	return fmt.Errorf("refusing to force-push %s: it is protected on GitHub and force-pushes are not allowed: %w", branch, git.ErrProtectedBranch)
+}
+
+// checkMergeProtection compares a PR with the protection rules of the branch it merges into,
+// so merging fails with what is missing (e.g. "needs 2 approval(s), has 1") rather than
+// GitHub's generic merge error
+func checkMergeProtection(prNumber int, base string, status *github.PRStatus, method string) error {
+	protection := getGitHubProtection(base)
+	if protection == nil {
+		ui.Warning(fmt.Sprintf("Could not read the protection rules of %s", base))
+		return nil
+	}
+	if !protection.Protected {
+		return nil
+	}
+
+	var reviews *github.PRReviews
+	if protection.RequiredApprovals > 0 || protection.RequireCodeOwnerReviews || protection.RequireResolvedThreads {
+		var err error
+		reviews, err = github.GetPRReviews(prNumber)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Could not check reviews of PR #%d: %v", prNumber, err))
+		}
+	}
+
+	blockers := protection.MergeBlockers(status, reviews, method)
+	if len(blockers) == 0 {
+		return nil
+	}
+	ui.Error(fmt.Sprintf("PR #%d can't be merged into %s yet:", prNumber, base))
+	for _, blocker := range blockers {
+		fmt.Printf("  - %s\n", blocker)
+	}
+	return fmt.Errorf("PR #%d is blocked by the protection rules of %s", prNumber, base)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	pushUpstack   bool
+	pushDownstack bool
+	pushForce     bool
+	pushStack     string
+)
+
+var pushCmd = &cobra.Command{
+	Use:     "push",
+	Aliases: []string{"ps"},
+	Short:   "Push every branch in the stack without rebasing",
+	Long: `Force-push (with lease) every branch in the current stack, parents first, setting upstreams
+for branches that have never been pushed. Nothing is rebased, fetched or changed on GitHub,
+so use it when branches are already in the right shape, e.g. after stak restack.
+
+With --upstack, only the current branch and its descendants are pushed.
+With --downstack, only the current branch and its ancestors are pushed.
+With --stack, every branch of the named stack is pushed instead, e.g. --stack payments.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPush(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	pushCmd.Flags().BoolVar(&pushUpstack, "upstack", false, "Only push the current branch and its descendants")
+	pushCmd.Flags().BoolVar(&pushDownstack, "downstack", false, "Only push the current branch and its ancestors")
+	pushCmd.Flags().BoolVar(&pushForce, "force", false, "Push even if branches are owned by someone else")
+	pushCmd.Flags().StringVar(&pushStack, "stack", "", "Push the stack with this name, or containing this branch, instead of the current one")
+	pushCmd.RegisterFlagCompletionFunc("stack", completeStackNames)
+	rootCmd.AddCommand(pushCmd)
+}
+
+func runPush() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	if pushUpstack && pushDownstack {
+		return fmt.Errorf("--upstack and --downstack cannot be used together")
+	}
+
+	var ctx *stack.StackContext
+	var candidates []string
+	if pushStack != "" {
+		if pushUpstack || pushDownstack {
+			return fmt.Errorf("--stack cannot be used with --upstack or --downstack")
+		}
+		var info stack.StackInfo
+		var err error
+		if ctx, info, err = findStackFlag(pushStack); err != nil {
+			return err
+		}
+		candidates = info.Branches
+	} else {
+		currentBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+
+		ctx, err = stack.LoadContext()
+		if err != nil {
+			return fmt.Errorf("failed to load stack: %w", err)
+		}
+		if !ctx.IsTracked(currentBranch) {
+			return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+		}
+
+		if !pushUpstack {
+			candidates = append(candidates, ctx.Ancestors(currentBranch)...)
+		}
+		candidates = append(candidates, currentBranch)
+		if !pushDownstack {
+			candidates = append(candidates, ctx.Descendants(currentBranch)...)
+		}
+	}
+
+	// Ancestors include the trunk, which is never pushed from here
+	var branches []string
+	for _, branch := range candidates {
+		if ctx.IsTracked(branch) {
+			branches = append(branches, branch)
+		}
+	}
+
+	if err := checkStackOwnership("force-push", branches, pushForce); err != nil {
+		return err
+	}
+
+	ui.Info(fmt.Sprintf("Pushing %d branch(es)", len(branches)))
+	pushed := 0
+	for _, branch := range branches {
+		// Without a remote-tracking branch it has never been pushed, so set its upstream
+		isNew := !git.RemoteTrackingBranchExists(branch)
+		if !isNew {
+			local, _ := git.GetCommitSHA(branch)
+			remote, _ := git.GetCommitSHA(git.RemoteRef(branch))
+			if local != "" && local == remote {
+				ui.Info(fmt.Sprintf("%s is up to date", branch))
+				continue
+			}
+		}
+
+		ui.Info(fmt.Sprintf("Pushing %s", branch))
+		if err := git.Push(branch, isNew, true); err != nil {
+			return err
+		}
+		pushed++
+	}
+
+	ui.Success(fmt.Sprintf("Pushed %d branch(es)", pushed))
+	return nil
+}
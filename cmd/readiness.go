@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// prReadiness is one row of the readiness matrix printed before merging a stack
+type prReadiness struct {
+	branch    string
+	prNumber  int
+	approved  string
+	checks    string
+	mergeable string
+	behind    string
+	blockers  []string
+}
+
+// checkMergeReadiness prints whether each PR of a chain is approved, has green checks, is
+// mergeable and is behind its base, and refuses to start merging if any PR is blocked.
+// Merging stops at the first blocked PR anyway; checking up front avoids landing half a stack.
+func checkMergeReadiness(branches []string, methods map[string]string) error {
+	ui.Info("Checking that every PR is ready to merge")
+
+	// Every PR ends up merged into the base of the bottom branch, so its rules apply to all
+	base, _ := stack.GetParent(branches[0])
+	protection := getGitHubProtection(base)
+
+	rows := make([]prReadiness, 0, len(branches))
+	blocked := 0
+	for _, branch := range branches {
+		row := prReadinessFor(branch, protection, methods[branch])
+		if len(row.blockers) > 0 {
+			blocked++
+		}
+		rows = append(rows, row)
+	}
+
+	fmt.Printf("\n  %-30s %-7s %-9s %-8s %-10s %s\n", "Branch", "PR", "Approved", "Checks", "Mergeable", "Behind base")
+	for _, row := range rows {
+		pr := "-"
+		if row.prNumber > 0 {
+			pr = fmt.Sprintf("#%d", row.prNumber)
+		}
+		fmt.Printf("  %-30s %-7s %-9s %-8s %-10s %s\n", row.branch, pr, row.approved, row.checks, row.mergeable, row.behind)
+	}
+	fmt.Println()
+
+	if blocked == 0 {
+		return nil
+	}
+	for _, row := range rows {
+		if len(row.blockers) > 0 {
+			ui.Error(fmt.Sprintf("%s: %s", row.branch, strings.Join(row.blockers, "; ")))
+		}
+	}
+	if mergeSkipChecks {
+		ui.Warning("Merging anyway (--skip-checks)")
+		return nil
+	}
+	return fmt.Errorf("%d PR(s) not ready to merge. Nothing was merged; use --skip-checks to merge anyway", blocked)
+}
+
+// prReadinessFor checks one branch's PR against what mergeBranch and the base's
+// protection rules require
+func prReadinessFor(branch string, protection *github.BranchProtection, method string) prReadiness {
+	row := prReadiness{branch: branch, approved: "-", checks: "-", mergeable: "-", behind: "-"}
+
+	metadata, err := stack.ReadBranchMetadata(branch)
+	if err != nil || metadata.PRNumber == 0 {
+		row.blockers = append(row.blockers, "no PR")
+		return row
+	}
+	row.prNumber = metadata.PRNumber
+
+	if behind, err := getCommitCount(git.RemoteRef(metadata.Parent), branch); err == nil {
+		row.behind = fmt.Sprintf("%d", behind)
+	}
+
+	status, err := github.GetPRStatus(metadata.PRNumber)
+	if err != nil {
+		row.blockers = append(row.blockers, err.Error())
+		return row
+	}
+	if status.IsMerged() {
+		row.approved = "merged"
+		return row
+	}
+	if !status.IsOpen() {
+		row.blockers = append(row.blockers, fmt.Sprintf("PR is %s", strings.ToLower(status.State)))
+		return row
+	}
+
+	var reviews *github.PRReviews
+	if protection != nil && protection.RequiredApprovals > 0 {
+		if reviews, err = github.GetPRReviews(metadata.PRNumber); err == nil {
+			row.approved = fmt.Sprintf("%d/%d", len(reviews.Approvers), protection.RequiredApprovals)
+		}
+	}
+	if status.IsApproved() {
+		row.approved = "yes"
+	} else {
+		if row.approved == "-" {
+			row.approved = "no"
+		}
+		// A missing approval count is reported by the protection rules below
+		if reviews == nil || len(reviews.Approvers) >= protection.RequiredApprovals {
+			row.blockers = append(row.blockers, "not approved")
+		}
+	}
+
+	switch {
+	case status.IsCIPending():
+		row.checks = "running"
+		if !mergeWaitChecks {
+			row.blockers = append(row.blockers, "checks still running (use --wait-checks)")
+		}
+	case status.IsCIPassing():
+		row.checks = "passing"
+	default:
+		row.checks = "failing"
+		row.blockers = append(row.blockers, "checks failing")
+	}
+
+	switch status.Mergeable {
+	case "MERGEABLE":
+		row.mergeable = "yes"
+	case "CONFLICTING":
+		row.mergeable = "conflicts"
+		row.blockers = append(row.blockers, fmt.Sprintf("conflicts with %s", metadata.Parent))
+	default:
+		row.mergeable = "unknown"
+	}
+
+	if protection != nil && protection.Protected {
+		row.blockers = append(row.blockers, protection.MergeBlockers(status, reviews, method)...)
+	}
+	return row
+}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	reconcileAll   bool
+	reconcileStack string
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:     "reconcile",
+	Aliases: []string{"rc"},
+	Short:   "Find and fix differences between the stack metadata and GitHub",
+	Long: `Compare what stak records for each branch of the current stack (its parent and PR
+number) with its PR on GitHub, and fix what no longer matches by updating whichever side
+you choose:
+
+  - PR base changed on GitHub: move the branch onto the new base, or retarget the PR
+    back to its parent
+  - PR re-created: record the new PR, or close it and keep the recorded one
+  - PR opened outside stak: record it
+  - Branch renamed: rename the local branch back to the PR's, or close the PR so the next
+    submit opens one for the new name
+
+PRs that were merged or closed are left to stak sync. With --all, every tracked branch is
+checked; with --stack, the named stack. Without prompts, the differences are only listed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runReconcile(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().BoolVar(&reconcileAll, "all", false, "Check every tracked branch, not just the current stack")
+	reconcileCmd.Flags().StringVar(&reconcileStack, "stack", "", "Check the stack with this name, or containing this branch, instead of the current one")
+	reconcileCmd.RegisterFlagCompletionFunc("stack", completeStackNames)
+	rootCmd.AddCommand(reconcileCmd)
+}
+
+// reconcileFix is one way of resolving a difference, updating one side to match the other
+type reconcileFix struct {
+	label string
+	apply func() error
+}
+
+func runReconcile() error {
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	branches, err := reconcileBranches()
+	if err != nil {
+		return err
+	}
+
+	// One query finds the open PR of every branch, however it was opened
+	openPRs, err := github.ListOpenPRs("")
+	if err != nil {
+		return err
+	}
+	openByHead := make(map[string]github.PRSummary)
+	for _, pr := range openPRs {
+		if !pr.IsCrossRepository {
+			openByHead[pr.HeadRefName] = pr
+		}
+	}
+
+	interactive := requireInteractive("how to reconcile") == nil
+	found, fixed := 0, 0
+	for _, branch := range branches {
+		description, fixes, err := reconcileDrift(branch, openByHead)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Could not check %s: %v", branch, err))
+			continue
+		}
+		if description == "" {
+			continue
+		}
+		found++
+		ui.Warning(description)
+		if !interactive || len(fixes) == 0 {
+			continue
+		}
+
+		items := make([]string, 0, len(fixes)+1)
+		for _, fix := range fixes {
+			items = append(items, fix.label)
+		}
+		prompt := promptui.Select{
+			Label: fmt.Sprintf("Reconcile %s?", branch),
+			Items: append(items, "Leave it as it is"),
+		}
+		index, _, err := runSelect(&prompt)
+		if err != nil || index >= len(fixes) {
+			continue
+		}
+		if err := fixes[index].apply(); err != nil {
+			return err
+		}
+		fixed++
+	}
+
+	switch {
+	case found == 0:
+		ui.Success("The stack matches GitHub")
+	case !interactive:
+		ui.Info(fmt.Sprintf("Found %d difference(s). Run 'stak reconcile' with prompts enabled to choose how to fix them", found))
+	default:
+		ui.Success(fmt.Sprintf("Reconciled %d of %d difference(s)", fixed, found))
+	}
+	return nil
+}
+
+// reconcileBranches returns the branches to check, parents first
+func reconcileBranches() ([]string, error) {
+	if reconcileStack != "" {
+		_, info, err := findStackFlag(reconcileStack)
+		if err != nil {
+			return nil, err
+		}
+		return info.Branches, nil
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+	if reconcileAll {
+		var branches []string
+		for _, branch := range stack.GetAllBranchesInOrder(ctx.Stack) {
+			branches = append(branches, branch.Name)
+		}
+		return branches, nil
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if !ctx.IsTracked(currentBranch) {
+		return nil, fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+	var branches []string
+	for _, branch := range ctx.FullStack(currentBranch) {
+		if ctx.IsTracked(branch) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// reconcileDrift compares a branch's metadata with its PR on GitHub. It returns what differs,
+// or "" if nothing does, and the ways to fix it. Metadata is read afresh, as fixing a branch
+// can change its children.
+func reconcileDrift(branch string, openByHead map[string]github.PRSummary) (string, []reconcileFix, error) {
+	metadata, err := stack.ReadBranchMetadata(branch)
+	if err != nil {
+		return "", nil, err
+	}
+	parent, prNumber := metadata.Parent, metadata.PRNumber
+	open, hasOpen := openByHead[branch]
+
+	if prNumber == 0 {
+		if !hasOpen {
+			return "", nil, nil
+		}
+		return fmt.Sprintf("%s has PR #%d on GitHub, which stak doesn't know about", branch, open.Number),
+			[]reconcileFix{recordPRFix(branch, open.Number)}, nil
+	}
+
+	details, err := github.GetPRDetails(prNumber)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// A new PR was opened for the branch, e.g. after the recorded one was closed
+	if hasOpen && open.Number != prNumber {
+		fixes := []reconcileFix{recordPRFix(branch, open.Number)}
+		if details.State == "OPEN" && details.HeadRefName == branch {
+			fixes = append(fixes, reconcileFix{
+				label: fmt.Sprintf("Close PR #%d and keep PR #%d (GitHub)", open.Number, prNumber),
+				apply: func() error {
+					if err := github.ClosePRWithComment(open.Number, fmt.Sprintf("Closing in favor of #%d.", prNumber)); err != nil {
+						return err
+					}
+					ui.Success(fmt.Sprintf("Closed PR #%d", open.Number))
+					return nil
+				},
+			})
+		}
+		return fmt.Sprintf("%s is recorded with PR #%d (%s), but its open PR on GitHub is #%d",
+			branch, prNumber, details.State, open.Number), fixes, nil
+	}
+
+	// GitHub can't rename a PR's branch, so a branch renamed locally has lost its PR
+	if details.HeadRefName != branch {
+		var fixes []reconcileFix
+		if exists, err := git.BranchExists(details.HeadRefName); err == nil && !exists {
+			fixes = append(fixes, renameBranchFix(branch, details.HeadRefName))
+		}
+		if details.State == "OPEN" {
+			fixes = append(fixes, reconcileFix{
+				label: fmt.Sprintf("Close PR #%d, so the next submit opens one for %s (GitHub)", prNumber, branch),
+				apply: func() error {
+					if err := github.ClosePRWithComment(prNumber, fmt.Sprintf("The branch was renamed to %s; it gets a new PR.", branch)); err != nil {
+						return err
+					}
+					if err := git.ClearBranchPRNumber(branch); err != nil {
+						return err
+					}
+					ui.Success(fmt.Sprintf("Closed PR #%d; 'stak submit' opens a new PR for %s", prNumber, branch))
+					return nil
+				},
+			})
+		} else {
+			fixes = append(fixes, reconcileFix{
+				label: fmt.Sprintf("Forget PR #%d, so the next submit opens one for %s (local)", prNumber, branch),
+				apply: func() error {
+					if err := git.ClearBranchPRNumber(branch); err != nil {
+						return err
+					}
+					ui.Success(fmt.Sprintf("Forgot PR #%d", prNumber))
+					return nil
+				},
+			})
+		}
+		return fmt.Sprintf("PR #%d of %s is for branch %s: the branch was renamed", prNumber, branch, details.HeadRefName), fixes, nil
+	}
+
+	// Merged and closed PRs are what sync cleans up
+	if details.State != "OPEN" || details.BaseRefName == parent {
+		return "", nil, nil
+	}
+
+	fixes := []reconcileFix{{
+		label: fmt.Sprintf("Retarget PR #%d to %s (GitHub)", prNumber, parent),
+		apply: func() error {
+			if err := github.UpdatePRBase(prNumber, parent); err != nil {
+				return err
+			}
+			ui.Success(fmt.Sprintf("Retargeted PR #%d to %s", prNumber, parent))
+			return nil
+		},
+	}}
+	if fix, ok := followPRBaseFix(branch, details.BaseRefName); ok {
+		fixes = append([]reconcileFix{fix}, fixes...)
+	}
+	return fmt.Sprintf("PR #%d of %s is based on %s on GitHub, but its parent is %s", prNumber, branch, details.BaseRefName, parent), fixes, nil
+}
+
+// recordPRFix records prNumber as the PR of branch
+func recordPRFix(branch string, prNumber int) reconcileFix {
+	return reconcileFix{
+		label: fmt.Sprintf("Record PR #%d for %s (local)", prNumber, branch),
+		apply: func() error {
+			if err := git.SetBranchPRNumber(branch, prNumber); err != nil {
+				return err
+			}
+			ui.Success(fmt.Sprintf("Recorded PR #%d for %s", prNumber, branch))
+			return nil
+		},
+	}
+}
+
+// renameBranchFix renames branch back to the name its PR has on GitHub
+func renameBranchFix(branch, name string) reconcileFix {
+	return reconcileFix{
+		label: fmt.Sprintf("Rename %s back to %s (local)", branch, name),
+		apply: func() error {
+			if err := git.RenameBranch(branch, name); err != nil {
+				return err
+			}
+			if err := git.RenameBranchMetadata(branch, name); err != nil {
+				return fmt.Errorf("failed to update metadata: %w", err)
+			}
+			ui.Success(fmt.Sprintf("Renamed %s to %s", branch, name))
+			return nil
+		},
+	}
+}
+
+// followPRBaseFix moves branch and its descendants onto base, the base its PR was given on
+// GitHub, if branch can be stacked on it
+func followPRBaseFix(branch, base string) (reconcileFix, bool) {
+	if !canStackOn(branch, base) {
+		return reconcileFix{}, false
+	}
+	return reconcileFix{
+		label: fmt.Sprintf("Move %s onto %s, like its PR (local)", branch, base),
+		apply: func() error {
+			descendants, err := stack.GetDescendants(branch)
+			if err != nil {
+				return fmt.Errorf("failed to get descendants: %w", err)
+			}
+			if err := moveSubtree(branch, base, descendants); err != nil {
+				return err
+			}
+			ui.Success(fmt.Sprintf("Moved %s onto %s", branch, base))
+			return nil
+		},
+	}, true
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	releaseNotesTitle     string
+	releaseNotesGroup     bool
+	releaseNotesBodies    bool
+	releaseNotesChangelog string
+	releaseNotesRelease   string
+)
+
+var releaseNotesCmd = &cobra.Command{
+	Use:     "release-notes [<pr>...]",
+	Aliases: []string{"rn"},
+	Short:   "Generate release notes from the merged PRs of a stack",
+	Long: `Generate release notes from the titles (and with --bodies, the descriptions) of a stack's
+merged PRs, bottom to top, and print them as Markdown.
+
+Without arguments, uses the PRs of the current stack, which must all be merged. Once the
+stack is cleaned up (stak merge and stak sync remove merged branches), pass the PR numbers
+instead; stak merge --all prints them when it is done.
+
+With --changelog, the notes are also added to CHANGELOG.md (or the given file), above earlier
+releases. With --github-release <tag>, they are posted as a draft GitHub release.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runReleaseNotes(args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	releaseNotesCmd.Flags().StringVar(&releaseNotesTitle, "title", "", "Heading of the release notes (default: the release tag, or \"Unreleased\")")
+	releaseNotesCmd.Flags().BoolVar(&releaseNotesGroup, "group", false, "Group entries by conventional-commit type")
+	releaseNotesCmd.Flags().BoolVar(&releaseNotesBodies, "bodies", false, "Include each PR's description below its title")
+	releaseNotesCmd.Flags().StringVar(&releaseNotesChangelog, "changelog", "", "Also add the notes to this changelog file")
+	releaseNotesCmd.Flags().Lookup("changelog").NoOptDefVal = "CHANGELOG.md"
+	releaseNotesCmd.Flags().StringVar(&releaseNotesRelease, "github-release", "", "Also create a draft GitHub release for this tag")
+	rootCmd.AddCommand(releaseNotesCmd)
+}
+
+func runReleaseNotes(args []string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	// Check if gh CLI is authenticated
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	var prNumbers []int
+	for _, arg := range args {
+		prNumber, ok := github.ParsePRReference(arg)
+		if !ok {
+			return fmt.Errorf("invalid PR reference %q", arg)
+		}
+		prNumbers = append(prNumbers, prNumber)
+	}
+	if len(prNumbers) == 0 {
+		stackPRs, err := currentStackPRNumbers()
+		if err != nil {
+			return err
+		}
+		prNumbers = stackPRs
+	}
+
+	var entries []changelogEntry
+	var unmerged []string
+	for _, prNumber := range prNumbers {
+		content, err := github.GetPRContent(prNumber)
+		if err != nil {
+			return err
+		}
+		if content.State != "MERGED" {
+			unmerged = append(unmerged, fmt.Sprintf("#%d (%s)", prNumber, strings.ToLower(content.State)))
+			continue
+		}
+		entry := changelogEntry{text: content.Title, prNumber: prNumber}
+		if releaseNotesBodies {
+			entry.body = content.Body
+		}
+		entries = append(entries, entry)
+	}
+	if len(unmerged) > 0 {
+		return fmt.Errorf("the stack has not fully landed: %s not merged", strings.Join(unmerged, ", "))
+	}
+
+	title := releaseNotesTitle
+	if title == "" {
+		title = releaseNotesRelease
+	}
+	if title == "" {
+		title = "Unreleased"
+	}
+	notes := formatChangelog(title, entries, releaseNotesGroup)
+	fmt.Print(notes)
+
+	if releaseNotesChangelog != "" {
+		if err := addToChangelog(releaseNotesChangelog, notes); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Added release notes to %s", releaseNotesChangelog))
+	}
+
+	if releaseNotesRelease != "" {
+		// The release has its own title, so drop the section heading from the body
+		body := strings.TrimPrefix(notes, fmt.Sprintf("## %s\n\n", title))
+		url, err := github.CreateDraftRelease(releaseNotesRelease, title, body)
+		if err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Created draft release %s: %s", releaseNotesRelease, url))
+	}
+	return nil
+}
+
+// currentStackPRNumbers returns the PR numbers of the current stack, bottom to top, or of
+// every stack based on the current branch if it is untracked
+func currentStackPRNumbers() ([]int, error) {
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	var roots []string
+	if ctx.IsTracked(currentBranch) {
+		roots = []string{stackRoot(ctx, currentBranch)}
+	} else {
+		roots = stacksBasedOn(ctx, currentBranch)
+	}
+
+	var prNumbers []int
+	for _, root := range roots {
+		for _, branch := range append([]string{root}, ctx.Descendants(root)...) {
+			if prNumber := ctx.PRNumber(branch); prNumber > 0 {
+				prNumbers = append(prNumbers, prNumber)
+			}
+		}
+	}
+	if len(prNumbers) == 0 {
+		return nil, fmt.Errorf("no PRs found in the stack. Pass the merged PR numbers instead: stak release-notes <pr>...")
+	}
+	return prNumbers, nil
+}
+
+// addToChangelog inserts a section above the first release ("## ...") of a changelog file,
+// or at its end if it has none. A missing file is created with a "# Changelog" heading.
+func addToChangelog(path, section string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	content := string(data)
+	if content == "" {
+		content = "# Changelog\n\n" + section
+	} else if i := strings.Index("\n"+content, "\n## "); i >= 0 {
+		content = content[:i] + section + "\n" + content[i:]
+	} else {
+		content = strings.TrimRight(content, "\n") + "\n\n" + section
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
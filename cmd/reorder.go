@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
@@ -13,6 +12,8 @@ import (
 	"stacking/internal/ui"
 )
 
+var reorderForce bool
+
 var reorderCmd = &cobra.Command{
 	Use:     "reorder",
 	Aliases: []string{"ro"},
@@ -20,20 +21,20 @@ var reorderCmd = &cobra.Command{
 	Long:    `Interactively reorder the branches in a stack by changing their parent relationships.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runReorder(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
+	reorderCmd.Flags().BoolVar(&reorderForce, "force", false, "Reorder even if branches are owned by someone else")
 	rootCmd.AddCommand(reorderCmd)
 }
 
 func runReorder() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -71,6 +72,10 @@ func runReorder() error {
 		return fmt.Errorf("stack has only %d branch(es), nothing to reorder", len(stackBranches))
 	}
 
+	if err := checkStackOwnership("reorder", stackBranches, reorderForce); err != nil {
+		return err
+	}
+
 	// Display current order
 	ui.Info("Current stack order:")
 	for i, branch := range stackBranches {
@@ -83,6 +88,9 @@ func runReorder() error {
 	}
 
 	// Prompt for new order
+	if err := requireInteractive("new stack order"); err != nil {
+		return err
+	}
 	ui.Info("")
 	ui.Info("Enter new order as comma-separated numbers (e.g., 1,3,2,4)")
 	ui.Info("Press Ctrl+C to cancel")
@@ -141,13 +149,19 @@ func runReorder() error {
 		fmt.Printf("  %d. %s (parent: %s)\n", i+1, branch, newParent)
 	}
 
+	// Warn about layers that change the same files before anything is rebased
+	label := "Apply this reorder?"
+	if checkReorderOverlap(stackBranches, newStackBranches) > 0 {
+		label = "Apply this reorder anyway?"
+	}
+
 	// Confirm reorder
 	prompt := promptui.Select{
-		Label: "Apply this reorder?",
+		Label: label,
 		Items: []string{"Yes", "No"},
 	}
 
-	_, result, err := prompt.Run()
+	_, result, err := runSelect(&prompt)
 	if err != nil || result == "No" {
 		ui.Info("Reorder cancelled")
 		return nil
@@ -188,13 +202,16 @@ func runReorder() error {
 			if err := git.RebaseOnto(newParent); err != nil {
 				ui.Error(fmt.Sprintf("Failed to rebase %s onto %s", branch, newParent))
 				ui.Info("You may need to resolve conflicts manually")
-				return fmt.Errorf("rebase failed")
+				return fmt.Errorf("rebase failed: %w", err)
 			}
 
 			// Update metadata
 			if err := stack.WriteBranchMetadata(branch, newParent, metadata.PRNumber); err != nil {
 				return fmt.Errorf("failed to update metadata: %w", err)
 			}
+			if err := stack.RecordParentSHA(branch, newParent); err != nil {
+				ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+			}
 
 			// Force push
 			if err := git.Push(branch, false, true); err != nil {
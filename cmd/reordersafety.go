@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// reorderOverlap is a pair of branches a reorder swaps, in their current order, with the
+// files both of them change
+type reorderOverlap struct {
+	lower, upper string
+	files        []string
+	touching     map[string]bool
+}
+
+// checkReorderOverlap compares every pair of branches whose relative order the reorder swaps
+// and warns about the files both change. Layers that change the same lines will likely
+// conflict; layers that change different parts of a file can still change what the code does,
+// since each was written on top of the other. It returns the number of overlapping pairs.
+func checkReorderOverlap(oldOrder, newOrder []string) int {
+	position := make(map[string]int)
+	for i, branch := range newOrder {
+		position[branch] = i
+	}
+
+	// Each layer's own changes, against its current parent
+	layers := make(map[string]map[string][]git.Hunk)
+	layer := func(branch string) map[string][]git.Hunk {
+		if hunks, ok := layers[branch]; ok {
+			return hunks
+		}
+		var hunks map[string][]git.Hunk
+		if parent, err := stack.GetParent(branch); err == nil && parent != "" {
+			if hunks, err = git.GetChangedHunks(parent, branch); err != nil {
+				ui.Warning(fmt.Sprintf("Could not diff %s: %v", branch, err))
+			}
+		}
+		layers[branch] = hunks
+		return hunks
+	}
+
+	var overlaps []reorderOverlap
+	for i, lower := range oldOrder {
+		for _, upper := range oldOrder[i+1:] {
+			if position[lower] < position[upper] {
+				continue
+			}
+			lowerHunks, upperHunks := layer(lower), layer(upper)
+			overlap := reorderOverlap{lower: lower, upper: upper, touching: make(map[string]bool)}
+			for file, hunks := range upperHunks {
+				if lowerFileHunks, ok := lowerHunks[file]; ok {
+					overlap.files = append(overlap.files, file)
+					overlap.touching[file] = hunksTouch(lowerFileHunks, hunks)
+				}
+			}
+			if len(overlap.files) > 0 {
+				sort.Strings(overlap.files)
+				overlaps = append(overlaps, overlap)
+			}
+		}
+	}
+
+	ui.Info("")
+	if len(overlaps) == 0 {
+		ui.Success("The swapped branches change different files")
+		return 0
+	}
+
+	ui.Warning(fmt.Sprintf("This reorder swaps %d pair(s) of branches that change the same files:", len(overlaps)))
+	for _, overlap := range overlaps {
+		fmt.Printf("  %s ↔ %s\n", overlap.lower, overlap.upper)
+		for _, file := range overlap.files {
+			if overlap.touching[file] {
+				fmt.Printf("    - %s (same lines changed, likely to conflict)\n", file)
+			} else {
+				fmt.Printf("    - %s (different lines, may still change behavior)\n", file)
+			}
+		}
+	}
+	ui.Warning("Each branch was written on top of the other's version of these files. Review the result even if it rebases cleanly")
+	return len(overlaps)
+}
+
+// hunksTouch checks if the lines a lower layer changed overlap or adjoin the lines an upper
+// layer changed. The upper layer's diff is against a version that includes the lower layer,
+// so its old line numbers line up with the lower layer's new ones (exactly, when the layers
+// are adjacent). Rebase needs an unchanged line between changes, so adjoining ranges count.
+func hunksTouch(lower, upper []git.Hunk) bool {
+	for _, l := range lower {
+		lowerStart, lowerEnd := l.NewStart, l.NewStart+max(l.NewLines, 1)
+		for _, u := range upper {
+			upperStart, upperEnd := u.OldStart, u.OldStart+max(u.OldLines, 1)
+			if lowerStart <= upperEnd && upperStart <= lowerEnd {
+				return true
+			}
+		}
+	}
+	return false
+}
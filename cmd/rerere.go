@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/ui"
+)
+
+var rerereTrainLimit int
+
+var rerereCmd = &cobra.Command{
+	Use:     "rerere",
+	Aliases: []string{"rr"},
+	Short:   "Manage git rerere, which replays conflict resolutions",
+	Long: `Show whether git rerere ("reuse recorded resolution") is enabled for this repository.
+
+When a parent is rewritten, each of its children often hits the same conflict while being
+restacked. With rerere enabled, git records how the first one was resolved and stak applies
+it to the rest, continuing the rebase by itself whenever every conflict was resolved.
+
+Use 'stak rerere enable' to turn it on and 'stak rerere train' to learn from earlier merges.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRerereStatus(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var rerereEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable git rerere for this repository",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRerereEnable(true); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var rerereDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable git rerere for this repository",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRerereEnable(false); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var rerereTrainCmd = &cobra.Command{
+	Use:   "train [<revision>...]",
+	Short: "Record conflict resolutions from earlier merge commits",
+	Long: `Replay the merge commits reachable from the given revisions (default: HEAD) and record
+how their conflicts were resolved, so rerere can apply them when the same conflict comes up
+again. Merges are replayed on a detached HEAD; the current branch is checked out afterwards.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRerereTrain(args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rerereTrainCmd.Flags().IntVar(&rerereTrainLimit, "limit", 100, "Maximum number of merge commits to replay")
+	rerereCmd.AddCommand(rerereEnableCmd)
+	rerereCmd.AddCommand(rerereDisableCmd)
+	rerereCmd.AddCommand(rerereTrainCmd)
+	rootCmd.AddCommand(rerereCmd)
+}
+
+func runRerereStatus() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	count, err := git.CountRerereResolutions()
+	if err != nil {
+		return err
+	}
+
+	if git.IsRerereEnabled() {
+		ui.Success(fmt.Sprintf("rerere is enabled, with %d recorded resolution(s)", count))
+		return nil
+	}
+	ui.Info(fmt.Sprintf("rerere is disabled (%d recorded resolution(s))", count))
+	fmt.Println("\nEnable it with: stak rerere enable")
+	return nil
+}
+
+func runRerereEnable(enable bool) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	if !enable {
+		if err := git.DisableRerere(); err != nil {
+			return err
+		}
+		ui.Success("Disabled rerere. Recorded resolutions are kept")
+		return nil
+	}
+
+	if err := git.EnableRerere(); err != nil {
+		return err
+	}
+	ui.Success("Enabled rerere. Conflicts you resolve are recorded and replayed when they come up again")
+	return nil
+}
+
+func runRerereTrain(revs []string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	if rerereTrainLimit <= 0 {
+		return fmt.Errorf("--limit must be positive")
+	}
+
+	hasChanges, err := git.HasUncommittedChanges()
+	if err != nil {
+		return err
+	}
+	if hasChanges {
+		return git.ErrDirtyTree
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	if len(revs) == 0 {
+		revs = []string{"HEAD"}
+	}
+
+	ui.Info(fmt.Sprintf("Replaying up to %d merge commit(s)", rerereTrainLimit))
+	recorded, trainErr := git.TrainRerere(revs, rerereTrainLimit)
+	if err := git.CheckoutBranch(currentBranch); err != nil {
+		return fmt.Errorf("failed to return to %s: %w", currentBranch, err)
+	}
+	if trainErr != nil {
+		return trainErr
+	}
+
+	ui.Success(fmt.Sprintf("Recorded resolutions from %d conflicted merge(s)", recorded))
+	if !git.IsRerereEnabled() {
+		fmt.Println("\nThey are only used once rerere is enabled: stak rerere enable")
+	}
+	return nil
+}
@@ -0,0 +1,418 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/restack"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	restackUpstack   bool
+	restackContinue  bool
+	restackMatch     []string
+	restackMergetool bool
+	restackStack     string
+)
+
+var restackCmd = &cobra.Command{
+	Use:     "restack",
+	Aliases: []string{"r"},
+	Short:   "Rebase the current stack onto its local parents",
+	Long: `Rebase every branch in the current stack onto its local parent, parents first.
+
+This is the local-only core of sync: nothing is fetched or pushed and GitHub is never
+contacted, so it works offline and is safe to run before deciding what to push.
+Run it from trunk to restack every stack that starts there.
+
+With --match, restacks the tracked branches matching the glob patterns instead, across all
+stacks, e.g. --match 'feat/payment-*'. With --stack, restacks the named stack instead of the
+current one, without leaving the current branch.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRestack(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	restackCmd.Flags().BoolVar(&restackUpstack, "upstack", false, "Only restack the current branch and its descendants")
+	restackCmd.Flags().BoolVar(&restackContinue, "continue", false, "Continue after resolving conflicts")
+	restackCmd.Flags().BoolVar(&restackMergetool, "mergetool", false, "Resolve rebase conflicts with git mergetool, then continue automatically")
+	restackCmd.Flags().StringSliceVar(&restackMatch, "match", nil, "Only restack branches matching these glob patterns (repeatable)")
+	restackCmd.Flags().StringVar(&restackStack, "stack", "", "Restack the stack with this name, or containing this branch, instead of the current one")
+	restackCmd.RegisterFlagCompletionFunc("stack", completeStackNames)
+	rootCmd.AddCommand(restackCmd)
+}
+
+func runRestack() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+	githubEnabled = false
+
+	if err := validatePatterns(restackMatch); err != nil {
+		return err
+	}
+
+	if restackContinue {
+		if err := continueRestackRebase(); err != nil {
+			return err
+		}
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	if len(restackMatch) > 0 {
+		return restackMatching(ctx, currentBranch)
+	}
+
+	// Restack from the bottom of the stack, or from each stack based on an untracked branch
+	var starts []string
+	switch {
+	case restackStack != "":
+		info, err := stack.FindStack(ctx, restackStack)
+		if err != nil {
+			return err
+		}
+		starts = []string{info.Root}
+	case !ctx.IsTracked(currentBranch):
+		starts = stacksBasedOn(ctx, currentBranch)
+	case restackUpstack:
+		starts = []string{currentBranch}
+	default:
+		starts = []string{stackRoot(ctx, currentBranch)}
+	}
+	if len(starts) == 0 {
+		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+
+	var branches []string
+	for _, start := range starts {
+		branches = append(branches, start)
+		branches = append(branches, ctx.Descendants(start)...)
+	}
+	if err := deepenShallowClone(branches); err != nil {
+		return err
+	}
+
+	restacked := make(map[string]bool)
+	for i := 0; i < len(starts); {
+		result, err := restack.Stack(starts[i], restack.Options{})
+		if result != nil {
+			for _, branch := range result.Restacked {
+				if !restacked[branch] {
+					ui.Success(fmt.Sprintf("Restacked %s onto %s", branch, ctx.Parent(branch)))
+					restacked[branch] = true
+				}
+			}
+		}
+		if err != nil {
+			resolved, rerr := resolveRestackConflict(err, currentBranch)
+			if rerr != nil {
+				return rerr
+			}
+			if !resolved {
+				return restackError(err)
+			}
+			// Go again: branches already restacked are no-ops
+			continue
+		}
+		i++
+	}
+
+	ui.Success(fmt.Sprintf("Restacked %d branch(es) locally. Nothing was pushed", len(restacked)))
+	return nil
+}
+
+// restackMatching restacks every tracked branch matching --match onto its parent, parents first
+func restackMatching(ctx *stack.StackContext, original string) error {
+	restacked := 0
+	for _, branch := range stack.GetAllBranchesInOrder(ctx.Stack) {
+		if branch.Parent == "" || !matchesAny(branch.Name, restackMatch) {
+			continue
+		}
+		if err := restack.Branch(branch.Name, branch.Parent, restack.Options{}); err != nil {
+			resolved, rerr := resolveRestackConflict(err, original)
+			if rerr != nil {
+				return rerr
+			}
+			if !resolved {
+				return restackError(err)
+			}
+		}
+		ui.Success(fmt.Sprintf("Restacked %s onto %s", branch.Name, branch.Parent))
+		restacked++
+	}
+
+	if restacked == 0 {
+		ui.Warning("No stack branches match --match")
+		return nil
+	}
+	ui.Success(fmt.Sprintf("Restacked %d branch(es) locally. Nothing was pushed", restacked))
+	return nil
+}
+
+// resolveRestackConflict resolves a conflict with git mergetool, if enabled, and checks out
+// original again. It returns true once the conflicted branch is fully rebased.
+func resolveRestackConflict(err error, original string) (bool, error) {
+	var conflict *restack.ConflictError
+	if !errors.As(err, &conflict) || !useMergetool(restackMergetool) {
+		return false, nil
+	}
+
+	resolved, err := resolveWithMergetool(conflict.Branch)
+	if err != nil || !resolved {
+		return false, err
+	}
+	if err := stack.RecordParentSHA(conflict.Branch, conflict.Onto); err != nil {
+		ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+	}
+	return true, git.CheckoutBranch(original)
+}
+
+// restackError explains how to recover from a failed restack
+func restackError(err error) error {
+	var conflict *restack.ConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+
+	ui.Error(fmt.Sprintf("Rebase conflict on branch %s", conflict.Branch))
+	printConflictedFiles(conflict.Files)
+
+	fmt.Println("\nTo resolve:")
+	fmt.Println("  1. Fix conflicts in the files above")
+	fmt.Printf("  2. Stage resolved files: %s\n", stageCommand(conflict.Files))
+	fmt.Println("  3. Continue restack: stak restack --continue")
+	fmt.Println("\nOr abort: git rebase --abort")
+
+	return fmt.Errorf("%w - resolve and continue", errConflict)
+}
+
+// deepenShallowClone fetches more history in a shallow clone until every branch shares some
+// with its parent, so rebases find what to replay instead of failing on cut-off history
+func deepenShallowClone(branches []string) error {
+	if !git.IsShallow() {
+		return nil
+	}
+	var pairs [][2]string
+	for _, branch := range branches {
+		if parent, err := stack.GetParent(branch); err == nil && parent != "" {
+			pairs = append(pairs, [2]string{branch, parent})
+		}
+	}
+
+	deepened, err := git.DeepenForMergeBases(pairs)
+	if err != nil {
+		return err
+	}
+	if deepened {
+		ui.Info("Fetched more history into the shallow clone to find where branches fork from their parents")
+	}
+	return nil
+}
+
+// printConflictedFiles lists the files a rebase stopped on. In a sparse checkout, git brings
+// conflicted files outside it into the working tree, which is pointed out. Submodules are
+// shown with the commit each side points them to, and how to keep the branch's.
+func printConflictedFiles(files []string) {
+	if len(files) == 0 {
+		return
+	}
+	outside := make(map[string]bool)
+	for _, file := range git.OutsideSparseCheckout(files) {
+		outside[file] = true
+	}
+	submodules := make(map[string]git.SubmoduleConflict)
+	conflicts, _ := git.GetSubmoduleConflicts()
+	for _, conflict := range conflicts {
+		submodules[conflict.Path] = conflict
+	}
+
+	fmt.Println("\nConflicted files:")
+	for _, file := range files {
+		if conflict, ok := submodules[file]; ok {
+			fmt.Printf("  - %s (submodule: %s upstream, %s on the branch)\n", file,
+				submoduleCommit(conflict.Upstream), submoduleCommit(conflict.Branch))
+		} else if outside[file] {
+			fmt.Printf("  - %s (outside the sparse checkout)\n", file)
+		} else {
+			fmt.Printf("  - %s\n", file)
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Println("\nTo keep the submodule commits of the branch:")
+	for _, conflict := range conflicts {
+		if conflict.Branch == "" {
+			fmt.Printf("  git rm --cached %s\n", conflict.Path)
+		} else {
+			fmt.Printf("  git update-index --cacheinfo 160000,%s,%s\n", conflict.Branch, conflict.Path)
+		}
+	}
+	fmt.Println("Or have restack and sync do it: git config stack.submodule-conflicts branch")
+}
+
+// submoduleCommit abbreviates the commit a submodule points to, or says it was removed
+func submoduleCommit(sha string) string {
+	if sha == "" {
+		return "removed"
+	}
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// stageCommand is how to stage resolved files: files outside a sparse checkout need --sparse
+func stageCommand(files []string) string {
+	if len(git.OutsideSparseCheckout(files)) > 0 {
+		return "git add --sparse <file>"
+	}
+	return "git add <file>"
+}
+
+// continueRestackRebase finishes the rebase a conflict stopped, so the restack can pick up after it
+func continueRestackRebase() error {
+	inProgress, err := git.IsRebaseInProgress()
+	if err != nil {
+		return fmt.Errorf("failed to check rebase status: %w", err)
+	}
+	if !inProgress {
+		return fmt.Errorf("no rebase in progress")
+	}
+
+	hasConflicts, err := git.HasMergeConflicts()
+	if err != nil {
+		return fmt.Errorf("failed to check for conflicts: %w", err)
+	}
+	if hasConflicts {
+		files, _ := git.GetConflictedFiles()
+		fmt.Println("Still have conflicts in:")
+		for _, file := range files {
+			fmt.Printf("  - %s\n", file)
+		}
+		return fmt.Errorf("%w - resolve all conflicts before continuing", errConflict)
+	}
+
+	onto, _ := git.GetRebaseOnto()
+
+	ui.Info("Continuing rebase")
+	if err := git.ContinueRebase(); err != nil {
+		return fmt.Errorf("failed to continue rebase: %w", err)
+	}
+
+	if branch, err := git.GetCurrentBranch(); err == nil && onto != "" {
+		if err := stack.RecordParentSHA(branch, onto); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+		}
+	}
+	return nil
+}
+
+// recordRestackUpstreams records, for each branch, the parent commit its own commits start
+// after, before its parent is changed or rewritten. Restacking then replays only those
+// commits, leaving behind whatever the old parent had. A commit recorded before the parent was
+// last rewritten is kept, being more accurate.
+func recordRestackUpstreams(ctx *stack.StackContext, branches []string) {
+	for _, branch := range branches {
+		parent := ctx.Parent(branch)
+		if parent == "" || stack.RewrittenParentBase(branch, parent) != "" {
+			continue
+		}
+		if err := stack.RecordParentSHA(branch, parent); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit for %s: %v", branch, err))
+		}
+	}
+}
+
+// pushRestacked force pushes the restacked branches that were already pushed, so their PRs
+// show the new commits. Branches never pushed are left for 'stak submit'. It tries every
+// branch, and returns an error naming those that failed.
+func pushRestacked(restacked []string) error {
+	var failed []string
+	for _, branch := range restacked {
+		if !git.RemoteTrackingBranchExists(branch) {
+			continue
+		}
+		ui.Info(fmt.Sprintf("Force pushing %s", branch))
+		if err := git.Push(branch, false, true); err != nil {
+			ui.Warning(fmt.Sprintf("Could not push %s: %v", branch, err))
+			failed = append(failed, branch)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to push %s. Run 'stak push' to try again", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// restackSubtrees rebases each of starts and its descendants onto their parents, then checks
+// out original again. It returns the branches restacked, and on an unresolved conflict an
+// error explaining how to continue.
+func restackSubtrees(starts []string, original string) ([]string, error) {
+	var restacked []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(starts); {
+		result, err := restack.Stack(starts[i], restack.Options{})
+		if result != nil {
+			for _, branch := range result.Restacked {
+				if !seen[branch] {
+					ui.Success(fmt.Sprintf("Restacked %s", branch))
+					restacked = append(restacked, branch)
+					seen[branch] = true
+				}
+			}
+		}
+		if err != nil {
+			resolved, rerr := resolveRestackConflict(err, original)
+			if rerr != nil {
+				return restacked, rerr
+			}
+			if !resolved {
+				return restacked, restackError(err)
+			}
+			// Go again: branches already restacked are no-ops
+			continue
+		}
+		i++
+	}
+	return restacked, nil
+}
+
+// checkCanRestack returns an error if a rebase is already in progress or the working tree is
+// dirty, so a command can refuse before changing any metadata
+func checkCanRestack(action, branch string) error {
+	inProgress, err := git.IsRebaseInProgress()
+	if err != nil {
+		return fmt.Errorf("failed to check rebase status: %w", err)
+	}
+	if inProgress {
+		return fmt.Errorf("a rebase is in progress. Finish it with 'stak restack --continue' or abort it first")
+	}
+	dirty, err := git.HasUncommittedChanges()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("cannot %s %s: %w", action, branch, git.ErrDirtyTree)
+	}
+	return nil
+}
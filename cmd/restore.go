@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	restoreAll    bool
+	restoreAuthor string
+)
+
+var restoreCmd = &cobra.Command{
+	Use:     "restore [branch]",
+	Aliases: []string{"rs"},
+	Short:   "Rebuild local stack metadata from GitHub",
+	Long: `Rebuild local stack metadata from the stak-metadata block embedded in a PR's stack comment.
+With --all, scans every open PR in the repository and reconstructs all stacks by following PR base branches.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		branchName := ""
+		if len(args) > 0 {
+			branchName = args[0]
+		}
+
+		var err error
+		if restoreAll {
+			err = runRestoreAll()
+		} else {
+			err = runRestore(branchName)
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreAll, "all", false, "Rebuild metadata for every stack from open PRs")
+	restoreCmd.Flags().StringVar(&restoreAuthor, "author", "", "With --all, only restore PRs by this author (e.g. @me)")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+func runRestore(branchName string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	// Check if gh CLI is authenticated
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	// Determine target branch
+	if branchName == "" {
+		var err error
+		branchName, err = git.GetCurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+	}
+
+	// Find the branch's PR, preferring local metadata
+	prNumber, err := git.GetBranchPRNumber(branchName)
+	if err != nil {
+		return err
+	}
+	if prNumber == 0 {
+		prNumber, err = github.GetPRNumberForBranch(branchName)
+		if err != nil {
+			return fmt.Errorf("could not find a PR for %s: %w", branchName, err)
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Reading stack metadata from PR #%d", prNumber))
+	body, err := github.GetStackCommentBody(prNumber)
+	if err != nil {
+		return err
+	}
+	if body == "" {
+		return fmt.Errorf("PR #%d has no stack comment. Try: stak restore --all", prNumber)
+	}
+
+	meta, err := stack.ParseCommentMetadata(body)
+	if err != nil {
+		return err
+	}
+	if meta == nil || len(meta.Branches) == 0 {
+		return fmt.Errorf("stack comment on PR #%d has no stak-metadata block. Try: stak restore --all", prNumber)
+	}
+
+	ui.Info("Fetching from remote")
+	if err := git.Fetch(); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	restored := 0
+	for _, b := range meta.Branches {
+		if restoreBranch(b.Name, b.Parent, b.PRNumber, b.Owner) {
+			restored++
+		}
+	}
+
+	ui.Success(fmt.Sprintf("Restored %d of %d branch(es)", restored, len(meta.Branches)))
+	ui.Info("Use 'stak list' to view the stack structure")
+	return nil
+}
+
+func runRestoreAll() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	// Check if gh CLI is authenticated
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	ui.Info("Fetching from remote")
+	if err := git.Fetch(); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	ui.Info("Listing open PRs")
+	prs, err := github.ListOpenPRs(restoreAuthor)
+	if err != nil {
+		return err
+	}
+
+	// Index PR heads so bases can be resolved to parent branches
+	heads := make(map[string]bool)
+	for _, pr := range prs {
+		if !pr.IsCrossRepository {
+			heads[pr.HeadRefName] = true
+		}
+	}
+
+	restored := 0
+	roots := 0
+	for _, pr := range prs {
+		if pr.IsCrossRepository {
+			ui.Warning(fmt.Sprintf("Skipping PR #%d (%s): opened from a fork", pr.Number, pr.HeadRefName))
+			continue
+		}
+		if !heads[pr.BaseRefName] {
+			roots++
+		}
+		if restoreBranch(pr.HeadRefName, pr.BaseRefName, pr.Number, pr.Author.Login) {
+			restored++
+		}
+	}
+
+	if restored == 0 {
+		ui.Warning("No stacks restored")
+		return nil
+	}
+
+	ui.Success(fmt.Sprintf("Restored %d branch(es) across %d stack(s)", restored, roots))
+	ui.Info("Use 'stak list' to view the stack structure")
+	return nil
+}
+
+// restoreBranch creates the local branch if needed and writes its metadata
+// Returns false if the branch could not be restored
+func restoreBranch(branch, parent string, prNumber int, owner string) bool {
+	exists, err := git.BranchExists(branch)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("  %s → could not check branch: %v", branch, err))
+		return false
+	}
+	if !exists {
+		if !git.RemoteTrackingBranchExists(branch) {
+			ui.Warning(fmt.Sprintf("  %s → not found locally or on %s, skipping", branch, git.Remote))
+			return false
+		}
+		if err := git.CreateTrackingBranch(branch); err != nil {
+			ui.Warning(fmt.Sprintf("  %s → %v", branch, err))
+			return false
+		}
+	}
+
+	currentParent, err := stack.GetParent(branch)
+	if err == nil && currentParent != "" && currentParent != parent {
+		ui.Warning(fmt.Sprintf("  %s → parent changed from %s to %s", branch, currentParent, parent))
+	}
+
+	if err := stack.WriteBranchMetadata(branch, parent, prNumber); err != nil {
+		ui.Warning(fmt.Sprintf("  %s → failed to track: %v", branch, err))
+		return false
+	}
+	stack.SetBranchOwner(branch, owner)
+
+	ui.Success(fmt.Sprintf("  %s → %s", branch, parent))
+	return true
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var reviewsCmd = &cobra.Command{
+	Use:     "reviews",
+	Aliases: []string{"rv"},
+	Short:   "Show review status for every PR in the stack",
+	Long: `Show, for each PR in the current stack, who has approved, who has requested changes,
+whose reviews are still pending, and how many review threads are unresolved.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runReviews(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reviewsCmd)
+}
+
+func runReviews() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	// Check if gh CLI is authenticated
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	prBranches, err := currentStackPRBranches()
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+	for _, branch := range prBranches {
+		metadata, err := stack.ReadBranchMetadata(branch)
+		if err != nil {
+			continue
+		}
+
+		reviews, err := github.GetPRReviews(metadata.PRNumber)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("%s: %v", branch, err))
+			continue
+		}
+
+		displayReviews(branch, reviews)
+		shown++
+	}
+
+	if shown == 0 {
+		fmt.Println("No PRs in this stack. Run 'stak submit' to create them.")
+	}
+
+	return nil
+}
+
+func displayReviews(branch string, reviews *github.PRReviews) {
+	fmt.Printf("%s  PR #%d - %s\n", branch, reviews.Number, reviews.Title)
+
+	if len(reviews.Approvers) > 0 {
+		fmt.Printf("  ✓ Approved by %s\n", formatLogins(reviews.Approvers))
+	}
+	if len(reviews.ChangesRequestedBy) > 0 {
+		fmt.Printf("  ✗ Changes requested by %s\n", formatLogins(reviews.ChangesRequestedBy))
+	}
+	if len(reviews.PendingReviewers) > 0 {
+		fmt.Printf("  ⏳ Waiting on %s\n", formatLogins(reviews.PendingReviewers))
+	}
+	if reviews.UnresolvedThreads > 0 {
+		fmt.Printf("  ⚠ %d unresolved thread(s)\n", reviews.UnresolvedThreads)
+	}
+	if len(reviews.Approvers) == 0 && len(reviews.ChangesRequestedBy) == 0 && len(reviews.PendingReviewers) == 0 {
+		fmt.Println("  ○ No reviewers yet")
+	}
+
+	fmt.Println()
+}
+
+// formatLogins renders GitHub logins (or org/team slugs) as a comma-separated @-mention list
+func formatLogins(logins []string) string {
+	mentions := make([]string, len(logins))
+	for i, login := range logins {
+		mentions[i] = "@" + login
+	}
+	return strings.Join(mentions, ", ")
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// rewordCommit replaces the message of commit, the tip of branch or another of its own
+// commits, leaving the changes alone: staged changes are not amended in. The commits after it
+// are replayed on top, and the branch's descendants restacked. Without a message, the editor
+// opens on the current one.
+func rewordCommit(branch, commit, message string) error {
+	if message == "" {
+		if err := requireInteractive("the new commit message"); err != nil {
+			return err
+		}
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	sha, err := git.GetCommitSHA(commit)
+	if err != nil {
+		return fmt.Errorf("%s is not a commit", commit)
+	}
+	tip, err := git.GetCommitSHA(branch)
+	if err != nil {
+		return err
+	}
+	parent := ctx.Parent(branch)
+	if !git.BranchContainsCommit(tip, sha) || (parent != "" && git.BranchContainsCommit(parent, sha)) {
+		return fmt.Errorf("%s is not one of the commits of %s", commit, branch)
+	}
+	if err := git.CheckNotProtected(branch, "reword"); err != nil {
+		return err
+	}
+
+	// Rewording below the tip, or with children to restack, checks out other commits
+	children := ctx.Children(branch)
+	if sha != tip || len(children) > 0 {
+		if err := checkCanRestack("reword a commit of", branch); err != nil {
+			return err
+		}
+	}
+	recordRestackUpstreams(ctx, ctx.Descendants(branch))
+	if err := git.BackupBranch(branch); err != nil {
+		return err
+	}
+
+	if sha == tip {
+		if err := amendMessage(message); err != nil {
+			return err
+		}
+	} else {
+		// Amend the commit on a detached HEAD, then replay the rest of the branch onto it
+		if err := git.CheckoutDetached(sha); err != nil {
+			return err
+		}
+		if err := amendMessage(message); err != nil {
+			git.CheckoutBranch(branch)
+			return err
+		}
+		reworded, err := git.GetCommitSHA("HEAD")
+		if err != nil {
+			return err
+		}
+		if err := git.CheckoutBranch(branch); err != nil {
+			return err
+		}
+		// Only the message changed, so the replayed commits apply cleanly
+		if err := git.RebaseOntoFrom(reworded, sha); err != nil {
+			return fmt.Errorf("failed to replay the commits after %s: %w", commit, err)
+		}
+	}
+	ui.Success(fmt.Sprintf("Reworded %s on %s", shortSHA(sha), branch))
+
+	if len(children) > 0 {
+		if _, err := restackSubtrees(children, branch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// amendMessage replaces the message of the HEAD commit, with the editor if message is empty
+func amendMessage(message string) error {
+	args := []string{"commit", "--amend", "--only"}
+	if message != "" {
+		args = append(args, "-m", message)
+	}
+	cmd := git.CommitCommand(args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to reword commit: %w", err)
+	}
+	return nil
+}
@@ -3,12 +3,21 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"stacking/internal/config"
+	"stacking/internal/git"
+	"stacking/internal/profile"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
 )
 
 var (
 	versionFlag bool
+	profileFlag bool
+	gpgSignFlag bool
+	repoFlag    string
 	appVersion  = "dev"
 )
 
@@ -17,6 +26,18 @@ var rootCmd = &cobra.Command{
 	Short: "A tool for managing stacked pull requests",
 	Long: `stak is a CLI tool that enables stacked PR workflows.
 It helps you create, sync, and manage dependent branches and their pull requests.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		changeToRepo()
+		if profileFlag {
+			profile.Enable()
+		}
+		applySettings()
+		startJournal(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		finishJournal()
+		profile.Report(os.Stderr)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if versionFlag {
 			fmt.Printf("stak version %s\n", appVersion)
@@ -28,9 +49,53 @@ It helps you create, sync, and manage dependent branches and their pull requests
 
 // Execute runs the root command
 func Execute() {
+	// -C before the command applies before aliases and plugins are looked up, like in git
+	args, err := applyLeadingRepoFlags(os.Args[1:])
+	if err == nil {
+		args, err = expandAlias(args)
+	}
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(exitError)
+	}
+
+	// Unknown subcommands are dispatched to stak-<name> plugins on PATH
+	if path, ok := lookupPlugin(args); ok {
+		code, err := runPlugin(path, args[1:])
+		if err != nil {
+			ui.Error(err.Error())
+		}
+		os.Exit(code)
+	}
+
+	rootCmd.SetArgs(args)
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// applySettings applies settings that change how stak talks to git and GitHub
+// Like all settings, they can come from git config or STAK_* environment variables
+func applySettings() {
+	git.Remote = config.GetString("remote", git.Remote)
+
+	// Trunks are always protected; protected-branches adds names and patterns like release/*
+	protected := append([]string{}, stack.BaseBranches()...)
+	protected = append(protected, strings.FieldsFunc(config.GetString("protected-branches", ""), func(r rune) bool {
+		return r == ',' || r == ' '
+	})...)
+	git.ProtectedBranches = protected
+	git.CheckForcePush = checkForcePush
+	git.SignCommits = gpgSignFlag || config.GetBool("gpg-sign", false)
+	git.NoVerify = skipHooks()
+	git.BeforeRewrite = warnUnsignedRewrite
+	git.ResolveSubmoduleConflicts = config.GetString("submodule-conflicts", "manual") == "branch"
+
+	// gh reads the host to talk to from GH_HOST
+	if host := config.GetString("github-host", ""); host != "" {
+		os.Setenv("GH_HOST", host)
 	}
 }
 
@@ -41,4 +106,53 @@ func SetVersion(version string) {
 
 func init() {
 	rootCmd.Flags().BoolVarP(&versionFlag, "version", "v", false, "Print version information")
+	rootCmd.PersistentFlags().BoolVar(&profileFlag, "profile", false, "Print time spent per phase and per external command")
+	rootCmd.PersistentFlags().BoolVarP(&gpgSignFlag, "gpg-sign", "S", false, "Sign the commits stak creates or rewrites")
+	rootCmd.PersistentFlags().StringVarP(&repoFlag, "repo", "C", "", "Run as if stak was started in this directory")
+	rootCmd.MarkPersistentFlagDirname("repo")
+}
+
+// changeToRepo changes to the directory given with -C after the command, before anything
+// reads the repository
+func changeToRepo() {
+	if repoFlag == "" {
+		return
+	}
+	if err := os.Chdir(repoFlag); err != nil {
+		ui.Error(fmt.Sprintf("cannot change to %s: %v", repoFlag, err))
+		os.Exit(exitError)
+	}
+	repoFlag = ""
+}
+
+// applyLeadingRepoFlags changes to the directories given with -C or --repo before the command,
+// each relative to the one before like git -C, and returns args without them
+func applyLeadingRepoFlags(args []string) ([]string, error) {
+	var rest []string
+	i := 0
+	for ; i < len(args) && strings.HasPrefix(args[i], "-") && args[i] != "--"; i++ {
+		dir, isRepo := "", true
+		switch arg := args[i]; {
+		case arg == "-C" || arg == "--repo":
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("flag needs an argument: %s", arg)
+			}
+			i++
+			dir = args[i]
+		case strings.HasPrefix(arg, "--repo="):
+			dir = strings.TrimPrefix(arg, "--repo=")
+		case strings.HasPrefix(arg, "-C") && !strings.HasPrefix(arg, "--"):
+			dir = strings.TrimPrefix(strings.TrimPrefix(arg, "-C"), "=")
+		default:
+			isRepo = false
+		}
+		if !isRepo {
+			rest = append(rest, args[i])
+			continue
+		}
+		if err := os.Chdir(dir); err != nil {
+			return nil, fmt.Errorf("cannot change to %s: %w", dir, err)
+		}
+	}
+	return append(rest, args[i:]...), nil
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyLeadingRepoFlags(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(base, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    []string
+		want    []string
+		wantDir string // Relative to base
+		wantErr bool
+	}{
+		{args: []string{"list"}, want: []string{"list"}, wantDir: "."},
+		{args: []string{"-C", "a", "list"}, want: []string{"list"}, wantDir: "a"},
+		{args: []string{"-Ca", "list"}, want: []string{"list"}, wantDir: "a"},
+		{args: []string{"-C=a", "list"}, want: []string{"list"}, wantDir: "a"},
+		{args: []string{"--repo", "a", "list"}, want: []string{"list"}, wantDir: "a"},
+		{args: []string{"--repo=a", "list"}, want: []string{"list"}, wantDir: "a"},
+		{args: []string{"-C", "a", "-C", "b", "log"}, want: []string{"log"}, wantDir: "a/b"},
+		{args: []string{"-C", base + "/a/b", "log"}, want: []string{"log"}, wantDir: "a/b"},
+		// Other flags stay, and only flags before the command apply
+		{args: []string{"--profile", "-C", "a", "sync", "-C", "b"}, want: []string{"--profile", "sync", "-C", "b"}, wantDir: "a"},
+		{args: []string{"--", "-C", "a"}, want: []string{"--", "-C", "a"}, wantDir: "."},
+		{args: []string{"-C"}, wantErr: true},
+		{args: []string{"--repo"}, wantErr: true},
+		{args: []string{"-C", "missing", "list"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Chdir(base)
+		got, err := applyLeadingRepoFlags(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("applyLeadingRepoFlags(%q) = %q, want an error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("applyLeadingRepoFlags(%q) failed: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("applyLeadingRepoFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+		dir, _ := os.Getwd()
+		if want := filepath.Join(base, tt.wantDir); dir != want {
+			t.Errorf("applyLeadingRepoFlags(%q) changed to %s, want %s", tt.args, dir, want)
+		}
+	}
+}
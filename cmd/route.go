@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"stacking/internal/git"
+	"stacking/internal/profile"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// routeStagedChanges moves each staged hunk into the downstack branch whose code it changes, as
+// told by git blame, by amending that branch's last commit. The branches above are restacked,
+// and hunks that change the current branch's own code, or the trunk's, stay staged.
+func routeStagedChanges(currentBranch string) error {
+	inProgress, err := git.IsRebaseInProgress()
+	if err != nil {
+		return fmt.Errorf("failed to check rebase status: %w", err)
+	}
+	if inProgress {
+		return fmt.Errorf("a rebase is in progress. Finish it with 'stak restack --continue' or abort it first")
+	}
+	hasStaged, err := git.HasStagedChanges()
+	if err != nil {
+		return fmt.Errorf("failed to check for staged changes: %w", err)
+	}
+	if !hasStaged {
+		return fmt.Errorf("no staged changes to route")
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	// The branches hunks can go to, from the bottom of the stack up to the current one, and
+	// which of them each commit belongs to
+	var branches []string
+	for _, branch := range ctx.Ancestors(currentBranch) {
+		if !stack.IsBaseBranch(branch) {
+			branches = append(branches, branch)
+		}
+	}
+	branches = append(branches, currentBranch)
+	rank := make(map[string]int) // Commit to its branch's index in branches
+	for i, branch := range branches {
+		parent := ctx.Parent(branch)
+		upToDate, err := isAncestorBranch(parent, branch)
+		if err != nil {
+			return err
+		}
+		if !upToDate {
+			return fmt.Errorf("%s is not on top of %s; run 'stak restack' first", branch, parent)
+		}
+		commits, err := git.GetCommitAncestors(branch, parent)
+		if err != nil {
+			return err
+		}
+		for _, commit := range commits {
+			rank[commit] = i
+		}
+	}
+	top := len(branches) - 1
+
+	hunks, err := git.GetStagedHunks()
+	if err != nil {
+		return err
+	}
+	byFile := make(map[string][]git.StagedHunk)
+	var files []string
+	for _, h := range hunks {
+		if _, ok := byFile[h.Path]; !ok {
+			files = append(files, h.Path)
+		}
+		byFile[h.Path] = append(byFile[h.Path], h)
+	}
+
+	// Work out the branch of each hunk: the topmost owner of the lines it changes, or for an
+	// insertion, the owner of the lines on both sides of it
+	plan := make(map[int]map[string][]git.StagedHunk) // Branch index to hunks by file
+	contents := make(map[string]string)               // HEAD content of the files
+	kept := 0
+	for _, file := range files {
+		content, err := git.GetFileContent("HEAD", file)
+		if err != nil {
+			return err
+		}
+		contents[file] = content
+		lineCount := strings.Count(content, "\n")
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			lineCount++
+		}
+
+		var ranges [][2]int
+		for _, h := range byFile[file] {
+			if first, last := blameRange(h.Hunk, lineCount); first <= last {
+				ranges = append(ranges, [2]int{first, last})
+			}
+		}
+		var blamed map[int]string
+		if len(ranges) > 0 {
+			if blamed, err = git.BlameLines("HEAD", file, ranges); err != nil {
+				return err
+			}
+		}
+
+		for _, h := range byFile[file] {
+			owner := -1
+			first, last := blameRange(h.Hunk, lineCount)
+			for line := first; line <= last; line++ {
+				r, ok := rank[blamed[line]]
+				if !ok {
+					r = -1
+				}
+				if h.OldLines == 0 && line > first && r != owner {
+					// The lines around an insertion belong to different branches
+					owner = -1
+					break
+				}
+				if line == first || r > owner {
+					owner = r
+				}
+			}
+			if owner < 0 || owner == top || git.IsProtected(branches[owner]) {
+				kept++
+				continue
+			}
+			if plan[owner] == nil {
+				plan[owner] = make(map[string][]git.StagedHunk)
+			}
+			plan[owner][file] = append(plan[owner][file], h)
+		}
+	}
+
+	// Merge each branch's hunks into its version of the files. A file that doesn't merge
+	// cleanly, e.g. because the branches above changed the lines next to a hunk, keeps its hunks.
+	var targets []int
+	for i := range plan {
+		targets = append(targets, i)
+	}
+	sort.Ints(targets)
+	trees := make(map[int]string)
+	routed := 0
+	for _, i := range targets {
+		for len(plan[i]) > 0 {
+			tree, conflicts, err := mergeRoutedHunks(branches[i], plan[i], contents)
+			if err != nil {
+				return err
+			}
+			if len(conflicts) == 0 {
+				trees[i] = tree
+				for _, fileHunks := range plan[i] {
+					routed += len(fileHunks)
+				}
+				break
+			}
+			removed := 0
+			for file, fileHunks := range plan[i] {
+				if contains(conflicts, file) {
+					ui.Warning(fmt.Sprintf("The changes to %s don't apply cleanly to %s; they stay staged", file, branches[i]))
+					kept += len(fileHunks)
+					delete(plan[i], file)
+					removed++
+				}
+			}
+			if removed == 0 {
+				// Conflicts elsewhere can't be helped by leaving files out
+				ui.Warning(fmt.Sprintf("The changes don't apply cleanly to %s; they stay staged", branches[i]))
+				for _, fileHunks := range plan[i] {
+					kept += len(fileHunks)
+				}
+				delete(plan, i)
+			}
+		}
+	}
+	if len(trees) == 0 {
+		ui.Info("No staged hunk changes code of a downstack branch; commit them with 'stak modify'")
+		return nil
+	}
+
+	var targetBranches []string
+	for _, i := range targets {
+		if _, ok := trees[i]; ok {
+			targetBranches = append(targetBranches, branches[i])
+			var changed []string
+			count := 0
+			for file, fileHunks := range plan[i] {
+				changed = append(changed, file)
+				count += len(fileHunks)
+			}
+			sort.Strings(changed)
+			ui.Info(fmt.Sprintf("%d hunk(s) → %s (%s)", count, branches[i], strings.Join(changed, ", ")))
+		}
+	}
+	if err := checkStackOwnership("rewrite", targetBranches, modifyForce); err != nil {
+		return err
+	}
+
+	// Amend the branches, then restack everything above the lowest of them. The index is put
+	// back as it was: the routed hunks, now in HEAD, drop out of the staged changes.
+	index, err := git.WriteIndexTree()
+	if err != nil {
+		return fmt.Errorf("failed to save the index: %w", err)
+	}
+	amended := make(map[string]string)
+	for _, i := range targets {
+		tree, ok := trees[i]
+		if !ok {
+			continue
+		}
+		if err := git.BackupBranch(branches[i]); err != nil {
+			return err
+		}
+		if git.BeforeRewrite != nil {
+			git.BeforeRewrite(branches[i], branches[i]+"^")
+		}
+		if amended[branches[i]], err = git.AmendTip(branches[i], tree); err != nil {
+			return err
+		}
+	}
+
+	if _, err := restackKeepingChanges(amended, ctx.Children(targetBranches[0]), index); err != nil {
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Routed %d hunk(s) to %s", routed, strings.Join(targetBranches, ", ")))
+	if kept > 0 {
+		ui.Info(fmt.Sprintf("%d hunk(s) stay staged, changing code of %s or of no stack branch; commit them with 'stak modify'", kept, currentBranch))
+	}
+	ui.Info("Use 'stak submit' to push the rewritten branches")
+	return nil
+}
+
+// blameRange returns the lines of HEAD whose owner decides where a hunk goes: those it
+// changes, or for an insertion, the lines before and after it that exist
+func blameRange(h git.Hunk, lineCount int) (int, int) {
+	if h.OldLines > 0 {
+		return h.OldStart, h.OldStart + h.OldLines - 1
+	}
+	first, last := h.OldStart, h.OldStart+1
+	if first < 1 {
+		first = 1
+	}
+	if last > lineCount {
+		last = lineCount
+	}
+	return first, last
+}
+
+// mergeRoutedHunks applies hunks, taken from the staged diff against HEAD, to branch with a
+// three-way merge: HEAD is the base, so the changes of the branches above it are left out.
+// It returns the merged tree, or the files that conflicted.
+func mergeRoutedHunks(branch string, hunks map[string][]git.StagedHunk, contents map[string]string) (string, []string, error) {
+	patched := make(map[string]string)
+	for file, fileHunks := range hunks {
+		patched[file] = git.ApplyHunks(contents[file], fileHunks)
+	}
+	tree, err := git.WriteTreeWith("HEAD", patched)
+	if err != nil {
+		return "", nil, err
+	}
+	theirs, err := git.CommitTree(tree, "HEAD")
+	if err != nil {
+		return "", nil, err
+	}
+	// A commit of the branch's tree on top of both it and HEAD makes HEAD the merge base
+	ours, err := git.CommitTree(branch+"^{tree}", branch, "HEAD")
+	if err != nil {
+		return "", nil, err
+	}
+	merged, conflicts, err := git.MergeTree(ours, theirs)
+	if err != nil {
+		return "", nil, err
+	}
+	return merged, conflicts, nil
+}
+
+// restackKeepingChanges moves branches to the new commits in tips, and restacks starts and
+// their descendants onto their parents like restackSubtrees, but in memory with git merge-tree:
+// nothing is checked out, and the local changes, untracked files included, stay where they
+// are. Branches are only moved once every one of them restacked cleanly. It is for changes that
+// were just committed downstack from the working tree, so the restack brings into HEAD what
+// the working tree already has. The index is then set to the tree index, or to HEAD if index
+// is empty. It returns the restacked branches. Where merge-tree can't be used, the branches are
+// restacked by checking them out instead, with restackByCheckout.
+func restackKeepingChanges(tips map[string]string, starts []string, index string) ([]string, error) {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+	var order []string
+	seen := make(map[string]bool)
+	for _, start := range starts {
+		for _, branch := range append([]string{start}, ctx.Descendants(start)...) {
+			if !seen[branch] {
+				seen[branch] = true
+				order = append(order, branch)
+			}
+		}
+	}
+
+	if !git.GetVersion().AtLeast(git.MergeTreeVersion) {
+		return restackByCheckout(ctx, tips, starts, order, index)
+	}
+
+	// Parents come before their children, so each is replayed onto its parent's new tip
+	newTips := make(map[string]string, len(tips)+len(order))
+	for branch, tip := range tips {
+		newTips[branch] = tip
+	}
+	for _, branch := range order {
+		parent := ctx.Parent(branch)
+		onto, ok := newTips[parent]
+		if !ok {
+			if onto, err = git.GetCommitSHA(parent); err != nil {
+				return nil, err
+			}
+		}
+		upstream := stack.RewrittenParentBase(branch, parent)
+		if upstream == "" {
+			if upstream, err = git.GetMergeBase(branch, parent); err != nil {
+				return nil, err
+			}
+		}
+		// A branch given a new commit is replayed with it
+		head := branch
+		if tip, ok := tips[branch]; ok {
+			head = tip
+		}
+		tip, conflicts, err := git.ReplayCommits(onto, upstream, head)
+		if errors.Is(err, git.ErrMergeCommits) {
+			ui.Info(fmt.Sprintf("%s has merge commits, so the branches are checked out to restack them", branch))
+			return restackByCheckout(ctx, tips, starts, order, index)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("restacking %s would conflict in %s, so nothing was changed", branch, strings.Join(conflicts, ", "))
+		}
+		newTips[branch] = tip
+	}
+
+	var moves []string
+	for branch := range tips {
+		if !seen[branch] {
+			moves = append(moves, branch)
+		}
+	}
+	sort.Strings(moves)
+	var restacked []string
+	for _, branch := range append(moves, order...) {
+		old, err := git.GetCommitSHA(branch)
+		if err != nil {
+			return restacked, err
+		}
+		if newTips[branch] == old {
+			continue
+		}
+		if err := git.MoveBranch(branch, newTips[branch], old); err != nil {
+			return restacked, err
+		}
+		if seen[branch] {
+			ui.Success(fmt.Sprintf("Restacked %s", branch))
+			restacked = append(restacked, branch)
+		}
+	}
+	for _, branch := range order {
+		if err := stack.RecordParentSHA(branch, ctx.Parent(branch)); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit for %s: %v", branch, err))
+		}
+	}
+
+	// HEAD moved along with its branch, while the working tree stayed
+	return restacked, restoreIndex(index)
+}
+
+// restackByCheckout is restackKeepingChanges for a git too old to merge in memory, or for
+// branches with merge commits: it moves the branches to tips, then restacks order with
+// restackSubtrees, with the local changes, untracked files included, stashed meanwhile. As the
+// restack brings the new commits into HEAD, the changes merge back cleanly.
+func restackByCheckout(ctx *stack.StackContext, tips map[string]string, starts, order []string, index string) ([]string, error) {
+	original, err := git.GetCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	// The branches above keep only their own commits once their parents move
+	recordRestackUpstreams(ctx, order)
+	var moves []string
+	for branch := range tips {
+		moves = append(moves, branch)
+	}
+	sort.Strings(moves)
+	for _, branch := range moves {
+		old, err := git.GetCommitSHA(branch)
+		if err != nil {
+			return nil, err
+		}
+		if err := git.MoveBranch(branch, tips[branch], old); err != nil {
+			return nil, err
+		}
+	}
+
+	before, _ := git.GetCommitSHA("refs/stash")
+	if output, err := profile.CombinedOutput(exec.Command("git", "stash", "push", "--quiet", "--include-untracked", "-m", "stak-restack-"+original)); err != nil {
+		return nil, fmt.Errorf("failed to stash changes: %s", strings.TrimSpace(string(output)))
+	}
+	// With nothing to stash, no stash is made, and popping would take someone else's
+	after, _ := git.GetCommitSHA("refs/stash")
+	stashed := after != "" && after != before
+
+	restacked, err := restackSubtrees(starts, original)
+	if err != nil {
+		if stashed {
+			ui.Warning("Your local changes are stashed; restore them with 'git stash pop' once the restack is done")
+		}
+		return restacked, err
+	}
+	if stashed {
+		if output, err := profile.CombinedOutput(exec.Command("git", "stash", "pop", "--quiet")); err != nil {
+			return restacked, fmt.Errorf("failed to restore your changes, which stay stashed: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	return restacked, restoreIndex(index)
+}
+
+// restoreIndex sets the index to the tree index, or to HEAD if index is empty, keeping the
+// working tree
+func restoreIndex(index string) error {
+	resetCmd := exec.Command("git", "reset", "--quiet")
+	if index != "" {
+		resetCmd = exec.Command("git", "read-tree", index)
+	}
+	if output, err := profile.CombinedOutput(resetCmd); err != nil {
+		return fmt.Errorf("failed to restore the staged changes: %s", strings.TrimSpace(string(output)))
+	}
+	profile.Run(exec.Command("git", "update-index", "-q", "--refresh"))
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"stacking/internal/git"
+	"stacking/internal/stack"
+)
+
+// resolveScope turns a directory given on the command line, relative to the working directory,
+// into the slash-separated path relative to the repository root that scopes are stored as
+func resolveScope(dir string) (string, error) {
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", fmt.Errorf("failed to resolve repository root: %w", err)
+	}
+
+	path := dir
+	if !filepath.IsAbs(path) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if cwd, err = filepath.EvalSymlinks(cwd); err != nil {
+			return "", fmt.Errorf("failed to resolve working directory: %w", err)
+		}
+		path = filepath.Join(cwd, path)
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", dir)
+	}
+	if rel == "." {
+		return "", fmt.Errorf("a scope is a directory inside the repository, not its root")
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// scopeBranches returns the branches of every stack relevant to the directory scope
+func scopeBranches(ctx *stack.StackContext, scope string) []string {
+	var branches []string
+	for _, info := range stack.Stacks(ctx) {
+		if stack.InScope(ctx, info, scope) {
+			branches = append(branches, info.Branches...)
+		}
+	}
+	return branches
+}
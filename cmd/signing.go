@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// signingWarned is set once a rewrite was warned about, so a stack is warned about once
+var signingWarned bool
+
+// warnUnsignedRewrite is run before the commits of branch after base are rewritten. Unless
+// they are signed again, it warns if they carry signatures, or if trunk only accepts signed
+// commits on GitHub. GitHub is only asked when the command may contact it anyway.
+func warnUnsignedRewrite(branch, base string) {
+	if signingWarned || git.SignsCommits() {
+		return
+	}
+
+	if signed := git.CountSignedCommits(base, branch); signed > 0 {
+		ui.Warning(fmt.Sprintf("Rewriting %s drops the signatures of its %d signed commit(s)", branch, signed))
+	} else if trunk, err := stack.Trunk(); err == nil && githubEnabled && requiresSignedCommits(trunk) {
+		ui.Warning(fmt.Sprintf("%s requires signed commits, but the commits stak rewrites on %s won't be signed", trunk, branch))
+	} else {
+		return
+	}
+	signingWarned = true
+	ui.Info("Sign them with --gpg-sign (-S), or always: git config stack.gpg-sign true")
+}
+
+// requiresSignedCommits checks if GitHub only accepts signed commits on a branch
+func requiresSignedCommits(branch string) bool {
+	protection := getGitHubProtection(branch)
+	return protection != nil && protection.RequireSignatures
+}
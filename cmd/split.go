@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
 	"strings"
 
@@ -15,16 +14,24 @@ import (
 )
 
 var (
-	splitAt   string
-	splitName string
+	splitAt     string
+	splitName   string
+	splitForce  bool
+	splitAssign bool
 )
 
 var splitCmd = &cobra.Command{
 	Use:     "split [branch]",
 	Aliases: []string{"sp"},
 	Short:   "Split a branch into two branches",
-	Long:    `Split a branch at a specific commit, creating a new branch with commits after the split point.`,
-	Args:    cobra.MaximumNArgs(1),
+	Long: `Split a branch at a specific commit, creating a new branch with commits after the split point.
+
+With --assign, pick for each commit whether it stays on the branch or moves to one of any
+number of new branches instead. The branch keeps its commits and the new branches are
+stacked on top of it, in the order they were created, each with its commits in their
+original order.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -32,22 +39,24 @@ var splitCmd = &cobra.Command{
 		}
 
 		if err := runSplit(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
 	splitCmd.Flags().StringVar(&splitAt, "at", "", "Commit hash to split at")
+	splitCmd.RegisterFlagCompletionFunc("at", completeSplitCommit)
 	splitCmd.Flags().StringVar(&splitName, "name", "", "Name for the new branch")
+	splitCmd.Flags().BoolVar(&splitForce, "force", false, "Split even if the branch is owned by someone else")
+	splitCmd.Flags().BoolVar(&splitAssign, "assign", false, "Assign each commit to the branch or to new branches interactively")
 	rootCmd.AddCommand(splitCmd)
 }
 
 func runSplit(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch
@@ -88,6 +97,10 @@ func runSplit(branchName string) error {
 		return fmt.Errorf("branch %s has no parent (is a root branch)", branchName)
 	}
 
+	if err := checkStackOwnership("split and force-push", []string{branchName}, splitForce); err != nil {
+		return err
+	}
+
 	// Checkout the branch
 	currentBranch, _ := git.GetCurrentBranch()
 	if currentBranch != branchName {
@@ -107,6 +120,10 @@ func runSplit(branchName string) error {
 		return fmt.Errorf("branch has only %d commit(s), cannot split", len(commits))
 	}
 
+	if splitAssign {
+		return runSplitAssign(branchName, parent, commits)
+	}
+
 	// Determine split point
 	var splitCommit string
 	if splitAt != "" {
@@ -152,6 +169,12 @@ func runSplit(branchName string) error {
 
 	// Reset original branch to split point (hard reset)
 	ui.Info(fmt.Sprintf("Resetting %s to %s", branchName, splitCommit))
+	if err := git.CheckNotProtected(branchName, "reset"); err != nil {
+		return err
+	}
+	if err := git.BackupBranch(branchName); err != nil {
+		return err
+	}
 	cmd = exec.Command("git", "reset", "--hard", splitCommit)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -182,8 +205,25 @@ func runSplit(branchName string) error {
 	}
 
 	// Update children to point to new branch
+	reparentSplitChildren(children, branchName, newBranchName)
+
+	// Push new branch
+	ui.Info(fmt.Sprintf("Pushing %s", newBranchName))
+	if err := git.Push(newBranchName, true, false); err != nil {
+		return fmt.Errorf("failed to push new branch: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Split %s into %s and %s", branchName, branchName, newBranchName))
+	ui.Info(fmt.Sprintf("Create PR for %s with: stak submit", newBranchName))
+
+	return nil
+}
+
+// reparentSplitChildren moves the children of a split branch onto the branch now holding
+// its last commits, updating their PR bases
+func reparentSplitChildren(children []string, from, to string) {
 	for _, child := range children {
-		ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", child, branchName, newBranchName))
+		ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", child, from, to))
 
 		childMetadata, err := stack.ReadBranchMetadata(child)
 		if err != nil {
@@ -192,31 +232,20 @@ func runSplit(branchName string) error {
 		}
 
 		// Update metadata
-		if err := stack.WriteBranchMetadata(child, newBranchName, childMetadata.PRNumber); err != nil {
+		if err := stack.WriteBranchMetadata(child, to, childMetadata.PRNumber); err != nil {
 			ui.Warning(fmt.Sprintf("Could not update metadata for %s: %v", child, err))
 			continue
 		}
 
 		// Update PR base if PR exists
 		if childMetadata.PRNumber > 0 {
-			if err := github.UpdatePRBase(childMetadata.PRNumber, newBranchName); err != nil {
+			if err := github.UpdatePRBase(childMetadata.PRNumber, to); err != nil {
 				ui.Warning(fmt.Sprintf("Could not update PR #%d base: %v", childMetadata.PRNumber, err))
 			} else {
-				ui.Success(fmt.Sprintf("Updated PR #%d base to %s", childMetadata.PRNumber, newBranchName))
+				ui.Success(fmt.Sprintf("Updated PR #%d base to %s", childMetadata.PRNumber, to))
 			}
 		}
 	}
-
-	// Push new branch
-	ui.Info(fmt.Sprintf("Pushing %s", newBranchName))
-	if err := git.Push(newBranchName, true, false); err != nil {
-		return fmt.Errorf("failed to push new branch: %w", err)
-	}
-
-	ui.Success(fmt.Sprintf("Split %s into %s and %s", branchName, branchName, newBranchName))
-	ui.Info(fmt.Sprintf("Create PR for %s with: stak submit", newBranchName))
-
-	return nil
 }
 
 func getCommitList(branch, base string) ([]string, error) {
@@ -264,7 +293,7 @@ func selectSplitPoint(commits []string) (string, error) {
 		Size:  10,
 	}
 
-	idx, _, err := prompt.Run()
+	idx, _, err := runSelect(&prompt)
 	if err != nil {
 		return "", fmt.Errorf("commit selection cancelled")
 	}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// splitGroup is a branch produced by an assignment split, with its commits oldest first
+type splitGroup struct {
+	name    string
+	commits []string
+}
+
+// runSplitAssign splits a branch by assigning each of its commits to it or to new branches,
+// then rebuilds every layer from the branch's fork point with cherry-picks. Branches are only
+// moved once every layer has been built, so a conflict leaves everything as it was.
+func runSplitAssign(branchName, parent string, commits []string) error {
+	if splitAt != "" {
+		return fmt.Errorf("--assign and --at cannot be used together")
+	}
+
+	groups, err := pickCommitAssignment(branchName, commits)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 1 {
+		return fmt.Errorf("every commit stays on %s, nothing to split", branchName)
+	}
+	if len(groups[0].commits) == 0 {
+		return fmt.Errorf("at least one commit must stay on %s", branchName)
+	}
+
+	dirty, err := git.HasUncommittedChanges()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("cannot split %s: %w", branchName, git.ErrDirtyTree)
+	}
+	if err := git.CheckNotProtected(branchName, "split"); err != nil {
+		return err
+	}
+
+	base, err := git.GetMergeBase(parent, branchName)
+	if err != nil {
+		return err
+	}
+
+	// Build each layer on a detached HEAD, on top of the previous one
+	heads := make([]string, len(groups))
+	for i, group := range groups {
+		ui.Info(fmt.Sprintf("Building %s with %d commit(s)", group.name, len(group.commits)))
+		head, err := cherryPickOnto(base, group.commits)
+		if err != nil {
+			git.CheckoutBranch(branchName)
+			return fmt.Errorf("failed to build %s: %w. Nothing was changed; assign the commit to a later branch or split without reordering", group.name, err)
+		}
+		heads[i] = head
+		base = head
+	}
+
+	children, err := stack.GetChildren(branchName)
+	if err != nil {
+		return fmt.Errorf("failed to get children: %w", err)
+	}
+
+	// Point the branches at their new commits
+	if err := git.BackupBranch(branchName); err != nil {
+		return err
+	}
+	for i, group := range groups {
+		args := []string{"branch", group.name, heads[i]}
+		if i == 0 {
+			args = []string{"branch", "--force", group.name, heads[i]}
+		}
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to update %s: %s", group.name, strings.TrimSpace(string(output)))
+		}
+		if i > 0 {
+			if err := stack.WriteBranchMetadata(group.name, groups[i-1].name, 0); err != nil {
+				return fmt.Errorf("failed to write metadata: %w", err)
+			}
+		}
+	}
+
+	top := groups[len(groups)-1].name
+	reparentSplitChildren(children, branchName, top)
+
+	if err := git.CheckoutBranch(top); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", top, err)
+	}
+
+	// Force push original branch
+	ui.Info(fmt.Sprintf("Force pushing %s", branchName))
+	if err := git.Push(branchName, false, true); err != nil {
+		return fmt.Errorf("failed to push: %w", err)
+	}
+	for _, group := range groups[1:] {
+		ui.Info(fmt.Sprintf("Pushing %s", group.name))
+		if err := git.Push(group.name, true, false); err != nil {
+			return fmt.Errorf("failed to push new branch: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.name)
+	}
+	ui.Success(fmt.Sprintf("Split %s into %s", branchName, strings.Join(names, " → ")))
+	ui.Info("Create PRs for the new branches with: stak submit")
+	if len(children) > 0 {
+		// The cherry-picks rewrote every commit, so children still hold the old ones
+		ui.Info(fmt.Sprintf("Run 'stak restack' to move %s onto %s", strings.Join(children, ", "), top))
+	}
+	return nil
+}
+
+// cherryPickOnto applies commits on a detached HEAD at base and returns the resulting commit.
+// A failed cherry-pick is aborted.
+func cherryPickOnto(base string, commits []string) (string, error) {
+	if output, err := exec.Command("git", "checkout", "--quiet", "--detach", base).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to checkout %s: %s", base, strings.TrimSpace(string(output)))
+	}
+
+	args := append([]string{"cherry-pick", "--allow-empty"}, commits...)
+	if output, err := git.CommitCommand(args...).CombinedOutput(); err != nil {
+		exec.Command("git", "cherry-pick", "--abort").Run()
+		if strings.Contains(string(output), "CONFLICT") {
+			return "", fmt.Errorf("a commit conflicts without the commits assigned elsewhere")
+		}
+		return "", fmt.Errorf("cherry-pick failed: %s", strings.TrimSpace(string(output)))
+	}
+	return git.GetCommitSHA("HEAD")
+}
+
+// pickCommitAssignment lets the user assign each commit to the branch or to new branches.
+// The first group is always the branch itself; new branches follow in creation order.
+func pickCommitAssignment(branchName string, commits []string) ([]splitGroup, error) {
+	subjects := make([]string, len(commits))
+	for i, hash := range commits {
+		output, err := exec.Command("git", "log", "-1", "--oneline", hash).Output()
+		if err != nil {
+			subjects[i] = hash
+		} else {
+			subjects[i] = strings.TrimSpace(string(output))
+		}
+	}
+
+	names := []string{branchName}
+	assignment := make([]int, len(commits))
+	cursor := 0
+	for {
+		items := make([]string, 0, len(commits)+1)
+		for i, subject := range subjects {
+			items = append(items, fmt.Sprintf("%-24s %s", names[assignment[i]], subject))
+		}
+		items = append(items, "Done")
+
+		prompt := promptui.Select{
+			Label:        "Select a commit to assign it to a branch (oldest first)",
+			Items:        items,
+			Size:         15,
+			CursorPos:    cursor,
+			HideSelected: true,
+		}
+		idx, _, err := runSelect(&prompt)
+		if err != nil {
+			return nil, fmt.Errorf("split cancelled")
+		}
+		if idx == len(commits) {
+			break
+		}
+		cursor = idx
+
+		targets := append(append([]string{}, names...), "New branch...")
+		target := promptui.Select{
+			Label:        fmt.Sprintf("Move %s to", commits[idx]),
+			Items:        targets,
+			CursorPos:    assignment[idx],
+			HideSelected: true,
+		}
+		choice, _, err := runSelect(&target)
+		if err != nil {
+			continue
+		}
+		if choice == len(names) {
+			name, err := promptNewSplitBranch(branchName, names)
+			if err != nil {
+				continue
+			}
+			names = append(names, name)
+		}
+		assignment[idx] = choice
+	}
+
+	// Branches left without commits are dropped, except the original
+	groups := make([]splitGroup, len(names))
+	for i, name := range names {
+		groups[i].name = name
+	}
+	for i, hash := range commits {
+		groups[assignment[i]].commits = append(groups[assignment[i]].commits, hash)
+	}
+	result := []splitGroup{groups[0]}
+	for _, group := range groups[1:] {
+		if len(group.commits) > 0 {
+			result = append(result, group)
+		}
+	}
+	return result, nil
+}
+
+// promptNewSplitBranch asks for the name of a new branch, suggesting <branch>-<n>
+func promptNewSplitBranch(branchName string, taken []string) (string, error) {
+	prompt := promptui.Prompt{
+		Label:   "New branch name",
+		Default: fmt.Sprintf("%s-%d", branchName, len(taken)+1),
+		Validate: func(input string) error {
+			input = strings.TrimSpace(input)
+			if input == "" {
+				return fmt.Errorf("branch name cannot be empty")
+			}
+			for _, name := range taken {
+				if name == input {
+					return fmt.Errorf("%s is already used", input)
+				}
+			}
+			if exists, _ := git.BranchExists(input); exists {
+				return fmt.Errorf("branch %s already exists", input)
+			}
+			return nil
+		},
+	}
+	name, err := runPrompt(&prompt)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(name), nil
+}
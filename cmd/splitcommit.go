@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+// splitTipCommit undoes the last commit of branch, keeping its changes, and has the user
+// commit them again piece by piece with git add --patch. Each new commit starts from the
+// original message and author. The branch's descendants are restacked afterwards.
+func splitTipCommit(branch string) error {
+	if err := requireInteractive("the changes of each commit"); err != nil {
+		return err
+	}
+	if err := checkCanRestack("split the last commit of", branch); err != nil {
+		return err
+	}
+	hasCommits, err := branchHasCommits(branch)
+	if err != nil {
+		return fmt.Errorf("failed to check for commits: %w", err)
+	}
+	if !hasCommits {
+		return fmt.Errorf("%s has no commit of its own to split", branch)
+	}
+	if err := git.CheckNotProtected(branch, "split a commit of"); err != nil {
+		return err
+	}
+
+	original, err := git.GetCommitSHA("HEAD")
+	if err != nil {
+		return err
+	}
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	recordRestackUpstreams(ctx, ctx.Descendants(branch))
+	if err := git.BackupBranch(branch); err != nil {
+		return err
+	}
+
+	// Files the commit added become untracked once it's undone; intent-to-add lets
+	// git add --patch offer them too
+	output, err := exec.Command("git", "diff", "--name-only", "-z", "--diff-filter=A", original+"^", original).Output()
+	if err != nil {
+		return fmt.Errorf("failed to list the files of %s: %w", shortSHA(original), err)
+	}
+	if output, err := exec.Command("git", "reset", "--quiet", "--mixed", "HEAD^").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to undo the last commit: %s", strings.TrimSpace(string(output)))
+	}
+	if added := strings.Split(strings.TrimRight(string(output), "\x00"), "\x00"); added[0] != "" {
+		args := append([]string{"add", "--intent-to-add", "--"}, added...)
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to add new files back: %s", strings.TrimSpace(string(output)))
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Undid %s. Select the changes of each new commit; the rest stays for the next one", shortSHA(original)))
+	commits := 0
+	for {
+		remaining, err := git.HasUncommittedChanges()
+		if err != nil {
+			return err
+		}
+		if !remaining {
+			break
+		}
+
+		ui.Info(fmt.Sprintf("Changes for commit %d", commits+1))
+		addCmd := exec.Command("git", "add", "--patch")
+		addCmd.Stdin = os.Stdin
+		addCmd.Stdout = os.Stdout
+		addCmd.Stderr = os.Stderr
+		if err := addCmd.Run(); err != nil {
+			return fmt.Errorf("failed to select changes: %w", err)
+		}
+
+		staged, err := git.HasStagedChanges()
+		if err != nil {
+			return err
+		}
+		if !staged {
+			prompt := promptui.Select{
+				Label: "Nothing selected. What would you like to do?",
+				Items: []string{"Select changes again", "Commit all remaining changes", "Abort the split"},
+			}
+			_, result, err := runSelect(&prompt)
+			if err != nil || result == "Abort the split" {
+				return abortSplitCommit(original)
+			}
+			if result == "Select changes again" {
+				continue
+			}
+			if output, err := exec.Command("git", "add", "--all").CombinedOutput(); err != nil {
+				return fmt.Errorf("failed to stage changes: %s", strings.TrimSpace(string(output)))
+			}
+		}
+
+		// Start from the original message and author, to edit for this part
+		commitCmd := git.CommitCommand("commit", "--reedit-message="+original)
+		commitCmd.Stdin = os.Stdin
+		commitCmd.Stdout = os.Stdout
+		commitCmd.Stderr = os.Stderr
+		if err := commitCmd.Run(); err != nil {
+			ui.Warning("Commit cancelled or failed")
+			prompt := promptui.Select{
+				Label: "What would you like to do?",
+				Items: []string{"Try again", "Abort the split"},
+			}
+			if _, result, err := runSelect(&prompt); err != nil || result == "Abort the split" {
+				return abortSplitCommit(original)
+			}
+			continue
+		}
+		commits++
+	}
+
+	ui.Success(fmt.Sprintf("Split %s into %d commit(s)", shortSHA(original), commits))
+	if children := ctx.Children(branch); len(children) > 0 {
+		if _, err := restackSubtrees(children, branch); err != nil {
+			return err
+		}
+	}
+	ui.Info(fmt.Sprintf("To split %s into several branches along the new commits, run 'stak split --at <commit>'", branch))
+	return nil
+}
+
+// abortSplitCommit puts the branch back on the commit being split, dropping the new commits
+func abortSplitCommit(original string) error {
+	if output, err := exec.Command("git", "reset", "--quiet", "--hard", original).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restore %s: %s", shortSHA(original), strings.TrimSpace(string(output)))
+	}
+	return fmt.Errorf("split cancelled, the branch is back at %s", shortSHA(original))
+}
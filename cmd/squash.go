@@ -6,43 +6,63 @@ import (
 	"os/exec"
 
 	"github.com/spf13/cobra"
+	"stacking/internal/config"
 	"stacking/internal/git"
 	"stacking/internal/stack"
 	"stacking/internal/ui"
 )
 
 var (
-	squashMessage string
+	squashMessage    string
+	squashForce      bool
+	squashCoAuthors  bool
+	squashKeepAuthor bool
 )
 
 var squashCmd = &cobra.Command{
 	Use:     "squash [branch]",
 	Aliases: []string{"sq"},
 	Short:   "Squash all commits in a branch",
-	Long:    `Consolidate all commits in a branch into a single commit. Useful for cleaning up commit history before merging.`,
-	Args:    cobra.MaximumNArgs(1),
+	Long: `Consolidate all commits in a branch into a single commit. Useful for cleaning up commit history before merging.
+
+With --co-authors, the other authors of the commits are credited in Co-authored-by trailers.
+With --keep-author, the commit keeps the author and date of the branch's first commit.
+The squash-co-authors and squash-keep-author settings turn them on by default.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
 			branchName = args[0]
 		}
 
+		// The authorship settings apply unless the flags are given explicitly
+		if !cmd.Flags().Changed("co-authors") {
+			squashCoAuthors = config.GetBool("squash-co-authors", false)
+		}
+		if !cmd.Flags().Changed("keep-author") {
+			squashKeepAuthor = config.GetBool("squash-keep-author", false)
+		}
+
 		if err := runSquash(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
 	squashCmd.Flags().StringVarP(&squashMessage, "message", "m", "", "Commit message for squashed commit")
+	squashCmd.Flags().BoolVar(&squashForce, "force", false, "Squash even if the branch is owned by someone else")
+	squashCmd.Flags().BoolVar(&squashCoAuthors, "co-authors", false, "Credit the other authors of the commits as co-authors")
+	squashCmd.Flags().BoolVar(&squashKeepAuthor, "keep-author", false, "Keep the author and date of the first commit")
+	addVerifyFlags(squashCmd)
 	rootCmd.AddCommand(squashCmd)
 }
 
 func runSquash(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch
@@ -83,6 +103,10 @@ func runSquash(branchName string) error {
 		return fmt.Errorf("branch %s has no parent (is a root branch)", branchName)
 	}
 
+	if err := checkStackOwnership("squash and force-push", []string{branchName}, squashForce); err != nil {
+		return err
+	}
+
 	// Checkout the branch
 	currentBranch, _ := git.GetCurrentBranch()
 	if currentBranch != branchName {
@@ -107,6 +131,17 @@ func runSquash(branchName string) error {
 
 	// Reset to parent (soft reset keeps changes staged)
 	ui.Info(fmt.Sprintf("Resetting to %s (keeping changes)", parent))
+	if err := git.CheckNotProtected(branchName, "squash"); err != nil {
+		return err
+	}
+	if err := git.BackupBranch(branchName); err != nil {
+		return err
+	}
+	warnUnsignedRewrite(branchName, parent)
+	authorship, err := git.BranchAuthorship(parent, branchName, squashKeepAuthor, squashCoAuthors)
+	if err != nil {
+		return err
+	}
 	cmd := exec.Command("git", "reset", "--soft", parent)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -120,7 +155,13 @@ func runSquash(branchName string) error {
 	} else {
 		// Use interactive editor for commit message
 		ui.Info("Opening editor for commit message")
-		commitCmd := exec.Command("git", "commit")
+		if len(authorship.CoAuthors) > 0 && !git.CanAddTrailers() {
+			ui.Warning(fmt.Sprintf("git %s can't add trailers (2.32 or later is needed); end the message with:", git.GetVersion()))
+			for _, coAuthor := range authorship.CoAuthors {
+				fmt.Printf("  Co-authored-by: %s\n", coAuthor)
+			}
+		}
+		commitCmd := git.CommitCommand(append([]string{"commit"}, authorship.Args()...)...)
 		commitCmd.Stdin = os.Stdin
 		commitCmd.Stdout = os.Stdout
 		commitCmd.Stderr = os.Stderr
@@ -131,7 +172,7 @@ func runSquash(branchName string) error {
 
 	// If message was provided via flag, commit with it
 	if squashMessage != "" {
-		if err := git.Commit(commitMsg); err != nil {
+		if err := git.CommitAs(commitMsg, authorship); err != nil {
 			return fmt.Errorf("failed to commit: %w", err)
 		}
 	}
@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	stacksNameClear   bool
+	stacksDependClear bool
+	stacksScopeClear  bool
+)
+
+// currentStackRef is what a --stack flag that takes an optional stack name is set to when it's
+// given without one: the current stack
+const currentStackRef = "."
+
+var stacksCmd = &cobra.Command{
+	Use:     "stacks",
+	Aliases: []string{"sk"},
+	Short:   "List every stack in the repository",
+	Long: `List every stack in the repository with its name, root branch, number of branches and the
+state of its PRs. The current stack is marked with *.
+
+A stack is a tracked branch built on trunk (or another untracked branch) together with all
+of its descendants. Name the current stack with 'stak stacks name <name>', then refer to it
+by that name, e.g. 'stak switch <name>'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runStacks(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var stacksNameCmd = &cobra.Command{
+	Use:   "name [name]",
+	Short: "Name the current stack",
+	Long: `Name the stack the current branch belongs to, or show its name. Branches created in the
+stack later join it under the same name. Use --clear to remove the name.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+
+		if err := runStacksName(name); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var stacksDependCmd = &cobra.Command{
+	Use:   "depend [stack|#pr]",
+	Short: "Make the current stack wait for another stack or PR",
+	Long: `Declare that the current stack depends on another stack, given by name, or on a PR, given
+as #<number>, landing first. Until it is merged, 'stak merge' refuses to merge the stack and
+'stak submit' warns. Without an argument, shows the dependency. Use --clear to remove it.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeStackNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		dependency := ""
+		if len(args) > 0 {
+			dependency = args[0]
+		}
+
+		if err := runStacksDepend(dependency); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var stacksScopeCmd = &cobra.Command{
+	Use:   "scope [dir]",
+	Short: "Tag the current stack with the monorepo directory it works in",
+	Long: `Tag the stack the current branch belongs to with a directory of the repository, so that
+'stak list --scope' and 'stak sync --scope' for that area include it. Without an argument,
+shows the tag. Use --clear to remove it.
+
+Untagged stacks match a --scope when one of their branches changes a file inside it.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := ""
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if err := runStacksScope(dir); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	stacksNameCmd.Flags().BoolVar(&stacksNameClear, "clear", false, "Remove the stack's name")
+	stacksDependCmd.Flags().BoolVar(&stacksDependClear, "clear", false, "Remove the stack's dependency")
+	stacksCmd.AddCommand(stacksNameCmd)
+	stacksScopeCmd.Flags().BoolVar(&stacksScopeClear, "clear", false, "Remove the stack's scope")
+	stacksCmd.AddCommand(stacksDependCmd)
+	stacksCmd.AddCommand(stacksScopeCmd)
+	rootCmd.AddCommand(stacksCmd)
+}
+
+func runStacks() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	stacks := stack.Stacks(ctx)
+	if len(stacks) == 0 {
+		ui.Info("No stacks yet. Create one with 'stak create'")
+		return nil
+	}
+
+	// One query for the PRs of every stack
+	var prNumbers []int
+	for _, info := range stacks {
+		for _, branch := range info.Branches {
+			if n := ctx.PRNumber(branch); n > 0 {
+				prNumbers = append(prNumbers, n)
+			}
+		}
+	}
+	var states map[int]string
+	if len(prNumbers) > 0 && github.IsGHAuthenticated() {
+		if states, err = github.GetPRStates(prNumbers); err != nil {
+			ui.Warning(err.Error())
+		}
+	}
+
+	current, _ := git.GetCurrentBranch()
+	currentStack, _ := stack.StackOf(ctx, current)
+
+	fmt.Printf("  %-20s %-30s %-9s %s\n", "Name", "Root", "Branches", "PRs")
+	for _, info := range stacks {
+		marker := " "
+		if info.Root == currentStack.Root {
+			marker = "*"
+		}
+		name := info.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Printf("%s %-20s %-30s %-9d %s\n", marker, name, info.Root, len(info.Branches), stackPRSummary(ctx, info, states))
+	}
+	return nil
+}
+
+// stackPRSummary counts the PRs of a stack by state, e.g. "2 open, 1 merged, 1 without PR".
+// Without states (gh unavailable), PRs are only counted.
+func stackPRSummary(ctx *stack.StackContext, info stack.StackInfo, states map[int]string) string {
+	counts := make(map[string]int)
+	var order []string
+	count := func(label string) {
+		if counts[label] == 0 {
+			order = append(order, label)
+		}
+		counts[label]++
+	}
+
+	for _, branch := range info.Branches {
+		prNumber := ctx.PRNumber(branch)
+		switch {
+		case prNumber == 0:
+			count("without PR")
+		case states == nil:
+			count("with PR")
+		case states[prNumber] == "":
+			count("unknown")
+		default:
+			count(strings.ToLower(states[prNumber]))
+		}
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, label := range order {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[label], label))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runStacksName(name string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	info, ok := stack.StackOf(ctx, currentBranch)
+	if !ok {
+		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+
+	switch {
+	case stacksNameClear:
+		if err := stack.SetStackName(ctx, info, ""); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Removed the name of the stack of %s", info.Root))
+	case name == "":
+		if info.Name == "" {
+			ui.Info(fmt.Sprintf("The stack of %s has no name. Name it with 'stak stacks name <name>'", info.Root))
+		} else {
+			fmt.Println(info.Name)
+		}
+	default:
+		if err := stack.SetStackName(ctx, info, name); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Named the stack of %s %q", info.Root, name))
+	}
+	return nil
+}
+
+func runStacksDepend(dependency string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	info, ok := stack.StackOf(ctx, currentBranch)
+	if !ok {
+		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+
+	switch {
+	case stacksDependClear:
+		if err := stack.SetStackDependency(ctx, info, ""); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("The stack of %s no longer depends on anything", info.Root))
+	case dependency == "":
+		if info.DependsOn == "" {
+			ui.Info(fmt.Sprintf("The stack of %s has no dependency. Add one with 'stak stacks depend <stack|#pr>'", info.Root))
+			return nil
+		}
+		fmt.Println(info.DependsOn)
+		if github.IsGHAuthenticated() {
+			waiting, err := unmetDependency(ctx, currentBranch)
+			if err != nil {
+				return err
+			}
+			if waiting == "" {
+				ui.Success("Merged, nothing to wait for")
+			} else {
+				ui.Info(fmt.Sprintf("Waiting for %s", waiting))
+			}
+		}
+	default:
+		if err := stack.SetStackDependency(ctx, info, dependency); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("The stack of %s now depends on %s", info.Root, dependency))
+	}
+	return nil
+}
+
+func runStacksScope(dir string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	info, ok := stack.StackOf(ctx, currentBranch)
+	if !ok {
+		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+
+	switch {
+	case stacksScopeClear:
+		if err := stack.SetStackScope(info, ""); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Removed the scope of the stack of %s", info.Root))
+	case dir == "":
+		if info.Scope == "" {
+			ui.Info(fmt.Sprintf("The stack of %s has no scope. Tag it with 'stak stacks scope <dir>'", info.Root))
+		} else {
+			fmt.Println(info.Scope)
+		}
+	default:
+		scope, err := resolveScope(dir)
+		if err != nil {
+			return err
+		}
+		if err := stack.SetStackScope(info, scope); err != nil {
+			return err
+		}
+		ui.Success(fmt.Sprintf("Scoped the stack of %s to %s/", info.Root, scope))
+	}
+	return nil
+}
+
+// findStackFlag resolves the --stack flag of commands that can act on a stack other than
+// the current one, without checking out any of its branches
+func findStackFlag(ref string) (*stack.StackContext, stack.StackInfo, error) {
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return nil, stack.StackInfo{}, fmt.Errorf("failed to load stack: %w", err)
+	}
+	info, err := stack.FindStack(ctx, ref)
+	if err != nil {
+		return nil, stack.StackInfo{}, err
+	}
+	return ctx, info, nil
+}
+
+// keepBranches returns the branches that are also in keep, in their original order
+func keepBranches(branches, keep []string) []string {
+	kept := make(map[string]bool, len(keep))
+	for _, branch := range keep {
+		kept[branch] = true
+	}
+	var result []string
+	for _, branch := range branches {
+		if kept[branch] {
+			result = append(result, branch)
+		}
+	}
+	return result
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var (
+	statsAll      bool
+	statsJSON     bool
+	statsNoGitHub bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:     "stats",
+	Aliases: []string{"st"},
+	Short:   "Show statistics for the branches and PRs of a stack",
+	Long: `Show per-branch and per-stack statistics: commits, files changed and lines added and
+removed relative to the parent, plus PR age and review latency (time from opening the PR to
+its first review by someone other than the author).
+
+Covers the current stack by default, or every stack with --all. Run from trunk, it covers
+every stack based on trunk. With --json, prints a JSON document for dashboards; durations
+are in seconds.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runStats(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsAll, "all", false, "Show every stack, not just the current one")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Print JSON instead of text")
+	statsCmd.Flags().BoolVar(&statsNoGitHub, "no-github", false, "Skip PR age and review latency, so no GitHub calls are made")
+	rootCmd.AddCommand(statsCmd)
+}
+
+// branchStats is the statistics of one branch, relative to its parent
+type branchStats struct {
+	Name          string `json:"name"`
+	Parent        string `json:"parent"`
+	Commits       int    `json:"commits"`
+	FilesChanged  int    `json:"files_changed"`
+	Insertions    int    `json:"insertions"`
+	Deletions     int    `json:"deletions"`
+	PRNumber      int    `json:"pr,omitempty"`
+	PRState       string `json:"pr_state,omitempty"`
+	PRCreatedAt   string `json:"pr_created_at,omitempty"`
+	PRAge         int64  `json:"pr_age,omitempty"`
+	FirstReviewAt string `json:"first_review_at,omitempty"`
+	ReviewLatency int64  `json:"review_latency,omitempty"`
+}
+
+// stackStats is one stack, from the branch based on an untracked branch upwards
+type stackStats struct {
+	Root     string        `json:"root"`
+	Base     string        `json:"base"`
+	Branches []branchStats `json:"branches"`
+	Totals   stackTotals   `json:"totals"`
+}
+
+// stackTotals adds up a stack's branches. Review latency is averaged over reviewed PRs
+type stackTotals struct {
+	Branches         int   `json:"branches"`
+	Commits          int   `json:"commits"`
+	FilesChanged     int   `json:"files_changed"`
+	Insertions       int   `json:"insertions"`
+	Deletions        int   `json:"deletions"`
+	PRs              int   `json:"prs"`
+	OldestPRAge      int64 `json:"oldest_pr_age,omitempty"`
+	AvgReviewLatency int64 `json:"avg_review_latency,omitempty"`
+}
+
+func runStats() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	var roots []string
+	switch {
+	case statsAll:
+		for _, branch := range stack.GetAllBranchesInOrder(ctx.Stack) {
+			if branch.Parent != "" && !ctx.IsTracked(branch.Parent) {
+				roots = append(roots, branch.Name)
+			}
+		}
+	case !ctx.IsTracked(currentBranch):
+		roots = stacksBasedOn(ctx, currentBranch)
+	default:
+		roots = []string{stackRoot(ctx, currentBranch)}
+	}
+	if len(roots) == 0 {
+		if statsAll {
+			ui.Warning("No stack branches found")
+			return nil
+		}
+		return fmt.Errorf("branch %s is not part of a stack", currentBranch)
+	}
+
+	now := time.Now()
+	stacks := []stackStats{}
+	for _, root := range roots {
+		s := stackStats{Root: root, Base: ctx.Parent(root), Branches: []branchStats{}}
+		for _, branch := range append([]string{root}, ctx.Descendants(root)...) {
+			stats, err := collectBranchStats(branch, ctx.Parent(branch), ctx.PRNumber(branch), now)
+			if err != nil {
+				return err
+			}
+			s.Branches = append(s.Branches, stats)
+		}
+		s.Totals = sumStackStats(s.Branches)
+		stacks = append(stacks, s)
+	}
+
+	if statsJSON {
+		output, err := json.MarshalIndent(struct {
+			Stacks []stackStats `json:"stacks"`
+		}{stacks}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode stats: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	for i, s := range stacks {
+		if i > 0 {
+			fmt.Println()
+		}
+		displayStackStats(s)
+	}
+	return nil
+}
+
+// collectBranchStats gathers the git statistics of a branch and, unless --no-github, its PR's
+func collectBranchStats(branch, parent string, prNumber int, now time.Time) (branchStats, error) {
+	stats := branchStats{Name: branch, Parent: parent, PRNumber: prNumber}
+
+	commits, err := getCommitCount(branch, parent)
+	if err != nil {
+		return stats, fmt.Errorf("failed to count commits on %s: %w", branch, err)
+	}
+	diff, err := git.GetDiffStat(parent, branch)
+	if err != nil {
+		return stats, err
+	}
+	stats.Commits = commits
+	stats.FilesChanged = diff.Files
+	stats.Insertions = diff.Insertions
+	stats.Deletions = diff.Deletions
+
+	if prNumber == 0 || statsNoGitHub {
+		return stats, nil
+	}
+	timeline, err := github.GetPRTimeline(prNumber)
+	if err != nil {
+		// Keep the git statistics; the PR may have been deleted or GitHub be unreachable
+		if !statsJSON {
+			ui.Warning(err.Error())
+		}
+		return stats, nil
+	}
+	stats.PRState = timeline.State
+	stats.PRCreatedAt = timeline.CreatedAt.Format(time.RFC3339)
+	stats.PRAge = int64(timeline.Age(now).Seconds())
+	if latency := timeline.ReviewLatency(); latency > 0 {
+		stats.FirstReviewAt = timeline.FirstReviewAt.Format(time.RFC3339)
+		stats.ReviewLatency = int64(latency.Seconds())
+	}
+	return stats, nil
+}
+
+func sumStackStats(branches []branchStats) stackTotals {
+	totals := stackTotals{Branches: len(branches)}
+	var latencySum int64
+	reviewed := 0
+	for _, b := range branches {
+		totals.Commits += b.Commits
+		totals.FilesChanged += b.FilesChanged
+		totals.Insertions += b.Insertions
+		totals.Deletions += b.Deletions
+		if b.PRCreatedAt == "" {
+			continue
+		}
+		totals.PRs++
+		if b.PRAge > totals.OldestPRAge {
+			totals.OldestPRAge = b.PRAge
+		}
+		if b.ReviewLatency > 0 {
+			latencySum += b.ReviewLatency
+			reviewed++
+		}
+	}
+	if reviewed > 0 {
+		totals.AvgReviewLatency = latencySum / int64(reviewed)
+	}
+	return totals
+}
+
+func displayStackStats(s stackStats) {
+	fmt.Printf("Stack %s (on %s)\n", s.Root, s.Base)
+	for _, b := range s.Branches {
+		fmt.Printf("  %s\n", b.Name)
+		fmt.Printf("    %d commit(s), %d file(s) changed, +%d -%d\n", b.Commits, b.FilesChanged, b.Insertions, b.Deletions)
+		if b.PRCreatedAt == "" {
+			if b.PRNumber > 0 {
+				fmt.Printf("    PR #%d\n", b.PRNumber)
+			}
+			continue
+		}
+		review := "no review yet"
+		if b.ReviewLatency > 0 {
+			review = "first review after " + formatDuration(b.ReviewLatency)
+		}
+		fmt.Printf("    PR #%d %s, age %s, %s\n", b.PRNumber, strings.ToLower(b.PRState), formatDuration(b.PRAge), review)
+	}
+
+	t := s.Totals
+	fmt.Printf("  Total: %d branch(es), %d commit(s), %d file(s) changed, +%d -%d\n",
+		t.Branches, t.Commits, t.FilesChanged, t.Insertions, t.Deletions)
+	if t.PRs > 0 {
+		line := fmt.Sprintf("  %d PR(s), oldest %s", t.PRs, formatDuration(t.OldestPRAge))
+		if t.AvgReviewLatency > 0 {
+			line += ", average time to first review " + formatDuration(t.AvgReviewLatency)
+		}
+		fmt.Println(line)
+	}
+}
+
+// formatDuration renders seconds as days and hours, or hours and minutes when shorter
+func formatDuration(seconds int64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", d/time.Hour, d%time.Hour/time.Minute)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
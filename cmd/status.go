@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var statusPorcelain bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the current stack",
+	Long: `Show where the current branch is in its stack, and for every branch of the stack how far it
+is ahead (↑) and behind (↓) its parent and its copy on the remote, as of the last fetch,
+and whether it needs a restack or a push. A rebase stopped on conflicts is pointed out.
+
+With --porcelain, prints one tab-separated line per branch of the stack, parents first:
+  <branch> <parent> <pr-number, 0 if none> <ahead> <behind> <remote-ahead> <remote-behind>
+  <needs-restack: true|false> <needs-push: true|false> <current: true|false>
+
+Prints nothing with --porcelain on a branch that isn't in a stack.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runStatus(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusPorcelain, "porcelain", false, "Stable tab-separated output for scripts")
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus() error {
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	info, inStack := stack.StackOf(ctx, currentBranch)
+
+	if statusPorcelain {
+		if !inStack {
+			return nil
+		}
+		for _, branch := range info.Branches {
+			b := ctx.Metadata(branch)
+			state := getBranchSync(b)
+			printPorcelain(b.Name, b.Parent, strconv.Itoa(b.PRNumber),
+				strconv.Itoa(state.ahead), strconv.Itoa(state.behind),
+				strconv.Itoa(state.remoteAhead), strconv.Itoa(state.remoteBehind),
+				porcelainBool(state.needsRestack()), porcelainBool(state.needsPush()),
+				porcelainBool(b.Name == currentBranch))
+		}
+		return nil
+	}
+
+	if inProgress, err := git.IsRebaseInProgress(); err == nil && inProgress {
+		ui.Warning("A rebase is in progress. Resolve the conflicts and run 'stak restack --continue'")
+	}
+	if !inStack {
+		ui.Info(fmt.Sprintf("On branch %s, which isn't in a stack", currentBranch))
+		return nil
+	}
+
+	name := info.Name
+	if name == "" {
+		name = "the stack of " + info.Root
+	}
+	fmt.Printf("On branch %s in %s, based on %s\n\n", currentBranch, name, info.Base)
+	for _, branch := range info.Branches {
+		marker := " "
+		if branch == currentBranch {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %s", marker, branch)
+		if pr := ctx.PRNumber(branch); pr > 0 {
+			line += fmt.Sprintf(" #%d", pr)
+		}
+		if state := getBranchSync(ctx.Metadata(branch)).String(); state != "" {
+			line += "  " + state
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -2,21 +2,29 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"stacking/internal/config"
 	"stacking/internal/git"
 	"stacking/internal/github"
+	"stacking/internal/profile"
 	"stacking/internal/stack"
 	"stacking/internal/ui"
 )
 
 var (
-	submitStack      bool
-	submitUpdateOnly bool
-	submitDraft      bool
+	submitStack        string
+	submitUpdateOnly   bool
+	submitDraft        bool
+	submitForce        bool
+	submitWaitChecks   bool
+	submitTimeout      time.Duration
+	submitInterval     time.Duration
+	submitNotify       bool
+	submitDeleteRemote bool
 )
 
 var submitCmd = &cobra.Command{
@@ -24,31 +32,74 @@ var submitCmd = &cobra.Command{
 	Aliases: []string{"s"},
 	Short:   "Create or update PRs in the stack",
 	Long: `Push branches and create or update pull requests for the current branch or entire stack.
-Does NOT merge PRs - use 'stak merge' to merge approved PRs.`,
+Does NOT merge PRs - use 'stak merge' to merge approved PRs.
+
+--stack submits the current branch and everything below it. Give it a stack name, as in
+--stack=payments, to submit every branch of that stack without checking it out.
+
+With --delete-remote, or the delete-remote setting, branches whose PR is already merged are
+deleted on the remote instead of pushed again.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// The stack name is optional, so in "--stack payments" payments is left as an argument
+		if len(args) > 0 {
+			return fmt.Errorf("unexpected argument %q. To submit a stack by name, use --stack=%s", args[0], args[0])
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		// The draft setting applies unless --draft is given explicitly
+		if !cmd.Flags().Changed("draft") {
+			submitDraft = config.GetBool("draft", false)
+		}
+		if !cmd.Flags().Changed("delete-remote") {
+			submitDeleteRemote = config.GetBool("delete-remote", false)
+		}
 		if err := runSubmit(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
-	submitCmd.Flags().BoolVarP(&submitStack, "stack", "s", false, "Submit entire stack from current branch")
+	submitCmd.Flags().StringVarP(&submitStack, "stack", "s", "", "Submit entire stack from current branch, or with --stack=<name> the named stack")
+	submitCmd.Flags().Lookup("stack").NoOptDefVal = currentStackRef
+	submitCmd.RegisterFlagCompletionFunc("stack", completeStackNames)
 	submitCmd.Flags().BoolVarP(&submitUpdateOnly, "update-only", "u", false, "Only update existing PRs, don't create new")
-	submitCmd.Flags().BoolVar(&submitDraft, "draft", false, "Create PRs as drafts")
+	submitCmd.Flags().BoolVar(&submitDraft, "draft", false, "Create PRs as drafts (default: draft setting)")
+	submitCmd.Flags().BoolVar(&submitForce, "force", false, "Push even if branches are owned by someone else")
+	submitCmd.Flags().BoolVar(&submitWaitChecks, "wait-checks", false, "Wait for CI checks to finish after pushing")
+	submitCmd.Flags().DurationVar(&submitTimeout, "checks-timeout", 30*time.Minute, "How long to wait for checks with --wait-checks")
+	submitCmd.Flags().DurationVar(&submitInterval, "checks-interval", 15*time.Second, "How often to poll checks with --wait-checks")
+	submitCmd.Flags().BoolVar(&submitDeleteRemote, "delete-remote", false, "Delete branches whose PR is merged on the remote instead of pushing them (default: delete-remote setting)")
+	submitCmd.Flags().BoolVar(&submitNotify, "notify", false, "Send a desktop notification when --wait-checks finishes")
 	rootCmd.AddCommand(submitCmd)
 }
 
 func runSubmit() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Check if gh CLI is authenticated
 	if !github.IsGHAuthenticated() {
-		return fmt.Errorf("gh CLI not authenticated. Run: gh auth login")
+		return errNotAuthenticated
+	}
+
+	// With a stack name, submit that whole stack wherever we are
+	if submitStack != "" && submitStack != currentStackRef {
+		_, info, err := findStackFlag(submitStack)
+		if err != nil {
+			return err
+		}
+		originalBranch, err := git.GetCurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+		if err := submitBranches(info.Branches); err != nil {
+			return err
+		}
+		return returnToOriginalOrAlternative(originalBranch)
 	}
 
 	// Get current branch
@@ -70,7 +121,7 @@ func runSubmit() error {
 
 	// Build list of branches to submit
 	var branchesToSubmit []string
-	if submitStack {
+	if submitStack != "" {
 		// Submit entire chain: ancestors + current
 		ancestors, err := stack.GetAncestors(currentBranch)
 		if err != nil {
@@ -82,12 +133,34 @@ func runSubmit() error {
 		branchesToSubmit = []string{currentBranch}
 	}
 
+	return submitBranches(branchesToSubmit)
+}
+
+// submitBranches pushes and creates or updates the PRs of the branches, parents first
+func submitBranches(branchesToSubmit []string) error {
+	if err := checkStackOwnership("force-push", branchesToSubmit, submitForce); err != nil {
+		return err
+	}
+
+	// PRs can be reviewed before their dependency lands, so this only warns
+	if err := checkStackDependency("submit", branchesToSubmit[0], true); err != nil {
+		return err
+	}
+
 	ui.Info(fmt.Sprintf("Submitting %d branch(es)", len(branchesToSubmit)))
 
 	// Fetch latest
+	done := profile.Phase("fetch")
 	if err := git.Fetch(); err != nil {
 		return fmt.Errorf("failed to fetch: %w", err)
 	}
+	done()
+
+	// A closed PR can't be updated, so ask what to do about it first
+	branchesToSubmit, err := handleClosedPRs(branchesToSubmit)
+	if err != nil {
+		return err
+	}
 
 	// Submit each branch in order
 	for _, branch := range branchesToSubmit {
@@ -97,10 +170,53 @@ func runSubmit() error {
 	}
 
 	ui.Success("All PRs created/updated successfully")
+
+	if submitWaitChecks {
+		if err := waitForSubmittedChecks(branchesToSubmit); err != nil {
+			return err
+		}
+	}
+
 	ui.Info("To merge approved PRs, run: stak merge")
 	return nil
 }
 
+// waitForSubmittedChecks blocks until CI finishes on every submitted PR and reports the outcome
+func waitForSubmittedChecks(branches []string) error {
+	// Give GitHub a moment to register checks for the commits we just pushed
+	time.Sleep(submitInterval)
+
+	failing := 0
+	for _, branch := range branches {
+		metadata, err := stack.ReadBranchMetadata(branch)
+		if err != nil || metadata.PRNumber == 0 {
+			continue
+		}
+
+		status, err := waitForChecks(metadata.PRNumber, submitTimeout, submitInterval)
+		if err != nil {
+			return err
+		}
+
+		if status.IsCIPassing() {
+			ui.Success(fmt.Sprintf("Checks passed on PR #%d (%s)", metadata.PRNumber, branch))
+		} else {
+			ui.Warning(fmt.Sprintf("Checks failed on PR #%d (%s)", metadata.PRNumber, branch))
+			notifyEvent(submitNotify, fmt.Sprintf("CI failed on branch %s (PR #%d)", branch, metadata.PRNumber))
+			failing++
+		}
+	}
+
+	if failing == 0 {
+		notifyEvent(submitNotify, "CI passed on all submitted PRs")
+	}
+
+	if failing > 0 {
+		return fmt.Errorf("%d PR(s) have failing CI checks. Run 'stak checks --failed' for details", failing)
+	}
+	return nil
+}
+
 func createPRForBranch(branchName string) error {
 	// Read metadata to get parent branch
 	metadata, err := stack.ReadBranchMetadata(branchName)
@@ -132,8 +248,11 @@ func createPRForBranch(branchName string) error {
 	ui.Info(fmt.Sprintf("Using commit message as PR title: %s", prTitle))
 
 	// Push branch to remote
-	ui.Info(fmt.Sprintf("Pushing branch %s to origin", branchName))
-	if err := git.Push(branchName, true, false); err != nil {
+	ui.Info(fmt.Sprintf("Pushing branch %s to %s", branchName, git.Remote))
+	done := profile.Phase("push")
+	err = git.Push(branchName, true, false)
+	done()
+	if err != nil {
 		return fmt.Errorf("failed to push branch: %w", err)
 	}
 
@@ -141,6 +260,9 @@ func createPRForBranch(branchName string) error {
 	ui.Info(fmt.Sprintf("Creating PR: %s → %s", branchName, parentBranch))
 
 	// Pass title but empty body - body will be auto-filled from commits
+	if prTitle == "" && !github.CanFillFirst() {
+		ui.Warning(fmt.Sprintf("gh %s can't title the PR after its first commit (2.31 or later is needed); filling it from all commits", github.GetVersion()))
+	}
 	prNumber, err := github.CreatePR(parentBranch, branchName, prTitle, "", submitDraft)
 	if err != nil {
 		return fmt.Errorf("failed to create PR: %w", err)
@@ -150,6 +272,7 @@ func createPRForBranch(branchName string) error {
 	if err := stack.WriteBranchMetadata(branchName, parentBranch, prNumber); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
+	recordOwner(branchName)
 
 	// Get PR URL
 	prURL, err := github.GetPRURL(prNumber)
@@ -170,47 +293,35 @@ func createPRForBranch(branchName string) error {
 }
 
 func updateStackComments(branchName string) error {
-	// Get all ancestors
-	ancestors, err := stack.GetAncestors(branchName)
-	if err != nil {
-		return fmt.Errorf("failed to get ancestors: %w", err)
-	}
+	defer profile.Phase("comments")()
 
-	// Get all descendants
-	descendants, err := stack.GetDescendants(branchName)
+	// Load metadata once; every comment below is rendered from this snapshot
+	ctx, err := stack.LoadContext()
 	if err != nil {
-		return fmt.Errorf("failed to get descendants: %w", err)
+		return fmt.Errorf("failed to load stack: %w", err)
 	}
 
-	// Build the full stack
-	fullStack := append(ancestors, branchName)
-	fullStack = append(fullStack, descendants...)
-
 	// Update comment on each PR in the stack
-	for _, branch := range fullStack {
-		metadata, err := stack.ReadBranchMetadata(branch)
-		if err != nil {
-			continue
-		}
-
-		if metadata.PRNumber == 0 {
+	for _, branch := range ctx.FullStack(branchName) {
+		prNumber := ctx.PRNumber(branch)
+		if prNumber == 0 {
 			continue
 		}
 
 		// Generate visualization for this branch
-		visualization, err := stack.GenerateStackVisualization(branch)
+		visualization, err := stack.GenerateStackVisualization(ctx, branch)
 		if err != nil {
 			ui.Warning(fmt.Sprintf("Failed to generate visualization for %s: %v", branch, err))
 			continue
 		}
 
 		// Post comment
-		if err := github.CommentOnPR(metadata.PRNumber, visualization); err != nil {
-			ui.Warning(fmt.Sprintf("Failed to comment on PR #%d: %v", metadata.PRNumber, err))
+		if err := github.CommentOnPR(prNumber, visualization); err != nil {
+			ui.Warning(fmt.Sprintf("Failed to comment on PR #%d: %v", prNumber, err))
 			continue
 		}
 
-		ui.Info(fmt.Sprintf("Updated stack comment on PR #%d", metadata.PRNumber))
+		ui.Info(fmt.Sprintf("Updated stack comment on PR #%d", prNumber))
 	}
 
 	return nil
@@ -255,6 +366,19 @@ func submitBranch(branch string) error {
 
 	// PR exists - push updates
 	prNumber := metadata.PRNumber
+
+	// Pushing the branch of a merged PR would bring back what --delete-remote deletes
+	if submitDeleteRemote {
+		status, err := github.GetPRStatus(prNumber)
+		if err != nil {
+			return err
+		}
+		if status.IsMerged() {
+			ui.Info(fmt.Sprintf("PR #%d is merged; run 'stak sync' to clean up %s", prNumber, branch))
+			deleteMergedRemoteBranch(branch)
+			return nil
+		}
+	}
 	ui.Info(fmt.Sprintf("Updating PR #%d for branch %s", prNumber, branch))
 
 	// Checkout the branch
@@ -266,8 +390,11 @@ func submitBranch(branch string) error {
 	}
 
 	// Push latest changes (force push for existing PRs since commits may have been amended)
-	ui.Info(fmt.Sprintf("Pushing %s to origin (force push)", branch))
-	if err := git.Push(branch, false, true); err != nil {
+	ui.Info(fmt.Sprintf("Pushing %s to %s (force push)", branch, git.Remote))
+	done := profile.Phase("push")
+	err = git.Push(branch, false, true)
+	done()
+	if err != nil {
 		return fmt.Errorf("failed to push branch: %w", err)
 	}
 
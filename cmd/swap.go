@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var swapForce bool
+
+var swapCmd = &cobra.Command{
+	Use:     "swap [branch]",
+	Aliases: []string{"sw"},
+	Short:   "Swap a branch with its parent",
+	Long: `Swap a branch with its parent, so the branch comes first in the stack.
+
+The branch is rebased onto its grandparent without the parent's commits, and the parent is
+rebased on top of it. The branch's children move onto the parent, which is now the upper of
+the two, and every descendant is restacked. PR bases are retargeted, and the branches that
+were already pushed are force-pushed.
+
+If a rebase conflicts, resolve it and run 'stak restack --continue', then 'stak push'.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
+	Run: func(cmd *cobra.Command, args []string) {
+		branchName := ""
+		if len(args) > 0 {
+			branchName = args[0]
+		}
+
+		if err := runSwap(branchName); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	swapCmd.Flags().BoolVarP(&swapForce, "force", "f", false, "Skip confirmation prompts")
+	rootCmd.AddCommand(swapCmd)
+}
+
+func runSwap(branchName string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	currentBranch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if branchName == "" {
+		branchName = currentBranch
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	if !ctx.IsTracked(branchName) {
+		return fmt.Errorf("branch %s is not tracked", branchName)
+	}
+	parent := ctx.Parent(branchName)
+	if !ctx.IsTracked(parent) {
+		return fmt.Errorf("%s is based on %s, which is not a stack branch, so there is nothing to swap it with", branchName, parent)
+	}
+	grandparent := ctx.Parent(parent)
+
+	for _, branch := range []string{branchName, parent} {
+		if err := git.CheckNotProtected(branch, "swap"); err != nil {
+			return err
+		}
+	}
+	if err := checkCanRestack("swap", branchName); err != nil {
+		return err
+	}
+
+	// The parent and everything above it is rewritten
+	affected := append([]string{parent}, ctx.Descendants(parent)...)
+	if err := checkStackOwnership("swap", affected, swapForce); err != nil {
+		return err
+	}
+
+	children := ctx.Children(branchName)
+	branchPR, parentPR := ctx.PRNumber(branchName), ctx.PRNumber(parent)
+
+	// Show confirmation
+	if !swapForce {
+		ui.Info("This will:")
+		ui.Info(fmt.Sprintf("  - Rebase %s onto %s, without the commits of %s", branchName, grandparent, parent))
+		ui.Info(fmt.Sprintf("  - Rebase %s onto %s", parent, branchName))
+		if len(children) > 0 {
+			ui.Info(fmt.Sprintf("  - Move %d child branch(es) of %s onto %s", len(children), branchName, parent))
+		}
+		if branchPR > 0 || parentPR > 0 {
+			ui.Info("  - Retarget their PRs and force-push the rewritten branches")
+		}
+
+		label := "Proceed with swap?"
+		if checkReorderOverlap([]string{parent, branchName}, []string{branchName, parent}) > 0 {
+			label = "Proceed with swap anyway?"
+		}
+		prompt := promptui.Select{
+			Label: label,
+			Items: []string{"Yes", "No"},
+		}
+
+		_, result, err := runSelect(&prompt)
+		if err != nil || result == "No" {
+			ui.Info("Swap cancelled")
+			return nil
+		}
+	}
+
+	recordRestackUpstreams(ctx, affected)
+
+	// Swap the two branches, and hand the branch's children to the parent
+	ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", branchName, parent, grandparent))
+	if err := stack.WriteBranchMetadata(branchName, grandparent, branchPR); err != nil {
+		return fmt.Errorf("failed to update metadata for %s: %w", branchName, err)
+	}
+	ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", parent, grandparent, branchName))
+	if err := stack.WriteBranchMetadata(parent, branchName, parentPR); err != nil {
+		return fmt.Errorf("failed to update metadata for %s: %w", parent, err)
+	}
+	for _, child := range children {
+		ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", child, branchName, parent))
+		if err := stack.WriteBranchMetadata(child, parent, ctx.PRNumber(child)); err != nil {
+			ui.Warning(fmt.Sprintf("Could not update metadata for %s: %v", child, err))
+		}
+	}
+
+	retargetPR(branchPR, grandparent)
+	retargetPR(parentPR, branchName)
+	for _, child := range children {
+		retargetPR(ctx.PRNumber(child), parent)
+	}
+
+	restacked, err := restackSubtrees([]string{branchName}, currentBranch)
+	if err != nil {
+		ui.Info("After the restack, run 'stak push' to update the PRs")
+		return err
+	}
+
+	if err := pushRestacked(restacked); err != nil {
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Swapped %s and %s: %s → %s → %s", branchName, parent, grandparent, branchName, parent))
+	return nil
+}
+
+// retargetPR changes the base of a PR, if there is one, warning if that fails
+func retargetPR(prNumber int, base string) {
+	if prNumber == 0 {
+		return
+	}
+	if err := github.UpdatePRBase(prNumber, base); err != nil {
+		ui.Warning(fmt.Sprintf("Could not update PR #%d base: %v", prNumber, err))
+	} else {
+		ui.Success(fmt.Sprintf("Updated PR #%d base to %s", prNumber, base))
+	}
+}
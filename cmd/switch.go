@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var switchCmd = &cobra.Command{
+	Use:   "switch <stack>",
+	Short: "Check out the tip of a stack",
+	Long: `Check out the last branch of a stack, given its name or the name of any of its branches.
+If the stack forks, pick which tip to check out.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeStackNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSwitch(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(switchCmd)
+}
+
+func runSwitch(ref string) error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+	info, err := stack.FindStack(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	tip, err := selectStackTip(ctx, info)
+	if err != nil {
+		return err
+	}
+
+	currentBranch, _ := git.GetCurrentBranch()
+	if currentBranch == tip {
+		ui.Info(fmt.Sprintf("Already on %s", tip))
+		return nil
+	}
+	if err := git.CheckoutBranch(tip); err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %w", tip, err)
+	}
+
+	name := info.Name
+	if name == "" {
+		name = "the stack of " + info.Root
+	}
+	ui.Success(fmt.Sprintf("Now on branch %s (tip of %s)", tip, name))
+	return nil
+}
+
+// selectStackTip returns the last branch of a stack, prompting for one if the stack forks
+func selectStackTip(ctx *stack.StackContext, info stack.StackInfo) (string, error) {
+	var tips []string
+	for _, branch := range info.Branches {
+		if len(ctx.Children(branch)) == 0 {
+			tips = append(tips, branch)
+		}
+	}
+	if len(tips) == 1 {
+		return tips[0], nil
+	}
+
+	prompt := promptui.Select{
+		Label: "The stack forks. Select a tip",
+		Items: tips,
+	}
+	_, tip, err := runSelect(&prompt)
+	if err != nil {
+		return "", fmt.Errorf("branch selection cancelled: %w", err)
+	}
+	return tip, nil
+}
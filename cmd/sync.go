@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
+	"stacking/internal/config"
 	"stacking/internal/git"
 	"stacking/internal/github"
+	"stacking/internal/profile"
 	"stacking/internal/stack"
 	"stacking/internal/ui"
 )
@@ -15,6 +17,15 @@ var (
 	syncRecursive   bool
 	syncCurrentOnly bool
 	syncContinue    bool
+	syncForce       bool
+	syncNoGitHub    bool
+	syncNoPush      bool
+	syncMatch       []string
+	syncMergetool   bool
+	syncDryRun      bool
+	syncStack       string
+	syncScope       string
+	syncFullFetch   bool
 )
 
 var syncCmd = &cobra.Command{
@@ -22,11 +33,19 @@ var syncCmd = &cobra.Command{
 	Aliases: []string{"sy"},
 	Short:   "Sync stack with remote",
 	Long: `Sync the current branch and its children with remote changes.
-Rebases the current branch onto its parent and recursively syncs all child branches.`,
+Rebases the current branch onto its parent and recursively syncs all child branches.
+
+With --dry-run, fetches and then simulates every rebase with git merge-tree, reporting which
+branches would conflict and in which files. Nothing is checked out, rebased, pushed or cleaned up.
+
+With --stack, only syncs the named stack, e.g. --stack payments, whichever branch is checked out.
+With --scope, only syncs the stacks relevant to a directory, e.g. --scope services/payments.
+
+Only the synced branches, their parents and trunk are fetched, which keeps sync fast in
+repositories with many refs. Use --full-fetch to fetch everything.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runSync(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -35,13 +54,31 @@ func init() {
 	syncCmd.Flags().BoolVarP(&syncRecursive, "recursive", "r", true, "Sync child branches recursively")
 	syncCmd.Flags().BoolVar(&syncCurrentOnly, "current-only", false, "Only sync current branch, skip children")
 	syncCmd.Flags().BoolVar(&syncContinue, "continue", false, "Continue sync after resolving conflicts")
+	syncCmd.Flags().BoolVar(&syncForce, "force", false, "Rebase and push even if branches are owned by someone else")
+	syncCmd.Flags().BoolVar(&syncNoGitHub, "no-github", false, "Skip GitHub checks for merged PRs (local-only sync)")
+	syncCmd.Flags().StringSliceVar(&syncMatch, "match", nil, "Only sync branches matching these glob patterns (repeatable)")
+	syncCmd.Flags().BoolVar(&syncMergetool, "mergetool", false, "Resolve rebase conflicts with git mergetool, then continue automatically")
+	syncCmd.Flags().BoolVar(&syncNoPush, "no-push", false, "Rebase locally without force-pushing or updating PRs")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Only predict which branches would conflict, without changing anything")
+	syncCmd.Flags().StringVar(&syncStack, "stack", "", "Only sync the stack with this name, or containing this branch")
+	syncCmd.RegisterFlagCompletionFunc("stack", completeStackNames)
+	syncCmd.Flags().StringVar(&syncScope, "scope", "", "Only sync the stacks relevant to this directory of a monorepo")
+	syncCmd.MarkFlagDirname("scope")
+	syncCmd.Flags().BoolVar(&syncFullFetch, "full-fetch", false, "Fetch every ref from the remote, not only the stack's branches and trunk")
+	addVerifyFlags(syncCmd)
 	rootCmd.AddCommand(syncCmd)
 }
 
 func runSync() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
+	}
+
+	githubEnabled = !syncNoGitHub
+
+	if syncDryRun && syncContinue {
+		return fmt.Errorf("--dry-run and --continue cannot be used together")
 	}
 
 	// Handle --continue flag
@@ -49,13 +86,17 @@ func runSync() error {
 		return continueSyncAfterConflict()
 	}
 
+	if err := validatePatterns(syncMatch); err != nil {
+		return err
+	}
+
 	// Check if there's already a rebase in progress
 	inProgress, err := git.IsRebaseInProgress()
 	if err != nil {
 		return fmt.Errorf("failed to check rebase status: %w", err)
 	}
 	if inProgress {
-		return fmt.Errorf("rebase already in progress. Resolve conflicts and run: stak sync --continue")
+		return fmt.Errorf("%w. Resolve conflicts and run: stak sync --continue", errRebaseInProgress)
 	}
 
 	// Get current branch to return to it later
@@ -64,12 +105,6 @@ func runSync() error {
 		return fmt.Errorf("failed to get current branch: %w", err)
 	}
 
-	// Fetch from remote
-	ui.Info("Fetching from remote")
-	if err := git.Fetch(); err != nil {
-		return fmt.Errorf("failed to fetch: %w", err)
-	}
-
 	// Get ALL branches with stack metadata
 	allStackBranches, err := stack.GetAllStackBranches()
 	if err != nil {
@@ -81,11 +116,71 @@ func runSync() error {
 		return nil
 	}
 
-	ui.Info(fmt.Sprintf("Syncing %d stack branch(es)", len(allStackBranches)))
+	// With --stack, only the branches of that stack are synced, and with --scope only
+	// those of the stacks relevant to the directory
+	var stackBranches []string
+	if syncStack != "" {
+		_, info, err := findStackFlag(syncStack)
+		if err != nil {
+			return err
+		}
+		stackBranches = info.Branches
+	}
+	if syncScope != "" {
+		scope, err := resolveScope(syncScope)
+		if err != nil {
+			return err
+		}
+		ctx, err := stack.LoadContext()
+		if err != nil {
+			return fmt.Errorf("failed to load stack: %w", err)
+		}
+		inScope := scopeBranches(ctx, scope)
+		if syncStack != "" {
+			inScope = keepBranches(stackBranches, inScope)
+		}
+		stackBranches = inScope
+	}
+
+	// With --match, only the matching branches are synced. The full list is still
+	// used to tell tracked parents from base branches
+	selectedBranches := selectSyncBranches(allStackBranches, stackBranches)
+	if len(selectedBranches) == 0 {
+		if len(syncMatch) > 0 {
+			ui.Warning("No stack branches match --match")
+		} else {
+			ui.Warning("No stacks are relevant to --scope")
+		}
+		return nil
+	}
+
+	// Fetch from remote: only the branches being synced and their parents, unless --full-fetch
+	done := profile.Phase("fetch")
+	if syncFullFetch {
+		ui.Info("Fetching from remote")
+		err = git.Fetch()
+	} else {
+		refs := syncFetchBranches(selectedBranches)
+		ui.Info(fmt.Sprintf("Fetching %d branch(es) from remote", len(refs)))
+		err = git.FetchBranches(refs)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	if err := deepenShallowClone(selectedBranches); err != nil {
+		return err
+	}
+	done()
+
+	if syncDryRun {
+		return predictSyncConflicts(selectedBranches)
+	}
+
+	ui.Info(fmt.Sprintf("Syncing %d stack branch(es)", len(selectedBranches)))
 
 	// Find all unique base branches and update them first
 	baseBranches := make(map[string]bool)
-	for _, branch := range allStackBranches {
+	for _, branch := range selectedBranches {
 		parent, err := stack.GetParent(branch)
 		if err != nil || parent == "" {
 			continue
@@ -106,6 +201,7 @@ func runSync() error {
 
 	// Update all base branches (main, etc.) from remote
 	// Only update if they exist locally
+	done = profile.Phase("update base branches")
 	for baseBranch := range baseBranches {
 		// Check if base branch exists locally before trying to update
 		exists, err := git.BranchExists(baseBranch)
@@ -124,32 +220,53 @@ func runSync() error {
 		}
 	}
 
-	// Clean up all merged branches first
-	ui.Info("Checking for merged branches")
-	for _, branch := range allStackBranches {
-		exists, err := git.BranchExists(branch)
-		if err != nil || !exists {
-			continue
+	done()
+
+	// Clean up all merged branches first. Those cleaned up count as synced
+	cleanedUp := selectedBranches
+	done = profile.Phase("cleanup")
+	if syncNoGitHub {
+		ui.Info("Skipping merged branch checks (--no-github)")
+	} else {
+		ui.Info("Checking for merged branches")
+		cleanupMergedBranches(selectedBranches)
+		if config.GetBool("parents-from-prs", false) {
+			convergePRBases(selectedBranches)
 		}
-		checkAndCleanupMergedBranch(branch)
 	}
+	done()
 
 	// Get updated list after cleanup
 	allStackBranches, err = stack.GetAllStackBranches()
 	if err != nil {
 		return fmt.Errorf("failed to get stack branches: %w", err)
 	}
+	selectedBranches = selectSyncBranches(allStackBranches, stackBranches)
+
+	// Ownership is checked against the GitHub login, so --no-github skips it too
+	if !syncNoGitHub {
+		if err := checkStackOwnership("rebase and force-push", selectedBranches, syncForce); err != nil {
+			return err
+		}
+	}
 
-	// Sync branches in dependency order (parents before children)
+	// Sync branches in dependency order (parents before children). syncedBranches holds those
+	// handled, synced holds those actually brought up to date
 	syncedBranches := make(map[string]bool)
-	maxIterations := len(allStackBranches) + 1
+	var synced []string
+	for _, branch := range cleanedUp {
+		if exists, err := git.BranchExists(branch); err == nil && !exists {
+			synced = append(synced, branch)
+		}
+	}
+	maxIterations := len(selectedBranches) + 1
 	iteration := 0
 
-	for len(syncedBranches) < len(allStackBranches) && iteration < maxIterations {
+	for len(syncedBranches) < len(selectedBranches) && iteration < maxIterations {
 		iteration++
 		progressMade := false
 
-		for _, branch := range allStackBranches {
+		for _, branch := range selectedBranches {
 			if syncedBranches[branch] {
 				continue
 			}
@@ -169,9 +286,9 @@ func runSync() error {
 				continue
 			}
 
-			// Check if parent is in stack
+			// Check if parent is being synced too
 			parentInStack := false
-			for _, b := range allStackBranches {
+			for _, b := range selectedBranches {
 				if b == parent {
 					parentInStack = true
 					break
@@ -181,7 +298,14 @@ func runSync() error {
 			// Can sync if: no parent, parent not in stack, or parent already synced
 			if parent == "" || !parentInStack || syncedBranches[parent] {
 				if err := syncBranch(branch); err != nil {
+					// A rebase stopped on conflicts needs the user: stop here and leave it in
+					// progress, so the exit code tells scripts and the daemon's status stays
+					if errors.Is(err, errConflict) || errors.Is(err, errRebaseInProgress) {
+						return err
+					}
 					ui.Warning(fmt.Sprintf("Failed to sync %s: %v", branch, err))
+				} else {
+					synced = append(synced, branch)
 				}
 				syncedBranches[branch] = true
 				progressMade = true
@@ -198,7 +322,16 @@ func runSync() error {
 		ui.Warning(fmt.Sprintf("Could not return to branch: %v", err))
 	}
 
+	// What the daemon flagged about the synced branches has now been handled. Branches left
+	// out by --stack, --scope or --match, or that failed, keep theirs
+	if err := stack.ClearSyncStatus(synced); err != nil {
+		ui.Warning(fmt.Sprintf("Could not clear sync status: %v", err))
+	}
+
 	ui.Success("Sync completed successfully")
+	if syncNoPush {
+		ui.Info("Nothing was pushed. Review the result, then run: stak push")
+	}
 	return nil
 }
 
@@ -231,31 +364,20 @@ func returnToOriginalOrAlternative(originalBranch string) error {
 		return git.CheckoutBranch(targetBranch)
 	}
 
-	// No stack branches left, go to main
-	ui.Info("No stack branches remaining, moving to main")
-	mainExists, err := git.BranchExists("main")
+	// No stack branches left, go to the trunk
+	trunk, err := stack.Trunk()
 	if err != nil {
-		return fmt.Errorf("failed to check if main exists: %w", err)
+		return fmt.Errorf("no suitable branch found: %w", err)
 	}
-
-	if mainExists {
-		return git.CheckoutBranch("main")
-	}
-
-	// Try master as fallback
-	masterExists, err := git.BranchExists("master")
-	if err != nil {
-		return fmt.Errorf("failed to check if master exists: %w", err)
-	}
-
-	if masterExists {
-		return git.CheckoutBranch("master")
-	}
-
-	return fmt.Errorf("no suitable branch found")
+	ui.Info(fmt.Sprintf("No stack branches remaining, moving to %s", trunk))
+	return git.CheckoutBranch(trunk)
 }
 
 func syncBranch(branch string) error {
+	if syncSkipped[branch] {
+		ui.Info(fmt.Sprintf("Not syncing %s", branch))
+		return nil
+	}
 	ui.Info(fmt.Sprintf("Syncing branch %s", branch))
 
 	// Get parent
@@ -269,34 +391,51 @@ func syncBranch(branch string) error {
 		return nil
 	}
 
-	// Check if remote parent branch exists
-	remoteParentExists, err := git.RemoteBranchExists(parent)
-	if err != nil {
-		return fmt.Errorf("failed to check if remote parent exists: %w", err)
-	}
-	if !remoteParentExists {
-		ui.Warning(fmt.Sprintf("Remote parent branch origin/%s does not exist, skipping sync for %s", parent, branch))
-		return nil
-	}
-
-	// Checkout the branch
-	if err := git.CheckoutBranch(branch); err != nil {
-		return fmt.Errorf("failed to checkout branch %s: %w", branch, err)
+	// Rebase onto the remote parent, which sync has just pushed. Without pushing,
+	// a tracked parent only exists in its rebased form locally
+	onto := git.RemoteRef(parent)
+	if tracked, _ := stack.HasStackMetadata(parent); syncNoPush && tracked {
+		onto = parent
+	} else {
+		// Check if remote parent branch exists
+		remoteParentExists, err := git.RemoteBranchExists(parent)
+		if err != nil {
+			return fmt.Errorf("failed to check if remote parent exists: %w", err)
+		}
+		if !remoteParentExists {
+			ui.Warning(fmt.Sprintf("Remote parent branch %s does not exist, skipping sync for %s", git.RemoteRef(parent), branch))
+			return nil
+		}
 	}
 
-	// Rebase onto parent
-	ui.Info(fmt.Sprintf("Rebasing %s onto origin/%s", branch, parent))
-	onto := fmt.Sprintf("origin/%s", parent)
-	if err := git.RebaseOnto(onto); err != nil {
-		if conflictErr, ok := err.(*git.RebaseConflictError); ok {
-			return handleRebaseConflict(branch, conflictErr)
+	// A branch already based on its parent's tip has nothing to rebase, so it isn't even
+	// checked out, and if the remote has it as it is, nothing to push either
+	if git.BranchContainsCommit(branch, onto) {
+		if err := stack.RecordParentSHA(branch, onto); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+		}
+		local, _ := git.GetCommitSHA(branch)
+		remote, _ := git.GetCommitSHA(git.RemoteRef(branch))
+		if syncNoPush || (local != "" && local == remote) {
+			ui.Success(fmt.Sprintf("%s is already up to date", branch))
+			return nil
+		}
+	} else {
+		if err := rebaseForSync(branch, parent, onto); err != nil {
+			return err
+		}
+		if syncNoPush {
+			ui.Success(fmt.Sprintf("Rebased %s (not pushed)", branch))
+			return nil
 		}
-		return fmt.Errorf("failed to rebase: %w", err)
 	}
 
 	// Push with force-with-lease
 	ui.Info(fmt.Sprintf("Force pushing %s", branch))
-	if err := git.Push(branch, false, true); err != nil {
+	done := profile.Phase("push")
+	err = git.Push(branch, false, true)
+	done()
+	if err != nil {
 		return fmt.Errorf("failed to push: %w", err)
 	}
 
@@ -304,6 +443,44 @@ func syncBranch(branch string) error {
 	return nil
 }
 
+// rebaseForSync checks out branch and rebases it onto onto, the ref of its parent sync rebases
+// on. It returns an error explaining how to continue if the rebase stops on conflicts.
+func rebaseForSync(branch, parent, onto string) error {
+	if err := git.CheckoutBranch(branch); err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %w", branch, err)
+	}
+
+	ui.Info(fmt.Sprintf("Rebasing %s onto %s", branch, onto))
+	done := profile.Phase("restack")
+	old, err := stack.Restack(branch, onto)
+	done()
+	if old != "" {
+		ui.Info(fmt.Sprintf("%s was rewritten since %s was last restacked, so only %s's own commits were replayed", parent, branch, branch))
+	}
+	if err == nil {
+		return nil
+	}
+
+	conflictErr, ok := err.(*git.RebaseConflictError)
+	if !ok {
+		return fmt.Errorf("failed to rebase: %w", err)
+	}
+	if !useMergetool(syncMergetool) {
+		return handleRebaseConflict(branch, conflictErr)
+	}
+	resolved, err := resolveWithMergetool(branch)
+	if err != nil {
+		return err
+	}
+	if !resolved {
+		return handleRebaseConflict(branch, conflictErr)
+	}
+	if err := stack.RecordParentSHA(branch, onto); err != nil {
+		ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+	}
+	return nil
+}
+
 func syncBranchRecursive(branch string) error {
 	// Check if this branch's PR is merged and clean up if needed
 	merged, err := checkAndCleanupMergedBranch(branch)
@@ -343,20 +520,19 @@ func handleRebaseConflict(branch string, conflictErr *git.RebaseConflictError) e
 	}
 
 	ui.Error(fmt.Sprintf("Rebase conflict on branch %s", branch))
-	if len(files) > 0 {
-		fmt.Println("\nConflicted files:")
-		for _, file := range files {
-			fmt.Printf("  - %s\n", file)
-		}
-	}
+	printConflictedFiles(files)
 
 	fmt.Println("\nTo resolve:")
 	fmt.Println("  1. Fix conflicts in the files above")
-	fmt.Println("  2. Stage resolved files: git add <file>")
-	fmt.Println("  3. Continue sync: stak sync --continue")
+	fmt.Printf("  2. Stage resolved files: %s\n", stageCommand(files))
+	if syncNoPush {
+		fmt.Println("  3. Continue sync: stak sync --continue --no-push")
+	} else {
+		fmt.Println("  3. Continue sync: stak sync --continue")
+	}
 	fmt.Println("\nOr abort: git rebase --abort")
 
-	return fmt.Errorf("rebase conflict - resolve and continue")
+	return fmt.Errorf("%w - resolve and continue", errConflict)
 }
 
 func continueSyncAfterConflict() error {
@@ -380,9 +556,12 @@ func continueSyncAfterConflict() error {
 		for _, file := range files {
 			fmt.Printf("  - %s\n", file)
 		}
-		return fmt.Errorf("resolve all conflicts before continuing")
+		return fmt.Errorf("%w - resolve all conflicts before continuing", errConflict)
 	}
 
+	// Remember what the rebase is onto, to record it as the new parent commit
+	onto, _ := git.GetRebaseOnto()
+
 	// Continue rebase
 	ui.Info("Continuing rebase")
 	if err := git.ContinueRebase(); err != nil {
@@ -394,11 +573,20 @@ func continueSyncAfterConflict() error {
 	if err != nil {
 		return fmt.Errorf("failed to get current branch: %w", err)
 	}
+	if onto != "" {
+		if err := stack.RecordParentSHA(currentBranch, onto); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+		}
+	}
 
 	// Push
-	ui.Info(fmt.Sprintf("Force pushing %s", currentBranch))
-	if err := git.Push(currentBranch, false, true); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
+	if syncNoPush {
+		ui.Info(fmt.Sprintf("Not pushing %s (--no-push)", currentBranch))
+	} else {
+		ui.Info(fmt.Sprintf("Force pushing %s", currentBranch))
+		if err := git.Push(currentBranch, false, true); err != nil {
+			return fmt.Errorf("failed to push: %w", err)
+		}
 	}
 
 	ui.Success("Sync completed successfully")
@@ -425,6 +613,12 @@ func updateLocalBranchFromRemote(branch string) error {
 		return nil
 	}
 
+	// Protected branches are only ever fast-forwarded, so local commits on them survive
+	if git.IsProtected(branch) {
+		_, err := git.FastForwardToRemote(branch)
+		return err
+	}
+
 	// Save current branch
 	currentBranch, err := git.GetCurrentBranch()
 	if err != nil {
@@ -439,7 +633,7 @@ func updateLocalBranchFromRemote(branch string) error {
 	// Reset to match remote
 	if err := git.ResetToRemote(branch); err != nil {
 		git.CheckoutBranch(currentBranch)
-		return fmt.Errorf("failed to reset %s to origin/%s: %w", branch, branch, err)
+		return fmt.Errorf("failed to reset %s to %s: %w", branch, git.RemoteRef(branch), err)
 	}
 
 	// Return to original branch
@@ -450,6 +644,55 @@ func updateLocalBranchFromRemote(branch string) error {
 	return nil
 }
 
+// cleanupMergedBranches checks every branch's PR with a single batched query
+// and cleans up those that are merged
+func cleanupMergedBranches(branches []string) {
+	prBranches := make(map[int]string)
+	var prNumbers []int
+	for _, branch := range branches {
+		exists, err := git.BranchExists(branch)
+		if err != nil || !exists {
+			continue
+		}
+		metadata, err := stack.ReadBranchMetadata(branch)
+		if err != nil || metadata.PRNumber == 0 {
+			continue
+		}
+		prBranches[metadata.PRNumber] = branch
+		prNumbers = append(prNumbers, metadata.PRNumber)
+	}
+
+	if len(prNumbers) == 0 {
+		return
+	}
+
+	states, err := github.GetPRStates(prNumbers)
+	if err != nil {
+		// Fall back to checking PRs one at a time
+		ui.Warning(fmt.Sprintf("Could not batch PR status checks: %v", err))
+		for _, prNumber := range prNumbers {
+			if _, err := checkAndCleanupMergedBranch(prBranches[prNumber]); err != nil {
+				ui.Warning(fmt.Sprintf("Could not clean up %s: %v", prBranches[prNumber], err))
+			}
+		}
+		return
+	}
+
+	for _, prNumber := range prNumbers {
+		branch := prBranches[prNumber]
+		switch states[prNumber] {
+		case "MERGED":
+			if _, err := cleanupMergedBranch(branch); err != nil {
+				ui.Warning(fmt.Sprintf("Could not clean up %s: %v", branch, err))
+			}
+		case "CLOSED":
+			if _, err := handleClosedPR(branch, prNumber); err != nil {
+				ui.Warning(fmt.Sprintf("Could not handle the closed PR #%d of %s: %v", prNumber, branch, err))
+			}
+		}
+	}
+}
+
 // checkAndCleanupMergedBranch checks if a branch's PR is merged on GitHub
 // and cleans up the local branch and metadata if so
 func checkAndCleanupMergedBranch(branch string) (bool, error) {
@@ -472,44 +715,43 @@ func checkAndCleanupMergedBranch(branch string) (bool, error) {
 		return false, nil
 	}
 
+	if status.State == "CLOSED" {
+		choice, err := handleClosedPR(branch, metadata.PRNumber)
+		return choice == closedPRRemoved, err
+	}
+
 	// If PR is not merged, nothing to clean up
 	if !status.IsMerged() {
 		return false, nil
 	}
 
-	// PR is merged, clean up the branch
-	ui.Info(fmt.Sprintf("PR #%d for branch %s is merged, cleaning up", metadata.PRNumber, branch))
-
-	// Get parent before deleting metadata
-	parentBranch := metadata.Parent
+	return cleanupMergedBranch(branch)
+}
 
-	// Get children to update their parent
-	children, err := stack.GetChildren(branch)
+// cleanupMergedBranch deletes a branch whose PR has been merged, reparenting its children
+func cleanupMergedBranch(branch string) (bool, error) {
+	metadata, err := stack.ReadBranchMetadata(branch)
 	if err != nil {
-		return false, fmt.Errorf("failed to get children of %s: %w", branch, err)
+		return false, fmt.Errorf("failed to read metadata for %s: %w", branch, err)
 	}
 
-	// Update each child's parent to point to this branch's parent
-	for _, child := range children {
-		childMetadata, err := stack.ReadBranchMetadata(child)
-		if err != nil {
-			ui.Warning(fmt.Sprintf("Could not read metadata for child %s: %v", child, err))
-			continue
-		}
+	// Get parent before deleting metadata
+	parentBranch := metadata.Parent
 
-		ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", child, branch, parentBranch))
-		if err := stack.WriteBranchMetadata(child, parentBranch, childMetadata.PRNumber); err != nil {
-			ui.Warning(fmt.Sprintf("Could not update metadata for %s: %v", child, err))
+	// Merged before its parent, the branch's work landed in the parent's branch or beyond it
+	if parentPR := openParentPR(parentBranch); parentPR > 0 {
+		landed, repair := repairOutOfOrderMerge(branch, metadata.PRNumber, parentBranch, parentPR)
+		if !repair {
+			return false, nil
 		}
+		parentBranch = landed
+	}
 
-		// Update PR base on GitHub if PR exists
-		if childMetadata.PRNumber > 0 {
-			if err := github.UpdatePRBase(childMetadata.PRNumber, parentBranch); err != nil {
-				ui.Warning(fmt.Sprintf("Could not update PR #%d base: %v", childMetadata.PRNumber, err))
-			} else {
-				ui.Info(fmt.Sprintf("Updated PR #%d base to %s", childMetadata.PRNumber, parentBranch))
-			}
-		}
+	// PR is merged, clean up the branch
+	ui.Info(fmt.Sprintf("PR #%d for branch %s is merged, cleaning up", metadata.PRNumber, branch))
+
+	if err := reparentChildren(branch, parentBranch); err != nil {
+		return false, err
 	}
 
 	// Get current branch so we can switch away if needed
@@ -539,3 +781,73 @@ func checkAndCleanupMergedBranch(branch string) (bool, error) {
 
 	return true, nil
 }
+
+// reparentChildren moves the children of a branch that goes away onto newParent, and their
+// PRs with them
+func reparentChildren(branch, parentBranch string) error {
+	children, err := stack.GetChildren(branch)
+	if err != nil {
+		return fmt.Errorf("failed to get children of %s: %w", branch, err)
+	}
+
+	// Update each child's parent to point to this branch's parent
+	for _, child := range children {
+		childMetadata, err := stack.ReadBranchMetadata(child)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Could not read metadata for child %s: %v", child, err))
+			continue
+		}
+
+		ui.Info(fmt.Sprintf("Updating %s parent: %s → %s", child, branch, parentBranch))
+		if err := stack.WriteBranchMetadata(child, parentBranch, childMetadata.PRNumber); err != nil {
+			ui.Warning(fmt.Sprintf("Could not update metadata for %s: %v", child, err))
+		}
+
+		// Update PR base on GitHub if PR exists
+		if childMetadata.PRNumber > 0 && syncNoPush {
+			ui.Info(fmt.Sprintf("Not updating PR #%d base (--no-push). Run 'stak sync' without --no-push, or 'stak reconcile', to update it", childMetadata.PRNumber))
+		} else if childMetadata.PRNumber > 0 {
+			if err := github.UpdatePRBase(childMetadata.PRNumber, parentBranch); err != nil {
+				ui.Warning(fmt.Sprintf("Could not update PR #%d base: %v", childMetadata.PRNumber, err))
+			} else {
+				ui.Info(fmt.Sprintf("Updated PR #%d base to %s", childMetadata.PRNumber, parentBranch))
+			}
+		}
+	}
+
+	return nil
+}
+
+// selectSyncBranches narrows the stack branches down to the ones --match, --stack and --scope
+// select
+func selectSyncBranches(allStackBranches, stackBranches []string) []string {
+	selected := filterBranches(allStackBranches, syncMatch)
+	if syncStack != "" || syncScope != "" {
+		selected = keepBranches(selected, stackBranches)
+	}
+	return selected
+}
+
+// syncFetchBranches lists the branches sync needs from the remote: the synced branches, their
+// parents and the trunk
+func syncFetchBranches(selected []string) []string {
+	seen := make(map[string]bool)
+	var branches []string
+	add := func(branch string) {
+		if branch != "" && !seen[branch] {
+			seen[branch] = true
+			branches = append(branches, branch)
+		}
+	}
+
+	if trunk, err := stack.Trunk(); err == nil {
+		add(trunk)
+	}
+	for _, branch := range selected {
+		add(branch)
+		if parent, err := stack.GetParent(branch); err == nil {
+			add(parent)
+		}
+	}
+	return branches
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"stacking/internal/git"
@@ -17,8 +16,7 @@ var topCmd = &cobra.Command{
 	Long:    `Navigate to the topmost branch in the current stack by following the parent chain.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runTop(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -30,7 +28,7 @@ func init() {
 func runTop() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -88,5 +86,6 @@ func runTop() error {
 	}
 
 	ui.Success(fmt.Sprintf("Now on branch %s (top of stack)", topBranch))
+	printStackMap(topBranch)
 	return nil
 }
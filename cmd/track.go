@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/manifoldco/promptui"
@@ -26,6 +25,7 @@ var trackCmd = &cobra.Command{
 	Short:   "Add existing branch to stack",
 	Long: `Track an existing branch by designating its parent branch.
 This allows you to incorporate branches not created with stak create into the stack system.`,
+	ValidArgsFunction: completeUntrackedBranch,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -33,14 +33,14 @@ This allows you to incorporate branches not created with stak create into the st
 		}
 
 		if err := runTrack(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
 	trackCmd.Flags().StringVar(&trackParent, "parent", "", "Specify parent branch explicitly")
+	trackCmd.RegisterFlagCompletionFunc("parent", completeParent)
 	trackCmd.Flags().BoolVar(&trackAuto, "auto", false, "Auto-detect parent from PR base")
 	trackCmd.Flags().BoolVar(&trackForce, "force", false, "Use most recent tracked ancestor as parent")
 	trackCmd.Flags().BoolVar(&trackRecursive, "recursive", false, "Recursively track untracked parents")
@@ -50,7 +50,7 @@ func init() {
 func runTrack(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// 1. Determine target branch (argument or current)
@@ -117,6 +117,11 @@ func runTrack(branchName string) error {
 	if err := stack.WriteBranchMetadata(branchName, parent, prNumber); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
+	if parent != "" {
+		if err := stack.RecordParentSHA(branchName, parent); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+		}
+	}
 
 	// 9. Show success with visualization
 	parentInfo := parent
@@ -160,8 +165,12 @@ func determineParent(branch string) (string, error) {
 func findMostRecentTrackedAncestor(branch string) (string, error) {
 	ui.Info("Finding most recent tracked ancestor...")
 
-	// Get commit history
-	ancestors, err := git.GetCommitAncestors(branch)
+	// Get commit history down to trunk, where the fallback below applies anyway
+	var stop []string
+	if trunk, err := stack.Trunk(); err == nil && trunk != branch {
+		stop = append(stop, trunk)
+	}
+	ancestors, err := git.GetCommitAncestors(branch, stop...)
 	if err != nil {
 		return "", fmt.Errorf("failed to get commit ancestors: %w", err)
 	}
@@ -183,19 +192,7 @@ func findMostRecentTrackedAncestor(branch string) (string, error) {
 	}
 
 	// Fallback to base branch
-	return getBaseBranch()
-}
-
-func getBaseBranch() (string, error) {
-	// Try common base branches in order
-	baseBranches := []string{"main", "master", "develop", "development"}
-	for _, base := range baseBranches {
-		exists, err := git.BranchExists(base)
-		if err == nil && exists {
-			return base, nil
-		}
-	}
-	return "", fmt.Errorf("no base branch found (tried: main, master, develop, development)")
+	return stack.Trunk()
 }
 
 func selectParentInteractive(branch string) (string, error) {
@@ -209,7 +206,7 @@ func selectParentInteractive(branch string) (string, error) {
 	var options []string
 
 	// 1. Base branches (main, master, develop)
-	baseBranches := []string{"main", "master", "develop", "development"}
+	baseBranches := stack.BaseBranches()
 	for _, base := range baseBranches {
 		if contains(allBranches, base) && base != branch {
 			options = append(options, fmt.Sprintf("%s (base branch)", base))
@@ -252,7 +249,7 @@ func selectParentInteractive(branch string) (string, error) {
 		Size:  10,
 	}
 
-	_, result, err := prompt.Run()
+	_, result, err := runSelect(&prompt)
 	if err != nil {
 		return "", fmt.Errorf("parent selection cancelled")
 	}
@@ -314,7 +311,7 @@ func validateAndTrackParent(parent, childBranch string) error {
 			},
 		}
 
-		_, result, err := prompt.Run()
+		_, result, err := runSelect(&prompt)
 		if err != nil || result == "Cancel" {
 			return fmt.Errorf("tracking cancelled")
 		}
@@ -357,7 +354,7 @@ func offerUpdateParent(branch string) error {
 		},
 	}
 
-	_, result, err := prompt.Run()
+	_, result, err := runSelect(&prompt)
 	if err != nil || result == "Cancel" || result == "Keep existing" {
 		return nil
 	}
@@ -391,6 +388,11 @@ func offerUpdateParent(branch string) error {
 	if err := stack.WriteBranchMetadata(branch, newParent, prNumber); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
+	if newParent != "" {
+		if err := stack.RecordParentSHA(branch, newParent); err != nil {
+			ui.Warning(fmt.Sprintf("Could not record parent commit: %v", err))
+		}
+	}
 
 	newParentInfo := newParent
 	if newParent == "" {
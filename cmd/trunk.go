@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var trunkCmd = &cobra.Command{
+	Use:     "trunk",
+	Aliases: []string{"tk", "pull"},
+	Short:   "Fast-forward trunk branches from the remote",
+	Long: `Fetch and fast-forward the trunk branch, and any other branch a stack is based on,
+to match the remote. Local commits are never rewritten: a trunk that has diverged is
+reported and left alone. Stack branches are not touched.
+
+Afterwards, reports how many commits each stack is behind its trunk, so you can decide
+when to run stak sync or stak restack.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTrunk(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trunkCmd)
+}
+
+func runTrunk() error {
+	// Check if we're in a git repository
+	if !git.IsGitRepository() {
+		return errNotGitRepository
+	}
+
+	ctx, err := stack.LoadContext()
+	if err != nil {
+		return fmt.Errorf("failed to load stack: %w", err)
+	}
+
+	// The configured trunk, plus whatever untracked branches stacks are based on
+	trunks := make(map[string]bool)
+	if trunk, err := stack.Trunk(); err == nil {
+		trunks[trunk] = true
+	}
+	for _, root := range ctx.Stack.Roots {
+		if root.Parent != "" && !ctx.IsTracked(root.Parent) {
+			trunks[root.Parent] = true
+		}
+	}
+	if len(trunks) == 0 {
+		return fmt.Errorf("no trunk branch found. Set one with: git config stack.trunk <branch>")
+	}
+
+	ui.Info(fmt.Sprintf("Fetching from %s", git.Remote))
+	if err := git.Fetch(); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	names := make([]string, 0, len(trunks))
+	for trunk := range trunks {
+		names = append(names, trunk)
+	}
+	sort.Strings(names)
+
+	for _, trunk := range names {
+		if exists, _ := git.BranchExists(trunk); !exists {
+			continue
+		}
+		if !git.RemoteTrackingBranchExists(trunk) {
+			ui.Warning(fmt.Sprintf("%s has no remote branch %s, skipping", trunk, git.RemoteRef(trunk)))
+			continue
+		}
+
+		count, err := git.FastForwardToRemote(trunk)
+		switch {
+		case errors.Is(err, git.ErrDiverged):
+			ui.Warning(fmt.Sprintf("%s has local commits not on %s, left unchanged", trunk, git.RemoteRef(trunk)))
+		case err != nil:
+			return err
+		case count == 0:
+			ui.Info(fmt.Sprintf("%s is up to date", trunk))
+		default:
+			ui.Success(fmt.Sprintf("Fast-forwarded %s by %d commit(s)", trunk, count))
+		}
+	}
+
+	// Report how far behind each stack now is
+	if len(ctx.Stack.Roots) > 0 {
+		fmt.Println()
+	}
+	for _, root := range ctx.Stack.Roots {
+		if root.Parent == "" || ctx.IsTracked(root.Parent) {
+			continue
+		}
+		behind, err := getCommitCount(root.Parent, root.Name)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Could not compare %s with %s: %v", root.Name, root.Parent, err))
+			continue
+		}
+		if behind == 0 {
+			ui.Info(fmt.Sprintf("%s is up to date with %s", root.Name, root.Parent))
+		} else {
+			ui.Info(fmt.Sprintf("%s is %d commit(s) behind %s", root.Name, behind, root.Parent))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/git"
+	"stacking/internal/github"
+	"stacking/internal/stack"
+	"stacking/internal/ui"
+)
+
+var tutorialKeep bool
+
+var tutorialCmd = &cobra.Command{
+	Use:     "tutorial",
+	Aliases: []string{"tu"},
+	Short:   "Learn the stacked workflow in a throwaway demo repository",
+	Long: `Create a throwaway demo repository (with its own local "origin" remote) and walk through
+the stacked workflow step by step: create → modify → sync → merge.
+
+Each step explains what to run in a second terminal inside the demo repository, then checks
+that it worked before moving on. Nothing outside the demo repository is touched, and it is
+deleted when the tutorial ends unless --keep is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTutorial(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	tutorialCmd.Flags().BoolVar(&tutorialKeep, "keep", false, "Keep the demo repository when the tutorial ends")
+	rootCmd.AddCommand(tutorialCmd)
+}
+
+// tutorialStep is one lesson of the tutorial
+type tutorialStep struct {
+	title    string
+	explain  string
+	commands []string
+	// setup runs before the step is shown, e.g. to simulate a teammate
+	setup func() error
+	// check verifies the user's commands had the intended effect
+	check func() error
+}
+
+// tutorialIdentity is used for commits the tutorial makes itself, so it works without a git identity
+var tutorialIdentity = []string{"-c", "user.name=stak tutorial", "-c", "user.email=tutorial@stak.invalid"}
+
+func runTutorial() error {
+	if err := requireInteractive("tutorial steps"); err != nil {
+		return err
+	}
+
+	// stak create needs gh even though the demo never talks to GitHub
+	if !github.IsGHAuthenticated() {
+		return errNotAuthenticated
+	}
+
+	dir, err := os.MkdirTemp("", "stak-tutorial-")
+	if err != nil {
+		return fmt.Errorf("failed to create demo directory: %w", err)
+	}
+	if tutorialKeep {
+		defer ui.Info(fmt.Sprintf("Demo repository kept at %s", filepath.Join(dir, "demo")))
+	} else {
+		defer func() {
+			ui.Info("Removing demo repository")
+			os.RemoveAll(dir)
+		}()
+	}
+
+	ui.Info("Setting up demo repository")
+	demo, err := setupTutorialRepo(dir)
+	if err != nil {
+		return err
+	}
+
+	// Checks use the git helpers, which work on the current directory
+	if err := os.Chdir(demo); err != nil {
+		return fmt.Errorf("failed to enter demo repository: %w", err)
+	}
+	git.Remote = "origin"
+	ui.Success(fmt.Sprintf("Demo repository ready at %s", demo))
+
+	fmt.Println()
+	fmt.Println("Open a second terminal and run:")
+	fmt.Printf("  cd %s\n", demo)
+	fmt.Println()
+	fmt.Println("At each step, run the commands shown there, then come back and press Enter.")
+	fmt.Println("Type s to skip a step, or q to quit.")
+
+	steps := tutorialSteps(dir)
+	reader := bufio.NewReader(os.Stdin)
+	for i, step := range steps {
+		fmt.Println()
+		fmt.Printf("Step %d/%d: %s\n", i+1, len(steps), step.title)
+		fmt.Println(strings.Repeat("─", 40))
+
+		if step.setup != nil {
+			if err := step.setup(); err != nil {
+				ui.Warning(fmt.Sprintf("Could not prepare this step, skipping it: %v", err))
+				continue
+			}
+		}
+
+		fmt.Println(step.explain)
+		fmt.Println()
+		for _, command := range step.commands {
+			fmt.Printf("  %s\n", command)
+		}
+
+		quit, err := waitForTutorialStep(reader, step)
+		if err != nil {
+			return err
+		}
+		if quit {
+			ui.Info("Tutorial stopped")
+			return nil
+		}
+	}
+
+	fmt.Println()
+	ui.Success("Tutorial complete!")
+	fmt.Println()
+	fmt.Println("In a real repository, the loop is:")
+	fmt.Println("  stak create <branch> -m <message>   stack a branch on the current one")
+	fmt.Println("  stak submit                         open or update PRs for the stack")
+	fmt.Println("  stak modify                         address review feedback")
+	fmt.Println("  stak merge                          merge the bottom PR")
+	fmt.Println("  stak sync                           clean up merged branches and restack")
+	fmt.Println()
+	fmt.Println("Run 'stak init' in your repository to get started.")
+	return nil
+}
+
+// waitForTutorialStep waits until the step's check passes, the user skips it, or quits
+func waitForTutorialStep(reader *bufio.Reader, step tutorialStep) (bool, error) {
+	for {
+		fmt.Println()
+		fmt.Print("Press Enter when done (s = skip, q = quit): ")
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			// stdin closed, nothing more to wait for
+			fmt.Println()
+			return true, nil
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "q", "quit":
+			return true, nil
+		case "s", "skip":
+			ui.Info("Skipped")
+			return false, nil
+		}
+
+		// The user changed metadata from another process
+		git.ReloadBranchMetadata()
+		if err := step.check(); err != nil {
+			ui.Warning(err.Error())
+			continue
+		}
+		ui.Success("Looks good")
+		return false, nil
+	}
+}
+
+// setupTutorialRepo creates a bare "remote" and a clone of it with one commit on main
+func setupTutorialRepo(dir string) (string, error) {
+	remote := filepath.Join(dir, "remote.git")
+	demo := filepath.Join(dir, "demo")
+
+	if _, err := tutorialGit(dir, "init", "-q", "--bare", remote); err != nil {
+		return "", err
+	}
+	if _, err := tutorialGit(remote, "symbolic-ref", "HEAD", "refs/heads/main"); err != nil {
+		return "", err
+	}
+	if _, err := tutorialGit(dir, "clone", "-q", remote, demo); err != nil {
+		return "", err
+	}
+	if _, err := tutorialGit(demo, "symbolic-ref", "HEAD", "refs/heads/main"); err != nil {
+		return "", err
+	}
+
+	// The user's own commits need an identity; fall back to the tutorial's inside the demo only
+	if out, _ := tutorialGit(demo, "config", "user.email"); out == "" {
+		if _, err := tutorialGit(demo, "config", "user.name", "stak tutorial"); err != nil {
+			return "", err
+		}
+		if _, err := tutorialGit(demo, "config", "user.email", "tutorial@stak.invalid"); err != nil {
+			return "", err
+		}
+	}
+
+	readme := "# stak demo\n\nA throwaway repository created by stak tutorial.\n"
+	if err := os.WriteFile(filepath.Join(demo, "README.md"), []byte(readme), 0644); err != nil {
+		return "", fmt.Errorf("failed to write README.md: %w", err)
+	}
+	if _, err := tutorialGit(demo, "add", "README.md"); err != nil {
+		return "", err
+	}
+	if _, err := tutorialGit(demo, "commit", "-q", "-m", "Initial commit"); err != nil {
+		return "", err
+	}
+	if _, err := tutorialGit(demo, "push", "-q", "-u", "origin", "main"); err != nil {
+		return "", err
+	}
+
+	return demo, nil
+}
+
+// tutorialSteps builds the lessons; dir is the directory holding the demo and its remote
+func tutorialSteps(dir string) []tutorialStep {
+	var featureATip string
+
+	return []tutorialStep{
+		{
+			title: "Create your first branch",
+			explain: `A stack is a chain of small branches, each built on the one below it.
+Stage a change and create the first branch on top of main:`,
+			commands: []string{
+				`echo "Feature A" > feature-a.txt && git add feature-a.txt`,
+				`stak create feature-a -m "Add feature A"`,
+			},
+			check: func() error {
+				return checkTutorialBranch("feature-a", "main")
+			},
+		},
+		{
+			title: "Stack a second branch on top",
+			explain: `stak create always stacks on the branch you are on. While still on feature-a,
+create a second branch that builds on it, then look at the stack:`,
+			commands: []string{
+				`echo "Feature B" > feature-b.txt && git add feature-b.txt`,
+				`stak create feature-b -m "Add feature B"`,
+				`stak list`,
+			},
+			check: func() error {
+				return checkTutorialBranch("feature-b", "feature-a")
+			},
+		},
+		{
+			title: "Modify a branch lower in the stack",
+			explain: `Review feedback often lands on a branch below the top. Check out feature-a,
+amend it, and let stak restack feature-b on top of the change:`,
+			commands: []string{
+				`stak checkout feature-a`,
+				`echo "Tests for feature A" > feature-a-test.txt && git add feature-a-test.txt`,
+				`stak modify --push`,
+			},
+			setup: func() error {
+				tip, err := tutorialGit(".", "rev-parse", "feature-a")
+				featureATip = tip
+				return err
+			},
+			check: func() error {
+				tip, err := tutorialGit(".", "rev-parse", "feature-a")
+				if err != nil {
+					return err
+				}
+				if tip == featureATip {
+					return fmt.Errorf("feature-a still points at the same commit. Stage your edit and run: stak modify --push")
+				}
+				if ok, _ := isAncestorBranch("feature-a", "feature-b"); !ok {
+					return fmt.Errorf("feature-b is not on top of the new feature-a yet. Run: stak sync --no-github")
+				}
+				return nil
+			},
+		},
+		{
+			title: "Sync with a moving main",
+			explain: `Meanwhile a teammate pushed a commit to main (the tutorial just did that for you).
+Sync pulls main and rebases every branch of the stack onto it, in order.
+There is no GitHub here, so skip the merged-PR checks:`,
+			commands: []string{
+				`stak sync --no-github`,
+				`git log --oneline --graph --all`,
+			},
+			setup: func() error {
+				return pushTeammateCommit(dir)
+			},
+			check: func() error {
+				if ok, _ := isAncestorBranch("origin/main", "main"); !ok {
+					return fmt.Errorf("local main does not have the teammate's commit yet. Run: stak sync --no-github")
+				}
+				for _, pair := range [][2]string{{"main", "feature-a"}, {"feature-a", "feature-b"}} {
+					if ok, _ := isAncestorBranch(pair[0], pair[1]); !ok {
+						return fmt.Errorf("%s is not rebased onto %s yet. Run: stak sync --no-github", pair[1], pair[0])
+					}
+				}
+				return nil
+			},
+		},
+		{
+			title: "Merge the bottom of the stack",
+			explain: `On GitHub you would run 'stak merge' to merge feature-a's PR, then 'stak sync' to delete
+the merged branch and move feature-b onto main. The demo has no GitHub, so the tutorial
+has just merged feature-a into main on the demo remote. Do the cleanup sync would do:`,
+			commands: []string{
+				`stak checkout feature-b`,
+				`stak move --parent main`,
+				`stak untrack feature-a --force && git branch -D feature-a`,
+				`stak sync --no-github`,
+				`stak list`,
+			},
+			setup: func() error {
+				if _, err := tutorialGit(".", "push", "-q", "origin", "feature-a:main"); err != nil {
+					return fmt.Errorf("failed to merge feature-a on the demo remote (is it synced with main?): %w", err)
+				}
+				// Like GitHub's "delete branch on merge"; the branch may never have been pushed
+				tutorialGit(".", "push", "-q", "origin", "--delete", "feature-a")
+				return nil
+			},
+			check: func() error {
+				if exists, _ := git.BranchExists("feature-a"); exists {
+					return fmt.Errorf("feature-a still exists locally. Run: stak untrack feature-a --force && git branch -D feature-a")
+				}
+				if parent, _ := stack.GetParent("feature-b"); parent != "main" {
+					return fmt.Errorf("feature-b's parent is %q, expected main. Run: stak move feature-b --parent main", parent)
+				}
+				if ok, _ := isAncestorBranch("origin/main", "main"); !ok {
+					return fmt.Errorf("local main does not have the merge yet. Run: stak sync --no-github")
+				}
+				if ok, _ := isAncestorBranch("main", "feature-b"); !ok {
+					return fmt.Errorf("feature-b is not rebased onto main yet. Run: stak sync --no-github")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// checkTutorialBranch verifies a branch exists, is tracked with the given parent, and has commits of its own
+func checkTutorialBranch(branch, parent string) error {
+	exists, err := git.BranchExists(branch)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("branch %s doesn't exist yet", branch)
+	}
+
+	actual, err := stack.GetParent(branch)
+	if err != nil || actual == "" {
+		return fmt.Errorf("%s is not tracked by stak. Create it with stak create rather than git branch", branch)
+	}
+	if actual != parent {
+		return fmt.Errorf("%s is stacked on %s, expected %s. Run stak create from %s", branch, actual, parent, parent)
+	}
+
+	count, err := tutorialGit(".", "rev-list", "--count", parent+".."+branch)
+	if err != nil {
+		return err
+	}
+	if count == "0" {
+		return fmt.Errorf("%s has no commits of its own. Stage a change with git add, then run: stak modify", branch)
+	}
+	return nil
+}
+
+// pushTeammateCommit pushes a commit to main on the demo remote from a separate clone
+func pushTeammateCommit(dir string) error {
+	teammate := filepath.Join(dir, "teammate")
+	if _, err := tutorialGit(dir, "clone", "-q", filepath.Join(dir, "remote.git"), teammate); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(teammate, "CONTRIBUTING.md"), []byte("Be kind.\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write teammate change: %w", err)
+	}
+	if _, err := tutorialGit(teammate, "add", "CONTRIBUTING.md"); err != nil {
+		return err
+	}
+	if _, err := tutorialGit(teammate, "commit", "-q", "-m", "Add contributing guide"); err != nil {
+		return err
+	}
+	_, err := tutorialGit(teammate, "push", "-q", "origin", "main")
+	return err
+}
+
+// tutorialGit runs git in dir and returns its trimmed output
+func tutorialGit(dir string, args ...string) (string, error) {
+	if args[0] == "commit" {
+		args = append(append([]string{}, tutorialIdentity...), args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
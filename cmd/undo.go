@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -11,30 +12,47 @@ import (
 	"stacking/internal/ui"
 )
 
-var undoForce bool
+var (
+	undoForce bool
+	undoID    int
+	undoList  bool
+)
 
 var undoCmd = &cobra.Command{
 	Use:     "undo",
 	Aliases: []string{"un"},
 	Short:   "View and undo recent stack operations",
-	Long:    `Display recent stack operations and their details. Note: Actual undo functionality is limited - this command shows operation history and provides guidance for manual reversal.`,
+	Long: `Display the last stack operation and how to undo it.
+
+Every stak command that moves, creates or deletes branches or changes stack metadata is
+recorded in .git/stak.log with each branch's head and metadata value before and after it.
+Use --list to see recorded operations and --id <n> to revert one of them, even if it was not
+the most recent, as long as no later operation changed the same branches or metadata since.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runUndo(); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
 
 func init() {
 	undoCmd.Flags().BoolVarP(&undoForce, "force", "f", false, "Skip confirmation")
+	undoCmd.Flags().IntVar(&undoID, "id", 0, "Revert the operation with this ID")
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "List recorded operations")
 	rootCmd.AddCommand(undoCmd)
 }
 
 func runUndo() error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
+	}
+
+	if undoID > 0 {
+		return runUndoOperation(undoID)
+	}
+	if undoList {
+		return runUndoList()
 	}
 
 	// Get last operation
@@ -45,6 +63,9 @@ func runUndo() error {
 
 	// Display operation details
 	ui.Info("Last operation:")
+	if lastOp.ID > 0 {
+		fmt.Printf("  ID:          %d\n", lastOp.ID)
+	}
 	fmt.Printf("  Command:     %s\n", lastOp.Command)
 	fmt.Printf("  Branch:      %s\n", lastOp.Branch)
 	fmt.Printf("  Description: %s\n", lastOp.Description)
@@ -58,9 +79,46 @@ func runUndo() error {
 	}
 
 	ui.Info("")
-	ui.Warning("Note: Automatic undo is not yet fully implemented.")
-	ui.Info("To manually undo this operation:")
+	if hasRecordedChanges(lastOp) {
+		ui.Info("Changes:")
+		printOperationChanges(lastOp)
+		ui.Info("")
+		ui.Info(fmt.Sprintf("To revert them: stak undo --id %d", lastOp.ID))
+	} else {
+		ui.Warning("No branch changes were recorded for this operation.")
+		ui.Info("To manually undo this operation:")
+		printUndoGuidance(lastOp)
+	}
+
+	ui.Info("")
+
+	// Confirm removal from history
+	if !undoForce {
+		prompt := promptui.Select{
+			Label: "Remove this operation from history?",
+			Items: []string{"Yes", "No"},
+		}
+
+		_, result, err := runSelect(&prompt)
+		if err != nil || result == "No" {
+			ui.Info("Operation kept in history")
+			return nil
+		}
+	}
+
+	// Remove the operation from log
+	if err := history.RemoveLastOperation(); err != nil {
+		return fmt.Errorf("failed to remove operation from history: %w", err)
+	}
+
+	ui.Success("Operation removed from history")
+	ui.Info("Use 'stak undo' again to see the previous operation")
 
+	return nil
+}
+
+// printUndoGuidance explains how to undo an operation logged without its branch changes
+func printUndoGuidance(lastOp *history.Operation) {
 	// Provide guidance based on operation type
 	switch lastOp.Command {
 	case "create":
@@ -101,30 +159,243 @@ func runUndo() error {
 	default:
 		ui.Info("  No specific undo guidance available for this operation.")
 	}
+}
 
-	ui.Info("")
+func runUndoList() error {
+	ops, err := history.ReadOperations()
+	if err != nil {
+		return fmt.Errorf("failed to get operation history: %w", err)
+	}
+	if len(ops) == 0 {
+		fmt.Println("No operations in history.")
+		return nil
+	}
+
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		id := "-"
+		if op.ID > 0 {
+			id = fmt.Sprintf("#%d", op.ID)
+		}
+		line := fmt.Sprintf("%-5s %s  %-10s %s", id, op.Timestamp.Format("2006-01-02 15:04:05"), op.Command, op.Description)
+		if hasRecordedChanges(&op) {
+			line += fmt.Sprintf(" (%d branch(es), %d metadata change(s))", len(op.Branches), len(op.MetadataChanges))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// runUndoOperation reverts the branch heads and metadata recorded for one operation.
+// Anything the operation changed must still be as it left it; otherwise a later
+// operation (or a plain git command) built on it and reverting would lose that work.
+func runUndoOperation(id int) error {
+	op, err := history.GetOperation(id)
+	if err != nil {
+		return err
+	}
+	if !hasRecordedChanges(op) {
+		return fmt.Errorf("operation #%d has no recorded branch changes to revert", id)
+	}
+
+	ops, err := history.ReadOperations()
+	if err != nil {
+		return fmt.Errorf("failed to get operation history: %w", err)
+	}
+	current, err := history.TakeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+	for _, c := range op.Branches {
+		if current.Heads[c.Name] != c.After {
+			conflicts = append(conflicts, fmt.Sprintf("branch %s %s", c.Name, laterChange(ops, id, func(later history.Operation) bool {
+				for _, lc := range later.Branches {
+					if lc.Name == c.Name {
+						return true
+					}
+				}
+				return false
+			})))
+		}
+	}
+	for _, c := range op.MetadataChanges {
+		if current.Metadata[c.Key] != c.After {
+			conflicts = append(conflicts, fmt.Sprintf("%s %s", c.Key, laterChange(ops, id, func(later history.Operation) bool {
+				for _, lc := range later.MetadataChanges {
+					if lc.Key == c.Key {
+						return true
+					}
+				}
+				return false
+			})))
+		}
+	}
+	if len(conflicts) > 0 {
+		ui.Error(fmt.Sprintf("Operation #%d can't be reverted on its own:", id))
+		for _, conflict := range conflicts {
+			fmt.Printf("  - %s\n", conflict)
+		}
+		return fmt.Errorf("revert the later operations first, or use 'stak backups restore'")
+	}
+
+	currentBranch, _ := git.GetCurrentBranch()
+	for _, c := range op.Branches {
+		if c.Name != currentBranch {
+			continue
+		}
+		if c.Before == "" {
+			return fmt.Errorf("operation #%d created %s, which is checked out. Check out another branch first", id, c.Name)
+		}
+		dirty, err := git.HasUncommittedChanges()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("cannot revert the checked-out branch %s: %w", c.Name, git.ErrDirtyTree)
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Reverting operation #%d (%s, %s):", id, op.Description, op.Timestamp.Format("2006-01-02 15:04:05")))
+	printOperationChanges(op)
 
-	// Confirm removal from history
 	if !undoForce {
 		prompt := promptui.Select{
-			Label: "Remove this operation from history?",
+			Label: "Revert these changes?",
 			Items: []string{"Yes", "No"},
 		}
 
-		_, result, err := prompt.Run()
+		_, result, err := runSelect(&prompt)
 		if err != nil || result == "No" {
-			ui.Info("Operation kept in history")
+			ui.Info("Nothing was changed")
 			return nil
 		}
 	}
 
-	// Remove the operation from log
-	if err := history.RemoveLastOperation(); err != nil {
-		return fmt.Errorf("failed to remove operation from history: %w", err)
+	for _, c := range op.Branches {
+		if c.Before == "" {
+			err = git.DeleteBranch(c.Name, true)
+		} else {
+			err = git.RestoreBranch(c.Name, c.Before)
+		}
+		if err != nil {
+			return err
+		}
 	}
+	for _, c := range op.MetadataChanges {
+		if c.Before == "" {
+			err = git.UnsetConfig(c.Key)
+		} else {
+			err = git.SetConfig(c.Key, c.Before)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	git.ReloadBranchMetadata()
 
-	ui.Success("Operation removed from history")
-	ui.Info("Use 'stak undo' again to see the previous operation")
-
+	ui.Success(fmt.Sprintf("Reverted operation #%d", id))
+	ui.Info("Remote branches were not changed. Run 'stak push' to update them.")
 	return nil
 }
+
+// laterChange describes what changed something an operation left behind
+func laterChange(ops []history.Operation, id int, touches func(history.Operation) bool) string {
+	for i := len(ops) - 1; i >= 0; i-- {
+		if ops[i].ID > id && touches(ops[i]) {
+			return fmt.Sprintf("was changed by #%d (%s)", ops[i].ID, ops[i].Description)
+		}
+	}
+	return "was changed outside stak"
+}
+
+func hasRecordedChanges(op *history.Operation) bool {
+	return len(op.Branches) > 0 || len(op.MetadataChanges) > 0
+}
+
+// printOperationChanges lists the branch heads and metadata an operation changed
+func printOperationChanges(op *history.Operation) {
+	for _, c := range op.Branches {
+		switch {
+		case c.Before == "":
+			fmt.Printf("  %s: created at %s\n", c.Name, shortSHA(c.After))
+		case c.After == "":
+			fmt.Printf("  %s: deleted (was %s)\n", c.Name, shortSHA(c.Before))
+		default:
+			fmt.Printf("  %s: %s -> %s\n", c.Name, shortSHA(c.Before), shortSHA(c.After))
+		}
+	}
+	for _, c := range op.MetadataChanges {
+		fmt.Printf("  %s: %s -> %s\n", c.Key, metadataValue(c.Before), metadataValue(c.After))
+	}
+}
+
+func shortSHA(sha string) string {
+	return sha[:min(len(sha), 8)]
+}
+
+func metadataValue(value string) string {
+	if value == "" {
+		return "(unset)"
+	}
+	return value
+}
+
+// journal holds the state before the running command, so its changes can be recorded
+var journal struct {
+	before      *history.Snapshot
+	command     string
+	description string
+	branch      string
+}
+
+// unjournaled lists the commands that never change branches or stack metadata. They skip the
+// snapshots the journal takes, which would only slow them down.
+var unjournaled = map[string]bool{
+	"help":                          true,
+	"completion":                    true,
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+	"version":                       true,
+	"list":                          true,
+	"log":                           true,
+	"stats":                         true,
+	"owners":                        true,
+	"reviews":                       true,
+	"checks":                        true,
+	"plugins":                       true,
+	"changelog":                     true,
+	"release-notes":                 true,
+	"backups list":                  true,
+	"archive list":                  true,
+	"archive show":                  true,
+	"daemon status":                 true,
+	"status":                        true,
+}
+
+// startJournal snapshots branch heads and stack metadata before a command runs
+func startJournal(cmd *cobra.Command) {
+	command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if unjournaled[command] || unjournaled[strings.Fields(command)[0]] || !git.IsGitRepository() {
+		return
+	}
+	snapshot, err := history.TakeSnapshot()
+	if err != nil {
+		return
+	}
+	journal.before = snapshot
+	journal.command = command
+	journal.description = strings.Join(append([]string{cmd.Root().Name()}, os.Args[1:]...), " ")
+	journal.branch, _ = git.GetCurrentBranch()
+}
+
+// finishJournal records what the command changed in the operation history
+func finishJournal() {
+	if journal.before == nil {
+		return
+	}
+	if err := history.RecordOperation(journal.command, journal.branch, journal.description, journal.before); err != nil {
+		ui.Warning(fmt.Sprintf("Could not record the operation in history: %v", err))
+	}
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"stacking/internal/git"
@@ -11,11 +10,12 @@ import (
 )
 
 var unfreezeCmd = &cobra.Command{
-	Use:     "unfreeze [branch]",
-	Aliases: []string{"uf"},
-	Short:   "Remove protection from a frozen branch",
-	Long:    `Unfreeze a branch to allow stack operations to modify it again.`,
-	Args:    cobra.MaximumNArgs(1),
+	Use:               "unfreeze [branch]",
+	Aliases:           []string{"uf"},
+	Short:             "Remove protection from a frozen branch",
+	Long:              `Unfreeze a branch to allow stack operations to modify it again.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeFrozen(true),
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -23,8 +23,7 @@ var unfreezeCmd = &cobra.Command{
 		}
 
 		if err := runUnfreeze(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -36,7 +35,7 @@ func init() {
 func runUnfreeze(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -17,11 +16,12 @@ var (
 )
 
 var untrackCmd = &cobra.Command{
-	Use:     "untrack [branch]",
-	Aliases: []string{"ut"},
-	Short:   "Stop tracking a branch",
-	Long:    `Remove a branch from stack tracking. This removes the branch's metadata but does not delete the branch or PR.`,
-	Args:    cobra.MaximumNArgs(1),
+	Use:               "untrack [branch]",
+	Aliases:           []string{"ut"},
+	Short:             "Stop tracking a branch",
+	Long:              `Remove a branch from stack tracking. This removes the branch's metadata but does not delete the branch or PR.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedBranches,
 	Run: func(cmd *cobra.Command, args []string) {
 		branchName := ""
 		if len(args) > 0 {
@@ -29,8 +29,7 @@ var untrackCmd = &cobra.Command{
 		}
 
 		if err := runUntrack(branchName); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -44,7 +43,7 @@ func init() {
 func runUntrack(branchName string) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Determine target branch (argument or current)
@@ -103,7 +102,7 @@ func runUntrack(branchName string) error {
 				},
 			}
 
-			_, result, err := prompt.Run()
+			_, result, err := runSelect(&prompt)
 			if err != nil || result == "Cancel" {
 				ui.Info("Untrack cancelled")
 				return nil
@@ -130,7 +129,7 @@ func runUntrack(branchName string) error {
 			Items: []string{"Yes", "No"},
 		}
 
-		_, result, err := prompt.Run()
+		_, result, err := runSelect(&prompt)
 		if err != nil || result == "No" {
 			ui.Info("Untrack cancelled")
 			return nil
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/spf13/cobra"
@@ -23,13 +22,11 @@ var upCmd = &cobra.Command{
 			var err error
 			steps, err = strconv.Atoi(args[0])
 			if err != nil || steps < 1 {
-				ui.Error("steps must be a positive integer")
-				os.Exit(1)
+				exitWithError(fmt.Errorf("steps must be a positive integer"))
 			}
 		}
 		if err := runUp(steps); err != nil {
-			ui.Error(err.Error())
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
@@ -41,7 +38,7 @@ func init() {
 func runUp(steps int) error {
 	// Check if we're in a git repository
 	if !git.IsGitRepository() {
-		return fmt.Errorf("not in a git repository")
+		return errNotGitRepository
 	}
 
 	// Get current branch
@@ -107,6 +104,7 @@ func runUp(steps int) error {
 			return fmt.Errorf("failed to checkout branch %s: %w", targetBranch, err)
 		}
 		ui.Success(fmt.Sprintf("Now on branch %s", targetBranch))
+		printStackMap(targetBranch)
 	}
 
 	return nil
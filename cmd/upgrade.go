@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/config"
+	"stacking/internal/ui"
+	"stacking/internal/update"
+)
+
+var (
+	upgradeChannel string
+	upgradeForce   bool
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:     "upgrade",
+	Aliases: []string{"ug"},
+	Short:   "Upgrade stak to the latest release",
+	Long: `Download the latest stak release for this platform, verify it against the release's SHA-256 checksums,
+whose signature is checked with the key built into stak, and replace the running binary. A build
+without a key only tells you about the new release.
+
+Use --channel beta to include pre-releases. The default channel can be set with:
+  git config --global stack.update-channel beta`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runUpgrade(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().StringVar(&upgradeChannel, "channel", "", "Release channel: stable or beta (default: update-channel setting, or stable)")
+	upgradeCmd.RegisterFlagCompletionFunc("channel", completeValues(update.ChannelStable, update.ChannelBeta))
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Reinstall even if already up to date")
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func runUpgrade() error {
+	exe, err := update.Executable()
+	if err != nil {
+		return err
+	}
+	if update.IsHomebrewInstall(exe) {
+		return fmt.Errorf("stak was installed with Homebrew. Run: brew upgrade stak")
+	}
+
+	channel := updateChannel(upgradeChannel)
+	ui.Info(fmt.Sprintf("Checking for updates (%s channel)", channel))
+
+	release, err := update.LatestRelease(channel)
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if !upgradeForce && !update.IsNewer(release.TagName, appVersion) {
+		if appVersion == "dev" {
+			ui.Info(fmt.Sprintf("This is a development build; latest release is %s (use --force to install it)", release.TagName))
+			return nil
+		}
+		ui.Success(fmt.Sprintf("Already up to date (%s)", appVersion))
+		return nil
+	}
+
+	if !update.CanVerify() {
+		ui.Info(fmt.Sprintf("stak %s is available: %s", release.TagName, release.HTMLURL))
+		ui.Info("This build can't verify releases, so it won't install one itself; download it from there")
+		return nil
+	}
+
+	asset := update.AssetName()
+	ui.Info(fmt.Sprintf("Downloading %s %s", asset, release.TagName))
+	data, err := update.Download(release, asset)
+	if err != nil {
+		return err
+	}
+	ui.Success("Signature and checksum verified")
+
+	if err := update.ReplaceExecutable(exe, data); err != nil {
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Upgraded stak %s → %s", appVersion, release.TagName))
+	return nil
+}
+
+// updateChannel resolves the release channel from a flag value or the update-channel setting
+func updateChannel(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return config.GetString("update-channel", update.ChannelStable)
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"stacking/internal/config"
+)
+
+var (
+	noVerifyFlag bool
+	verifyFlag   bool
+)
+
+// addVerifyFlags adds --no-verify and --verify to a command that commits or pushes, to skip
+// or run git hooks regardless of the verify setting
+func addVerifyFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&noVerifyFlag, "no-verify", false, "Skip git's pre-commit, commit-msg and pre-push hooks")
+	cmd.Flags().BoolVar(&verifyFlag, "verify", false, "Run git hooks even if the verify setting is false")
+	cmd.MarkFlagsMutuallyExclusive("no-verify", "verify")
+}
+
+// skipHooks decides whether stak's commits and pushes skip git hooks. --no-verify and
+// --verify win over the verify setting, which defaults to running them.
+func skipHooks() bool {
+	switch {
+	case noVerifyFlag:
+		return true
+	case verifyFlag:
+		return false
+	}
+	return !config.GetBool("verify", true)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"stacking/internal/ui"
+	"stacking/internal/update"
+)
+
+var (
+	versionCheck   bool
+	versionChannel string
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the stak version",
+	Long: `Print the stak version, and those of git and gh with any features they are too old for.
+With --check, also look up the latest release and report whether an update is available.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runVersion(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "Check whether a newer release is available")
+	versionCmd.Flags().StringVar(&versionChannel, "channel", "", "Release channel to check: stable or beta")
+	versionCmd.RegisterFlagCompletionFunc("channel", completeValues(update.ChannelStable, update.ChannelBeta))
+	rootCmd.AddCommand(versionCmd)
+}
+
+func runVersion() error {
+	fmt.Printf("stak version %s\n", appVersion)
+	printToolVersions()
+	if !versionCheck {
+		return nil
+	}
+
+	release, err := update.LatestRelease(updateChannel(versionChannel))
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if update.IsNewer(release.TagName, appVersion) {
+		ui.Warning(fmt.Sprintf("A new version is available: %s", release.TagName))
+		fmt.Println("Run 'stak upgrade' to install it")
+		fmt.Println(release.HTMLURL)
+		return nil
+	}
+
+	ui.Success(fmt.Sprintf("Up to date (latest release: %s)", release.TagName))
+	return nil
+}
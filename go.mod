@@ -2,12 +2,14 @@ module stacking
 
 go 1.24.2
 
-require github.com/spf13/cobra v1.10.2
+require (
+	github.com/manifoldco/promptui v0.9.0
+	github.com/spf13/cobra v1.10.2
+)
 
 require (
 	github.com/chzyer/readline v0.0.0-20180603132655-2972be24d48e // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/manifoldco/promptui v0.9.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	golang.org/x/sys v0.0.0-20181122145206-62eef0e2fa9b // indirect
 )
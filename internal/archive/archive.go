@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"stacking/internal/profile"
+)
+
+// refPrefix is where the final commit of every archived branch is kept, so it survives
+// the branch being deleted and garbage collection
+const refPrefix = "refs/stak/archive/"
+
+// Stack is the snapshot of a completed stack
+type Stack struct {
+	ID         string    `json:"id"`
+	Base       string    `json:"base"`
+	ArchivedAt time.Time `json:"archived_at"`
+	Branches   []Branch  `json:"branches"`
+}
+
+// Branch is one archived branch, in stack order (parents first)
+type Branch struct {
+	Name     string    `json:"name"`
+	Parent   string    `json:"parent"`
+	SHA      string    `json:"sha"`
+	PRNumber int       `json:"pr,omitempty"`
+	PRState  string    `json:"pr_state,omitempty"`
+	OpenedAt time.Time `json:"opened_at"`
+	MergedAt time.Time `json:"merged_at"`
+}
+
+// Ref returns the ref that keeps an archived branch's final commit
+func (s *Stack) Ref(branch string) string {
+	return refPrefix + s.ID + "/" + branch
+}
+
+// Duration returns the time from the first PR being opened to the last one merging,
+// or 0 if the PR times are unknown
+func (s *Stack) Duration() time.Duration {
+	var first, last time.Time
+	for _, b := range s.Branches {
+		if !b.OpenedAt.IsZero() && (first.IsZero() || b.OpenedAt.Before(first)) {
+			first = b.OpenedAt
+		}
+		if b.MergedAt.After(last) {
+			last = b.MergedAt
+		}
+	}
+	if first.IsZero() || last.IsZero() {
+		return 0
+	}
+	return last.Sub(first)
+}
+
+// path returns the archive file, which lives in the git directory like the operation log
+func path() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "stak-archive.json")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// List returns all archived stacks, oldest first
+func List() ([]Stack, error) {
+	file, err := path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return []Stack{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var stacks []Stack
+	if err := json.Unmarshal(data, &stacks); err != nil {
+		return nil, fmt.Errorf("failed to parse archive: %w", err)
+	}
+	return stacks, nil
+}
+
+// Get returns the archived stack with the given ID
+func Get(id string) (*Stack, error) {
+	stacks, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range stacks {
+		if stacks[i].ID == id {
+			return &stacks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no archived stack %s. See stak archive list", id)
+}
+
+// Add stores a snapshot, choosing a unique ID from its root branch and the date, and
+// creates a ref for each branch's final commit
+func Add(s *Stack) error {
+	stacks, err := List()
+	if err != nil {
+		return err
+	}
+
+	s.ID = uniqueID(stacks, s.Branches[0].Name+"-"+s.ArchivedAt.Format("20060102"))
+	for _, b := range s.Branches {
+		// A branch already deleted locally has no commit to keep
+		if b.SHA == "" {
+			continue
+		}
+		cmd := exec.Command("git", "update-ref", s.Ref(b.Name), b.SHA)
+		if output, err := profile.CombinedOutput(cmd); err != nil {
+			return fmt.Errorf("failed to keep %s at %s: %s", b.Name, b.SHA, strings.TrimSpace(string(output)))
+		}
+	}
+
+	stacks = append(stacks, *s)
+	data, err := json.MarshalIndent(stacks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive: %w", err)
+	}
+	file, err := path()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+func uniqueID(stacks []Stack, id string) string {
+	taken := make(map[string]bool)
+	for _, s := range stacks {
+		taken[s.ID] = true
+	}
+	candidate := id
+	for n := 2; taken[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", id, n)
+	}
+	return candidate
+}
@@ -0,0 +1,119 @@
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Locations are where GitHub looks for a CODEOWNERS file, in order
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule assigns owners to the paths matching a pattern
+type Rule struct {
+	Pattern string
+	Owners  []string
+	regexp  *regexp.Regexp
+}
+
+// File is a parsed CODEOWNERS file
+type File struct {
+	Path  string
+	Rules []Rule
+}
+
+// Load finds and parses the CODEOWNERS file of the repository at root.
+// It returns nil without an error if the repository has none.
+func Load(root string) (*File, error) {
+	for _, location := range Locations {
+		path := filepath.Join(root, location)
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		defer f.Close()
+
+		file := &File{Path: location}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			// Trailing comments are allowed after the owners
+			if i := strings.Index(line, " #"); i >= 0 {
+				line = line[:i]
+			}
+			fields := strings.Fields(line)
+			re, err := compilePattern(fields[0])
+			if err != nil {
+				continue
+			}
+			file.Rules = append(file.Rules, Rule{Pattern: fields[0], Owners: fields[1:], regexp: re})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		return file, nil
+	}
+	return nil, nil
+}
+
+// Owners returns the owners of a path. As on GitHub, the last matching rule wins, and a
+// matching rule without owners leaves the path unowned.
+func (f *File) Owners(path string) []string {
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].regexp.MatchString(path) {
+			return f.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// compilePattern turns a gitignore-style CODEOWNERS pattern into a regexp over paths
+// relative to the repository root
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	// A pattern with a slash anywhere but the end is relative to the root;
+	// otherwise it matches at any depth
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	p := strings.TrimPrefix(pattern, "/")
+
+	// "dir/*" matches the files directly in dir; anything else also matches everything
+	// below a matching directory
+	suffix := "(?:/.*)?$"
+	if strings.HasSuffix(p, "/*") {
+		suffix = "$"
+	}
+	p = strings.TrimSuffix(p, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(p[i])))
+		}
+	}
+	b.WriteString(suffix)
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"stacking/internal/git"
+)
+
+// aliasKey is the git config prefix of command aliases, e.g.
+//
+//	git config --global stack.aliases.ss "sync --current-only"
+const aliasKey = "stack.aliases."
+
+// projectAliasKey is the prefix of command aliases in the project file, whose [aliases]
+// section is shared by the team:
+//
+//	[aliases]
+//	ss = "sync --current-only"
+const projectAliasKey = "aliases."
+
+// Aliases returns the command aliases, keyed by name, with the arguments they stand for. Those
+// in git config override those in the project file.
+func Aliases() map[string]string {
+	aliases := make(map[string]string)
+	if path, err := ProjectFilePath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			values, _ := git.GetConfigFileRegexp(path, `^aliases\.`)
+			for key, value := range values {
+				aliases[strings.TrimPrefix(key, projectAliasKey)] = value
+			}
+		}
+	}
+	values, _ := git.GetConfigRegexp(`^stack\.aliases\.`)
+	for key, value := range values {
+		aliases[strings.TrimPrefix(key, aliasKey)] = value
+	}
+	return aliases
+}
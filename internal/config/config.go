@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"stacking/internal/git"
+)
+
+// Settings live in git config under the [stack] section, next to branch metadata:
+//
+//	[stack]
+//	    github-app-id = 12345
+//
+// Keys are passed without the "stack." prefix.
+//
+// Every setting can be overridden with an environment variable named STAK_ plus
+// the key in upper case with dashes as underscores, e.g. STAK_MERGE_METHOD for
+// merge-method. The environment wins over git config.
+//
+// Settings shared by a whole team can be committed in .stak.toml at the
+// repository root (see project.go); git config and the environment override it.
+
+// Get retrieves a stak setting, returning "" if it is not set
+func Get(key string) (string, error) {
+	if value, ok := os.LookupEnv(EnvName(key)); ok {
+		return value, nil
+	}
+	value, err := git.GetConfig(settingKey(key))
+	if err != nil || value != "" {
+		return value, err
+	}
+	return projectSettings()[key], nil
+}
+
+// EnvName returns the environment variable that overrides a setting
+func EnvName(key string) string {
+	return "STAK_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// GetString retrieves a stak setting, falling back to def if unset or unreadable
+func GetString(key, def string) string {
+	value, err := Get(key)
+	if err != nil || value == "" {
+		return def
+	}
+	return value
+}
+
+// GetBool retrieves a boolean stak setting, falling back to def if unset or invalid
+func GetBool(key string, def bool) bool {
+	value, err := Get(key)
+	if err != nil || value == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// GetInt retrieves an integer stak setting, falling back to def if unset or invalid
+func GetInt(key string, def int) int {
+	value, err := Get(key)
+	if err != nil || value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// Set stores a stak setting in the repository's git config
+func Set(key, value string) error {
+	if err := git.SetConfig(settingKey(key), value); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+// Unset removes a stak setting from the repository's git config
+func Unset(key string) error {
+	return git.UnsetConfig(settingKey(key))
+}
+
+func settingKey(key string) string {
+	return "stack." + key
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"stacking/internal/git"
+)
+
+// ProjectFile is the shared settings file, committed at the repository root.
+// It is written so that it is valid TOML and valid git config syntax at once:
+//
+//	[stack]
+//	trunk = "main"
+//	draft = false
+//
+// which lets git parse it (git config -f) without a TOML dependency.
+const ProjectFile = ".stak.toml"
+
+// Setting is a single key/value written to the project file
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// project caches the project file's settings, keyed without the "stack." prefix
+var project map[string]string
+
+// projectSettings returns the settings in the project file, loading them on first use
+func projectSettings() map[string]string {
+	if project != nil {
+		return project
+	}
+	project = make(map[string]string)
+
+	path, err := ProjectFilePath()
+	if err != nil {
+		return project
+	}
+	if _, err := os.Stat(path); err != nil {
+		return project
+	}
+
+	values, err := git.GetConfigFileRegexp(path, `^stack\.`)
+	if err != nil {
+		return project
+	}
+	for key, value := range values {
+		project[strings.TrimPrefix(key, "stack.")] = value
+	}
+	return project
+}
+
+// ProjectFilePath returns the path of the project file in the current repository
+func ProjectFilePath() (string, error) {
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, ProjectFile), nil
+}
+
+// WriteProjectFile replaces the project file with the given settings
+func WriteProjectFile(settings []Setting) (string, error) {
+	path, err := ProjectFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("# stak settings shared by everyone working on this repository.\n")
+	b.WriteString("# Personal git config (git config stack.<key>) and STAK_* environment variables override them.\n")
+	b.WriteString("[stack]\n")
+	for _, s := range settings {
+		fmt.Fprintf(&b, "%s = %s\n", s.Key, formatValue(s.Value))
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", ProjectFile, err)
+	}
+
+	project = nil // Reload on next read
+	return path, nil
+}
+
+// formatValue renders a value that both TOML and git config parse the same way
+func formatValue(value string) string {
+	if value == "true" || value == "false" {
+		return value
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
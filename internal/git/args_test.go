@@ -0,0 +1,43 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "sync --no-push", want: []string{"sync", "--no-push"}},
+		{value: "  log \t--short  ", want: []string{"log", "--short"}},
+		{value: `modify -m "fix typo"`, want: []string{"modify", "-m", "fix typo"}},
+		{value: `create -m 'it''s'`, want: []string{"create", "-m", "its"}},
+		{value: `create -m 'say "hi"'`, want: []string{"create", "-m", `say "hi"`}},
+		{value: `create -m "say \"hi\""`, want: []string{"create", "-m", `say "hi"`}},
+		{value: `create -m a\ b`, want: []string{"create", "-m", "a b"}},
+		{value: `create -m 'a\ b'`, want: []string{"create", "-m", `a\ b`}},
+		{value: `submit --title ""`, want: []string{"submit", "--title", ""}},
+		{value: `sync $HOME ~`, want: []string{"sync", "$HOME", "~"}},
+		{value: `"/opt/Sublime Text/subl" --wait`, want: []string{"/opt/Sublime Text/subl", "--wait"}},
+		{value: `modify -m "unterminated`, wantErr: true},
+		{value: `modify -m 'unterminated`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SplitArgs(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SplitArgs(%q) = %q, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitArgs(%q) failed: %v", tt.value, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
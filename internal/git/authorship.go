@@ -0,0 +1,127 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// Authorship is who a commit that replaces other commits, e.g. by squashing them, is credited
+// to. The zero value credits the user making it, as git does.
+type Authorship struct {
+	Author    string   // "Name <email>" of the author, or "" for the user
+	Date      string   // Author date, or "" for now
+	CoAuthors []string // "Name <email>" of each Co-authored-by trailer
+}
+
+// Args returns the git commit flags that apply the authorship. git before 2.32 has no
+// --trailer, so the co-authors are left out; see Message and CanAddTrailers.
+func (a Authorship) Args() []string {
+	var args []string
+	if a.Author != "" {
+		args = append(args, "--author="+a.Author)
+	}
+	if a.Date != "" {
+		args = append(args, "--date="+a.Date)
+	}
+	if CanAddTrailers() {
+		for _, coAuthor := range a.CoAuthors {
+			args = append(args, "--trailer", "Co-authored-by: "+coAuthor)
+		}
+	}
+	return args
+}
+
+// Message returns message with the Co-authored-by trailers that Args couldn't add
+func (a Authorship) Message(message string) string {
+	if CanAddTrailers() || len(a.CoAuthors) == 0 {
+		return message
+	}
+	message = strings.TrimRight(message, "\n") + "\n\n"
+	for _, coAuthor := range a.CoAuthors {
+		message += "Co-authored-by: " + coAuthor + "\n"
+	}
+	return message
+}
+
+// CanAddTrailers reports whether git commit has --trailer
+func CanAddTrailers() bool {
+	return GetVersion().AtLeast(TrailerVersion)
+}
+
+// BranchAuthorship works out the authorship of a commit replacing the commits of branch after
+// base. With keepAuthor, the author and date of the first of them are kept. With coAuthors,
+// the authors of the others, and the co-authors their messages credit, become co-authors;
+// the commit's author isn't listed, nor anyone twice.
+func BranchAuthorship(base, branch string, keepAuthor, coAuthors bool) (Authorship, error) {
+	var authorship Authorship
+	if !keepAuthor && !coAuthors {
+		return authorship, nil
+	}
+
+	cmd := exec.Command("git", "log", "--reverse", "--no-merges",
+		"--format=%an <%ae>%x00%aI%x00%(trailers:key=Co-authored-by,valueonly,separator=%x01)",
+		base+".."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return authorship, fmt.Errorf("failed to read the authors of %s: %w", branch, err)
+	}
+
+	var authors []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		if keepAuthor && authorship.Author == "" {
+			authorship.Author, authorship.Date = fields[0], fields[1]
+		}
+		authors = append(authors, fields[0])
+		for _, trailer := range strings.Split(fields[2], "\x01") {
+			if trailer = strings.TrimSpace(trailer); trailer != "" {
+				authors = append(authors, trailer)
+			}
+		}
+	}
+	if !coAuthors {
+		return authorship, nil
+	}
+
+	author := authorship.Author
+	if author == "" {
+		author = currentAuthor()
+	}
+	seen := map[string]bool{identEmail(author): true}
+	for _, coAuthor := range authors {
+		if email := identEmail(coAuthor); !seen[email] {
+			seen[email] = true
+			authorship.CoAuthors = append(authorship.CoAuthors, coAuthor)
+		}
+	}
+	return authorship, nil
+}
+
+// currentAuthor returns "Name <email>" of the user, as git would record them as author
+func currentAuthor() string {
+	output, err := profile.Output(exec.Command("git", "var", "GIT_AUTHOR_IDENT"))
+	if err != nil {
+		return ""
+	}
+	// The ident ends with the timestamp and timezone
+	ident := strings.TrimSpace(string(output))
+	if i := strings.LastIndex(ident, ">"); i >= 0 {
+		ident = ident[:i+1]
+	}
+	return ident
+}
+
+// identEmail returns the email of "Name <email>", lower-cased, which identifies a person better
+// than their name
+func identEmail(ident string) string {
+	if start, end := strings.LastIndex(ident, "<"), strings.LastIndex(ident, ">"); start >= 0 && end > start {
+		return strings.ToLower(ident[start+1 : end])
+	}
+	return strings.ToLower(strings.TrimSpace(ident))
+}
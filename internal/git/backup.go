@@ -0,0 +1,173 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"stacking/internal/profile"
+)
+
+// BackupRefPrefix is where branch heads are saved before stak rewrites or deletes them:
+// refs/stak/backup/<timestamp>/<branch>. A force-push saves the remote head it replaces
+// as <branch>@<remote>.
+const BackupRefPrefix = "refs/stak/backup/"
+
+// backupStampFormat names the backups of one stak command, in local time
+const backupStampFormat = "20060102-150405"
+
+var (
+	// backupStamp groups every backup made by this process under one timestamp
+	backupStamp string
+	// backedUp remembers which heads were saved, so only the state before the command is kept
+	backedUp = make(map[string]bool)
+)
+
+// Backup is a saved branch head
+type Backup struct {
+	Stamp  string
+	Time   time.Time
+	Branch string
+	// Remote is set when the remote branch was saved before a force-push
+	Remote string
+	SHA    string
+}
+
+// Ref returns the ref holding the backup
+func (b Backup) Ref() string {
+	name := b.Branch
+	if b.Remote != "" {
+		name += "@" + b.Remote
+	}
+	return BackupRefPrefix + b.Stamp + "/" + name
+}
+
+// BackupBranch saves the current head of a local branch, once per stak command
+func BackupBranch(branch string) error {
+	sha, err := GetCommitSHA("refs/heads/" + branch)
+	if err != nil {
+		// Nothing to lose if the branch doesn't exist
+		return nil
+	}
+	return saveBackup(branch, sha)
+}
+
+// BackupCurrentBranch saves the head of the checked-out branch, if any
+func BackupCurrentBranch() error {
+	branch, err := GetCurrentBranch()
+	if err != nil || branch == "HEAD" {
+		return nil
+	}
+	return BackupBranch(branch)
+}
+
+// backupRemoteBranch saves the remote head a force-push of branch would replace, unless
+// the local branch already contains it
+func backupRemoteBranch(branch string) error {
+	sha, err := GetCommitSHA(RemoteRef(branch))
+	if err != nil || BranchContainsCommit("refs/heads/"+branch, sha) {
+		return nil
+	}
+	return saveBackup(branch+"@"+Remote, sha)
+}
+
+func saveBackup(name, sha string) error {
+	if backedUp[name] {
+		return nil
+	}
+	if backupStamp == "" {
+		backupStamp = newBackupStamp()
+	}
+
+	ref := BackupRefPrefix + backupStamp + "/" + name
+	cmd := exec.Command("git", "update-ref", ref, sha)
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to back up %s: %s", name, strings.TrimSpace(string(output)))
+	}
+	backedUp[name] = true
+	return nil
+}
+
+// newBackupStamp returns the current time as a stamp no earlier command used, moving it
+// forward a second at a time if two commands ran within the same second
+func newBackupStamp() string {
+	t := time.Now()
+	for {
+		stamp := t.Format(backupStampFormat)
+		cmd := exec.Command("git", "for-each-ref", "--count=1", BackupRefPrefix+stamp+"/")
+		output, err := profile.Output(cmd)
+		if err != nil || len(strings.TrimSpace(string(output))) == 0 {
+			return stamp
+		}
+		t = t.Add(time.Second)
+	}
+}
+
+// ListBackups returns all saved branch heads, newest first
+func ListBackups() ([]Backup, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(objectname) %(refname)", BackupRefPrefix)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []Backup
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		sha, ref, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		stamp, name, ok := strings.Cut(strings.TrimPrefix(ref, BackupRefPrefix), "/")
+		if !ok {
+			continue
+		}
+		t, err := time.ParseInLocation(backupStampFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+
+		b := Backup{Stamp: stamp, Time: t, Branch: name, SHA: sha}
+		if i := strings.LastIndex(name, "@"); i > 0 && name[i+1:] == Remote {
+			b.Branch, b.Remote = name[:i], Remote
+		}
+		backups = append(backups, b)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Stamp > backups[j].Stamp
+	})
+	return backups, nil
+}
+
+// DeleteBackup removes a saved branch head
+func DeleteBackup(b Backup) error {
+	cmd := exec.Command("git", "update-ref", "-d", b.Ref())
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to delete backup %s: %s", b.Ref(), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RestoreBranch points a branch at a commit, creating it if needed. The checked-out branch
+// is reset along with the working tree. The branch's current head is backed up first.
+func RestoreBranch(branch, sha string) error {
+	if err := CheckNotProtected(branch, "reset"); err != nil {
+		return err
+	}
+	if err := BackupBranch(branch); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if current, _ := GetCurrentBranch(); current == branch {
+		cmd = exec.Command("git", "reset", "--hard", sha)
+	} else {
+		cmd = exec.Command("git", "branch", "--force", branch, sha)
+	}
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to restore %s: %s", branch, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -1,25 +1,37 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+
+	"stacking/internal/profile"
 )
 
-// GetCurrentBranch returns the name of the current branch
+// GetCurrentBranch returns the name of the current branch. In a jj colocated repository, it is
+// the bookmark jj works on.
 func GetCurrentBranch() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
 	}
-	return strings.TrimSpace(string(output)), nil
+	branch := strings.TrimSpace(string(output))
+	// jj keeps HEAD detached, on the branch it works on; mid-rebase it is git's own doing
+	if branch == "HEAD" && IsJJColocated() {
+		if inProgress, _ := IsRebaseInProgress(); !inProgress {
+			return jjCurrentBranch()
+		}
+	}
+	return branch, nil
 }
 
 // BranchExists checks if a branch exists locally
 func BranchExists(branch string) (bool, error) {
 	cmd := exec.Command("git", "rev-parse", "--verify", branch)
-	err := cmd.Run()
+	err := profile.Run(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 128 {
 			return false, nil
@@ -32,7 +44,7 @@ func BranchExists(branch string) (bool, error) {
 // CreateBranch creates a new branch from the current HEAD
 func CreateBranch(name string) error {
 	cmd := exec.Command("git", "checkout", "-b", name)
-	if err := cmd.Run(); err != nil {
+	if err := profile.Run(cmd); err != nil {
 		return fmt.Errorf("failed to create branch %s: %w", name, err)
 	}
 	return nil
@@ -41,52 +53,172 @@ func CreateBranch(name string) error {
 // CheckoutBranch checks out an existing branch
 func CheckoutBranch(name string) error {
 	cmd := exec.Command("git", "checkout", name)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
+		if isDirtyTreeOutput(string(output)) {
+			return fmt.Errorf("failed to checkout branch %s: %w", name, ErrDirtyTree)
+		}
 		return fmt.Errorf("failed to checkout branch %s: %s", name, string(output))
 	}
 	return nil
 }
 
+// CheckoutDetached checks out a commit on a detached HEAD
+func CheckoutDetached(ref string) error {
+	cmd := exec.Command("git", "checkout", "--quiet", "--detach", ref)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		if isDirtyTreeOutput(string(output)) {
+			return fmt.Errorf("failed to checkout %s: %w", ref, ErrDirtyTree)
+		}
+		return fmt.Errorf("failed to checkout %s: %s", ref, string(output))
+	}
+	return nil
+}
+
 // DeleteBranch deletes a local branch
 func DeleteBranch(name string, force bool) error {
+	if err := CheckNotProtected(name, "delete"); err != nil {
+		return err
+	}
+	if err := BackupBranch(name); err != nil {
+		return err
+	}
+
 	flag := "-d"
 	if force {
 		flag = "-D"
 	}
 	cmd := exec.Command("git", "branch", flag, name)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to delete branch %s: %s", name, string(output))
 	}
 	return nil
 }
 
+// RenameBranch renames a local branch
+func RenameBranch(name, newName string) error {
+	if err := CheckNotProtected(name, "rename"); err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "branch", "-m", name, newName)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to rename branch %s to %s: %s", name, newName, string(output))
+	}
+	return nil
+}
+
+// Remote is the git remote stak pushes to and fetches from
+var Remote = "origin"
+
+// RemoteRef returns the remote-tracking ref for a branch, e.g. origin/feature
+func RemoteRef(branch string) string {
+	return Remote + "/" + branch
+}
+
+// NoVerify makes the commits and pushes stak makes skip git's pre-commit, commit-msg and
+// pre-push hooks (--no-verify). Like Remote, it is set at startup.
+var NoVerify bool
+
 // Push pushes the current branch to remote
 func Push(branch string, setUpstream bool, force bool) error {
 	args := []string{"push"}
+	if NoVerify {
+		args = append(args, "--no-verify")
+	}
 	if force {
+		if err := CheckNotProtected(branch, "force-push"); err != nil {
+			return err
+		}
+		if CheckForcePush != nil {
+			if err := CheckForcePush(branch); err != nil {
+				return err
+			}
+		}
+		if err := backupRemoteBranch(branch); err != nil {
+			return err
+		}
 		args = append(args, "--force-with-lease")
 	}
 	if setUpstream {
-		args = append(args, "-u", "origin", branch)
+		args = append(args, "-u", Remote, branch)
 	} else {
-		args = append(args, "origin", branch)
+		args = append(args, Remote, branch)
+	}
+
+	// Without its pre-push hook, LFS content would not follow the pointers to the remote
+	if err := pushLFSObjects(branch); err != nil {
+		return err
 	}
 
 	cmd := exec.Command("git", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to push branch %s: %s", branch, string(output))
 	}
 	return nil
 }
 
+// DeleteRemoteBranch deletes branch on the remote, e.g. once its PR is merged
+func DeleteRemoteBranch(branch string) error {
+	if err := CheckNotProtected(branch, "delete"); err != nil {
+		return err
+	}
+	args := []string{"push"}
+	if NoVerify {
+		args = append(args, "--no-verify")
+	}
+	args = append(args, Remote, "--delete", branch)
+	output, err := profile.CombinedOutput(exec.Command("git", args...))
+	if err != nil {
+		return fmt.Errorf("failed to delete %s on %s: %s", branch, Remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // Fetch fetches from remote
 func Fetch() error {
-	cmd := exec.Command("git", "fetch", "origin")
-	output, err := cmd.CombinedOutput()
+	cmd := exec.Command("git", "fetch", Remote)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %s", string(output))
+	}
+	return nil
+}
+
+// FetchBranches fetches only the given branches into their remote-tracking branches, which
+// is much faster than Fetch in repositories with many refs. Branches the remote doesn't have,
+// e.g. never pushed or deleted after merging, are skipped: git would give up at the first
+// one, so a single ls-remote finds which exist first.
+func FetchBranches(branches []string) error {
+	if len(branches) == 0 {
+		return nil
+	}
+	args := []string{"ls-remote", "--heads", Remote}
+	wanted := make(map[string]bool, len(branches))
+	for _, branch := range branches {
+		args = append(args, "refs/heads/"+branch)
+		wanted[branch] = true
+	}
+	output, err := profile.CombinedOutput(exec.Command("git", args...))
 	if err != nil {
+		return fmt.Errorf("failed to list branches on %s: %s", Remote, strings.TrimSpace(string(output)))
+	}
+
+	args = []string{"fetch", Remote}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		// ls-remote matches patterns against the end of ref names, so it may list others too
+		if _, ref, ok := strings.Cut(line, "\t"); ok && wanted[strings.TrimPrefix(ref, "refs/heads/")] {
+			branch := strings.TrimPrefix(ref, "refs/heads/")
+			args = append(args, fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branch, Remote, branch))
+		}
+	}
+	if len(args) == 2 {
+		return nil
+	}
+	if output, err := profile.CombinedOutput(exec.Command("git", args...)); err != nil {
 		return fmt.Errorf("failed to fetch: %s", string(output))
 	}
 	return nil
@@ -95,7 +227,7 @@ func Fetch() error {
 // HasUncommittedChanges checks if there are uncommitted changes
 func HasUncommittedChanges() (bool, error) {
 	cmd := exec.Command("git", "status", "--porcelain")
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return false, fmt.Errorf("failed to check git status: %w", err)
 	}
@@ -105,7 +237,7 @@ func HasUncommittedChanges() (bool, error) {
 // HasCommits checks if the current branch has any commits
 func HasCommits() (bool, error) {
 	cmd := exec.Command("git", "rev-parse", "HEAD")
-	err := cmd.Run()
+	err := profile.Run(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 128 {
 			return false, nil
@@ -118,14 +250,45 @@ func HasCommits() (bool, error) {
 // IsGitRepository checks if the current directory is a git repository
 func IsGitRepository() bool {
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	err := cmd.Run()
+	err := profile.Run(cmd)
 	return err == nil
 }
 
-// GetRemoteURL gets the remote URL for origin
+// GetRepoRoot returns the top-level directory of the working tree
+func GetRepoRoot() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository root: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRemotes returns the names of all configured remotes
+func GetRemotes() ([]string, error) {
+	cmd := exec.Command("git", "remote")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// GetGitPath resolves a path inside the git directory, e.g. hooks/pre-push
+// Honors settings like core.hooksPath and worktrees
+func GetGitPath(name string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", name)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git path %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRemoteURL gets the URL of the configured remote
 func GetRemoteURL() (string, error) {
-	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
-	output, err := cmd.Output()
+	cmd := exec.Command("git", "config", "--get", "remote."+Remote+".url")
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to get remote URL: %w", err)
 	}
@@ -134,8 +297,8 @@ func GetRemoteURL() (string, error) {
 
 // RemoteBranchExists checks if a branch exists on remote
 func RemoteBranchExists(branch string) (bool, error) {
-	cmd := exec.Command("git", "ls-remote", "--heads", "origin", branch)
-	output, err := cmd.Output()
+	cmd := exec.Command("git", "ls-remote", "--heads", Remote, branch)
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return false, fmt.Errorf("failed to check remote branch: %w", err)
 	}
@@ -144,19 +307,73 @@ func RemoteBranchExists(branch string) (bool, error) {
 
 // ResetToRemote resets the current branch to match its remote counterpart
 func ResetToRemote(branch string) error {
-	remoteBranch := fmt.Sprintf("origin/%s", branch)
+	if err := CheckNotProtected(branch, "reset"); err != nil {
+		return err
+	}
+	if err := BackupBranch(branch); err != nil {
+		return err
+	}
+
+	remoteBranch := RemoteRef(branch)
 	cmd := exec.Command("git", "reset", "--hard", remoteBranch)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to reset to %s: %s", remoteBranch, string(output))
 	}
 	return nil
 }
 
+// ErrDiverged is returned when a branch can't be fast-forwarded to its remote counterpart
+var ErrDiverged = errors.New("branch has diverged from the remote")
+
+// FastForwardToRemote moves a local branch up to its remote-tracking branch, without
+// checking it out unless it is the current branch. It never rewrites local commits:
+// if the branch has commits the remote lacks, it returns ErrDiverged.
+// It returns how many commits the branch moved forward.
+func FastForwardToRemote(branch string) (int, error) {
+	remoteBranch := RemoteRef(branch)
+	local, err := GetCommitSHA(branch)
+	if err != nil {
+		return 0, err
+	}
+	remote, err := GetCommitSHA(remoteBranch)
+	if err != nil {
+		return 0, err
+	}
+	if local == remote || BranchContainsCommit(local, remote) {
+		return 0, nil
+	}
+	if !BranchContainsCommit(remote, local) {
+		return 0, fmt.Errorf("cannot fast-forward %s to %s: %w", branch, remoteBranch, ErrDiverged)
+	}
+
+	cmd := exec.Command("git", "rev-list", "--count", local+".."+remote)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+
+	current, _ := GetCurrentBranch()
+	if current == branch {
+		// Updating the checked-out branch must update the working tree too
+		cmd = exec.Command("git", "merge", "--ff-only", "--quiet", remoteBranch)
+	} else {
+		cmd = exec.Command("git", "update-ref", "refs/heads/"+branch, remote, local)
+	}
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		if isDirtyTreeOutput(string(output)) {
+			return 0, fmt.Errorf("cannot fast-forward %s: %w", branch, ErrDirtyTree)
+		}
+		return 0, fmt.Errorf("failed to fast-forward %s: %s", branch, strings.TrimSpace(string(output)))
+	}
+	return count, nil
+}
+
 // GetAllLocalBranches returns a list of all local branch names
 func GetAllLocalBranches() ([]string, error) {
 	cmd := exec.Command("git", "branch", "--format=%(refname:short)")
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list branches: %w", err)
 	}
@@ -170,10 +387,15 @@ func GetAllLocalBranches() ([]string, error) {
 	return branches, nil
 }
 
-// GetCommitAncestors returns a list of commit hashes in ancestry order
-func GetCommitAncestors(branch string) ([]string, error) {
-	cmd := exec.Command("git", "rev-list", "--first-parent", branch)
-	output, err := cmd.Output()
+// GetCommitAncestors returns a list of commit hashes in ancestry order, stopping at the
+// history of the stop refs, if any, instead of walking all the way to the root commit
+func GetCommitAncestors(branch string, stop ...string) ([]string, error) {
+	args := []string{"rev-list", "--first-parent", branch}
+	if len(stop) > 0 {
+		args = append(append(args, "--not"), stop...)
+	}
+	cmd := exec.Command("git", args...)
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get commit ancestors: %w", err)
 	}
@@ -190,13 +412,210 @@ func GetCommitAncestors(branch string) ([]string, error) {
 // BranchContainsCommit checks if a branch contains a specific commit
 func BranchContainsCommit(branch, commit string) bool {
 	cmd := exec.Command("git", "merge-base", "--is-ancestor", commit, branch)
-	return cmd.Run() == nil
+	return profile.Run(cmd) == nil
+}
+
+// GetCommitSHA resolves a branch or other revision to its full commit hash
+func GetCommitSHA(ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s", ref)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetMergeBase returns the best common ancestor of two commits
+func GetMergeBase(a, b string) (string, error) {
+	cmd := exec.Command("git", "merge-base", a, b)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base of %s and %s: %w", a, b, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// AheadBehind counts the commits branch has that base doesn't (ahead) and those base has that
+// branch doesn't (behind)
+func AheadBehind(base, branch string) (int, int, error) {
+	cmd := exec.Command("git", "rev-list", "--left-right", "--count", base+"..."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare %s with %s: %w", branch, base, err)
+	}
+	counts := strings.Fields(string(output))
+	if len(counts) != 2 {
+		return 0, 0, fmt.Errorf("failed to compare %s with %s: unexpected output %q", branch, base, string(output))
+	}
+	behind, _ := strconv.Atoi(counts[0])
+	ahead, _ := strconv.Atoi(counts[1])
+	return ahead, behind, nil
+}
+
+// GetCommitSubjects returns the subjects of the commits on branch that base doesn't have,
+// oldest first. Merge commits are left out.
+func GetCommitSubjects(base, branch string) ([]string, error) {
+	cmd := exec.Command("git", "log", "--reverse", "--no-merges", "--format=%s", base+".."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits on %s: %w", branch, err)
+	}
+
+	var subjects []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects, nil
+}
+
+// CommitMessage is the full message of a commit
+type CommitMessage struct {
+	SHA     string
+	Message string
+}
+
+// GetCommitMessages returns the commits on branch that base doesn't have with their messages,
+// oldest first, following only first parents. Merge commits are left out.
+func GetCommitMessages(base, branch string) ([]CommitMessage, error) {
+	cmd := exec.Command("git", "log", "--reverse", "--first-parent", "--no-merges", "--format=%H%x00%B%x1e", base+".."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits on %s: %w", branch, err)
+	}
+
+	var commits []CommitMessage
+	for _, record := range strings.Split(string(output), "\x1e") {
+		sha, message, ok := strings.Cut(strings.TrimSpace(record), "\x00")
+		if ok {
+			commits = append(commits, CommitMessage{SHA: sha, Message: message})
+		}
+	}
+	return commits, nil
+}
+
+// DiffStat summarizes the changes a branch made since it forked from base
+type DiffStat struct {
+	Files      int
+	Insertions int
+	Deletions  int
+}
+
+// GetDiffStat returns the files changed and lines added and removed on branch since its
+// merge base with base, i.e. "git diff base...branch". Binary files count as changed only.
+func GetDiffStat(base, branch string) (DiffStat, error) {
+	cmd := exec.Command("git", "diff", "--numstat", base+"..."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return DiffStat{}, fmt.Errorf("failed to diff %s against %s: %w", branch, base, err)
+	}
+
+	var stat DiffStat
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		stat.Files++
+		// Binary files show "-" instead of line counts
+		if n, err := strconv.Atoi(fields[0]); err == nil {
+			stat.Insertions += n
+		}
+		if n, err := strconv.Atoi(fields[1]); err == nil {
+			stat.Deletions += n
+		}
+	}
+	return stat, nil
+}
+
+// GetChangedFiles returns the paths a branch changed since it forked from base
+func GetChangedFiles(base, branch string) ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", base+"..."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s against %s: %w", branch, base, err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// Hunk is a changed line range of a file. Old lines are in the base version and new lines in
+// the branch's; a range with no lines is an insertion or deletion after its start line.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+}
+
+// GetChangedHunks returns the line ranges a branch changed in each file since it forked from base
+func GetChangedHunks(base, branch string) (map[string][]Hunk, error) {
+	cmd := exec.Command("git", "diff", "-U0", "--no-color", "--no-ext-diff", base+"..."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s against %s: %w", branch, base, err)
+	}
+
+	hunks := make(map[string][]Hunk)
+	file := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- a/"):
+			file = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "+++ b/"):
+			// Deleted files keep the old path
+			file = strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			var h Hunk
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			h.OldStart, h.OldLines = parseHunkRange(fields[1])
+			h.NewStart, h.NewLines = parseHunkRange(fields[2])
+			hunks[file] = append(hunks[file], h)
+		}
+	}
+	return hunks, nil
+}
+
+// parseHunkRange parses "-start,lines" or "+start" from a hunk header
+func parseHunkRange(field string) (int, int) {
+	start, lines, found := strings.Cut(field[1:], ",")
+	s, _ := strconv.Atoi(start)
+	if !found {
+		return s, 1
+	}
+	n, _ := strconv.Atoi(lines)
+	return s, n
+}
+
+// GetBranchTips returns the commit hash of every local branch, keyed by branch name
+func GetBranchTips() (map[string]string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname:short) %(objectname)", "refs/heads/")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branch tips: %w", err)
+	}
+
+	tips := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if name, sha, ok := strings.Cut(line, " "); ok {
+			tips[name] = sha
+		}
+	}
+	return tips, nil
 }
 
 // HasUnstagedChanges checks if there are unstaged changes in the working directory
 func HasUnstagedChanges() (bool, error) {
 	cmd := exec.Command("git", "diff", "--quiet")
-	err := cmd.Run()
+	err := profile.Run(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
 			return true, nil // Exit code 1 means there are changes
@@ -209,7 +628,7 @@ func HasUnstagedChanges() (bool, error) {
 // HasStagedChanges checks if there are staged changes in the index
 func HasStagedChanges() (bool, error) {
 	cmd := exec.Command("git", "diff", "--cached", "--quiet")
-	err := cmd.Run()
+	err := profile.Run(cmd)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
 			return true, nil // Exit code 1 means there are staged changes
@@ -222,7 +641,7 @@ func HasStagedChanges() (bool, error) {
 // StageAll stages all changes (tracked and untracked files)
 func StageAll() error {
 	cmd := exec.Command("git", "add", "-A")
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to stage all changes: %s", string(output))
 	}
@@ -231,10 +650,53 @@ func StageAll() error {
 
 // Commit creates a new commit with the given message
 func Commit(message string) error {
-	cmd := exec.Command("git", "commit", "-m", message)
-	output, err := cmd.CombinedOutput()
+	return CommitAs(message, Authorship{})
+}
+
+// CommitAs creates a new commit with the given message and authorship
+func CommitAs(message string, authorship Authorship) error {
+	cmd := CommitCommand(append([]string{"commit", "-m", authorship.Message(message)}, authorship.Args()...)...)
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to commit: %s", string(output))
 	}
 	return nil
 }
+
+// RemoteTrackingBranchExists checks if <remote>/<branch> exists locally (as of the last fetch)
+func RemoteTrackingBranchExists(branch string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/remotes/"+RemoteRef(branch))
+	return profile.Run(cmd) == nil
+}
+
+// CreateBranchAt creates a branch at a commit without checking it out
+func CreateBranchAt(name, commit string) error {
+	cmd := exec.Command("git", "branch", name, commit)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s: %s", name, string(output))
+	}
+	return nil
+}
+
+// CreateTrackingBranch creates a local branch from <remote>/<branch> without checking it out
+func CreateTrackingBranch(branch string) error {
+	cmd := exec.Command("git", "branch", "--track", branch, RemoteRef(branch))
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s from %s: %s", branch, RemoteRef(branch), string(output))
+	}
+	return nil
+}
+
+// FetchPullRequest fetches a PR's head (refs/pull/<n>/head) into a local branch
+// This works for PRs opened from forks, whose branches don't exist on the remote
+func FetchPullRequest(prNumber int, branch string) error {
+	refspec := fmt.Sprintf("pull/%d/head:%s", prNumber, branch)
+	cmd := exec.Command("git", "fetch", Remote, refspec)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to fetch PR #%d: %s", prNumber, string(output))
+	}
+	return nil
+}
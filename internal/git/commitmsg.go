@@ -0,0 +1,140 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// HasHook checks if git would run the named hook, honoring core.hooksPath: the hook file
+// exists and is executable
+func HasHook(name string) bool {
+	path, err := GetGitPath("hooks/" + name)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+// EditCommitMessage opens the editor git commit would use (GIT_EDITOR, core.editor, VISUAL,
+// EDITOR) on template, and returns the message without comment lines and surplus blank lines
+func EditCommitMessage(template string) (string, error) {
+	path, err := GetGitPath("COMMIT_EDITMSG")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(template), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	output, err := profile.Output(exec.Command("git", "var", "GIT_EDITOR"))
+	if err != nil {
+		return "", fmt.Errorf("failed to find an editor: %w", err)
+	}
+	cmd, err := editorCommand(strings.TrimSpace(string(output)), path)
+	if err != nil {
+		return "", err
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := profile.Run(cmd); err != nil {
+		return "", fmt.Errorf("the editor failed: %w", err)
+	}
+	return readCommitMessage(path)
+}
+
+// shellMetachars are the characters, besides quotes, backslashes and blanks, that git takes to
+// mean a command needs the shell
+const shellMetachars = "|&;<>()$`*?[#~=%\n"
+
+// editorCommand returns the command that opens editor on path. An editor with arguments is
+// split into them and run directly; only one that needs the shell, for a pipe, a variable or
+// the like, is run through sh, as git would.
+func editorCommand(editor, path string) (*exec.Cmd, error) {
+	if !strings.ContainsAny(editor, shellMetachars) {
+		if args, err := SplitArgs(editor); err == nil && len(args) > 0 {
+			return exec.Command(args[0], append(args[1:], path)...), nil
+		}
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		return nil, fmt.Errorf("the editor %q needs a shell to run, but sh isn't on PATH", editor)
+	}
+	return exec.Command("sh", "-c", editor+` "$@"`, editor, path), nil
+}
+
+// RunCommitMsgHook runs the commit-msg hook on message, unless NoVerify is set, the way git
+// commit does, and returns the message as the hook left it
+func RunCommitMsgHook(message string) (string, error) {
+	if NoVerify || !HasHook("commit-msg") {
+		return message, nil
+	}
+	path, err := GetGitPath("COMMIT_EDITMSG")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(message+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	hook, err := GetGitPath("hooks/commit-msg")
+	if err != nil {
+		return "", err
+	}
+	root, err := GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(hook, path)
+	cmd.Dir = root
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := profile.Run(cmd); err != nil {
+		return "", fmt.Errorf("the commit-msg hook rejected the message (skip it with --no-verify)")
+	}
+	return readCommitMessage(path)
+}
+
+// readCommitMessage reads a commit message file the way git commit does, dropping comment
+// lines and surplus blank lines. An empty message is an error.
+func readCommitMessage(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	cmd := exec.Command("git", "stripspace", "--strip-comments")
+	cmd.Stdin = strings.NewReader(string(content))
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the commit message: %w", err)
+	}
+	message := strings.TrimSpace(string(output))
+	if message == "" {
+		return "", fmt.Errorf("aborting commit due to empty commit message")
+	}
+	return message, nil
+}
+
+// WriteIndexTree writes the index as a tree object and returns it
+func WriteIndexTree() (string, error) {
+	output, err := profile.Output(exec.Command("git", "write-tree"))
+	if err != nil {
+		return "", fmt.Errorf("failed to write the index: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// StashCreate makes a commit of the changes to tracked files on top of HEAD the way git stash
+// does, without storing it in the stash or touching the working tree. It returns "" when
+// there are no changes.
+func StashCreate() (string, error) {
+	output, err := profile.Output(exec.Command("git", "stash", "create"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read changes: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,29 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		editor string
+		want   []string
+	}{
+		{"vim", []string{"vim", "MSG"}},
+		{"code --wait", []string{"code", "--wait", "MSG"}},
+		{`"/opt/Sublime Text/subl" -w`, []string{"/opt/Sublime Text/subl", "-w", "MSG"}},
+		// Anything else the shell would expand goes through it
+		{`$HOME/bin/edit`, []string{"sh", "-c", `$HOME/bin/edit "$@"`, `$HOME/bin/edit`, "MSG"}},
+		{"emacs -nw 2>/dev/null", []string{"sh", "-c", `emacs -nw 2>/dev/null "$@"`, "emacs -nw 2>/dev/null", "MSG"}},
+		{`vim "unterminated`, []string{"sh", "-c", `vim "unterminated "$@"`, `vim "unterminated`, "MSG"}},
+	}
+	for _, tt := range tests {
+		cmd, err := editorCommand(tt.editor, "MSG")
+		if err != nil {
+			t.Errorf("editorCommand(%q) failed: %v", tt.editor, err)
+		} else if !reflect.DeepEqual(cmd.Args, tt.want) {
+			t.Errorf("editorCommand(%q) = %q, want %q", tt.editor, cmd.Args, tt.want)
+		}
+	}
+}
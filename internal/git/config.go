@@ -3,14 +3,17 @@ package git
 import (
 	"fmt"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
+
+	"stacking/internal/profile"
 )
 
 // GetConfig retrieves a git config value
 func GetConfig(key string) (string, error) {
 	cmd := exec.Command("git", "config", "--get", key)
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		// Exit code 1 means key doesn't exist
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
@@ -24,16 +27,39 @@ func GetConfig(key string) (string, error) {
 // SetConfig sets a git config value
 func SetConfig(key, value string) error {
 	cmd := exec.Command("git", "config", key, value)
-	if err := cmd.Run(); err != nil {
+	if err := profile.Run(cmd); err != nil {
 		return fmt.Errorf("failed to set git config %s=%s: %w", key, value, err)
 	}
 	return nil
 }
 
+// GetGlobalConfig retrieves a value from the user's global git config
+func GetGlobalConfig(key string) (string, error) {
+	cmd := exec.Command("git", "config", "--global", "--get", key)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		// Exit code 1 means key doesn't exist
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get global git config %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetGlobalConfig sets a value in the user's global git config
+func SetGlobalConfig(key, value string) error {
+	cmd := exec.Command("git", "config", "--global", key, value)
+	if err := profile.Run(cmd); err != nil {
+		return fmt.Errorf("failed to set global git config %s=%s: %w", key, value, err)
+	}
+	return nil
+}
+
 // UnsetConfig removes a git config value
 func UnsetConfig(key string) error {
 	cmd := exec.Command("git", "config", "--unset", key)
-	if err := cmd.Run(); err != nil {
+	if err := profile.Run(cmd); err != nil {
 		// Ignore error if key doesn't exist
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 5 {
 			return nil
@@ -45,8 +71,18 @@ func UnsetConfig(key string) error {
 
 // GetConfigRegexp retrieves all git config entries matching a regexp
 func GetConfigRegexp(pattern string) (map[string]string, error) {
-	cmd := exec.Command("git", "config", "--get-regexp", pattern)
-	output, err := cmd.Output()
+	return getConfigRegexp(pattern)
+}
+
+// GetConfigFileRegexp retrieves all entries matching a regexp from a file in git config syntax
+func GetConfigFileRegexp(file, pattern string) (map[string]string, error) {
+	return getConfigRegexp(pattern, "-f", file)
+}
+
+func getConfigRegexp(pattern string, extraArgs ...string) (map[string]string, error) {
+	args := append([]string{"config"}, extraArgs...)
+	cmd := exec.Command("git", append(args, "--get-regexp", pattern)...)
+	output, err := profile.Output(cmd)
 	if err != nil {
 		// Exit code 1 means no matches
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
@@ -56,7 +92,8 @@ func GetConfigRegexp(pattern string) (map[string]string, error) {
 	}
 
 	result := make(map[string]string)
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	// An empty value is printed as the key and a space, which trimming spaces would lose
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
 	for _, line := range lines {
 		if line == "" {
 			continue
@@ -69,22 +106,125 @@ func GetConfigRegexp(pattern string) (map[string]string, error) {
 	return result, nil
 }
 
+// branchMetadata caches every stack.branch.<name>.<field> key, keyed by branch then field.
+// It is loaded with a single git config call on first use and kept in sync by the setters
+// below, so reading metadata for any number of branches costs one git invocation.
+var branchMetadata map[string]map[string]string
+
+// loadBranchMetadata returns the metadata cache, loading it if needed
+func loadBranchMetadata() (map[string]map[string]string, error) {
+	if branchMetadata != nil {
+		return branchMetadata, nil
+	}
+
+	configs, err := GetConfigRegexp("^stack\\.branch\\.")
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]map[string]string)
+	for key, value := range configs {
+		// Key looks like "stack.branch.<name>.<field>"; the name itself may contain dots
+		rest := strings.TrimPrefix(key, "stack.branch.")
+		dot := strings.LastIndex(rest, ".")
+		if dot <= 0 {
+			continue
+		}
+		name, field := rest[:dot], rest[dot+1:]
+		if metadata[name] == nil {
+			metadata[name] = make(map[string]string)
+		}
+		metadata[name][field] = value
+	}
+
+	branchMetadata = metadata
+	return branchMetadata, nil
+}
+
+// ReloadBranchMetadata discards cached metadata so the next read picks up
+// changes made by other processes (e.g. in long-running commands)
+func ReloadBranchMetadata() {
+	branchMetadata = nil
+}
+
+// getBranchField reads a single metadata field from the cache
+func getBranchField(branch, field string) (string, error) {
+	metadata, err := loadBranchMetadata()
+	if err != nil {
+		return "", err
+	}
+	return metadata[branch][field], nil
+}
+
+// setBranchField writes a single metadata field, skipping the write if it is unchanged
+func setBranchField(branch, field, value string) error {
+	metadata, err := loadBranchMetadata()
+	if err != nil {
+		return err
+	}
+	if current, ok := metadata[branch][field]; ok && current == value {
+		return nil
+	}
+
+	if err := SetConfig(fmt.Sprintf("stack.branch.%s.%s", branch, field), value); err != nil {
+		return err
+	}
+	if metadata[branch] == nil {
+		metadata[branch] = make(map[string]string)
+	}
+	metadata[branch][field] = value
+	return nil
+}
+
+// setBranchFields writes several metadata fields of a branch, in a stable order, skipping
+// those that are unchanged
+func setBranchFields(branch string, fields map[string]string) error {
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	for _, field := range names {
+		if err := setBranchField(branch, field, fields[field]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsetBranchField removes a single metadata field, skipping the call if it is not set
+func unsetBranchField(branch, field string) error {
+	metadata, err := loadBranchMetadata()
+	if err != nil {
+		return err
+	}
+	if _, ok := metadata[branch][field]; !ok {
+		return nil
+	}
+
+	if err := UnsetConfig(fmt.Sprintf("stack.branch.%s.%s", branch, field)); err != nil {
+		return err
+	}
+	delete(metadata[branch], field)
+	if len(metadata[branch]) == 0 {
+		delete(metadata, branch)
+	}
+	return nil
+}
+
 // GetBranchParent retrieves the parent branch for a given branch
 func GetBranchParent(branch string) (string, error) {
-	key := fmt.Sprintf("stack.branch.%s.parent", branch)
-	return GetConfig(key)
+	return getBranchField(branch, "parent")
 }
 
 // SetBranchParent sets the parent branch for a given branch
 func SetBranchParent(branch, parent string) error {
-	key := fmt.Sprintf("stack.branch.%s.parent", branch)
-	return SetConfig(key, parent)
+	return setBranchField(branch, "parent", parent)
 }
 
 // GetBranchPRNumber retrieves the PR number for a given branch
 func GetBranchPRNumber(branch string) (int, error) {
-	key := fmt.Sprintf("stack.branch.%s.pr-number", branch)
-	value, err := GetConfig(key)
+	value, err := getBranchField(branch, "pr-number")
 	if err != nil {
 		return 0, err
 	}
@@ -100,64 +240,215 @@ func GetBranchPRNumber(branch string) (int, error) {
 
 // SetBranchPRNumber sets the PR number for a given branch
 func SetBranchPRNumber(branch string, prNumber int) error {
-	key := fmt.Sprintf("stack.branch.%s.pr-number", branch)
-	return SetConfig(key, strconv.Itoa(prNumber))
+	return setBranchField(branch, "pr-number", strconv.Itoa(prNumber))
+}
+
+// ClearBranchPRNumber forgets the PR of a branch, e.g. so a new one can be opened
+func ClearBranchPRNumber(branch string) error {
+	return unsetBranchField(branch, "pr-number")
 }
 
 // GetAllStackBranches retrieves all branches that have stack metadata
 func GetAllStackBranches() ([]string, error) {
-	configs, err := GetConfigRegexp("^stack\\.branch\\.")
+	metadata, err := loadBranchMetadata()
 	if err != nil {
 		return nil, err
 	}
 
-	branchSet := make(map[string]bool)
-	for key := range configs {
-		// Extract branch name from key like "stack.branch.feature-a.parent"
-		parts := strings.Split(key, ".")
-		if len(parts) >= 3 {
-			branchName := parts[2]
-			branchSet[branchName] = true
-		}
-	}
-
-	branches := make([]string, 0, len(branchSet))
-	for branch := range branchSet {
+	branches := make([]string, 0, len(metadata))
+	for branch := range metadata {
 		branches = append(branches, branch)
 	}
+	sort.Strings(branches)
 	return branches, nil
 }
 
 // UnsetBranchMetadata removes all stack metadata for a given branch
 func UnsetBranchMetadata(branch string) error {
-	parentKey := fmt.Sprintf("stack.branch.%s.parent", branch)
-	prKey := fmt.Sprintf("stack.branch.%s.pr-number", branch)
-	frozenKey := fmt.Sprintf("stack.branch.%s.frozen", branch)
-
-	if err := UnsetConfig(parentKey); err != nil {
+	metadata, err := loadBranchMetadata()
+	if err != nil {
 		return err
 	}
-	if err := UnsetConfig(prKey); err != nil {
-		return err
+	if _, ok := metadata[branch]; !ok {
+		return nil
 	}
-	if err := UnsetConfig(frozenKey); err != nil {
-		return err
+
+	// Drop the whole [stack "branch.<name>"] section in one call
+	section := fmt.Sprintf("stack.branch.%s", branch)
+	cmd := exec.Command("git", "config", "--remove-section", section)
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to remove git config section %s: %s", section, strings.TrimSpace(string(output)))
 	}
+	delete(metadata, branch)
 	return nil
 }
 
+// RenameBranchMetadata moves the metadata of a branch to its new name, and points the
+// branches built on it at that name
+func RenameBranchMetadata(branch, newName string) error {
+	metadata, err := loadBranchMetadata()
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]string)
+	for field, value := range metadata[branch] {
+		fields[field] = value
+	}
+	for field, value := range fields {
+		if err := setBranchField(newName, field, value); err != nil {
+			return err
+		}
+	}
+	for child, childFields := range metadata {
+		if childFields["parent"] == branch {
+			if err := setBranchField(child, "parent", newName); err != nil {
+				return err
+			}
+		}
+	}
+	return UnsetBranchMetadata(branch)
+}
+
 // GetBranchFrozen retrieves the frozen status for a given branch
 func GetBranchFrozen(branch string) (string, error) {
-	key := fmt.Sprintf("stack.branch.%s.frozen", branch)
-	return GetConfig(key)
+	return getBranchField(branch, "frozen")
 }
 
 // SetBranchFrozen sets the frozen status for a given branch
 func SetBranchFrozen(branch, frozen string) error {
-	key := fmt.Sprintf("stack.branch.%s.frozen", branch)
 	if frozen == "false" || frozen == "" {
 		// Unset the key if unfreezing
-		return UnsetConfig(key)
+		return unsetBranchField(branch, "frozen")
+	}
+	return setBranchField(branch, "frozen", frozen)
+}
+
+// GetBranchOwner retrieves the GitHub login of the branch's owner
+func GetBranchOwner(branch string) (string, error) {
+	return getBranchField(branch, "owner")
+}
+
+// SetBranchOwner sets the GitHub login of the branch's owner
+func SetBranchOwner(branch, owner string) error {
+	return setBranchField(branch, "owner", owner)
+}
+
+// GetBranchParentSHA retrieves the parent commit the branch was last restacked onto
+func GetBranchParentSHA(branch string) (string, error) {
+	return getBranchField(branch, "parent-sha")
+}
+
+// SetBranchParentSHA records the parent commit the branch was last restacked onto
+func SetBranchParentSHA(branch, sha string) error {
+	return setBranchField(branch, "parent-sha", sha)
+}
+
+// GetBranchStackName retrieves the name of the stack the branch belongs to, if it was named
+func GetBranchStackName(branch string) (string, error) {
+	return getBranchField(branch, "stack-name")
+}
+
+// SetBranchStackName sets the name of the stack the branch belongs to
+func SetBranchStackName(branch, name string) error {
+	if name == "" {
+		return unsetBranchField(branch, "stack-name")
+	}
+	return setBranchField(branch, "stack-name", name)
+}
+
+// GetBranchDependsOn retrieves what the branch's stack must wait for: a stack name or "#<pr>"
+func GetBranchDependsOn(branch string) (string, error) {
+	return getBranchField(branch, "depends-on")
+}
+
+// SetBranchDependsOn sets what the branch's stack must wait for
+func SetBranchDependsOn(branch, dependency string) error {
+	if dependency == "" {
+		return unsetBranchField(branch, "depends-on")
+	}
+	return setBranchField(branch, "depends-on", dependency)
+}
+
+// GetBranchScope retrieves the directory the branch's stack is scoped to in a monorepo
+func GetBranchScope(branch string) (string, error) {
+	return getBranchField(branch, "scope")
+}
+
+// SetBranchScope sets the directory the branch's stack is scoped to
+func SetBranchScope(branch, scope string) error {
+	if scope == "" {
+		return unsetBranchField(branch, "scope")
+	}
+	return setBranchField(branch, "scope", scope)
+}
+
+// GetBranchPRBase retrieves the base the branch's PR had when stak last saw it
+func GetBranchPRBase(branch string) (string, error) {
+	return getBranchField(branch, "pr-base")
+}
+
+// SetBranchPRBase records the base the branch's PR has on GitHub
+func SetBranchPRBase(branch, base string) error {
+	return setBranchField(branch, "pr-base", base)
+}
+
+// GetBranchMergeMethod retrieves the method the branch's PR is merged with, if set for the branch
+func GetBranchMergeMethod(branch string) (string, error) {
+	return getBranchField(branch, "merge-method")
+}
+
+// SetBranchMergeMethod sets the method the branch's PR is merged with; "" goes back to the default
+func SetBranchMergeMethod(branch, method string) error {
+	if method == "" {
+		return unsetBranchField(branch, "merge-method")
+	}
+	return setBranchField(branch, "merge-method", method)
+}
+
+// stackFields are the fields that describe a branch's whole stack rather than the branch, and
+// are kept the same on every branch of the stack
+var stackFields = []string{"stack-name", "depends-on", "scope"}
+
+// SetBranchMetadata sets the parent and PR number of a branch and gives it the stack fields of
+// its parent that it doesn't have yet. Only the fields that change are written. An empty
+// parent or a PR number of 0 leaves that field as it is.
+func SetBranchMetadata(branch, parent string, prNumber int) error {
+	fields := make(map[string]string)
+	if parent != "" {
+		fields["parent"] = parent
 	}
-	return SetConfig(key, frozen)
+	if prNumber > 0 {
+		fields["pr-number"] = strconv.Itoa(prNumber)
+	}
+	for _, field := range stackFields {
+		value, err := getBranchField(parent, field)
+		if err != nil || value == "" {
+			continue
+		}
+		if current, err := getBranchField(branch, field); err == nil && current == "" {
+			fields[field] = value
+		}
+	}
+	return setBranchFields(branch, fields)
+}
+
+// CopyStackFields sets the stack fields of a branch to those of another branch, removing the
+// ones the other branch doesn't have. An empty from clears them all.
+func CopyStackFields(branch, from string) error {
+	for _, field := range stackFields {
+		value := ""
+		if from != "" {
+			value, _ = getBranchField(from, field)
+		}
+		var err error
+		if value == "" {
+			err = unsetBranchField(branch, field)
+		} else {
+			err = setBranchField(branch, field, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
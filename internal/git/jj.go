@@ -0,0 +1,90 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// jjColocated caches IsJJColocated
+var jjColocated *bool
+
+// IsJJColocated checks if the repository is also a Jujutsu (jj) repository colocated with git.
+// jj keeps its bookmarks as git branches, which stak works on as usual, but leaves HEAD
+// detached at the parent of its working-copy commit, and the changes of that commit show up
+// as uncommitted to git.
+func IsJJColocated() bool {
+	if jjColocated == nil {
+		isColocated := false
+		if root, err := GetRepoRoot(); err == nil {
+			info, err := os.Stat(filepath.Join(root, ".jj"))
+			isColocated = err == nil && info.IsDir()
+		}
+		jjColocated = &isColocated
+	}
+	return *jjColocated
+}
+
+// IsJJOnly checks if the working directory is in a jj repository that keeps its git repository
+// inside .jj instead of colocated with it, where no git command, and so no stak command, works
+func IsJJOnly() bool {
+	if IsGitRepository() {
+		return false
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".jj")); err == nil && info.IsDir() {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// jjCurrentBranch returns the branch jj's detached HEAD is on, i.e. the bookmark of the parent
+// of the working-copy commit. When a branch and the branches stacked on it without commits of
+// their own share the commit, it is the one at the top of the stack.
+func jjCurrentBranch() (string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--points-at", "HEAD", "--format=%(refname:short)", "refs/heads/")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to list bookmarks at HEAD: %w", err)
+	}
+	bookmarks := strings.Fields(string(output))
+	if len(bookmarks) == 0 {
+		return "", fmt.Errorf("the parent of jj's working-copy commit has no bookmark. Set one with 'jj bookmark set <name> -r @-', or run 'jj new <bookmark>' to work on a branch")
+	}
+
+	// Drop the bookmarks others are stacked on
+	isParent := make(map[string]bool)
+	for _, bookmark := range bookmarks {
+		if parent, err := GetBranchParent(bookmark); err == nil {
+			isParent[parent] = true
+		}
+	}
+	var tops []string
+	for _, bookmark := range bookmarks {
+		if !isParent[bookmark] {
+			tops = append(tops, bookmark)
+		}
+	}
+	if len(tops) != 1 {
+		return "", fmt.Errorf("the parent of jj's working-copy commit has several bookmarks (%s), so stak can't tell which branch you are on. Check one out with 'git checkout <branch>'",
+			strings.Join(bookmarks, ", "))
+	}
+	return tops[0], nil
+}
+
+// jjDirtyTreeMessage explains uncommitted changes in a jj colocated repository, where they are
+// the changes of jj's working-copy commit
+const jjDirtyTreeMessage = "jj's working-copy commit has changes, which git sees as uncommitted. Move them into the branch with 'jj squash' first, or set them aside with 'jj new <bookmark>'"
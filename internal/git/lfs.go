@@ -0,0 +1,56 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// UsesLFS checks if the .gitattributes files at rev route any files through Git LFS, in which
+// case the branch holds LFS pointers whose content is uploaded separately when pushing
+func UsesLFS(rev string) bool {
+	cmd := exec.Command("git", "grep", "-q", "-F", "filter=lfs", rev, "--", ".gitattributes", ":(glob)**/.gitattributes")
+	return profile.Run(cmd) == nil
+}
+
+// IsLFSInstalled checks if git-lfs is on the PATH and its filters are set up ("git lfs install"),
+// without which checkouts leave pointer files and pushes don't upload LFS content
+func IsLFSInstalled() bool {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return false
+	}
+	value, err := GetConfig("filter.lfs.process")
+	if err != nil || value == "" {
+		value, err = GetConfig("filter.lfs.clean")
+	}
+	return err == nil && value != ""
+}
+
+// hasLFSPrePushHook checks if the pre-push hook git runs, honoring core.hooksPath, calls Git
+// LFS. Hook managers that move core.hooksPath often leave it out.
+func hasLFSPrePushHook() bool {
+	path, err := GetGitPath("hooks/pre-push")
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), "git lfs pre-push")
+}
+
+// pushLFSObjects uploads the LFS content of a branch about to be pushed when git's pre-push
+// hook won't, because it doesn't call Git LFS or hooks are skipped with NoVerify. It does
+// nothing for branches without LFS files or without Git LFS installed.
+func pushLFSObjects(branch string) error {
+	if !UsesLFS(branch) || !IsLFSInstalled() || (hasLFSPrePushHook() && !NoVerify) {
+		return nil
+	}
+	cmd := exec.Command("git", "lfs", "push", Remote, branch)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to push Git LFS objects of %s: %s", branch, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// MergeTree merges theirs into ours in memory with "git merge-tree --write-tree", without
+// touching the working tree or any ref. It returns the resulting tree, which contains
+// conflict markers where the merge conflicted, and the conflicted paths. Needs git 2.38.
+func MergeTree(ours, theirs string) (tree string, conflicts []string, err error) {
+	if err := RequireVersion("Merging without a checkout", MergeTreeVersion); err != nil {
+		return "", nil, err
+	}
+	cmd := exec.Command("git", "merge-tree", "--write-tree", "--name-only", ours, theirs)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		var exitErr *exec.ExitError
+		// Exit code 1 means the merge conflicted; anything else is a failure
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", nil, fmt.Errorf("failed to simulate merge of %s into %s: %w", theirs, ours, err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	tree = strings.TrimSpace(lines[0])
+	seen := make(map[string]bool)
+	for _, line := range lines[1:] {
+		// Conflicted paths come first, then a blank line and informational messages
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if !seen[line] {
+			seen[line] = true
+			conflicts = append(conflicts, line)
+		}
+	}
+	return tree, conflicts, nil
+}
+
+// MergeInWorktree merges theirs into ours like MergeTree, but with git merge in a temporary
+// worktree, for a git too old for merge-tree --write-tree. The repository's own working tree,
+// index and refs aren't touched. It returns the resulting tree, or the conflicted paths.
+func MergeInWorktree(ours, theirs string) (tree string, conflicts []string, err error) {
+	dir, err := os.MkdirTemp("", "stak-merge-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create a temporary worktree: %w", err)
+	}
+	defer func() {
+		os.RemoveAll(dir)
+		profile.Run(exec.Command("git", "worktree", "prune"))
+	}()
+	// Hooks such as post-checkout are for the user's checkouts, not this one
+	noHooks := "core.hooksPath=" + os.DevNull
+	add := exec.Command("git", "-c", noHooks, "worktree", "add", "--detach", dir, ours)
+	if output, err := profile.CombinedOutput(add); err != nil {
+		return "", nil, fmt.Errorf("failed to create a temporary worktree: %s", strings.TrimSpace(string(output)))
+	}
+	git := func(args ...string) *exec.Cmd {
+		return exec.Command("git", append([]string{"-C", dir, "-c", noHooks}, args...)...)
+	}
+
+	if _, err := profile.CombinedOutput(git("merge", "--no-commit", "--no-ff", "--quiet", theirs)); err != nil {
+		output, derr := profile.Output(git("diff", "--name-only", "--diff-filter=U"))
+		if derr != nil || strings.TrimSpace(string(output)) == "" {
+			return "", nil, fmt.Errorf("failed to merge %s into %s: %w", theirs, ours, err)
+		}
+		seen := make(map[string]bool)
+		for _, path := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+			if !seen[path] {
+				seen[path] = true
+				conflicts = append(conflicts, path)
+			}
+		}
+		return "", conflicts, nil
+	}
+	output, err := profile.Output(git("write-tree"))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to write the merged tree: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil, nil
+}
+
+// CommitTree creates a commit object for tree with the given parents, without updating
+// any ref. The commit is only reachable by its SHA, and git gc removes it eventually.
+func CommitTree(tree string, parents ...string) (string, error) {
+	args := []string{"commit-tree", tree, "-m", "stak: simulated commit"}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	cmd := exec.Command("git", args...)
+	// Simulated commits don't need the user's identity, which may not be configured
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=stak", "GIT_AUTHOR_EMAIL=stak@localhost",
+		"GIT_COMMITTER_NAME=stak", "GIT_COMMITTER_EMAIL=stak@localhost")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create commit for tree %s: %w", tree, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// IsPartialClone checks if the repository was cloned with a filter such as blob:none, so
+// objects missing locally are fetched on demand from the remote
+func IsPartialClone() bool {
+	value, err := GetConfig("remote." + Remote + ".promisor")
+	return err == nil && value == "true"
+}
+
+// PrefetchDiffBlobs fetches, in a single request, the blobs needed to show the diffs of the
+// commits that "git log" selects with args. A partial clone would otherwise download them one
+// at a time as the diffs are computed, which makes patch ID detection crawl. Nothing is
+// fetched in a full clone.
+func PrefetchDiffBlobs(args ...string) error {
+	if !IsPartialClone() {
+		return nil
+	}
+
+	// --raw compares trees only, so listing the blobs doesn't fetch them
+	logArgs := append([]string{"log", "--raw", "--no-abbrev", "--no-renames", "--no-merges", "--format="}, args...)
+	output, err := profile.Output(exec.Command("git", logArgs...))
+	if err != nil {
+		return fmt.Errorf("failed to list changed blobs: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var oids []string
+	for _, line := range strings.Split(string(output), "\n") {
+		// :<old mode> <new mode> <old blob> <new blob> <status>
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], ":") {
+			continue
+		}
+		modes := []string{strings.TrimPrefix(fields[0], ":"), fields[1]}
+		for i, oid := range fields[2:4] {
+			// Submodule entries point at commits of another repository
+			if modes[i] == "160000" || strings.Trim(oid, "0") == "" || seen[oid] {
+				continue
+			}
+			seen[oid] = true
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return nil
+	}
+
+	// The same request git makes for a missing object, for all of them at once
+	cmd := exec.Command("git", "-c", "fetch.negotiationAlgorithm=noop", "fetch", Remote,
+		"--no-tags", "--no-write-fetch-head", "--recurse-submodules=no", "--filter=blob:none", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(oids, "\n") + "\n")
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to fetch %d blob(s): %s", len(oids), string(output))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// ProtectedBranches are the branch names and glob patterns (e.g. release/*) stak must never
+// force-push, reset, rebase or delete. Like Remote, it is set from the settings at startup.
+var ProtectedBranches []string
+
+// CheckForcePush, when set, is asked before every force-push, e.g. to honor protection
+// rules on the remote
+var CheckForcePush func(branch string) error
+
+// ErrProtectedBranch is returned when an operation would rewrite or delete a protected branch
+var ErrProtectedBranch = errors.New("branch is protected")
+
+// IsProtected checks if a branch matches one of the protected branch patterns
+func IsProtected(branch string) bool {
+	for _, pattern := range ProtectedBranches {
+		if pattern == branch {
+			return true
+		}
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckNotProtected returns an error if action would touch a protected branch
+func CheckNotProtected(branch, action string) error {
+	if IsProtected(branch) {
+		return fmt.Errorf("refusing to %s %s: %w", action, branch, ErrProtectedBranch)
+	}
+	return nil
+}
@@ -2,17 +2,84 @@ package git
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+
+	"stacking/internal/profile"
 )
 
-// RebaseOnto rebases the current branch onto another branch
+// ErrDirtyTree is returned when git refuses to proceed because of uncommitted changes
+var ErrDirtyTree error = dirtyTreeError{}
+
+// dirtyTreeError is the type of ErrDirtyTree, whose advice depends on the repository
+type dirtyTreeError struct{}
+
+func (dirtyTreeError) Error() string {
+	if IsJJColocated() {
+		return jjDirtyTreeMessage
+	}
+	return "you have uncommitted changes. Commit or stash them first"
+}
+
+// isDirtyTreeOutput reports whether git output is a refusal due to local changes
+func isDirtyTreeOutput(output string) bool {
+	return strings.Contains(output, "You have unstaged changes") ||
+		strings.Contains(output, "Your index contains uncommitted changes") ||
+		strings.Contains(output, "would be overwritten by checkout") ||
+		strings.Contains(output, "would be overwritten by merge")
+}
+
+// RebaseOnto rebases the current branch onto another branch. Commits that onto already
+// contains, one-to-one or squashed together (see SquashedPrefix), are dropped.
 func RebaseOnto(onto string) error {
-	cmd := exec.Command("git", "rebase", onto)
-	output, err := cmd.CombinedOutput()
+	return RebaseOntoFrom(onto, "")
+}
+
+// RebaseOntoFrom rebases the commits of the current branch after upstream onto another branch,
+// i.e. "git rebase --onto <onto> <upstream>". With an empty upstream it behaves like RebaseOnto.
+func RebaseOntoFrom(onto, upstream string) error {
+	current, err := GetCurrentBranch()
+	if err == nil {
+		if err := CheckNotProtected(current, "rebase"); err != nil {
+			return err
+		}
+	}
+	if err := BackupCurrentBranch(); err != nil {
+		return err
+	}
+
+	// A shallow clone may not have the history the rebase needs to find what to replay
+	if _, err := DeepenForMergeBases([][2]string{{"HEAD", onto}}); err != nil {
+		return err
+	}
+
+	args := []string{"rebase", onto}
+	base := onto
+	if upstream != "" {
+		args = []string{"rebase", "--onto", onto, upstream}
+		base = upstream
+	} else if upto, count, err := SquashedPrefix(onto); err == nil && count > 0 {
+		args = []string{"rebase", "--onto", onto, upto}
+		base = upto
+	}
+	if BeforeRewrite != nil && current != "" {
+		BeforeRewrite(current, base)
+	}
+
+	cmd := CommitCommand(args...)
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
+		if isDirtyTreeOutput(string(output)) {
+			return fmt.Errorf("cannot rebase onto %s: %w", onto, ErrDirtyTree)
+		}
 		// Check if it's a rebase conflict
 		if strings.Contains(string(output), "CONFLICT") || strings.Contains(string(output), "could not apply") {
+			// The same conflict comes up for every child of a rewritten parent, and submodule
+			// pointers may be set to resolve to the branch's
+			if continueAutoResolved() {
+				return nil
+			}
 			return &RebaseConflictError{
 				Onto:   onto,
 				Output: string(output),
@@ -36,22 +103,12 @@ func (e *RebaseConflictError) Error() string {
 // IsRebaseInProgress checks if a rebase is currently in progress
 func IsRebaseInProgress() (bool, error) {
 	// Check if .git/rebase-merge or .git/rebase-apply exists
-	cmd2 := exec.Command("git", "rev-parse", "--git-path", "rebase-merge")
-	gitPath, err := cmd2.Output()
-	if err == nil {
-		// Check if directory exists
-		checkCmd := exec.Command("test", "-d", strings.TrimSpace(string(gitPath)))
-		if checkCmd.Run() == nil {
-			return true, nil
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := GetGitPath(dir)
+		if err != nil {
+			continue
 		}
-	}
-
-	cmd3 := exec.Command("git", "rev-parse", "--git-path", "rebase-apply")
-	gitPath, err = cmd3.Output()
-	if err == nil {
-		// Check if directory exists
-		checkCmd := exec.Command("test", "-d", strings.TrimSpace(string(gitPath)))
-		if checkCmd.Run() == nil {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
 			return true, nil
 		}
 	}
@@ -59,30 +116,52 @@ func IsRebaseInProgress() (bool, error) {
 	return false, nil
 }
 
+// GetRebaseOnto returns the commit an in-progress rebase is replaying onto
+func GetRebaseOnto() (string, error) {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := GetGitPath(dir + "/onto")
+		if err != nil {
+			return "", err
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+	return "", fmt.Errorf("no rebase in progress")
+}
+
 // ContinueRebase continues a rebase after resolving conflicts
 func ContinueRebase() error {
-	cmd := exec.Command("git", "rebase", "--continue")
-	output, err := cmd.CombinedOutput()
+	cmd := CommitCommand("rebase", "--continue")
+	// Keep the commit messages: output is captured, so an editor would have no terminal
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to continue rebase: %s", string(output))
 	}
+
+	// Once the rebase is done, conflicted files outside a sparse checkout can go again
+	if inProgress, err := IsRebaseInProgress(); err == nil && !inProgress {
+		ReapplySparseCheckout()
+	}
 	return nil
 }
 
 // AbortRebase aborts an in-progress rebase
 func AbortRebase() error {
 	cmd := exec.Command("git", "rebase", "--abort")
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to abort rebase: %s", string(output))
 	}
+	ReapplySparseCheckout()
 	return nil
 }
 
 // GetConflictedFiles returns a list of files with conflicts
 func GetConflictedFiles() ([]string, error) {
 	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conflicted files: %w", err)
 	}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// ReadRefContents returns the content of the objects the refs under prefix point to, keyed
+// by ref name without the prefix, e.g. the blobs Graphite keeps under refs/branch-metadata/.
+// All of them are read with a single git cat-file call.
+func ReadRefContents(prefix string) (map[string]string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname)", prefix)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	refs := strings.Fields(string(output))
+	contents := make(map[string]string, len(refs))
+	if len(refs) == 0 {
+		return contents, nil
+	}
+
+	cmd = exec.Command("git", "cat-file", "--batch")
+	cmd.Stdin = strings.NewReader(strings.Join(refs, "\n") + "\n")
+	output, err = profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", prefix, err)
+	}
+
+	// Each object is a "<oid> <type> <size>" header, its content and a newline
+	reader := bufio.NewReader(bytes.NewReader(output))
+	for _, ref := range refs {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ref, err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: unexpected header %q", ref, strings.TrimSpace(header))
+		}
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ref, err)
+		}
+		contents[strings.TrimPrefix(ref, prefix)] = string(content[:size])
+	}
+	return contents, nil
+}
+
+// WriteRefContent stores content as a blob and points ref at it
+func WriteRefContent(ref, content string) error {
+	cmd := exec.Command("git", "hash-object", "-w", "--stdin")
+	cmd.Stdin = strings.NewReader(content)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to store %s: %w", ref, err)
+	}
+	cmd = exec.Command("git", "update-ref", ref, strings.TrimSpace(string(output)))
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to update %s: %s", ref, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ReadRefTargets returns the object each ref under prefix points to, keyed by full ref name
+func ReadRefTargets(prefix string) (map[string]string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(objectname) %(refname)", prefix)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	targets := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if oid, ref, ok := strings.Cut(line, " "); ok {
+			targets[ref] = oid
+		}
+	}
+	return targets, nil
+}
+
+// PushRefs pushes refs to the remote with a lease: each one is only replaced there if it still
+// points to the object expected has for it, or doesn't exist if expected has none, so refs a
+// teammate pushed in the meantime aren't overwritten
+func PushRefs(refs []string, expected map[string]string) error {
+	args := []string{"push"}
+	if NoVerify {
+		args = append(args, "--no-verify")
+	}
+	for _, ref := range refs {
+		args = append(args, fmt.Sprintf("--force-with-lease=%s:%s", ref, expected[ref]))
+	}
+	args = append(args, Remote)
+	for _, ref := range refs {
+		args = append(args, ref+":"+ref)
+	}
+	output, err := profile.CombinedOutput(exec.Command("git", args...))
+	if err != nil {
+		return fmt.Errorf("failed to push %s to %s: %s", strings.Join(refs, ", "), Remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// FetchRefs fetches the refs under prefix from the remote, replacing the local ones
+func FetchRefs(prefix string) error {
+	cmd := exec.Command("git", "fetch", Remote, fmt.Sprintf("+%s*:%s*", prefix, prefix))
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %s", prefix, Remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// IsRerereEnabled reports whether git records and replays conflict resolutions (rerere.enabled)
+func IsRerereEnabled() bool {
+	cmd := exec.Command("git", "config", "--type=bool", "--get", "rerere.enabled")
+	output, err := profile.Output(cmd)
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// EnableRerere turns on rerere for the repository. autoUpdate stages the files it resolves,
+// so a rebase can continue without a manual "git add".
+func EnableRerere() error {
+	if err := SetConfig("rerere.enabled", "true"); err != nil {
+		return err
+	}
+	return SetConfig("rerere.autoUpdate", "true")
+}
+
+// DisableRerere turns off rerere for the repository. Recorded resolutions are kept.
+func DisableRerere() error {
+	return SetConfig("rerere.enabled", "false")
+}
+
+// CountRerereResolutions returns how many conflict resolutions rerere has recorded
+func CountRerereResolutions() (int, error) {
+	dir, err := GetGitPath("rr-cache")
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	count := 0
+	for _, entry := range entries {
+		// A conflict is only resolved once its postimage is recorded
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), "postimage")); err == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// continueAutoResolved keeps a rebase that stopped on a conflict going for as long as rerere
+// has resolved every conflicted file, the way a user would stage them and continue. With
+// ResolveSubmoduleConflicts, conflicting submodule pointers are resolved first.
+// It returns true once the rebase has finished.
+func continueAutoResolved() bool {
+	rerere := IsRerereEnabled()
+	if !rerere && !ResolveSubmoduleConflicts {
+		return false
+	}
+
+	for {
+		if ResolveSubmoduleConflicts {
+			if _, err := TakeBranchSubmodules(); err != nil {
+				return false
+			}
+		}
+
+		// Without rerere.autoUpdate the resolved files are not staged yet
+		files, err := GetConflictedFiles()
+		if err != nil {
+			return false
+		}
+		if len(files) > 0 {
+			if !rerere {
+				return false
+			}
+			cmd := exec.Command("git", "rerere", "remaining")
+			output, err := profile.Output(cmd)
+			if err != nil || strings.TrimSpace(string(output)) != "" {
+				return false
+			}
+			if err := StageFiles(files); err != nil {
+				return false
+			}
+		}
+
+		err = ContinueRebase()
+		if err == nil {
+			return true
+		}
+		// Only keep going if the next commit stopped on a conflict too
+		if !strings.Contains(err.Error(), "CONFLICT") && !strings.Contains(err.Error(), "could not apply") {
+			return false
+		}
+	}
+}
+
+// TrainRerere replays the merge commits reachable from revs (at most limit of them) and
+// records how each conflict was resolved, like git's contrib/rerere-train.sh.
+// HEAD is left detached while it runs; the caller must check out a branch afterwards.
+// It returns the number of merges that conflicted and were recorded.
+func TrainRerere(revs []string, limit int) (int, error) {
+	args := append([]string{"rev-list", "--parents", "--merges", fmt.Sprintf("--max-count=%d", limit)}, revs...)
+	output, err := profile.Output(exec.Command("git", args...))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list merge commits: %w", err)
+	}
+
+	recorded := 0
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		commit, parents := fields[0], fields[1:]
+
+		if output, err := profile.CombinedOutput(exec.Command("git", "checkout", "-q", "--detach", parents[0])); err != nil {
+			return recorded, fmt.Errorf("failed to check out %s: %s", parents[0], string(output))
+		}
+
+		args := append([]string{"-c", "rerere.enabled=true", "merge", "--no-commit", "--no-ff", "-q"}, parents[1:]...)
+		if profile.Run(exec.Command("git", args...)) != nil {
+			// The merge conflicted and rerere saw it; record the committed resolution
+			files, _ := GetConflictedFiles()
+			if len(files) > 0 {
+				resolve := exec.Command("git", "checkout", commit, "--", ".")
+				record := exec.Command("git", "-c", "rerere.enabled=true", "rerere")
+				if profile.Run(resolve) == nil && profile.Run(record) == nil {
+					recorded++
+				}
+			}
+		}
+
+		if output, err := profile.CombinedOutput(exec.Command("git", "reset", "-q", "--hard")); err != nil {
+			return recorded, fmt.Errorf("failed to reset after replaying %s: %s", commit, string(output))
+		}
+	}
+
+	return recorded, nil
+}
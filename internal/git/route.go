@@ -0,0 +1,324 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// StagedHunk is a hunk of the staged changes to a file, against HEAD, with the lines it adds
+type StagedHunk struct {
+	Path string
+	Hunk
+	Added []string // New lines, each with its line ending
+}
+
+// GetStagedHunks returns the staged hunks of files modified in place, without context so each
+// hunk covers only the lines it changes. Added, deleted, renamed and binary files have none.
+func GetStagedHunks() ([]StagedHunk, error) {
+	cmd := exec.Command("git", "-c", "core.quotePath=false", "diff", "--cached", "-U0", "--no-color",
+		"--no-ext-diff", "--no-renames", "--diff-filter=M", "HEAD")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff staged changes: %w", err)
+	}
+
+	var hunks []StagedHunk
+	file := ""
+	inHunk := false
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, inHunk = "", false
+		case !inHunk && strings.HasPrefix(line, "+++ b/"):
+			file = strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			h := StagedHunk{Path: file}
+			h.OldStart, h.OldLines = parseHunkRange(fields[1])
+			h.NewStart, h.NewLines = parseHunkRange(fields[2])
+			hunks = append(hunks, h)
+			inHunk = true
+		case inHunk && strings.HasPrefix(line, "+"):
+			last := &hunks[len(hunks)-1]
+			last.Added = append(last.Added, line[1:]+"\n")
+		case inHunk && strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" after an added line
+			last := &hunks[len(hunks)-1]
+			if n := len(last.Added); n > 0 && len(last.Added) == last.NewLines {
+				last.Added[n-1] = strings.TrimSuffix(last.Added[n-1], "\n")
+			}
+		}
+	}
+	return hunks, nil
+}
+
+// ApplyHunks applies hunks of one file, sorted by line and taken from a diff against content,
+// to content. They may be any subset of the diff's hunks, as only their old lines are used.
+func ApplyHunks(content string, hunks []StagedHunk) string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var result strings.Builder
+	next := 0 // Index of the first line not yet copied
+	for _, h := range hunks {
+		// A hunk without old lines inserts after its start line
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start = h.OldStart
+		}
+		for ; next < start && next < len(lines); next++ {
+			result.WriteString(lines[next])
+		}
+		for _, line := range h.Added {
+			result.WriteString(line)
+		}
+		next = start + h.OldLines
+	}
+	for ; next < len(lines); next++ {
+		result.WriteString(lines[next])
+	}
+	return result.String()
+}
+
+// BlameLines returns the commit that last changed each of the given lines of path at rev,
+// keyed by line number. Ranges are [first, last] line pairs.
+func BlameLines(rev, path string, ranges [][2]int) (map[int]string, error) {
+	args := []string{"blame", "--porcelain"}
+	for _, r := range ranges {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", r[0], r[1]))
+	}
+	args = append(args, rev, "--", path)
+	root, err := GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	// The path is from the top, not the current directory
+	cmd := exec.Command("git", args...)
+	cmd.Dir = root
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", path, err)
+	}
+
+	// Each line starts with "<commit> <original line> <final line>[ <lines in group>]"; the
+	// header lines that may follow hold no numbers in those places
+	commits := make(map[int]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || len(fields[0]) < 40 || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if _, err := strconv.ParseUint(fields[0][:8], 16, 32); err != nil {
+			continue
+		}
+		if n, err := strconv.Atoi(fields[2]); err == nil {
+			commits[n] = fields[0]
+		}
+	}
+	return commits, nil
+}
+
+// GetFileContent returns the content of path at rev, as stored in the repository
+func GetFileContent(rev, path string) (string, error) {
+	output, err := profile.Output(exec.Command("git", "cat-file", "blob", rev+":"+path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s at %s: %w", path, rev, err)
+	}
+	return string(output), nil
+}
+
+// WriteTreeWith writes the tree of rev with the given files, by path from the top, replaced by
+// new content. It uses a temporary index, so neither the real one nor the working tree is touched.
+func WriteTreeWith(rev string, contents map[string]string) (string, error) {
+	root, err := GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	indexFile, err := os.CreateTemp("", "stak-index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	indexFile.Close()
+	// git refuses to read an empty file as an index
+	os.Remove(indexFile.Name())
+	defer os.Remove(indexFile.Name())
+	env := append(os.Environ(), "GIT_INDEX_FILE="+indexFile.Name())
+
+	run := func(stdin string, args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = env
+		cmd.Dir = root
+		cmd.Stdin = strings.NewReader(stdin)
+		output, err := profile.Output(cmd)
+		return strings.TrimSpace(string(output)), err
+	}
+	if _, err := run("", "read-tree", rev); err != nil {
+		return "", fmt.Errorf("failed to read tree of %s: %w", rev, err)
+	}
+	for path, content := range contents {
+		entry, err := run("", "ls-tree", rev, "--", path)
+		mode, _, _ := strings.Cut(entry, " ")
+		if err != nil || mode == "" {
+			return "", fmt.Errorf("failed to find %s at %s", path, rev)
+		}
+		blob, err := run(content, "hash-object", "-w", "--stdin")
+		if err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if _, err := run("", "update-index", "--cacheinfo", mode+","+blob+","+path); err != nil {
+			return "", fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+	tree, err := run("", "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree: %w", err)
+	}
+	return tree, nil
+}
+
+// AmendTip creates the commit that replaces the last commit of branch with one of tree,
+// keeping its parents, message and author, the way git commit --amend would. The branch is
+// left where it is; MoveBranch moves it.
+func AmendTip(branch, tree string) (string, error) {
+	parents, message, author, err := readCommit(branch)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the last commit of %s: %w", branch, err)
+	}
+	commit, err := newCommit(tree, parents, message, author)
+	if err != nil {
+		return "", fmt.Errorf("failed to amend the last commit of %s: %w", branch, err)
+	}
+	return commit, nil
+}
+
+// CommitOnBranch creates a commit of tree with message on top of branch, the way git commit
+// would if branch were checked out. The branch is left where it is; MoveBranch moves it.
+func CommitOnBranch(branch, tree, message string) (string, error) {
+	tip, err := GetCommitSHA(branch)
+	if err != nil {
+		return "", err
+	}
+	commit, err := newCommit(tree, []string{tip}, message, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit to %s: %w", branch, err)
+	}
+	return commit, nil
+}
+
+// ErrMergeCommits is returned by ReplayCommits for a branch it can't replay in memory; git
+// rebase can, with the branch checked out
+var ErrMergeCommits = errors.New("has merge commits, which can't be replayed without checking it out")
+
+// ReplayCommits replays the commits of branch after upstream onto onto, the way
+// "git rebase --onto <onto> <upstream> <branch>" would, but in memory with git merge-tree: no
+// ref, index or working tree is touched. Commits left empty are dropped. It returns the new
+// tip, or the files the first commit that doesn't apply cleanly conflicts in. Branches with
+// merge commits get ErrMergeCommits.
+func ReplayCommits(onto, upstream, branch string) (string, []string, error) {
+	output, err := profile.Output(exec.Command("git", "rev-list", "--reverse", "--parents", upstream+".."+branch))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list the commits of %s: %w", branch, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if lines[0] == "" {
+		return onto, nil, nil
+	}
+	if BeforeRewrite != nil {
+		BeforeRewrite(branch, upstream)
+	}
+
+	tip := onto
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return "", nil, fmt.Errorf("%s %w", branch, ErrMergeCommits)
+		}
+		commit, parent := fields[0], fields[1]
+
+		// A commit of tip's tree on top of both tip and the commit's parent makes that
+		// parent the merge base, so only the commit's own changes are applied
+		ours, err := CommitTree(tip+"^{tree}", tip, parent)
+		if err != nil {
+			return "", nil, err
+		}
+		tree, conflicts, err := MergeTree(ours, commit)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(conflicts) > 0 {
+			return "", conflicts, nil
+		}
+		tipTree, err := profile.Output(exec.Command("git", "rev-parse", tip+"^{tree}"))
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read the tree of %s: %w", tip, err)
+		}
+		if tree == strings.TrimSpace(string(tipTree)) {
+			continue
+		}
+
+		_, message, author, err := readCommit(commit)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read commit %s: %w", commit, err)
+		}
+		if tip, err = newCommit(tree, []string{tip}, message, author); err != nil {
+			return "", nil, fmt.Errorf("failed to replay commit %s: %w", commit, err)
+		}
+	}
+	return tip, nil, nil
+}
+
+// readCommit returns the parents and message of a commit, and its author as the environment
+// that makes commit-tree keep it
+func readCommit(rev string) (parents []string, message string, author []string, err error) {
+	output, err := profile.Output(exec.Command("git", "log", "-1", "--format=%an%x00%ae%x00%aI%x00%P%x00%B", rev))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	fields := strings.SplitN(string(output), "\x00", 5)
+	if len(fields) < 5 {
+		return nil, "", nil, fmt.Errorf("unexpected output %q", string(output))
+	}
+	author = []string{"GIT_AUTHOR_NAME=" + fields[0], "GIT_AUTHOR_EMAIL=" + fields[1], "GIT_AUTHOR_DATE=" + fields[2]}
+	return strings.Fields(fields[3]), strings.TrimRight(fields[4], "\n"), author, nil
+}
+
+// newCommit creates a commit object of tree, signed if SignsCommits says so. env adds to the
+// environment, e.g. to set the author.
+func newCommit(tree string, parents []string, message string, env []string) (string, error) {
+	args := []string{"commit-tree", tree, "-F", "-"}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	// Unlike git commit, commit-tree ignores commit.gpgSign
+	if SignsCommits() {
+		args = append(args, "-S")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = strings.NewReader(message + "\n")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// MoveBranch points branch at commit without checking it out, provided it still points at old
+func MoveBranch(branch, commit, old string) error {
+	cmd := exec.Command("git", "update-ref", "-m", "stak: commit", "refs/heads/"+branch, commit, old)
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("failed to update %s: %s", branch, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
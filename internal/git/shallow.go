@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// deepenSteps are how many more commits of history a shallow clone fetches at a time while
+// looking for a merge base, before giving up and fetching all of it
+var deepenSteps = []int{50, 200, 1000}
+
+// shallow caches IsShallow, which only changes when stak unshallows the clone
+var shallow *bool
+
+// IsShallow checks if the repository is a shallow clone, with history cut off at some depth
+func IsShallow() bool {
+	if shallow == nil {
+		cmd := exec.Command("git", "rev-parse", "--is-shallow-repository")
+		output, err := profile.Output(cmd)
+		isShallow := err == nil && strings.TrimSpace(string(output)) == "true"
+		shallow = &isShallow
+	}
+	return *shallow
+}
+
+// lacksMergeBase checks if two commits the clone has share no history it has. Refs that
+// don't resolve can't be helped by deepening, so they don't count.
+func lacksMergeBase(a, b string) bool {
+	if _, err := GetCommitSHA(a); err != nil {
+		return false
+	}
+	if _, err := GetCommitSHA(b); err != nil {
+		return false
+	}
+	return profile.Run(exec.Command("git", "merge-base", a, b)) != nil
+}
+
+// DeepenForMergeBases makes sure each pair of refs has a merge base in a shallow clone,
+// fetching more history in growing steps, and finally all of it, until they do. Without it,
+// rebases replay or conflict on history the clone cut off, and ancestry checks fail. It
+// returns whether the clone was deepened; full clones are left alone. It fetches, so only the
+// commands that rebase call it, up front, and ancestry helpers like GetMergeBase never do.
+func DeepenForMergeBases(pairs [][2]string) (bool, error) {
+	if !IsShallow() {
+		return false, nil
+	}
+
+	missing := func() bool {
+		for _, pair := range pairs {
+			if lacksMergeBase(pair[0], pair[1]) {
+				return true
+			}
+		}
+		return false
+	}
+	if !missing() {
+		return false, nil
+	}
+
+	for _, depth := range deepenSteps {
+		cmd := exec.Command("git", "fetch", "--deepen", fmt.Sprint(depth), Remote)
+		if output, err := profile.CombinedOutput(cmd); err != nil {
+			return false, fmt.Errorf("failed to deepen shallow clone: %s", string(output))
+		}
+		if !missing() {
+			return true, nil
+		}
+	}
+
+	cmd := exec.Command("git", "fetch", "--unshallow", Remote)
+	if output, err := profile.CombinedOutput(cmd); err != nil {
+		return false, fmt.Errorf("failed to unshallow clone: %s", string(output))
+	}
+	isShallow := false
+	shallow = &isShallow
+	return true, nil
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// SignCommits makes every commit stak creates or rewrites be signed with the user's key
+// (--gpg-sign), as if commit.gpgSign were set. Like Remote, it is set at startup.
+var SignCommits bool
+
+// BeforeRewrite, when set, is called before the commits of branch after base are rewritten,
+// e.g. to warn that they lose their signatures
+var BeforeRewrite func(branch, base string)
+
+// CommitCommand returns a git command that creates commits, such as commit, rebase or
+// cherry-pick, signing them when SignCommits is set. With NoVerify, commit and merge skip
+// their hooks.
+func CommitCommand(args ...string) *exec.Cmd {
+	if NoVerify && len(args) > 0 && (args[0] == "commit" || args[0] == "merge") {
+		args = append([]string{args[0], "--no-verify"}, args[1:]...)
+	}
+	if SignCommits {
+		args = append([]string{"-c", "commit.gpgSign=true"}, args...)
+	}
+	return exec.Command("git", args...)
+}
+
+// SignsCommits checks if rewritten commits are signed again, by --gpg-sign or commit.gpgSign
+func SignsCommits() bool {
+	if SignCommits {
+		return true
+	}
+	cmd := exec.Command("git", "config", "--type=bool", "--get", "commit.gpgSign")
+	output, err := profile.Output(cmd)
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// CountSignedCommits counts the commits of branch after base that carry a signature. It
+// doesn't verify them, so it needs neither gpg nor the signers' keys.
+func CountSignedCommits(base, branch string) int {
+	cmd := exec.Command("git", "rev-list", "--format=raw", base+".."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return 0
+	}
+
+	// Headers aren't indented, unlike the message lines that follow them
+	count := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "gpgsig ") || strings.HasPrefix(line, "gpgsig-sha256 ") {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// IsSparseCheckout checks if the working tree only has part of the repository checked out
+func IsSparseCheckout() bool {
+	value, err := GetConfig("core.sparseCheckout")
+	return err == nil && value == "true"
+}
+
+// IsSparseIndex checks if the index of a sparse checkout collapses the directories outside it
+func IsSparseIndex() bool {
+	value, err := GetConfig("index.sparse")
+	return err == nil && value == "true" && IsSparseCheckout()
+}
+
+// SetSparseIndex converts the index to a sparse index or back to a full one. Tools built on
+// libgit2, such as git-absorb, can't read a sparse index.
+func SetSparseIndex(sparse bool) error {
+	flag := "--no-sparse-index"
+	if sparse {
+		flag = "--sparse-index"
+	}
+	cmd := exec.Command("git", "sparse-checkout", "reapply", flag)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to update the sparse index: %s", string(output))
+	}
+	return nil
+}
+
+// ReapplySparseCheckout removes files outside the sparse checkout again. Resolving a conflict
+// in such a file brings it into the working tree, and git leaves it there afterwards.
+func ReapplySparseCheckout() error {
+	if !IsSparseCheckout() {
+		return nil
+	}
+	cmd := exec.Command("git", "sparse-checkout", "reapply")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to reapply sparse checkout: %s", string(output))
+	}
+	return nil
+}
+
+// OutsideSparseCheckout returns the paths, relative to the repository root, that lie outside
+// a cone-mode sparse checkout. Paths are never reported outside a full checkout, nor with
+// non-cone patterns, whose rules stak doesn't interpret.
+func OutsideSparseCheckout(paths []string) []string {
+	if !IsSparseCheckout() {
+		return nil
+	}
+	if cone, err := GetConfig("core.sparseCheckoutCone"); err != nil || cone != "true" {
+		return nil
+	}
+	output, err := profile.Output(exec.Command("git", "sparse-checkout", "list"))
+	if err != nil {
+		return nil
+	}
+	dirs := strings.Fields(string(output))
+
+	var outside []string
+	for _, path := range paths {
+		if !inSparseCone(path, dirs) {
+			outside = append(outside, path)
+		}
+	}
+	return outside
+}
+
+// inSparseCone applies the cone rules: files at the root, everything inside a cone directory,
+// and the files directly inside the directories leading to one are checked out
+func inSparseCone(path string, dirs []string) bool {
+	parent := ""
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		parent = path[:i]
+	}
+	if parent == "" {
+		return true
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+"/") || strings.HasPrefix(dir, parent+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// StageFiles stages files given relative to the repository root, such as conflicted files,
+// from any directory of the working tree and including files outside a sparse checkout
+func StageFiles(files []string) error {
+	args := []string{"add"}
+	if IsSparseCheckout() {
+		args = append(args, "--sparse")
+	}
+	args = append(args, "--")
+	for _, file := range files {
+		args = append(args, ":(top,literal)"+file)
+	}
+	output, err := profile.CombinedOutput(exec.Command("git", args...))
+	if err != nil {
+		return fmt.Errorf("failed to stage files: %s", string(output))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// maxSquashScan bounds how many commits SquashedPrefix inspects, since each one costs a diff
+const maxSquashScan = 100
+
+// SquashedPrefix finds the oldest commits of the current branch whose combined change is
+// already in onto as a single commit, which is what a squash-merged parent leaves behind.
+// git rebase only skips commits that match upstream one-to-one, so replaying these would
+// conflict with the squash. It returns the newest such commit, to be used as the upstream
+// of "git rebase --onto", and how many commits it covers; upto is empty if there are none.
+func SquashedPrefix(onto string) (upto string, count int, err error) {
+	return SquashedPrefixOf("HEAD", onto)
+}
+
+// SquashedPrefixOf is SquashedPrefix for any branch, not just the checked-out one
+func SquashedPrefixOf(branch, onto string) (upto string, count int, err error) {
+	base, err := GetMergeBase(onto, branch)
+	if err != nil {
+		return "", 0, err
+	}
+	// Only a parent that moved can have squashed the branch's commits; a restack that is a
+	// no-op needs no scan
+	if tip, err := GetCommitSHA(onto); err != nil || tip == base {
+		return "", 0, err
+	}
+
+	cmd := exec.Command("git", "rev-list", "--reverse", "--parents", base+".."+branch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list commits: %w", err)
+	}
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			// A merge commit has no single prefix to compare; leave it to git
+			return "", 0, nil
+		}
+		commits = append(commits, fields[0])
+	}
+	if len(commits) == 0 || len(commits) > maxSquashScan {
+		return "", 0, nil
+	}
+
+	// Only upstream commits touching the branch's files can match its commits, which keeps
+	// the scan short when onto has moved a lot, e.g. trunk of a big repository
+	cmd = exec.Command("git", "log", "--name-only", "--no-merges", "--format=", base+".."+branch)
+	output, err = profile.Output(cmd)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	pathspecs := []string{"--"}
+	seen := make(map[string]bool)
+	for _, file := range strings.Split(string(output), "\n") {
+		if file = strings.TrimSpace(file); file != "" && !seen[file] {
+			seen[file] = true
+			pathspecs = append(pathspecs, ":(literal)"+file)
+		}
+	}
+	if len(pathspecs) == 1 {
+		return "", 0, nil
+	}
+	upstreamRange := append([]string{"--full-diff", base + ".." + onto}, pathspecs...)
+
+	// In a partial clone, fetch every blob the diffs below need in one go
+	if err := PrefetchDiffBlobs(base + ".." + branch); err != nil {
+		return "", 0, err
+	}
+	if err := PrefetchDiffBlobs(upstreamRange...); err != nil {
+		return "", 0, err
+	}
+
+	args := append([]string{"log", "-p", "--no-merges", "--format=commit %H"}, upstreamRange...)
+	output, err = profile.Output(exec.Command("git", args...))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read commits on %s: %w", onto, err)
+	}
+	upstream, err := patchIDs(output)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(upstream) == 0 {
+		return "", 0, nil
+	}
+
+	// Grow the range from the last match one commit at a time, so parents that were
+	// squash-merged one after another are each found
+	from := base
+	for i, commit := range commits {
+		cmd := exec.Command("git", "diff", "--full-index", from, commit)
+		diff, err := profile.Output(cmd)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to diff %s..%s: %w", from, commit, err)
+		}
+		ids, err := patchIDs(diff)
+		if err != nil {
+			return "", 0, err
+		}
+		for id := range ids {
+			if upstream[id] {
+				upto, count, from = commit, i+1, commit
+			}
+		}
+	}
+
+	return upto, count, nil
+}
+
+// patchIDs returns the stable patch IDs of a diff or of "git log -p" output
+func patchIDs(patch []byte) (map[string]bool, error) {
+	cmd := exec.Command("git", "patch-id", "--stable")
+	cmd.Stdin = bytes.NewReader(patch)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute patch IDs: %w", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			ids[fields[0]] = true
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// ResolveSubmoduleConflicts makes rebases resolve conflicting submodule pointers by keeping
+// the commit the branch points the submodule to, and continue when nothing else conflicts
+var ResolveSubmoduleConflicts bool
+
+// SubmoduleConflict is a submodule whose pointer conflicts in a rebase. Upstream is the commit
+// the branch is being rebased onto points it to, Branch the commit the replayed branch commit
+// does. Either is empty if that side removed the submodule.
+type SubmoduleConflict struct {
+	Path     string
+	Upstream string
+	Branch   string
+}
+
+// GetSubmoduleConflicts returns the conflicted paths of the rebase in progress that are submodules
+func GetSubmoduleConflicts() ([]SubmoduleConflict, error) {
+	cmd := exec.Command("git", "ls-files", "--unmerged", "-z")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmerged files: %w", err)
+	}
+
+	// Each entry is "<mode> <object> <stage>\t<path>"; stage 2 is upstream, stage 3 the branch
+	var conflicts []SubmoduleConflict
+	index := make(map[string]int)
+	for _, entry := range strings.Split(string(output), "\x00") {
+		info, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 3 || fields[0] != "160000" {
+			continue
+		}
+		i, seen := index[path]
+		if !seen {
+			i = len(conflicts)
+			index[path] = i
+			conflicts = append(conflicts, SubmoduleConflict{Path: path})
+		}
+		switch fields[2] {
+		case "2":
+			conflicts[i].Upstream = fields[1]
+		case "3":
+			conflicts[i].Branch = fields[1]
+		}
+	}
+	return conflicts, nil
+}
+
+// TakeBranchSubmodules resolves the submodule conflicts of the rebase in progress with the
+// branch's side: its submodule commit is staged, or the submodule removed if the branch did.
+// It returns the number of submodules resolved.
+func TakeBranchSubmodules() (int, error) {
+	conflicts, err := GetSubmoduleConflicts()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, conflict := range conflicts {
+		args := []string{"update-index", "--cacheinfo", "160000," + conflict.Branch + "," + conflict.Path}
+		if conflict.Branch == "" {
+			args = []string{"update-index", "--force-remove", "--", conflict.Path}
+		}
+		output, err := profile.CombinedOutput(exec.Command("git", args...))
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve submodule %s: %s", conflict.Path, string(output))
+		}
+	}
+	return len(conflicts), nil
+}
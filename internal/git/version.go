@@ -0,0 +1,86 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"stacking/internal/profile"
+)
+
+// Version is the version of an installed tool such as git or gh
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// MinimumVersion is the oldest git stak works with, e.g. for git sparse-checkout
+var MinimumVersion = Version{2, 25, 0}
+
+// Features stak uses that need a newer git than MinimumVersion
+var (
+	MergeTreeVersion = Version{2, 38, 0} // merge-tree --write-tree: sync --dry-run, modify --route, in-memory --into
+	TrailerVersion   = Version{2, 32, 0} // commit --trailer: co-authors of squash and fold
+)
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Known reports whether the version could be detected
+func (v Version) Known() bool {
+	return v != Version{}
+}
+
+// AtLeast reports whether v is min or newer. An unknown version counts as new enough, so a
+// tool whose version can't be read is used as is rather than worked around.
+func (v Version) AtLeast(min Version) bool {
+	if !v.Known() {
+		return true
+	}
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Patch >= min.Patch
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion reads the first version number in the output of e.g. "git --version", which
+// looks like "git version 2.39.3 (Apple Git-146)" or "git version 2.45.1.windows.1"
+func ParseVersion(output string) Version {
+	match := versionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return Version{}
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	v.Patch, _ = strconv.Atoi(match[3])
+	return v
+}
+
+// gitVersion caches GetVersion, which doesn't change while stak runs
+var gitVersion *Version
+
+// GetVersion returns the version of the installed git, or the zero Version if it can't be read
+func GetVersion() Version {
+	if gitVersion == nil {
+		output, _ := profile.Output(exec.Command("git", "--version"))
+		v := ParseVersion(string(output))
+		gitVersion = &v
+	}
+	return *gitVersion
+}
+
+// RequireVersion returns an error naming what needs a newer git if the installed one is older
+// than min, rather than letting git fail on an option it doesn't know
+func RequireVersion(what string, min Version) error {
+	if v := GetVersion(); !v.AtLeast(min) {
+		return fmt.Errorf("%s needs git %d.%d or later, but git %s is installed", what, min.Major, min.Minor, v)
+	}
+	return nil
+}
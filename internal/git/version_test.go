@@ -0,0 +1,45 @@
+package git
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   Version
+	}{
+		{"git version 2.39.5", Version{2, 39, 5}},
+		{"git version 2.39.3 (Apple Git-146)", Version{2, 39, 3}},
+		{"git version 2.45.1.windows.1", Version{2, 45, 1}},
+		{"gh version 2.40.0 (2023-12-13)\nhttps://github.com/cli/cli/releases/tag/v2.40.0", Version{2, 40, 0}},
+		{"git version 2.25", Version{2, 25, 0}},
+		{"", Version{}},
+		{"command not found", Version{}},
+	}
+	for _, tt := range tests {
+		if got := ParseVersion(tt.output); got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		v, min Version
+		want   bool
+	}{
+		{Version{2, 38, 0}, Version{2, 38, 0}, true},
+		{Version{2, 38, 1}, Version{2, 38, 0}, true},
+		{Version{2, 38, 0}, Version{2, 38, 1}, false},
+		{Version{2, 39, 0}, Version{2, 38, 5}, true},
+		{Version{2, 37, 9}, Version{2, 38, 0}, false},
+		{Version{3, 0, 0}, Version{2, 45, 0}, true},
+		{Version{1, 99, 99}, Version{2, 0, 0}, false},
+		// An unknown version is used as is rather than worked around
+		{Version{}, Version{2, 38, 0}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.v.AtLeast(tt.min); got != tt.want {
+			t.Errorf("%v.AtLeast(%v) = %v, want %v", tt.v, tt.min, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"stacking/internal/config"
+)
+
+const apiBaseURL = "https://api.github.com"
+
+// AppCredentials identifies a GitHub App installation used to post stack comments
+type AppCredentials struct {
+	AppID          string
+	InstallationID string
+	PrivateKeyPath string
+}
+
+// cached installation token, valid for one hour after issue
+var (
+	appToken       string
+	appTokenExpiry time.Time
+)
+
+// LoadAppCredentials reads GitHub App settings from config
+// Returns nil if no app is configured
+func LoadAppCredentials() (*AppCredentials, error) {
+	appID, err := config.Get("github-app-id")
+	if err != nil {
+		return nil, err
+	}
+	if appID == "" {
+		return nil, nil
+	}
+
+	installationID, err := config.Get("github-app-installation-id")
+	if err != nil {
+		return nil, err
+	}
+	keyPath, err := config.Get("github-app-private-key")
+	if err != nil {
+		return nil, err
+	}
+
+	if installationID == "" || keyPath == "" {
+		return nil, fmt.Errorf("stack.github-app-id is set but stack.github-app-installation-id or stack.github-app-private-key is missing")
+	}
+
+	return &AppCredentials{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKeyPath: keyPath,
+	}, nil
+}
+
+// InstallationToken exchanges a signed app JWT for an installation access token
+func (c *AppCredentials) InstallationToken() (string, error) {
+	if appToken != "" && time.Now().Before(appTokenExpiry) {
+		return appToken, nil
+	}
+
+	jwt, err := c.signJWT()
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", apiBaseURL, c.InstallationID)
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read installation token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to get installation token (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse installation token: %w", err)
+	}
+
+	appToken = result.Token
+	// Refresh a few minutes early so long-running commands don't use an expired token
+	appTokenExpiry = result.ExpiresAt.Add(-5 * time.Minute)
+	return appToken, nil
+}
+
+// signJWT builds the short-lived RS256 JWT GitHub requires to authenticate as the app
+func (c *AppCredentials) signJWT() (string, error) {
+	keyData, err := os.ReadFile(c.PrivateKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+
+	key, err := parseRSAPrivateKey(keyData)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	claims := map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(), // allow for clock drift
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": c.AppID,
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(headerJSON) + "." + enc.EncodeToString(claimsJSON)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	return signingInput + "." + enc.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey accepts both PKCS#1 (GitHub's default download) and PKCS#8 keys
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not valid PEM")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// commentCommand builds a gh command that authenticates as the configured
+// GitHub App when one is set, falling back to the user's gh login otherwise
+func commentCommand(args ...string) (*exec.Cmd, error) {
+	cmd := exec.Command("gh", args...)
+
+	creds, err := LoadAppCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil {
+		return cmd, nil
+	}
+
+	token, err := creds.InstallationToken()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Env = append(os.Environ(), "GH_TOKEN="+token)
+	return cmd, nil
+}
@@ -0,0 +1,180 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"stacking/internal/profile"
+)
+
+// Check is a single CI check on a PR, either a check run (GitHub Actions, apps)
+// or a legacy commit status
+type Check struct {
+	TypeName     string `json:"__typename"`
+	Name         string `json:"name"`
+	Context      string `json:"context"`
+	WorkflowName string `json:"workflowName"`
+	Status       string `json:"status"`
+	Conclusion   string `json:"conclusion"`
+	State        string `json:"state"`
+	StartedAt    string `json:"startedAt"`
+	CompletedAt  string `json:"completedAt"`
+	DetailsURL   string `json:"detailsUrl"`
+	TargetURL    string `json:"targetUrl"`
+}
+
+// GetPRChecks retrieves every CI check reported on a PR's head commit
+func GetPRChecks(prNumber int) ([]Check, error) {
+	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "statusCheckRollup")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checks for PR #%d: %s", prNumber, string(output))
+	}
+
+	var result struct {
+		StatusCheckRollup []Check `json:"statusCheckRollup"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse checks for PR #%d: %w", prNumber, err)
+	}
+
+	return result.StatusCheckRollup, nil
+}
+
+// DisplayName returns the check's name, prefixed by its workflow when known
+func (c *Check) DisplayName() string {
+	name := c.Name
+	if name == "" {
+		name = c.Context
+	}
+	if c.WorkflowName != "" && c.WorkflowName != name {
+		return c.WorkflowName + " / " + name
+	}
+	return name
+}
+
+// Result returns Passing, Failing, Running, Skipped or Unknown
+func (c *Check) Result() string {
+	if c.Conclusion != "" {
+		switch c.Conclusion {
+		case "SUCCESS", "NEUTRAL":
+			return "Passing"
+		case "FAILURE", "ERROR", "CANCELLED", "TIMED_OUT", "ACTION_REQUIRED", "STARTUP_FAILURE":
+			return "Failing"
+		case "SKIPPED", "STALE":
+			return "Skipped"
+		}
+		return "Unknown"
+	}
+
+	status := c.Status
+	if status == "" {
+		status = c.State
+	}
+	switch status {
+	case "SUCCESS":
+		return "Passing"
+	case "FAILURE", "ERROR":
+		return "Failing"
+	case "IN_PROGRESS", "QUEUED", "PENDING", "WAITING", "REQUESTED", "EXPECTED":
+		return "Running"
+	}
+	return "Unknown"
+}
+
+// IsFailed reports whether the check completed unsuccessfully
+func (c *Check) IsFailed() bool {
+	return c.Result() == "Failing"
+}
+
+// Duration returns how long the check ran, or 0 if it hasn't started or finished
+func (c *Check) Duration() time.Duration {
+	started, err := time.Parse(time.RFC3339, c.StartedAt)
+	if err != nil || started.IsZero() || started.Year() < 2000 {
+		return 0
+	}
+	completed, err := time.Parse(time.RFC3339, c.CompletedAt)
+	if err != nil || completed.Year() < 2000 {
+		// Still running
+		return time.Since(started).Round(time.Second)
+	}
+	return completed.Sub(started).Round(time.Second)
+}
+
+// URL returns the link to the check's details page
+func (c *Check) URL() string {
+	if c.DetailsURL != "" {
+		return c.DetailsURL
+	}
+	return c.TargetURL
+}
+
+// actionsRunPattern extracts the workflow run ID from a GitHub Actions check URL
+var actionsRunPattern = regexp.MustCompile(`/actions/runs/(\d+)`)
+
+// RerunFailedChecks re-triggers every failed check on a PR's head commit
+// GitHub Actions workflow runs re-run only their failed jobs; other apps get their check suite re-requested
+// Returns the number of workflow runs and check suites that were re-run
+func RerunFailedChecks(prNumber int) (int, error) {
+	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "headRefOid", "--jq", ".headRefOid")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get head commit for PR #%d: %s", prNumber, string(output))
+	}
+	sha := strings.TrimSpace(string(output))
+
+	cmd = exec.Command("gh", "api", fmt.Sprintf("/repos/{owner}/{repo}/commits/%s/check-runs?filter=latest&per_page=100", sha))
+	output, err = profile.CombinedOutput(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list check runs for PR #%d: %s", prNumber, string(output))
+	}
+
+	var result struct {
+		CheckRuns []struct {
+			Name       string `json:"name"`
+			Conclusion string `json:"conclusion"`
+			DetailsURL string `json:"details_url"`
+			CheckSuite struct {
+				ID int64 `json:"id"`
+			} `json:"check_suite"`
+		} `json:"check_runs"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse check runs for PR #%d: %w", prNumber, err)
+	}
+
+	// Several failed jobs usually belong to one run or suite; re-run each only once
+	seen := make(map[string]bool)
+	rerun := 0
+	for _, run := range result.CheckRuns {
+		switch run.Conclusion {
+		case "failure", "cancelled", "timed_out", "action_required", "startup_failure":
+		default:
+			continue
+		}
+
+		var endpoint string
+		if m := actionsRunPattern.FindStringSubmatch(run.DetailsURL); m != nil {
+			endpoint = fmt.Sprintf("/repos/{owner}/{repo}/actions/runs/%s/rerun-failed-jobs", m[1])
+		} else {
+			endpoint = fmt.Sprintf("/repos/{owner}/{repo}/check-suites/%d/rerequest", run.CheckSuite.ID)
+		}
+		if seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+
+		cmd := exec.Command("gh", "api", "-X", "POST", endpoint)
+		if output, err := profile.CombinedOutput(cmd); err != nil {
+			return rerun, fmt.Errorf("failed to re-run %s on PR #%d: %s", run.Name, prNumber, string(output))
+		}
+		rerun++
+	}
+
+	return rerun, nil
+}
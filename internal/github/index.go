@@ -0,0 +1,38 @@
+package github
+
+// PRIndex indexes a batch of PRs so stack relationships can be resolved
+// in memory instead of with one gh call per branch
+type PRIndex struct {
+	ByNumber map[int]PRSummary
+	ByHead   map[string]PRSummary   // same-repository PRs only
+	ByBase   map[string][]PRSummary // all PRs targeting a branch, including forks
+}
+
+// NewPRIndex builds an index from a list of PRs
+func NewPRIndex(prs []PRSummary) *PRIndex {
+	index := &PRIndex{
+		ByNumber: make(map[int]PRSummary),
+		ByHead:   make(map[string]PRSummary),
+		ByBase:   make(map[string][]PRSummary),
+	}
+
+	for _, pr := range prs {
+		index.ByNumber[pr.Number] = pr
+		// Fork heads can share names with branches in this repository
+		if !pr.IsCrossRepository {
+			index.ByHead[pr.HeadRefName] = pr
+		}
+		index.ByBase[pr.BaseRefName] = append(index.ByBase[pr.BaseRefName], pr)
+	}
+
+	return index
+}
+
+// LoadOpenPRIndex lists all open PRs with a single query and indexes them
+func LoadOpenPRIndex() (*PRIndex, error) {
+	prs, err := ListOpenPRs("")
+	if err != nil {
+		return nil, err
+	}
+	return NewPRIndex(prs), nil
+}
@@ -6,15 +6,16 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+
+	"stacking/internal/profile"
 )
 
 // PRStatus represents the status of a pull request
 type PRStatus struct {
-	State          string `json:"state"`
-	ReviewDecision string `json:"reviewDecision"`
-	StatusCheckRollup []struct {
-		State string `json:"state"`
-	} `json:"statusCheckRollup"`
+	State             string  `json:"state"`
+	ReviewDecision    string  `json:"reviewDecision"`
+	Mergeable         string  `json:"mergeable"`
+	StatusCheckRollup []Check `json:"statusCheckRollup"`
 }
 
 // CreatePR creates a pull request and returns the PR number
@@ -29,7 +30,11 @@ func CreatePR(base, head, title, body string, draft bool) (int, error) {
 	// - If body provided: use it with --body
 	if title == "" && body == "" {
 		// Auto-generate both title and body from first commit
-		args = append(args, "--fill-first")
+		if CanFillFirst() {
+			args = append(args, "--fill-first")
+		} else {
+			args = append(args, "--fill")
+		}
 	} else {
 		if title != "" {
 			args = append(args, "--title", title)
@@ -48,7 +53,7 @@ func CreatePR(base, head, title, body string, draft bool) (int, error) {
 	}
 
 	cmd := exec.Command("gh", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create PR: %s", string(output))
 	}
@@ -65,8 +70,8 @@ func CreatePR(base, head, title, body string, draft bool) (int, error) {
 
 // GetPRStatus retrieves the status of a pull request
 func GetPRStatus(prNumber int) (*PRStatus, error) {
-	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "state,reviewDecision,statusCheckRollup")
-	output, err := cmd.Output()
+	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "state,reviewDecision,mergeable,statusCheckRollup")
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PR status for #%d: %w", prNumber, err)
 	}
@@ -81,6 +86,12 @@ func GetPRStatus(prNumber int) (*PRStatus, error) {
 
 // MergePR merges a pull request
 func MergePR(prNumber int, method string) error {
+	return MergePRWithMessage(prNumber, method, "", "")
+}
+
+// MergePRWithMessage merges a pull request with the given commit subject and body instead of
+// GitHub's default message. Empty ones keep the default; a rebase merge has no message.
+func MergePRWithMessage(prNumber int, method, subject, body string) error {
 	args := []string{"pr", "merge", strconv.Itoa(prNumber)}
 
 	switch method {
@@ -94,8 +105,17 @@ func MergePR(prNumber int, method string) error {
 		args = append(args, "--squash") // default to squash
 	}
 
+	if method != "rebase" {
+		if subject != "" {
+			args = append(args, "--subject", subject)
+		}
+		if body != "" {
+			args = append(args, "--body", body)
+		}
+	}
+
 	cmd := exec.Command("gh", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to merge PR #%d: %s", prNumber, string(output))
 	}
@@ -103,10 +123,88 @@ func MergePR(prNumber int, method string) error {
 	return nil
 }
 
+// MergeMethods are the merge methods a repository allows for pull requests
+type MergeMethods struct {
+	Squash bool `json:"squashMergeAllowed"`
+	Merge  bool `json:"mergeCommitAllowed"`
+	Rebase bool `json:"rebaseMergeAllowed"`
+}
+
+// Allows checks if the repository allows merging with method: squash, merge or rebase
+func (m *MergeMethods) Allows(method string) bool {
+	switch method {
+	case "squash":
+		return m.Squash
+	case "merge":
+		return m.Merge
+	case "rebase":
+		return m.Rebase
+	}
+	return false
+}
+
+// Allowed lists the allowed methods, in the order stak prefers them
+func (m *MergeMethods) Allowed() []string {
+	var methods []string
+	for _, method := range []string{"squash", "merge", "rebase"} {
+		if m.Allows(method) {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// GetMergeMethods retrieves the merge methods the repository allows
+func GetMergeMethods() (*MergeMethods, error) {
+	cmd := exec.Command("gh", "api", "graphql",
+		"-f", "query=query($owner: String!, $repo: String!) { repository(owner: $owner, name: $repo) { squashMergeAllowed mergeCommitAllowed rebaseMergeAllowed } }",
+		"-F", "owner={owner}",
+		"-F", "repo={repo}")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowed merge methods: %s", strings.TrimSpace(string(output)))
+	}
+
+	var resp struct {
+		Data struct {
+			Repository *MergeMethods `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil || resp.Data.Repository == nil {
+		return nil, fmt.Errorf("failed to parse allowed merge methods: %s", strings.TrimSpace(string(output)))
+	}
+	return resp.Data.Repository, nil
+}
+
+// DeletesMergedBranches reports whether the repository has GitHub delete the head branch of a
+// PR once it is merged ("Automatically delete head branches")
+func DeletesMergedBranches() (bool, error) {
+	cmd := exec.Command("gh", "api", "graphql",
+		"-f", "query=query($owner: String!, $repo: String!) { repository(owner: $owner, name: $repo) { deleteBranchOnMerge } }",
+		"-F", "owner={owner}",
+		"-F", "repo={repo}")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return false, fmt.Errorf("failed to get repository settings: %s", strings.TrimSpace(string(output)))
+	}
+
+	var resp struct {
+		Data struct {
+			Repository *struct {
+				DeleteBranchOnMerge bool `json:"deleteBranchOnMerge"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil || resp.Data.Repository == nil {
+		return false, fmt.Errorf("failed to parse repository settings: %s", strings.TrimSpace(string(output)))
+	}
+	return resp.Data.Repository.DeleteBranchOnMerge, nil
+}
+
 // UpdatePRBase changes the base branch of a pull request
 func UpdatePRBase(prNumber int, newBase string) error {
 	cmd := exec.Command("gh", "pr", "edit", strconv.Itoa(prNumber), "--base", newBase)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to update PR #%d base to %s: %s", prNumber, newBase, string(output))
 	}
@@ -127,7 +225,7 @@ func EditPR(prNumber int, title, body string) error {
 	}
 
 	cmd := exec.Command("gh", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to edit PR #%d: %s", prNumber, string(output))
 	}
@@ -135,17 +233,63 @@ func EditPR(prNumber int, title, body string) error {
 	return nil
 }
 
+// ReassignPR assigns a pull request to a new user, removing the previous assignee
+func ReassignPR(prNumber int, to, from string) error {
+	args := []string{"pr", "edit", strconv.Itoa(prNumber), "--add-assignee", to}
+	if from != "" && !strings.EqualFold(from, to) {
+		args = append(args, "--remove-assignee", from)
+	}
+
+	cmd := exec.Command("gh", args...)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to reassign PR #%d to %s: %s", prNumber, to, string(output))
+	}
+
+	return nil
+}
+
+// RequestReviewers requests reviews on a pull request from users or teams ("org/team")
+func RequestReviewers(prNumber int, reviewers []string) error {
+	cmd := exec.Command("gh", "pr", "edit", strconv.Itoa(prNumber), "--add-reviewer", strings.Join(reviewers, ","))
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to request reviewers on PR #%d: %s", prNumber, string(output))
+	}
+
+	return nil
+}
+
+// cached login of the authenticated gh user
+var currentUser string
+
+// GetCurrentUser returns the GitHub login of the authenticated gh user
+func GetCurrentUser() (string, error) {
+	if currentUser != "" {
+		return currentUser, nil
+	}
+
+	cmd := exec.Command("gh", "api", "user", "--jq", ".login")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current GitHub user: %w", err)
+	}
+
+	currentUser = strings.TrimSpace(string(output))
+	return currentUser, nil
+}
+
 // IsGHAuthenticated checks if the gh CLI is authenticated
 func IsGHAuthenticated() bool {
 	cmd := exec.Command("gh", "auth", "status")
-	err := cmd.Run()
+	err := profile.Run(cmd)
 	return err == nil
 }
 
 // GetPRURL gets the URL for a pull request
 func GetPRURL(prNumber int) (string, error) {
 	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "url", "-q", ".url")
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to get PR URL for #%d: %w", prNumber, err)
 	}
@@ -171,12 +315,39 @@ func extractPRNumber(output string) (int, error) {
 	return 0, fmt.Errorf("could not find PR number in output: %s", output)
 }
 
+// ParsePRReference parses a PR number from "123", "#123", or a PR URL
+// such as https://github.com/owner/repo/pull/123
+// Returns false if ref does not look like a PR reference
+func ParsePRReference(ref string) (int, bool) {
+	ref = strings.TrimSpace(ref)
+
+	if idx := strings.Index(ref, "/pull/"); idx != -1 && strings.Contains(ref, "://") {
+		numStr := ref[idx+len("/pull/"):]
+		// Ignore trailing path segments like /files or /commits
+		if slash := strings.IndexAny(numStr, "/?#"); slash != -1 {
+			numStr = numStr[:slash]
+		}
+		num, err := strconv.Atoi(numStr)
+		if err != nil || num <= 0 {
+			return 0, false
+		}
+		return num, true
+	}
+
+	num, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	if err != nil || num <= 0 {
+		return 0, false
+	}
+	return num, true
+}
+
 // IsApproved checks if a PR is approved
 func (s *PRStatus) IsApproved() bool {
 	return s.ReviewDecision == "APPROVED"
 }
 
 // IsCIPassing checks if CI checks are passing
+// Checks that are still running do not count as passing
 func (s *PRStatus) IsCIPassing() bool {
 	if len(s.StatusCheckRollup) == 0 {
 		// No checks, consider as passing
@@ -184,13 +355,31 @@ func (s *PRStatus) IsCIPassing() bool {
 	}
 
 	for _, check := range s.StatusCheckRollup {
-		if check.State != "SUCCESS" {
+		switch check.Result() {
+		case "Passing", "Skipped":
+			continue
+		default:
 			return false
 		}
 	}
 	return true
 }
 
+// IsCIPending checks if any CI check is still queued or running
+func (s *PRStatus) IsCIPending() bool {
+	for _, check := range s.StatusCheckRollup {
+		if check.Result() == "Running" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasConflicts checks if GitHub found conflicts between the PR and its base
+func (s *PRStatus) HasConflicts() bool {
+	return s.Mergeable == "CONFLICTING"
+}
+
 // IsOpen checks if a PR is open
 func (s *PRStatus) IsOpen() bool {
 	return s.State == "OPEN"
@@ -202,7 +391,8 @@ func (s *PRStatus) IsMerged() bool {
 }
 
 // CommentOnPR adds or updates a comment on a pull request
-// Looks for existing comment with stack marker and updates it, or creates new one
+// Looks for existing comment with stack marker and updates it, or creates new one.
+// Comments are posted as the configured GitHub App when one is set up.
 func CommentOnPR(prNumber int, body string) error {
 	// First, try to find existing stack comment
 	existingCommentID, err := findStackComment(prNumber)
@@ -220,40 +410,99 @@ func CommentOnPR(prNumber int, body string) error {
 	return createComment(prNumber, body)
 }
 
-// findStackComment finds the comment ID of an existing stack visualization comment
+// stackComment is a comment on a PR carrying stak's stack marker
+type stackComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	App *struct {
+		ID int64 `json:"id"`
+	} `json:"performed_via_github_app"`
+}
+
+// findStackComment finds the comment ID of an existing stack visualization comment to update.
+// Only comments stak posts itself count: those of the configured GitHub App, or without one,
+// of the authenticated user. Anyone else's comment quoting the marker is left alone.
 func findStackComment(prNumber int) (string, error) {
-	cmd := exec.Command("gh", "api", fmt.Sprintf("/repos/{owner}/{repo}/issues/%d/comments", prNumber))
-	output, err := cmd.Output()
+	creds, err := LoadAppCredentials()
 	if err != nil {
 		return "", err
 	}
+	login := ""
+	if creds == nil {
+		if login, err = GetCurrentUser(); err != nil {
+			return "", err
+		}
+	}
 
-	var comments []struct {
-		ID   int64  `json:"id"`
-		Body string `json:"body"`
+	comments, err := getStackComments(prNumber)
+	if err != nil {
+		return "", err
 	}
+	for _, comment := range comments {
+		ours := comment.User.Login == login
+		if creds != nil {
+			ours = comment.App != nil && strconv.FormatInt(comment.App.ID, 10) == creds.AppID
+		}
+		if ours {
+			return strconv.FormatInt(comment.ID, 10), nil
+		}
+	}
+	return "", nil
+}
 
+// GetStackCommentBody returns the body of the stack visualization comment on a PR, whoever
+// posted it, so a stack handed off by a teammate or posted by another identity can be
+// restored. With several, the newest wins. Returns an empty string if the PR has none.
+func GetStackCommentBody(prNumber int) (string, error) {
+	comments, err := getStackComments(prNumber)
+	if err != nil {
+		return "", fmt.Errorf("failed to read comments on PR #%d: %w", prNumber, err)
+	}
+	if len(comments) == 0 {
+		return "", nil
+	}
+	return comments[len(comments)-1].Body, nil
+}
+
+// getStackComments returns the comments on a PR that carry the stack marker, oldest first
+func getStackComments(prNumber int) ([]stackComment, error) {
+	cmd, err := commentCommand("api", fmt.Sprintf("/repos/{owner}/{repo}/issues/%d/comments?per_page=100", prNumber))
+	if err != nil {
+		return nil, err
+	}
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []stackComment
 	if err := json.Unmarshal(output, &comments); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Look for comment containing stack marker
+	// Look for comments containing the stack marker
 	stackMarker := "_This stack is managed by [stak]"
+	var marked []stackComment
 	for _, comment := range comments {
 		if strings.Contains(comment.Body, stackMarker) {
-			return strconv.FormatInt(comment.ID, 10), nil
+			marked = append(marked, comment)
 		}
 	}
-
-	return "", nil
+	return marked, nil
 }
 
 // createComment creates a new comment on a PR
 func createComment(prNumber int, body string) error {
 	args := []string{"pr", "comment", strconv.Itoa(prNumber), "--body", body}
 
-	cmd := exec.Command("gh", args...)
-	output, err := cmd.CombinedOutput()
+	cmd, err := commentCommand(args...)
+	if err != nil {
+		return fmt.Errorf("failed to comment on PR #%d: %w", prNumber, err)
+	}
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to comment on PR #%d: %s", prNumber, string(output))
 	}
@@ -263,11 +512,14 @@ func createComment(prNumber int, body string) error {
 
 // updateComment updates an existing comment
 func updateComment(commentID string, body string) error {
-	cmd := exec.Command("gh", "api", "-X", "PATCH",
+	cmd, err := commentCommand("api", "-X", "PATCH",
 		fmt.Sprintf("/repos/{owner}/{repo}/issues/comments/%s", commentID),
 		"-f", fmt.Sprintf("body=%s", body))
+	if err != nil {
+		return fmt.Errorf("failed to update comment %s: %w", commentID, err)
+	}
 
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to update comment %s: %s", commentID, string(output))
 	}
@@ -281,7 +533,7 @@ func GetPRForBranch(branch string) (int, string, error) {
 	cmd := exec.Command("gh", "pr", "list",
 		"--json", "number,headRefName,baseRefName",
 		"--head", branch)
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to list PRs: %w", err)
 	}
@@ -304,6 +556,125 @@ func GetPRForBranch(branch string) (int, string, error) {
 	return prs[0].Number, prs[0].BaseRefName, nil
 }
 
+// PRSummary is the minimal PR information needed to reconstruct stacks
+type PRSummary struct {
+	Number            int    `json:"number"`
+	HeadRefName       string `json:"headRefName"`
+	BaseRefName       string `json:"baseRefName"`
+	IsCrossRepository bool   `json:"isCrossRepository"`
+	Author            struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// ListOpenPRs lists all open PRs in the repository with a single query
+// If author is non-empty, only PRs by that author are returned ("@me" is supported)
+func ListOpenPRs(author string) ([]PRSummary, error) {
+	args := []string{"pr", "list", "--state", "open", "--limit", "1000",
+		"--json", "number,headRefName,baseRefName,isCrossRepository,author"}
+	if author != "" {
+		args = append(args, "--author", author)
+	}
+
+	cmd := exec.Command("gh", args...)
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open PRs: %w", err)
+	}
+
+	var prs []PRSummary
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse PR list: %w", err)
+	}
+	return prs, nil
+}
+
+// prStatesBatchSize caps how many PRs are queried per GraphQL request
+const prStatesBatchSize = 100
+
+// prRefs is the state and base of a PR, as queried in batches
+type prRefs struct {
+	State       string `json:"state"`
+	BaseRefName string `json:"baseRefName"`
+}
+
+// getPRRefs queries fields of many PRs (state, and baseRefName if needed) using one GraphQL
+// query per batch
+func getPRRefs(prNumbers []int, fields string) (map[int]prRefs, error) {
+	refs := make(map[int]prRefs)
+
+	for start := 0; start < len(prNumbers); start += prStatesBatchSize {
+		end := start + prStatesBatchSize
+		if end > len(prNumbers) {
+			end = len(prNumbers)
+		}
+
+		// Alias each PR as pr<number> so they can all be fetched in one request
+		var query strings.Builder
+		for _, n := range prNumbers[start:end] {
+			fmt.Fprintf(&query, "pr%d: pullRequest(number: %d) { %s } ", n, n, fields)
+		}
+		graphql := fmt.Sprintf("query($owner: String!, $repo: String!) { repository(owner: $owner, name: $repo) { %s} }", query.String())
+
+		cmd := exec.Command("gh", "api", "graphql",
+			"-f", "query="+graphql,
+			"-F", "owner={owner}",
+			"-F", "repo={repo}")
+		output, err := profile.CombinedOutput(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PR states: %s", string(output))
+		}
+
+		var resp struct {
+			Data struct {
+				Repository map[string]*prRefs `json:"repository"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(output, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse PR states: %w", err)
+		}
+
+		for alias, pr := range resp.Data.Repository {
+			n, err := strconv.Atoi(strings.TrimPrefix(alias, "pr"))
+			if err != nil || pr == nil {
+				continue
+			}
+			refs[n] = *pr
+		}
+	}
+
+	return refs, nil
+}
+
+// GetPRStates returns the state (OPEN, CLOSED or MERGED) of many PRs using one GraphQL query per batch
+func GetPRStates(prNumbers []int) (map[int]string, error) {
+	refs, err := getPRRefs(prNumbers, "state")
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[int]string, len(refs))
+	for n, pr := range refs {
+		states[n] = pr.State
+	}
+	return states, nil
+}
+
+// GetOpenPRBases returns the base branch of those of many PRs that are open, batched like
+// GetPRStates
+func GetOpenPRBases(prNumbers []int) (map[int]string, error) {
+	refs, err := getPRRefs(prNumbers, "state baseRefName")
+	if err != nil {
+		return nil, err
+	}
+	bases := make(map[int]string)
+	for n, pr := range refs {
+		if pr.State == "OPEN" {
+			bases[n] = pr.BaseRefName
+		}
+	}
+	return bases, nil
+}
+
 // GetPRNumberForBranch finds the PR number for a branch
 // Returns PR number and error
 func GetPRNumberForBranch(branch string) (int, error) {
@@ -320,6 +691,8 @@ type PRDetails struct {
 	IsDraft        bool   `json:"isDraft"`
 	BaseRefName    string `json:"baseRefName"`
 	HeadRefName    string `json:"headRefName"`
+	URL            string `json:"url"`
+	IsCrossRepo    bool   `json:"isCrossRepository"`
 	Commits        struct {
 		TotalCount int `json:"totalCount"`
 	} `json:"commits"`
@@ -335,9 +708,9 @@ type PRDetails struct {
 func GetPRDetails(prNumber int) (*PRDetails, error) {
 	// Query with --jq to get commit count instead of full commit array
 	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json",
-		"number,title,state,reviewDecision,isDraft,baseRefName,headRefName,commits,statusCheckRollup",
-		"--jq", "{number, title, state, reviewDecision, isDraft, baseRefName, headRefName, commits: {totalCount: (.commits | length)}, statusCheckRollup}")
-	output, err := cmd.CombinedOutput()
+		"number,title,state,reviewDecision,isDraft,baseRefName,headRefName,url,isCrossRepository,commits,statusCheckRollup",
+		"--jq", "{number, title, state, reviewDecision, isDraft, baseRefName, headRefName, url, isCrossRepository, commits: {totalCount: (.commits | length)}, statusCheckRollup}")
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PR details for #%d: %w (output: %s)", prNumber, err, string(output))
 	}
@@ -449,7 +822,27 @@ func (d *PRDetails) GetStateDisplay() string {
 // ClosePR closes a pull request
 func ClosePR(prNumber int) error {
 	cmd := exec.Command("gh", "pr", "close", strconv.Itoa(prNumber))
-	output, err := cmd.CombinedOutput()
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to close PR #%d: %s", prNumber, string(output))
+	}
+	return nil
+}
+
+// ReopenPR reopens a pull request that was closed without merging
+func ReopenPR(prNumber int) error {
+	cmd := exec.Command("gh", "pr", "reopen", strconv.Itoa(prNumber))
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to reopen PR #%d: %s", prNumber, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ClosePRWithComment closes a pull request, leaving a comment explaining why
+func ClosePRWithComment(prNumber int, comment string) error {
+	cmd := exec.Command("gh", "pr", "close", strconv.Itoa(prNumber), "--comment", comment)
+	output, err := profile.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to close PR #%d: %s", prNumber, string(output))
 	}
@@ -0,0 +1,140 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// BranchProtection holds the rules GitHub enforces on updates to a branch, from branch
+// protection and rulesets combined. A zero value means the branch is unprotected.
+type BranchProtection struct {
+	Branch                  string
+	Protected               bool
+	RequiredApprovals       int
+	RequiredChecks          []string
+	RequireCodeOwnerReviews bool
+	RequireResolvedThreads  bool
+	LinearHistory           bool
+	RequireSignatures       bool
+	AllowsForcePushes       bool
+	AllowsDeletions         bool
+}
+
+const protectionQuery = `query($owner: String!, $repo: String!, $ref: String!) {
+  repository(owner: $owner, name: $repo) {
+    ref(qualifiedName: $ref) {
+      refUpdateRule {
+        requiredApprovingReviewCount
+        requiredStatusCheckContexts
+        requiresCodeOwnerReviews
+        requiresConversationResolution
+        requiresLinearHistory
+        requiredSignatures
+        allowsForcePushes
+        allowsDeletions
+      }
+    }
+  }
+}`
+
+// GetBranchProtection retrieves the rules that apply to updating a branch. Unlike the
+// branch protection REST API, this works without admin access to the repository.
+func GetBranchProtection(branch string) (*BranchProtection, error) {
+	cmd := exec.Command("gh", "api", "graphql",
+		"-f", "query="+protectionQuery,
+		"-F", "owner={owner}",
+		"-F", "repo={repo}",
+		"-f", "ref=refs/heads/"+branch)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get protection rules for %s: %s", branch, strings.TrimSpace(string(output)))
+	}
+
+	var resp struct {
+		Data struct {
+			Repository struct {
+				Ref *struct {
+					RefUpdateRule *struct {
+						RequiredApprovingReviewCount   int      `json:"requiredApprovingReviewCount"`
+						RequiredStatusCheckContexts    []string `json:"requiredStatusCheckContexts"`
+						RequiresCodeOwnerReviews       bool     `json:"requiresCodeOwnerReviews"`
+						RequiresConversationResolution bool     `json:"requiresConversationResolution"`
+						RequiresLinearHistory          bool     `json:"requiresLinearHistory"`
+						RequiredSignatures             bool     `json:"requiredSignatures"`
+						AllowsForcePushes              bool     `json:"allowsForcePushes"`
+						AllowsDeletions                bool     `json:"allowsDeletions"`
+					} `json:"refUpdateRule"`
+				} `json:"ref"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse protection rules for %s: %w", branch, err)
+	}
+
+	protection := &BranchProtection{Branch: branch, AllowsForcePushes: true, AllowsDeletions: true}
+	ref := resp.Data.Repository.Ref
+	if ref == nil || ref.RefUpdateRule == nil {
+		return protection, nil
+	}
+
+	rule := ref.RefUpdateRule
+	protection.Protected = true
+	protection.RequiredApprovals = rule.RequiredApprovingReviewCount
+	protection.RequiredChecks = rule.RequiredStatusCheckContexts
+	protection.RequireCodeOwnerReviews = rule.RequiresCodeOwnerReviews
+	protection.RequireResolvedThreads = rule.RequiresConversationResolution
+	protection.LinearHistory = rule.RequiresLinearHistory
+	protection.RequireSignatures = rule.RequiredSignatures
+	protection.AllowsForcePushes = rule.AllowsForcePushes
+	protection.AllowsDeletions = rule.AllowsDeletions
+	return protection, nil
+}
+
+// MergeBlockers lists what keeps a PR from being merged into the protected branch with
+// the given method, e.g. "needs 2 approvals, has 1". Required checks that are still
+// running are not listed, since they may yet pass.
+func (p *BranchProtection) MergeBlockers(status *PRStatus, reviews *PRReviews, method string) []string {
+	var blockers []string
+
+	if reviews != nil {
+		if approvals := len(reviews.Approvers); approvals < p.RequiredApprovals {
+			blockers = append(blockers, fmt.Sprintf("needs %d approval(s), has %d", p.RequiredApprovals, approvals))
+		} else if p.RequireCodeOwnerReviews && reviews.ReviewDecision == "REVIEW_REQUIRED" {
+			blockers = append(blockers, "needs a review from a code owner")
+		}
+		if len(reviews.ChangesRequestedBy) > 0 {
+			blockers = append(blockers, fmt.Sprintf("changes requested by %s", strings.Join(reviews.ChangesRequestedBy, ", ")))
+		}
+		if p.RequireResolvedThreads && reviews.UnresolvedThreads > 0 {
+			blockers = append(blockers, fmt.Sprintf("%d unresolved conversation(s)", reviews.UnresolvedThreads))
+		}
+	}
+
+	for _, required := range p.RequiredChecks {
+		result := ""
+		for i := range status.StatusCheckRollup {
+			check := &status.StatusCheckRollup[i]
+			if check.Name == required || check.Context == required {
+				result = check.Result()
+			}
+		}
+		switch result {
+		case "Passing", "Skipped", "Running":
+		case "":
+			blockers = append(blockers, fmt.Sprintf("required check %q has not run", required))
+		default:
+			blockers = append(blockers, fmt.Sprintf("required check %q is %s", required, strings.ToLower(result)))
+		}
+	}
+
+	if p.LinearHistory && method == "merge" {
+		blockers = append(blockers, "linear history is required, so merge commits are not allowed (use --method squash or rebase)")
+	}
+
+	return blockers
+}
@@ -0,0 +1,88 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"stacking/internal/profile"
+)
+
+// PRContent is the title and description of a pull request
+type PRContent struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	State  string `json:"state"`
+}
+
+// GetPRContent retrieves the title, description and state of a pull request
+func GetPRContent(prNumber int) (*PRContent, error) {
+	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "number,title,body,state")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %s", prNumber, string(output))
+	}
+
+	var content PRContent
+	if err := json.Unmarshal(output, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse PR #%d: %w", prNumber, err)
+	}
+	return &content, nil
+}
+
+// GetPRCoAuthors returns "Name <email>" of the authors of a pull request's commits other than
+// the PR's author, who GitHub credits for a squash merge, each once
+func GetPRCoAuthors(prNumber int) ([]string, error) {
+	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "author,commits")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the commits of PR #%d: %s", prNumber, string(output))
+	}
+
+	var pr struct {
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		Commits []struct {
+			Authors []struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+				Login string `json:"login"`
+			} `json:"authors"`
+		} `json:"commits"`
+	}
+	if err := json.Unmarshal(output, &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse the commits of PR #%d: %w", prNumber, err)
+	}
+
+	var coAuthors []string
+	seen := make(map[string]bool)
+	for _, commit := range pr.Commits {
+		for _, author := range commit.Authors {
+			email := strings.ToLower(author.Email)
+			if author.Email == "" || seen[email] || (author.Login != "" && author.Login == pr.Author.Login) {
+				continue
+			}
+			seen[email] = true
+			coAuthors = append(coAuthors, fmt.Sprintf("%s <%s>", author.Name, author.Email))
+		}
+	}
+	return coAuthors, nil
+}
+
+// CreateDraftRelease creates a draft GitHub release for tag and returns its URL.
+// The tag is only created when the release is published.
+func CreateDraftRelease(tag, title, notes string) (string, error) {
+	cmd := exec.Command("gh", "release", "create", tag, "--draft", "--title", title, "--notes-file", "-")
+	cmd.Stdin = strings.NewReader(notes)
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create release %s: %s", tag, string(output))
+	}
+	// gh prints the release URL last
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1], nil
+}
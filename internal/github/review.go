@@ -0,0 +1,148 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"stacking/internal/profile"
+)
+
+// PRReviews summarizes who has reviewed a PR and what is still outstanding
+type PRReviews struct {
+	Number             int
+	Title              string
+	ReviewDecision     string
+	Approvers          []string
+	ChangesRequestedBy []string
+	PendingReviewers   []string
+	UnresolvedThreads  int
+}
+
+const reviewsQuery = `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      number
+      title
+      reviewDecision
+      latestOpinionatedReviews(first: 100) {
+        nodes { state author { login } }
+      }
+      reviewRequests(first: 100) {
+        nodes {
+          requestedReviewer {
+            ... on User { login }
+            ... on Mannequin { login }
+            ... on Team { combinedSlug }
+          }
+        }
+      }
+      reviewThreads(first: 100) {
+        nodes { isResolved }
+      }
+    }
+  }
+}`
+
+// GetPRReviews retrieves approvals, pending review requests and unresolved thread counts for a PR
+func GetPRReviews(prNumber int) (*PRReviews, error) {
+	// Review threads are only exposed through GraphQL
+	cmd := exec.Command("gh", "api", "graphql",
+		"-f", "query="+reviewsQuery,
+		"-F", "owner={owner}",
+		"-F", "repo={repo}",
+		"-F", "number="+strconv.Itoa(prNumber))
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews for PR #%d: %s", prNumber, string(output))
+	}
+
+	var resp struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					Number                   int    `json:"number"`
+					Title                    string `json:"title"`
+					ReviewDecision           string `json:"reviewDecision"`
+					LatestOpinionatedReviews struct {
+						Nodes []struct {
+							State  string `json:"state"`
+							Author struct {
+								Login string `json:"login"`
+							} `json:"author"`
+						} `json:"nodes"`
+					} `json:"latestOpinionatedReviews"`
+					ReviewRequests struct {
+						Nodes []struct {
+							RequestedReviewer struct {
+								Login        string `json:"login"`
+								CombinedSlug string `json:"combinedSlug"`
+							} `json:"requestedReviewer"`
+						} `json:"nodes"`
+					} `json:"reviewRequests"`
+					ReviewThreads struct {
+						Nodes []struct {
+							IsResolved bool `json:"isResolved"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse reviews for PR #%d: %w", prNumber, err)
+	}
+
+	pr := resp.Data.Repository.PullRequest
+	reviews := &PRReviews{
+		Number:         pr.Number,
+		Title:          pr.Title,
+		ReviewDecision: pr.ReviewDecision,
+	}
+
+	for _, review := range pr.LatestOpinionatedReviews.Nodes {
+		switch review.State {
+		case "APPROVED":
+			reviews.Approvers = append(reviews.Approvers, review.Author.Login)
+		case "CHANGES_REQUESTED":
+			reviews.ChangesRequestedBy = append(reviews.ChangesRequestedBy, review.Author.Login)
+		}
+	}
+
+	for _, request := range pr.ReviewRequests.Nodes {
+		if request.RequestedReviewer.CombinedSlug != "" {
+			reviews.PendingReviewers = append(reviews.PendingReviewers, request.RequestedReviewer.CombinedSlug)
+		} else if request.RequestedReviewer.Login != "" {
+			reviews.PendingReviewers = append(reviews.PendingReviewers, request.RequestedReviewer.Login)
+		}
+	}
+
+	for _, thread := range pr.ReviewThreads.Nodes {
+		if !thread.IsResolved {
+			reviews.UnresolvedThreads++
+		}
+	}
+
+	return reviews, nil
+}
+
+// HasReviewHistory checks if a PR is open and has any reviews or comments that would be
+// lost from view if it were closed
+func HasReviewHistory(prNumber int) (bool, error) {
+	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "state,reviews,comments")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return false, fmt.Errorf("failed to get PR #%d: %s", prNumber, string(output))
+	}
+
+	var pr struct {
+		State    string            `json:"state"`
+		Reviews  []json.RawMessage `json:"reviews"`
+		Comments []json.RawMessage `json:"comments"`
+	}
+	if err := json.Unmarshal(output, &pr); err != nil {
+		return false, fmt.Errorf("failed to parse PR #%d: %w", prNumber, err)
+	}
+	return pr.State == "OPEN" && len(pr.Reviews)+len(pr.Comments) > 0, nil
+}
@@ -0,0 +1,82 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"stacking/internal/profile"
+)
+
+// PRTimeline holds when a PR was opened, first reviewed and closed
+type PRTimeline struct {
+	Number int
+	State  string
+	// CreatedAt is when the PR was opened
+	CreatedAt time.Time
+	// FirstReviewAt is the first review by someone other than the author, zero if none yet
+	FirstReviewAt time.Time
+	// ClosedAt is when the PR was merged or closed, zero while it is open
+	ClosedAt time.Time
+}
+
+// GetPRTimeline retrieves the timestamps needed for PR age and review latency
+func GetPRTimeline(prNumber int) (*PRTimeline, error) {
+	cmd := exec.Command("gh", "pr", "view", strconv.Itoa(prNumber), "--json", "number,state,author,createdAt,closedAt,reviews")
+	output, err := profile.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PR #%d: %s", prNumber, string(output))
+	}
+
+	var pr struct {
+		Number int    `json:"number"`
+		State  string `json:"state"`
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		CreatedAt time.Time  `json:"createdAt"`
+		ClosedAt  *time.Time `json:"closedAt"`
+		Reviews   []struct {
+			Author struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			SubmittedAt time.Time `json:"submittedAt"`
+		} `json:"reviews"`
+	}
+	if err := json.Unmarshal(output, &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse PR #%d: %w", prNumber, err)
+	}
+
+	timeline := &PRTimeline{Number: pr.Number, State: pr.State, CreatedAt: pr.CreatedAt}
+	if pr.ClosedAt != nil {
+		timeline.ClosedAt = *pr.ClosedAt
+	}
+	for _, review := range pr.Reviews {
+		// Replies the author posts in review threads show up as reviews too
+		if review.Author.Login == pr.Author.Login || review.SubmittedAt.IsZero() {
+			continue
+		}
+		if timeline.FirstReviewAt.IsZero() || review.SubmittedAt.Before(timeline.FirstReviewAt) {
+			timeline.FirstReviewAt = review.SubmittedAt
+		}
+	}
+	return timeline, nil
+}
+
+// Age returns how long the PR has been open, or was open before it closed
+func (t *PRTimeline) Age(now time.Time) time.Duration {
+	if !t.ClosedAt.IsZero() {
+		return t.ClosedAt.Sub(t.CreatedAt)
+	}
+	return now.Sub(t.CreatedAt)
+}
+
+// ReviewLatency returns how long the PR waited for its first review, 0 if it has none
+func (t *PRTimeline) ReviewLatency() time.Duration {
+	if t.FirstReviewAt.IsZero() {
+		return 0
+	}
+	return t.FirstReviewAt.Sub(t.CreatedAt)
+}
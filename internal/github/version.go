@@ -0,0 +1,33 @@
+package github
+
+import (
+	"os/exec"
+
+	"stacking/internal/git"
+	"stacking/internal/profile"
+)
+
+// Features stak uses that need a newer gh than the oldest it runs on
+var (
+	FillFirstVersion = git.Version{Major: 2, Minor: 31} // pr create --fill-first
+)
+
+// ghVersion caches GetVersion, which doesn't change while stak runs
+var ghVersion *git.Version
+
+// GetVersion returns the version of the installed gh, or the zero Version if it can't be read,
+// e.g. because gh isn't installed
+func GetVersion() git.Version {
+	if ghVersion == nil {
+		output, _ := profile.Output(exec.Command("gh", "--version"))
+		v := git.ParseVersion(string(output))
+		ghVersion = &v
+	}
+	return *ghVersion
+}
+
+// CanFillFirst reports whether gh pr create has --fill-first, which titles a PR after its
+// first commit. Older versions fill the PR from all its commits with --fill instead.
+func CanFillFirst() bool {
+	return GetVersion().AtLeast(FillFirstVersion)
+}
@@ -0,0 +1,114 @@
+package history
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"stacking/internal/git"
+	"stacking/internal/profile"
+)
+
+// BranchChange records where an operation moved a branch. An empty Before means the
+// operation created the branch; an empty After means it deleted it.
+type BranchChange struct {
+	Name   string `json:"name"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// MetadataChange records a stack metadata key (stack.branch.<name>.<field>) changed by an
+// operation. An empty value means the key was not set.
+type MetadataChange struct {
+	Key    string `json:"key"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Snapshot is the state of every local branch head and all stack metadata at one point
+type Snapshot struct {
+	Heads    map[string]string
+	Metadata map[string]string
+}
+
+// TakeSnapshot records the current branch heads and stack metadata
+func TakeSnapshot() (*Snapshot, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(objectname) %(refname)", "refs/heads/")
+	output, err := profile.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	heads := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if sha, ref, ok := strings.Cut(line, " "); ok {
+			heads[strings.TrimPrefix(ref, "refs/heads/")] = sha
+		}
+	}
+
+	metadata, err := git.GetConfigRegexp("^stack\\.branch\\.")
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Heads: heads, Metadata: metadata}, nil
+}
+
+// Diff returns the branches and metadata keys that differ between s and a later snapshot
+func (s *Snapshot) Diff(after *Snapshot) ([]BranchChange, []MetadataChange) {
+	var branches []BranchChange
+	for _, name := range unionKeys(s.Heads, after.Heads) {
+		if s.Heads[name] != after.Heads[name] {
+			branches = append(branches, BranchChange{Name: name, Before: s.Heads[name], After: after.Heads[name]})
+		}
+	}
+
+	var metadata []MetadataChange
+	for _, key := range unionKeys(s.Metadata, after.Metadata) {
+		if s.Metadata[key] != after.Metadata[key] {
+			metadata = append(metadata, MetadataChange{Key: key, Before: s.Metadata[key], After: after.Metadata[key]})
+		}
+	}
+	return branches, metadata
+}
+
+// RecordOperation compares the repository with a snapshot taken before an operation and
+// logs the operation with what it changed. Operations that changed nothing are not logged.
+func RecordOperation(command, branch, description string, before *Snapshot) error {
+	after, err := TakeSnapshot()
+	if err != nil {
+		return err
+	}
+	branches, metadata := before.Diff(after)
+	if len(branches) == 0 && len(metadata) == 0 {
+		return nil
+	}
+
+	logPath, err := GetLogPath()
+	if err != nil {
+		return err
+	}
+	return appendOperation(logPath, Operation{
+		Timestamp:       time.Now(),
+		Command:         command,
+		Branch:          branch,
+		Description:     description,
+		Branches:        branches,
+		MetadataChanges: metadata,
+	})
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
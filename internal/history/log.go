@@ -8,15 +8,21 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"stacking/internal/profile"
 )
 
 // Operation represents a stack operation that can be undone
 type Operation struct {
+	ID          int                    `json:"id,omitempty"`
 	Timestamp   time.Time              `json:"timestamp"`
 	Command     string                 `json:"command"`
 	Branch      string                 `json:"branch"`
 	Description string                 `json:"description"`
 	Metadata    map[string]interface{} `json:"metadata"`
+	// Branches and MetadataChanges record what the operation changed, so it can be reverted
+	Branches        []BranchChange   `json:"branches,omitempty"`
+	MetadataChanges []MetadataChange `json:"metadata_changes,omitempty"`
 }
 
 // GetLogPath returns the path to the operation log file
@@ -35,14 +41,17 @@ func LogOperation(command, branch, description string, metadata map[string]inter
 		return err
 	}
 
-	op := Operation{
+	return appendOperation(logPath, Operation{
 		Timestamp:   time.Now(),
 		Command:     command,
 		Branch:      branch,
 		Description: description,
 		Metadata:    metadata,
-	}
+	})
+}
 
+// appendOperation numbers an operation after the last logged one and appends it to the log
+func appendOperation(logPath string, op Operation) error {
 	// Read existing operations
 	ops, err := ReadOperations()
 	if err != nil && !os.IsNotExist(err) {
@@ -50,6 +59,10 @@ func LogOperation(command, branch, description string, metadata map[string]inter
 	}
 
 	// Append new operation
+	op.ID = 1
+	if len(ops) > 0 {
+		op.ID = ops[len(ops)-1].ID + 1
+	}
 	ops = append(ops, op)
 
 	// Keep only last 50 operations
@@ -107,6 +120,21 @@ func GetLastOperation() (*Operation, error) {
 	return &ops[len(ops)-1], nil
 }
 
+// GetOperation returns the logged operation with the given ID
+func GetOperation(id int) (*Operation, error) {
+	ops, err := ReadOperations()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ops {
+		if ops[i].ID == id {
+			return &ops[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no operation #%d in history", id)
+}
+
 // RemoveLastOperation removes the most recent operation from the log
 func RemoveLastOperation() error {
 	logPath, err := GetLogPath()
@@ -141,7 +169,7 @@ func RemoveLastOperation() error {
 
 func getGitDir() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	output, err := cmd.Output()
+	output, err := profile.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("not in a git repository")
 	}
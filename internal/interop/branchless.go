@@ -0,0 +1,66 @@
+package interop
+
+import (
+	"fmt"
+	"sort"
+
+	"stacking/internal/git"
+)
+
+// LoadBranchless reconstructs the stacks of a repository set up with git-branchless, which
+// tracks commits rather than branches. As its smartlog draws them, each branch is stacked on
+// the nearest branch below it in its history, or on the main branch. It returns the branches
+// sorted by name, leaving out those already in the main branch, and the main branch.
+func LoadBranchless() ([]Branch, string, error) {
+	trunk, err := git.GetConfig("branchless.core.mainBranch")
+	if err != nil {
+		return nil, "", err
+	}
+	if trunk == "" {
+		return nil, "", fmt.Errorf("git-branchless is not set up in this repository (no branchless.core.mainBranch)")
+	}
+
+	tips, err := git.GetBranchTips()
+	if err != nil {
+		return nil, "", err
+	}
+	if _, ok := tips[trunk]; !ok {
+		return nil, "", fmt.Errorf("main branch %s does not exist", trunk)
+	}
+	byTip := make(map[string][]string)
+	var names []string
+	for name, tip := range tips {
+		if name == trunk || git.BranchContainsCommit(trunk, tip) {
+			continue
+		}
+		byTip[tip] = append(byTip[tip], name)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, branches := range byTip {
+		sort.Strings(branches)
+	}
+
+	var branches []Branch
+	for _, name := range names {
+		commits, err := git.GetCommitAncestors(name, trunk)
+		if err != nil {
+			return nil, "", err
+		}
+		branch := Branch{Name: name, Parent: trunk}
+		// The first commit is the branch's own tip, which branches sharing it don't stack on
+		for _, commit := range commits[1:] {
+			if below := byTip[commit]; len(below) > 0 {
+				branch.Parent, branch.ParentRevision = below[0], commit
+				break
+			}
+		}
+		if branch.ParentRevision == "" {
+			if branch.ParentRevision, err = git.GetMergeBase(name, trunk); err != nil {
+				return nil, "", err
+			}
+		}
+		branches = append(branches, branch)
+	}
+	return branches, trunk, nil
+}
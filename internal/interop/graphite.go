@@ -0,0 +1,155 @@
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"stacking/internal/git"
+)
+
+// GraphiteRefs is where the Graphite CLI keeps a JSON blob per tracked branch
+const GraphiteRefs = "refs/branch-metadata/"
+
+// Files in the git directory where Graphite keeps the repository's trunk and the PRs it knows
+const (
+	graphiteRepoConfig = ".graphite_repo_config"
+	graphitePRCache    = ".graphite_pr_info"
+)
+
+// graphiteMetadata is the content of a refs/branch-metadata/<branch> blob
+type graphiteMetadata struct {
+	ParentBranchName     string          `json:"parentBranchName"`
+	ParentBranchRevision string          `json:"parentBranchRevision,omitempty"`
+	PRInfo               *graphitePRInfo `json:"prInfo,omitempty"`
+}
+
+// graphitePRInfo is what Graphite's metadata says about a branch's PR
+type graphitePRInfo struct {
+	Number int    `json:"number"`
+	Base   string `json:"base,omitempty"`
+}
+
+// LoadGraphite reads Graphite's metadata of the current repository. It returns the tracked
+// branches sorted by name, and the trunk if Graphite's repository config names one. PR numbers
+// missing from a branch's metadata are taken from Graphite's PR cache. No branches and no
+// error mean the repository has no Graphite metadata.
+func LoadGraphite() ([]Branch, string, error) {
+	blobs, err := git.ReadRefContents(GraphiteRefs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var branches []Branch
+	for name, blob := range blobs {
+		var metadata graphiteMetadata
+		if err := json.Unmarshal([]byte(blob), &metadata); err != nil {
+			return nil, "", fmt.Errorf("failed to parse Graphite metadata of %s: %w", name, err)
+		}
+		// Graphite keeps a record for trunk too, without a parent
+		if metadata.ParentBranchName == "" {
+			continue
+		}
+		branch := Branch{Name: name, Parent: metadata.ParentBranchName, ParentRevision: metadata.ParentBranchRevision}
+		if metadata.PRInfo != nil {
+			branch.PRNumber = metadata.PRInfo.Number
+		}
+		branches = append(branches, branch)
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+
+	prNumbers, err := loadGraphitePRInfo()
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range branches {
+		if branches[i].PRNumber == 0 {
+			branches[i].PRNumber = prNumbers[branches[i].Name]
+		}
+	}
+
+	var config struct {
+		Trunk string `json:"trunk"`
+	}
+	if err := readGitFile(graphiteRepoConfig, &config); err != nil {
+		return nil, "", err
+	}
+	return branches, config.Trunk, nil
+}
+
+// loadGraphitePRInfo reads Graphite's PR cache, returning the PR number of each branch in it
+func loadGraphitePRInfo() (map[string]int, error) {
+	var info struct {
+		PRInfos []struct {
+			PRNumber    int    `json:"prNumber"`
+			HeadRefName string `json:"headRefName"`
+		} `json:"prInfos"`
+	}
+	if err := readGitFile(graphitePRCache, &info); err != nil {
+		return nil, err
+	}
+	prNumbers := make(map[string]int, len(info.PRInfos))
+	for _, pr := range info.PRInfos {
+		prNumbers[pr.HeadRefName] = pr.PRNumber
+	}
+	return prNumbers, nil
+}
+
+// WriteGraphite records branches in Graphite's metadata, so the Graphite CLI sees the same
+// stacks. Only the parent, fork point and PR number and base are set; anything else Graphite
+// keeps about a branch, like its PR's title and state, is left as it is. The trunk is written
+// to Graphite's repository config unless it names one already.
+func WriteGraphite(branches []Branch, trunk string) error {
+	blobs, err := git.ReadRefContents(GraphiteRefs)
+	if err != nil {
+		return err
+	}
+
+	for _, branch := range branches {
+		var metadata map[string]any
+		if blob, ok := blobs[branch.Name]; ok {
+			if err := json.Unmarshal([]byte(blob), &metadata); err != nil {
+				return fmt.Errorf("failed to parse Graphite metadata of %s: %w", branch.Name, err)
+			}
+		}
+		if metadata == nil {
+			metadata = make(map[string]any)
+		}
+
+		metadata["parentBranchName"] = branch.Parent
+		if branch.ParentRevision != "" {
+			metadata["parentBranchRevision"] = branch.ParentRevision
+		} else {
+			delete(metadata, "parentBranchRevision")
+		}
+		if branch.PRNumber > 0 {
+			prInfo, _ := metadata["prInfo"].(map[string]any)
+			if prInfo == nil {
+				prInfo = make(map[string]any)
+			}
+			prInfo["number"] = branch.PRNumber
+			prInfo["base"] = branch.Parent
+			metadata["prInfo"] = prInfo
+		}
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		if err := git.WriteRefContent(GraphiteRefs+branch.Name, string(data)); err != nil {
+			return err
+		}
+	}
+
+	var config map[string]any
+	if err := readGitFile(graphiteRepoConfig, &config); err != nil {
+		return err
+	}
+	if config["trunk"] != nil || trunk == "" {
+		return nil
+	}
+	if config == nil {
+		config = make(map[string]any)
+	}
+	config["trunk"] = trunk
+	return writeGitFile(graphiteRepoConfig, config)
+}
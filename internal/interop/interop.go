@@ -0,0 +1,59 @@
+// Package interop reads and writes the stacks other stacking tools track, so teams can move
+// to stak or use it alongside them
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"stacking/internal/git"
+)
+
+// Branch is a branch of a stack as another tool tracks it
+type Branch struct {
+	Name string
+	// Parent is the branch it is stacked on
+	Parent string
+	// ParentRevision is the commit of the parent it was last restacked onto, or ""
+	ParentRevision string
+	// PRNumber is its PR, or 0 if the tool doesn't know it
+	PRNumber int
+	// Commit is where to create the branch for tools that don't keep one, or ""
+	Commit string
+}
+
+// readGitFile parses a JSON file of the git directory into v, leaving v alone if there is none
+func readGitFile(name string, v any) error {
+	path, err := git.GetGitPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeGitFile writes v as JSON to a file of the git directory
+func writeGitFile(name string, v any) error {
+	path, err := git.GetGitPath(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
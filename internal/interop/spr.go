@@ -0,0 +1,67 @@
+package interop
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"stacking/internal/git"
+	"stacking/internal/github"
+)
+
+var (
+	// sprCommitID is the trailer ejoffe/spr adds to each commit, which names its PR's branch
+	sprCommitID = regexp.MustCompile(`(?m)^commit-id:\s*(\S+)\s*$`)
+	// sprPullRequest is the line getcord/spr adds to each commit once it has a PR
+	sprPullRequest = regexp.MustCompile(`(?m)^Pull Request:\s*\S*/pull/(\d+)\s*$`)
+)
+
+// LoadSpr reconstructs the stack of branch, managed with spr's one commit per PR, with a branch
+// per PR instead. ejoffe/spr marks each commit with a commit-id trailer and pushes it as
+// spr/<trunk>/<commit-id>; getcord/spr links each commit to its PR with a "Pull Request:"
+// line. Each marked commit becomes a branch at that commit, named after its PR's branch and
+// stacked on the one below it; unmarked commits go with the marked commit above them, and
+// those above the last one are left out. openPRs give the PRs' numbers and branches.
+func LoadSpr(branch, trunk string, openPRs []github.PRSummary) ([]Branch, error) {
+	headPRs := make(map[string]int)
+	prHeads := make(map[int]string)
+	for _, pr := range openPRs {
+		headPRs[pr.HeadRefName] = pr.Number
+		prHeads[pr.Number] = pr.HeadRefName
+	}
+
+	// Work on a local trunk isn't in the remote trunk yet
+	base := trunk
+	if git.RemoteTrackingBranchExists(trunk) {
+		base = git.RemoteRef(trunk)
+	}
+	commits, err := git.GetCommitMessages(base, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	parent, parentRevision := trunk, ""
+	if len(commits) > 0 {
+		if parentRevision, err = git.GetMergeBase(base, branch); err != nil {
+			return nil, err
+		}
+	}
+	for _, commit := range commits {
+		imported := Branch{Parent: parent, ParentRevision: parentRevision, Commit: commit.SHA}
+		if m := sprCommitID.FindStringSubmatch(commit.Message); m != nil {
+			imported.Name = fmt.Sprintf("spr/%s/%s", trunk, m[1])
+			imported.PRNumber = headPRs[imported.Name]
+		} else if m := sprPullRequest.FindStringSubmatch(commit.Message); m != nil {
+			imported.PRNumber, _ = strconv.Atoi(m[1])
+			if imported.Name = prHeads[imported.PRNumber]; imported.Name == "" {
+				return nil, fmt.Errorf("PR #%d of commit %s is not open: update the stack with spr first", imported.PRNumber, commit.SHA[:7])
+			}
+		} else {
+			continue
+		}
+		branches = append(branches, imported)
+		parent, parentRevision = imported.Name, commit.SHA
+	}
+	return branches, nil
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Send shows a desktop notification using the platform's native mechanism:
+// osascript on macOS, notify-send on Linux and a toast via PowerShell on Windows.
+// Falls back to ringing the terminal bell if no notifier is available.
+func Send(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(message), appleScriptQuote(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, message))
+	default:
+		if _, err := exec.LookPath("notify-send"); err == nil {
+			cmd = exec.Command("notify-send", "--app-name=stak", title, message)
+		}
+	}
+
+	if cmd == nil {
+		bell()
+		return nil
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		bell()
+		return fmt.Errorf("failed to send notification: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// bell rings the terminal bell on stderr so it doesn't mix with command output
+func bell() {
+	fmt.Fprint(os.Stderr, "\a")
+}
+
+// appleScriptQuote returns s as an AppleScript string literal
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// windowsToastScript builds a PowerShell script that shows a toast notification
+func windowsToastScript(title, message string) string {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return strings.Join([]string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"$t = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$x = $t.GetElementsByTagName('text')",
+		"$x.Item(0).AppendChild($t.CreateTextNode(" + quote(title) + ")) > $null",
+		"$x.Item(1).AppendChild($t.CreateTextNode(" + quote(message) + ")) > $null",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('stak').Show([Windows.UI.Notifications.ToastNotification]::new($t))",
+	}, "; ")
+}
@@ -0,0 +1,151 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// stat accumulates how often something ran and for how long
+type stat struct {
+	name  string
+	count int
+	total time.Duration
+}
+
+var (
+	enabled  bool
+	started  time.Time
+	commands = make(map[string]*stat)
+	phases   []*stat // In the order they first ran
+)
+
+// Enable turns on timing of external commands and phases for this process
+func Enable() {
+	enabled = true
+	started = time.Now()
+}
+
+// Enabled reports whether profiling is on
+func Enabled() bool {
+	return enabled
+}
+
+// Phase starts timing a named phase of a command and returns a function that stops it.
+// Typical use: defer profile.Phase("fetch")()
+func Phase(name string) func() {
+	if !enabled {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		var s *stat
+		for _, p := range phases {
+			if p.name == name {
+				s = p
+				break
+			}
+		}
+		if s == nil {
+			s = &stat{name: name}
+			phases = append(phases, s)
+		}
+		s.count++
+		s.total += time.Since(start)
+	}
+}
+
+// Run runs cmd like cmd.Run, recording its duration when profiling
+func Run(cmd *exec.Cmd) error {
+	defer track(cmd)()
+	return cmd.Run()
+}
+
+// Output runs cmd like cmd.Output, recording its duration when profiling
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	defer track(cmd)()
+	return cmd.Output()
+}
+
+// CombinedOutput runs cmd like cmd.CombinedOutput, recording its duration when profiling
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	defer track(cmd)()
+	return cmd.CombinedOutput()
+}
+
+// track starts timing an external command and returns a function that records it
+func track(cmd *exec.Cmd) func() {
+	if !enabled {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		name := commandName(cmd)
+		s := commands[name]
+		if s == nil {
+			s = &stat{name: name}
+			commands[name] = s
+		}
+		s.count++
+		s.total += time.Since(start)
+	}
+}
+
+// commandName groups commands by tool and subcommand, e.g. "git fetch" or "gh pr view"
+func commandName(cmd *exec.Cmd) string {
+	words := []string{filepath.Base(cmd.Path)}
+	depth := 1
+	if words[0] == "gh" {
+		depth = 2
+	}
+	for _, arg := range cmd.Args[1:] {
+		if len(words) > depth {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// Report writes a breakdown of time spent per phase and per external command
+func Report(w io.Writer) {
+	if !enabled {
+		return
+	}
+
+	fmt.Fprintf(w, "\nProfile (total %s)\n", time.Since(started).Round(time.Millisecond))
+
+	if len(phases) > 0 {
+		fmt.Fprintln(w, "\nPhases:")
+		for _, p := range phases {
+			fmt.Fprintf(w, "  %-24s %10s\n", p.name, p.total.Round(time.Millisecond))
+		}
+	}
+
+	if len(commands) > 0 {
+		sorted := make([]*stat, 0, len(commands))
+		var total time.Duration
+		calls := 0
+		for _, c := range commands {
+			sorted = append(sorted, c)
+			total += c.total
+			calls += c.count
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].total > sorted[j].total
+		})
+
+		fmt.Fprintln(w, "\nExternal commands:")
+		for _, c := range sorted {
+			fmt.Fprintf(w, "  %-24s %5dx %10s\n", c.name, c.count, c.total.Round(time.Millisecond))
+		}
+		fmt.Fprintf(w, "  %-24s %5dx %10s\n", "total", calls, total.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,163 @@
+// Package restack rebases stacked branches onto their parents.
+//
+// It operates on the git repository in the current working directory and
+// finds each branch's parent in the stack metadata.
+package restack
+
+import (
+	"errors"
+	"fmt"
+
+	"stacking/internal/git"
+	"stacking/internal/stack"
+)
+
+var (
+	// ErrDirtyTree is returned when the working tree has uncommitted changes
+	ErrDirtyTree = git.ErrDirtyTree
+
+	// ErrRebaseInProgress is returned when a rebase is already underway
+	ErrRebaseInProgress = errors.New("a rebase is already in progress")
+)
+
+// ConflictError is returned when rebasing a branch stops on a conflict
+type ConflictError struct {
+	Branch string   // Branch being rebased
+	Onto   string   // Ref it was being rebased onto
+	Files  []string // Conflicted files, if known
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("rebase conflict while rebasing %s onto %s", e.Branch, e.Onto)
+}
+
+// Options control how branches are restacked
+type Options struct {
+	// Remote, if set, rebases onto <Remote>/<parent> instead of the local parent branch
+	Remote string
+
+	// AbortOnConflict aborts the rebase on conflict instead of leaving it for the user to resolve
+	AbortOnConflict bool
+}
+
+// Result lists the branches that were rebased, in order
+type Result struct {
+	Restacked []string
+}
+
+// Branch rebases a single branch onto a ref
+// The original branch is checked out again unless a conflict is left for the user to resolve.
+func Branch(branch, onto string, opts Options) error {
+	if err := checkClean(); err != nil {
+		return err
+	}
+
+	original, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	if err := rebase(branch, onto, opts); err != nil {
+		restore(original, err, opts)
+		return err
+	}
+	return git.CheckoutBranch(original)
+}
+
+// Stack rebases a branch and all of its descendants onto their parents, parents first
+// It stops at the first conflict, returning a *ConflictError and the branches restacked so far.
+// The original branch is checked out again unless a conflict is left for the user to resolve.
+func Stack(branch string, opts Options) (*Result, error) {
+	if err := checkClean(); err != nil {
+		return nil, err
+	}
+
+	s, err := stack.BuildStack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+	if s.GetBranch(branch) == nil {
+		return nil, fmt.Errorf("branch %s is not tracked in a stack", branch)
+	}
+
+	original, err := git.GetCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	result := &Result{}
+	queue := []string{branch}
+	for len(queue) > 0 {
+		current := s.GetBranch(queue[0])
+		queue = queue[1:]
+
+		onto := current.Parent
+		if opts.Remote != "" {
+			onto = opts.Remote + "/" + onto
+		}
+		if err := rebase(current.Name, onto, opts); err != nil {
+			restore(original, err, opts)
+			return result, err
+		}
+		result.Restacked = append(result.Restacked, current.Name)
+
+		for _, child := range current.Children {
+			queue = append(queue, child.Name)
+		}
+	}
+
+	if err := git.CheckoutBranch(original); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func checkClean() error {
+	inProgress, err := git.IsRebaseInProgress()
+	if err != nil {
+		return fmt.Errorf("failed to check rebase status: %w", err)
+	}
+	if inProgress {
+		return ErrRebaseInProgress
+	}
+
+	dirty, err := git.HasUncommittedChanges()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return ErrDirtyTree
+	}
+	return nil
+}
+
+// restore checks out the original branch after a failure, unless a rebase was left in progress
+func restore(original string, err error, opts Options) {
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) && !opts.AbortOnConflict {
+		return
+	}
+	git.CheckoutBranch(original)
+}
+
+func rebase(branch, onto string, opts Options) error {
+	if err := git.CheckoutBranch(branch); err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %w", branch, err)
+	}
+
+	_, err := stack.Restack(branch, onto)
+	if err == nil {
+		return nil
+	}
+
+	var conflictErr *git.RebaseConflictError
+	if !errors.As(err, &conflictErr) {
+		return fmt.Errorf("failed to rebase %s onto %s: %w", branch, onto, err)
+	}
+
+	files, _ := git.GetConflictedFiles()
+	if opts.AbortOnConflict {
+		git.AbortRebase()
+	}
+	return &ConflictError{Branch: branch, Onto: onto, Files: files}
+}
@@ -0,0 +1,73 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	metadataBlockStart = "<!-- stak-metadata "
+	metadataBlockEnd   = " -->"
+	// metadataVersion is bumped whenever the block's schema changes incompatibly
+	metadataVersion = 1
+)
+
+// CommentMetadata is the machine-readable stack description embedded in stack comments
+type CommentMetadata struct {
+	Version  int             `json:"version"`
+	Branches []CommentBranch `json:"branches"`
+}
+
+// CommentBranch describes a single branch in the embedded metadata
+type CommentBranch struct {
+	Name     string `json:"name"`
+	Parent   string `json:"parent"`
+	PRNumber int    `json:"pr,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+}
+
+// renderMetadataBlock builds a hidden HTML comment holding the parent/PR of every branch
+func renderMetadataBlock(ctx *StackContext, fullStack []string) string {
+	meta := CommentMetadata{Version: metadataVersion}
+	for _, branch := range fullStack {
+		metadata := ctx.Metadata(branch)
+		meta.Branches = append(meta.Branches, CommentBranch{
+			Name:     branch,
+			Parent:   metadata.Parent,
+			PRNumber: metadata.PRNumber,
+			Owner:    metadata.Owner,
+		})
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return ""
+	}
+	// "--" would terminate the HTML comment early; branch names may contain it
+	escaped := strings.ReplaceAll(string(data), "--", "\\u002d\\u002d")
+	return "\n" + metadataBlockStart + escaped + metadataBlockEnd + "\n"
+}
+
+// ParseCommentMetadata extracts the embedded metadata block from a stack comment body
+// Returns nil if the comment has no metadata block
+func ParseCommentMetadata(body string) (*CommentMetadata, error) {
+	start := strings.Index(body, metadataBlockStart)
+	if start == -1 {
+		return nil, nil
+	}
+	rest := body[start+len(metadataBlockStart):]
+	end := strings.Index(rest, metadataBlockEnd)
+	if end == -1 {
+		return nil, fmt.Errorf("unterminated stak-metadata block")
+	}
+
+	var meta CommentMetadata
+	if err := json.Unmarshal([]byte(rest[:end]), &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse stak-metadata block: %w", err)
+	}
+	if meta.Version > metadataVersion {
+		return nil, fmt.Errorf("stak-metadata version %d is newer than supported version %d; upgrade stak", meta.Version, metadataVersion)
+	}
+	return &meta, nil
+}
@@ -0,0 +1,112 @@
+package stack
+
+import (
+	"stacking/pkg/models"
+)
+
+// StackContext is a snapshot of all stack metadata, loaded once per command.
+// Tree queries against it are in-memory, so walking a large stack costs a single
+// round of git config reads instead of one per branch per lookup.
+// The snapshot does not see metadata written after it was loaded; reload it if needed.
+type StackContext struct {
+	Stack *models.Stack
+}
+
+// LoadContext reads all stack metadata into a new StackContext
+func LoadContext() (*StackContext, error) {
+	s, err := BuildStack()
+	if err != nil {
+		return nil, err
+	}
+	return &StackContext{Stack: s}, nil
+}
+
+// Metadata returns the metadata for a branch, like ReadBranchMetadata
+// Untracked branches (e.g. main) get empty metadata rather than nil
+func (c *StackContext) Metadata(name string) *models.Branch {
+	if b := c.Stack.GetBranch(name); b != nil {
+		return b
+	}
+	return models.NewBranch(name, "", 0)
+}
+
+// IsTracked reports whether a branch has stack metadata
+func (c *StackContext) IsTracked(name string) bool {
+	return c.Stack.GetBranch(name) != nil
+}
+
+// Parent returns the parent of a branch, or "" if it has none or is not tracked
+func (c *StackContext) Parent(name string) string {
+	b := c.Stack.GetBranch(name)
+	if b == nil {
+		return ""
+	}
+	return b.Parent
+}
+
+// PRNumber returns the PR number of a branch, or 0 if it has none
+func (c *StackContext) PRNumber(name string) int {
+	b := c.Stack.GetBranch(name)
+	if b == nil {
+		return 0
+	}
+	return b.PRNumber
+}
+
+// Children returns the direct children of a branch
+func (c *StackContext) Children(name string) []string {
+	b := c.Stack.GetBranch(name)
+	if b == nil {
+		return []string{}
+	}
+
+	children := make([]string, 0, len(b.Children))
+	for _, child := range b.Children {
+		children = append(children, child.Name)
+	}
+	return children
+}
+
+// Ancestors returns all ancestors of a branch from the base down, including the base branch
+func (c *StackContext) Ancestors(name string) []string {
+	ancestors := []string{}
+	visited := map[string]bool{name: true}
+
+	for current := c.Parent(name); current != ""; current = c.Parent(current) {
+		if visited[current] {
+			break // Cycle guard
+		}
+		visited[current] = true
+		ancestors = append([]string{current}, ancestors...) // Prepend to maintain order
+	}
+	return ancestors
+}
+
+// Descendants returns all descendants of a branch in BFS order
+func (c *StackContext) Descendants(name string) []string {
+	descendants := []string{}
+	queue := []string{name}
+	visited := make(map[string]bool)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		for _, child := range c.Children(current) {
+			descendants = append(descendants, child)
+			queue = append(queue, child)
+		}
+	}
+	return descendants
+}
+
+// FullStack returns the ancestors of a branch, the branch itself and its descendants
+func (c *StackContext) FullStack(name string) []string {
+	fullStack := append(c.Ancestors(name), name)
+	return append(fullStack, c.Descendants(name)...)
+}
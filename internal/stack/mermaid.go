@@ -0,0 +1,99 @@
+package stack
+
+import (
+	"fmt"
+	"strings"
+
+	"stacking/internal/github"
+)
+
+// generateMermaidVisualization renders the stack as a Mermaid flowchart, which
+// GitHub draws natively in PR comments. Nodes are colored by PR state and link
+// to their PRs; the current branch gets a thicker outline.
+func generateMermaidVisualization(ctx *StackContext, fullStack []string, currentBranch string) string {
+	var b strings.Builder
+	b.WriteString("## 📚 Stack\n\n")
+	b.WriteString("```mermaid\nflowchart TD\n")
+
+	ids := make(map[string]string)
+	for i, branch := range fullStack {
+		ids[branch] = fmt.Sprintf("n%d", i)
+	}
+
+	var edges, classes, clicks []string
+	baseWritten := false
+
+	for _, branch := range fullStack {
+		metadata := ctx.Metadata(branch)
+		id := ids[branch]
+
+		label := mermaidEscape(branch)
+		state := "nopr"
+		if metadata.PRNumber > 0 {
+			label += fmt.Sprintf("<br/>#%d", metadata.PRNumber)
+			state = "open"
+			if details, err := github.GetPRDetails(metadata.PRNumber); err == nil {
+				state = mermaidStateClass(details)
+				if details.URL != "" {
+					clicks = append(clicks, fmt.Sprintf("    click %s \"%s\" \"PR #%d\"", id, details.URL, metadata.PRNumber))
+				}
+			}
+		}
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", id, label)
+		classes = append(classes, fmt.Sprintf("    class %s %s", id, state))
+
+		// Link to the parent, drawing the base branch (e.g. main) as its own node
+		if parentID, ok := ids[metadata.Parent]; ok {
+			edges = append(edges, fmt.Sprintf("    %s --> %s", parentID, id))
+		} else if metadata.Parent != "" {
+			if !baseWritten {
+				fmt.Fprintf(&b, "    base([\"%s\"])\n", mermaidEscape(metadata.Parent))
+				classes = append(classes, "    class base trunk")
+				baseWritten = true
+			}
+			edges = append(edges, fmt.Sprintf("    base --> %s", id))
+		}
+	}
+
+	for _, lines := range [][]string{edges, clicks, classes} {
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	b.WriteString("    classDef trunk fill:#f6f8fa,stroke:#8c959f,color:#24292f\n")
+	b.WriteString("    classDef open fill:#dafbe1,stroke:#1a7f37,color:#24292f\n")
+	b.WriteString("    classDef draft fill:#eaeef2,stroke:#6e7781,color:#24292f\n")
+	b.WriteString("    classDef merged fill:#fbefff,stroke:#8250df,color:#24292f\n")
+	b.WriteString("    classDef closed fill:#ffebe9,stroke:#cf222e,color:#24292f\n")
+	b.WriteString("    classDef nopr fill:#ffffff,stroke:#d0d7de,color:#57606a,stroke-dasharray:4\n")
+	if id, ok := ids[currentBranch]; ok {
+		fmt.Fprintf(&b, "    style %s stroke-width:4px\n", id)
+	}
+	b.WriteString("```\n")
+
+	b.WriteString(renderMetadataBlock(ctx, fullStack))
+	b.WriteString(stackCommentFooter)
+	return b.String()
+}
+
+// mermaidStateClass maps a PR's state to one of the classDefs above
+func mermaidStateClass(details *github.PRDetails) string {
+	switch details.State {
+	case "MERGED":
+		return "merged"
+	case "CLOSED":
+		return "closed"
+	}
+	if details.IsDraft {
+		return "draft"
+	}
+	return "open"
+}
+
+// mermaidEscape makes a branch name safe inside a quoted Mermaid label
+func mermaidEscape(s string) string {
+	s = strings.ReplaceAll(s, "\"", "#quot;")
+	s = strings.ReplaceAll(s, "<", "#lt;")
+	return strings.ReplaceAll(s, ">", "#gt;")
+}
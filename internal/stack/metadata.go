@@ -2,10 +2,16 @@ package stack
 
 import (
 	"fmt"
+	"strings"
+
+	"stacking/internal/config"
 	"stacking/internal/git"
 	"stacking/pkg/models"
 )
 
+// stackCommentFooter marks stack comments so they can be found and updated later
+const stackCommentFooter = "\n---\n_This stack is managed by [stak](https://github.com/yourusername/stacking)_"
+
 // ReadBranchMetadata reads metadata for a single branch
 func ReadBranchMetadata(branch string) (*models.Branch, error) {
 	parent, err := git.GetBranchParent(branch)
@@ -18,23 +24,33 @@ func ReadBranchMetadata(branch string) (*models.Branch, error) {
 		return nil, fmt.Errorf("failed to read PR number for branch %s: %w", branch, err)
 	}
 
-	return models.NewBranch(branch, parent, prNumber), nil
+	owner, err := git.GetBranchOwner(branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read owner for branch %s: %w", branch, err)
+	}
+
+	b := models.NewBranch(branch, parent, prNumber)
+	b.Owner = owner
+	return b, nil
 }
 
 // WriteBranchMetadata writes metadata for a single branch
 func WriteBranchMetadata(branch, parent string, prNumber int) error {
-	if parent != "" {
-		if err := git.SetBranchParent(branch, parent); err != nil {
-			return fmt.Errorf("failed to set parent for branch %s: %w", branch, err)
-		}
+	// New branches join their parent's stack: its name, dependency and scope
+	if err := git.SetBranchMetadata(branch, parent, prNumber); err != nil {
+		return fmt.Errorf("failed to write metadata for branch %s: %w", branch, err)
 	}
+	return nil
+}
 
-	if prNumber > 0 {
-		if err := git.SetBranchPRNumber(branch, prNumber); err != nil {
-			return fmt.Errorf("failed to set PR number for branch %s: %w", branch, err)
-		}
+// SetBranchOwner records the GitHub login that owns a branch
+func SetBranchOwner(branch, owner string) error {
+	if owner == "" {
+		return nil
+	}
+	if err := git.SetBranchOwner(branch, owner); err != nil {
+		return fmt.Errorf("failed to set owner for branch %s: %w", branch, err)
 	}
-
 	return nil
 }
 
@@ -77,22 +93,13 @@ func GetParent(branch string) (string, error) {
 }
 
 // GetChildren returns all direct children of a branch
+// Loads all metadata; when querying many branches, use a StackContext instead
 func GetChildren(branch string) ([]string, error) {
-	stack, err := BuildStack()
+	ctx, err := LoadContext()
 	if err != nil {
 		return nil, err
 	}
-
-	b := stack.GetBranch(branch)
-	if b == nil {
-		return []string{}, nil
-	}
-
-	children := make([]string, 0, len(b.Children))
-	for _, child := range b.Children {
-		children = append(children, child.Name)
-	}
-	return children, nil
+	return ctx.Children(branch), nil
 }
 
 // GetAncestors returns all ancestor branches from the given branch to the base
@@ -118,31 +125,11 @@ func GetAncestors(branch string) ([]string, error) {
 
 // GetDescendants returns all descendant branches using BFS
 func GetDescendants(branch string) ([]string, error) {
-	descendants := []string{}
-	queue := []string{branch}
-	visited := make(map[string]bool)
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		if visited[current] {
-			continue
-		}
-		visited[current] = true
-
-		children, err := GetChildren(current)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, child := range children {
-			descendants = append(descendants, child)
-			queue = append(queue, child)
-		}
+	ctx, err := LoadContext()
+	if err != nil {
+		return nil, err
 	}
-
-	return descendants, nil
+	return ctx.Descendants(branch), nil
 }
 
 // GetAllStackBranches returns all branches that have stack metadata
@@ -160,32 +147,20 @@ func HasStackMetadata(branch string) (bool, error) {
 }
 
 // GenerateStackVisualization creates a markdown visualization of the stack
-func GenerateStackVisualization(currentBranch string) (string, error) {
-	// Get all ancestors
-	ancestors, err := GetAncestors(currentBranch)
-	if err != nil {
-		return "", fmt.Errorf("failed to get ancestors: %w", err)
-	}
+func GenerateStackVisualization(ctx *StackContext, currentBranch string) (string, error) {
+	// Build the full stack: ancestors + current + descendants
+	fullStack := ctx.FullStack(currentBranch)
 
-	// Get all descendants
-	descendants, err := GetDescendants(currentBranch)
-	if err != nil {
-		return "", fmt.Errorf("failed to get descendants: %w", err)
+	if config.GetString("comment-format", "list") == "mermaid" {
+		return generateMermaidVisualization(ctx, fullStack, currentBranch), nil
 	}
 
-	// Build the full stack: ancestors + current + descendants
-	fullStack := append(ancestors, currentBranch)
-	fullStack = append(fullStack, descendants...)
-
 	// Generate markdown
 	var result string
 	result += "## 📚 Stack\n\n"
 
 	for _, branch := range fullStack {
-		metadata, err := ReadBranchMetadata(branch)
-		if err != nil {
-			continue
-		}
+		metadata := ctx.Metadata(branch)
 
 		prefix := "- "
 		if branch == currentBranch {
@@ -209,7 +184,8 @@ func GenerateStackVisualization(currentBranch string) (string, error) {
 		}
 	}
 
-	result += "\n---\n_This stack is managed by [stak](https://github.com/yourusername/stacking)_"
+	result += renderMetadataBlock(ctx, fullStack)
+	result += stackCommentFooter
 
 	return result, nil
 }
@@ -247,10 +223,42 @@ func WouldCreateCycle(branch, proposedParent string) (bool, error) {
 	return false, nil
 }
 
+// defaultBaseBranches are the common trunk names, tried in order when no trunk is configured
+var defaultBaseBranches = []string{"main", "master", "develop", "development"}
+
+// BaseBranches returns the branch names treated as trunks, the configured trunk first
+func BaseBranches() []string {
+	trunk := config.GetString("trunk", "")
+	if trunk == "" {
+		return defaultBaseBranches
+	}
+
+	bases := []string{trunk}
+	for _, base := range defaultBaseBranches {
+		if base != trunk {
+			bases = append(bases, base)
+		}
+	}
+	return bases
+}
+
+// Trunk returns the configured trunk, or the first common base branch that exists locally
+func Trunk() (string, error) {
+	if trunk := config.GetString("trunk", ""); trunk != "" {
+		return trunk, nil
+	}
+	for _, base := range defaultBaseBranches {
+		exists, err := git.BranchExists(base)
+		if err == nil && exists {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("no base branch found (tried: %s). Set one with: git config stack.trunk <branch>", strings.Join(defaultBaseBranches, ", "))
+}
+
 // IsBaseBranch checks if a branch is a common base branch
 func IsBaseBranch(branch string) bool {
-	baseBranches := []string{"main", "master", "develop", "development"}
-	for _, base := range baseBranches {
+	for _, base := range BaseBranches() {
 		if branch == base {
 			return true
 		}
@@ -0,0 +1,205 @@
+package stack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"stacking/internal/git"
+	"stacking/pkg/models"
+)
+
+// StackInfo describes one stack: a tracked branch built on an untracked branch such as
+// trunk, and all of its descendants
+type StackInfo struct {
+	Name      string
+	Root      string
+	Base      string
+	Branches  []string // Root first, then descendants parents first
+	DependsOn string   // Stack name or "#<pr>" that must be merged first, if any
+	Scope     string   // Directory of a monorepo the stack works in, if tagged
+}
+
+// Stacks returns every stack in the repository
+func Stacks(ctx *StackContext) []StackInfo {
+	var stacks []StackInfo
+	for _, root := range ctx.Stack.Roots {
+		// A tracked branch without a parent acts as a trunk for the stacks on it
+		if root.Parent == "" {
+			for _, child := range root.Children {
+				stacks = append(stacks, ctx.stackInfo(child.Name))
+			}
+			continue
+		}
+		stacks = append(stacks, ctx.stackInfo(root.Name))
+	}
+	return stacks
+}
+
+// StackOf returns the stack a tracked branch belongs to
+func StackOf(ctx *StackContext, branch string) (StackInfo, bool) {
+	if !ctx.IsTracked(branch) || ctx.Parent(branch) == "" {
+		return StackInfo{}, false
+	}
+	root := branch
+	for parent := ctx.Parent(root); ctx.IsTracked(parent) && ctx.Parent(parent) != ""; parent = ctx.Parent(root) {
+		root = parent
+	}
+	return ctx.stackInfo(root), true
+}
+
+// FindStack finds a stack by its name, or by the name of one of its branches
+func FindStack(ctx *StackContext, ref string) (StackInfo, error) {
+	for _, info := range Stacks(ctx) {
+		if info.Name == ref {
+			return info, nil
+		}
+	}
+	if info, ok := StackOf(ctx, ref); ok {
+		return info, nil
+	}
+	return StackInfo{}, fmt.Errorf("no stack named %s, and no tracked branch %s. Run 'stak stacks' to list them", ref, ref)
+}
+
+// SetStackName names a stack, or removes its name if name is empty. The name is stored on
+// every branch of the stack, so it survives the root being merged or removed, and branches
+// added later pick it up from their stack.
+func SetStackName(ctx *StackContext, info StackInfo, name string) error {
+	if name != "" {
+		if strings.ContainsAny(name, " \t\n") {
+			return fmt.Errorf("stack names cannot contain spaces")
+		}
+		for _, other := range Stacks(ctx) {
+			if other.Name == name && other.Root != info.Root {
+				return fmt.Errorf("the stack of %s is already named %s", other.Root, name)
+			}
+		}
+	}
+
+	for _, branch := range info.Branches {
+		if err := git.SetBranchStackName(branch, name); err != nil {
+			return fmt.Errorf("failed to name stack: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetStackDependency records that a stack must wait for another stack, given by name, or a PR,
+// given as "#<number>", to be merged first. An empty dependency removes it. Like the name, it
+// is stored on every branch of the stack.
+func SetStackDependency(ctx *StackContext, info StackInfo, dependency string) error {
+	if strings.HasPrefix(dependency, "#") {
+		if n, err := strconv.Atoi(dependency[1:]); err != nil || n <= 0 {
+			return fmt.Errorf("invalid PR %q, expected #<number>", dependency)
+		}
+	} else if dependency != "" {
+		if dependency == info.Name {
+			return fmt.Errorf("a stack cannot depend on itself")
+		}
+		other, err := FindStack(ctx, dependency)
+		if err != nil {
+			return err
+		}
+		if other.Root == info.Root {
+			return fmt.Errorf("a stack cannot depend on itself")
+		}
+		if other.Name != dependency {
+			return fmt.Errorf("%s is not a stack name. Name the stack of %s with 'stak stacks name', or depend on a PR with #<number>", dependency, other.Root)
+		}
+		if info.Name != "" && other.DependsOn == info.Name {
+			return fmt.Errorf("%s already depends on %s", other.Name, info.Name)
+		}
+	}
+
+	for _, branch := range info.Branches {
+		if err := git.SetBranchDependsOn(branch, dependency); err != nil {
+			return fmt.Errorf("failed to set stack dependency: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetStackScope tags a stack with the directory it works in, given relative to the repository
+// root, or removes the tag if scope is empty
+func SetStackScope(info StackInfo, scope string) error {
+	for _, branch := range info.Branches {
+		if err := git.SetBranchScope(branch, scope); err != nil {
+			return fmt.Errorf("failed to set stack scope: %w", err)
+		}
+	}
+	return nil
+}
+
+// InScope checks if a stack is relevant to a directory of the repository. A tagged stack is
+// when its scope contains the directory or lies inside it. An untagged stack is when one of
+// its branches changes a file inside the directory.
+func InScope(ctx *StackContext, info StackInfo, dir string) bool {
+	if info.Scope != "" {
+		return pathWithin(info.Scope, dir) || pathWithin(dir, info.Scope)
+	}
+	for _, branch := range info.Branches {
+		files, err := git.GetChangedFiles(ctx.Parent(branch), branch)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if pathWithin(file, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pathWithin checks if a slash-separated path is dir or lies inside it
+func pathWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// FilterStacks returns a copy of the stack tree with only the stacks keep accepts. A tracked
+// branch without a parent stays as long as one of the stacks on it does.
+func FilterStacks(ctx *StackContext, keep func(info StackInfo) bool) *models.Stack {
+	filtered := &models.Stack{Branches: ctx.Stack.Branches}
+	for _, root := range ctx.Stack.Roots {
+		if root.Parent != "" {
+			if keep(ctx.stackInfo(root.Name)) {
+				filtered.Roots = append(filtered.Roots, root)
+			}
+			continue
+		}
+
+		trunk := *root
+		trunk.Children = nil
+		for _, child := range root.Children {
+			if keep(ctx.stackInfo(child.Name)) {
+				trunk.Children = append(trunk.Children, child)
+			}
+		}
+		if len(trunk.Children) > 0 {
+			filtered.Roots = append(filtered.Roots, &trunk)
+		}
+	}
+	return filtered
+}
+
+// stackInfo builds the StackInfo of the stack rooted at root. The stack's name, dependency and
+// scope are the ones on its lowest branch that has one.
+func (c *StackContext) stackInfo(root string) StackInfo {
+	info := StackInfo{
+		Root:     root,
+		Base:     c.Parent(root),
+		Branches: append([]string{root}, c.Descendants(root)...),
+	}
+	for _, branch := range info.Branches {
+		if info.Name == "" {
+			info.Name, _ = git.GetBranchStackName(branch)
+		}
+		if info.DependsOn == "" {
+			info.DependsOn, _ = git.GetBranchDependsOn(branch)
+		}
+		if info.Scope == "" {
+			info.Scope, _ = git.GetBranchScope(branch)
+		}
+	}
+	return info
+}
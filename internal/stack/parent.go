@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"fmt"
+	"stacking/internal/git"
+	"stacking/pkg/models"
+)
+
+// RecordParentSHA remembers the commit of parent (a branch or remote ref) that branch is now
+// based on, i.e. their merge base, so a later rewrite of the parent can be detected
+func RecordParentSHA(branch, parent string) error {
+	sha, err := git.GetMergeBase(branch, parent)
+	if err != nil {
+		return err
+	}
+	if err := git.SetBranchParentSHA(branch, sha); err != nil {
+		return fmt.Errorf("failed to record parent commit for branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// RewrittenParentBase returns the parent commit recorded for branch if parent no longer
+// contains it, meaning the parent was amended or rebased since branch was last restacked.
+// It returns "" if the parent only gained commits, or if nothing usable was recorded.
+func RewrittenParentBase(branch, parent string) string {
+	old, err := git.GetBranchParentSHA(branch)
+	if err != nil || old == "" {
+		return ""
+	}
+	tip, err := git.GetCommitSHA(parent)
+	if err != nil || tip == old || git.BranchContainsCommit(parent, old) {
+		return ""
+	}
+	// Once the branch itself was rebased elsewhere, the recorded commit says nothing about it
+	if !git.BranchContainsCommit(branch, old) {
+		return ""
+	}
+	return old
+}
+
+// RewrittenParents returns the tracked branches whose local parent was rewritten, parents
+// before children. Branch tips are read with a single git call, so only branches whose
+// parent moved cost an ancestry check.
+func RewrittenParents(ctx *StackContext) ([]*models.Branch, error) {
+	tips, err := git.GetBranchTips()
+	if err != nil {
+		return nil, err
+	}
+
+	var rewritten []*models.Branch
+	for _, branch := range GetAllBranchesInOrder(ctx.Stack) {
+		name := branch.Name
+		old, err := git.GetBranchParentSHA(name)
+		if err != nil || old == "" || branch.Parent == "" {
+			continue
+		}
+		// Trunk branches move by pulling, which never rewrites them
+		tip, ok := tips[branch.Parent]
+		if !ok || tip == old || !ctx.IsTracked(branch.Parent) {
+			continue
+		}
+		if !git.BranchContainsCommit(branch.Parent, old) && git.BranchContainsCommit(name, old) {
+			rewritten = append(rewritten, branch)
+		}
+	}
+	return rewritten, nil
+}
+
+// Restack rebases the checked-out branch onto onto, its parent or the parent's remote ref,
+// and records the new parent commit. If the parent was rewritten since the last restack,
+// only the commits after the old parent commit are replayed ("git rebase --onto"), so the
+// parent's previous version isn't carried along. It returns that old commit, or "".
+func Restack(branch, onto string) (string, error) {
+	old := RewrittenParentBase(branch, onto)
+	if err := git.RebaseOntoFrom(onto, old); err != nil {
+		return old, err
+	}
+	return old, RecordParentSHA(branch, onto)
+}
+
+// PredictRestack simulates restacking branch onto onto without touching the working tree.
+// parentRef is the parent as it is now (a branch or remote ref), which decides what would be
+// replayed the same way Restack does; onto may be any commit, including a simulated one.
+// It returns the commit branch would end up at, with conflict markers in its tree if the
+// restack conflicts, and the conflicted paths.
+func PredictRestack(branch, parentRef, onto string) (string, []string, error) {
+	upstream := RewrittenParentBase(branch, parentRef)
+	if upstream == "" {
+		if upto, count, err := git.SquashedPrefixOf(branch, onto); err == nil && count > 0 {
+			upstream = upto
+		}
+	}
+	if upstream == "" {
+		base, err := git.GetMergeBase(parentRef, branch)
+		if err != nil {
+			return "", nil, err
+		}
+		upstream = base
+	}
+
+	// merge-tree picks the merge base itself. Making upstream a parent of our side makes it
+	// the merge base, so only the commits after it are applied, like "git rebase --onto"
+	ours, err := git.CommitTree(onto+"^{tree}", onto, upstream)
+	if err != nil {
+		return "", nil, err
+	}
+	tree, conflicts, err := git.MergeTree(ours, branch)
+	if err != nil {
+		return "", nil, err
+	}
+	result, err := git.CommitTree(tree, onto)
+	if err != nil {
+		return "", nil, err
+	}
+	return result, conflicts, nil
+}
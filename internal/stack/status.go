@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"stacking/internal/git"
+)
+
+// Sync status recorded by 'stak daemon', readable by other commands and shell prompts:
+//
+//	[stack "status"]
+//	    needs-sync = feature-a is behind main; feature-b was merged (PR #12)
+//	    needs-sync-branches = feature-a; feature-b
+//	    checked-at = 1700000000
+//
+// needs-sync-branches names the branch each reason is about, in the same order. Branch names
+// never contain spaces, so they split on the separator safely.
+const (
+	needsSyncKey         = "stack.status.needs-sync"
+	needsSyncBranchesKey = "stack.status.needs-sync-branches"
+	checkedAtKey         = "stack.status.checked-at"
+	reasonSep            = "; "
+)
+
+// SyncStatus is the last recorded result of the background watcher
+type SyncStatus struct {
+	Reasons   []string  // Why the stack needs syncing, empty if it is up to date
+	Branches  []string  // The branch each reason is about, empty if recorded by an older stak
+	CheckedAt time.Time // When the watcher last checked, zero if never
+}
+
+// NeedsSync reports whether the watcher found anything that needs syncing
+func (s *SyncStatus) NeedsSync() bool {
+	return len(s.Reasons) > 0
+}
+
+// ReadSyncStatus returns the last recorded sync status
+func ReadSyncStatus() (*SyncStatus, error) {
+	status := &SyncStatus{}
+
+	reasons, err := git.GetConfig(needsSyncKey)
+	if err != nil {
+		return nil, err
+	}
+	if reasons != "" {
+		status.Reasons = strings.Split(reasons, reasonSep)
+	}
+	branches, err := git.GetConfig(needsSyncBranchesKey)
+	if err != nil {
+		return nil, err
+	}
+	if branches != "" {
+		status.Branches = strings.Split(branches, reasonSep)
+	}
+
+	checkedAt, err := git.GetConfig(checkedAtKey)
+	if err != nil {
+		return nil, err
+	}
+	if secs, err := strconv.ParseInt(checkedAt, 10, 64); err == nil {
+		status.CheckedAt = time.Unix(secs, 0)
+	}
+
+	return status, nil
+}
+
+// RecordSyncStatus stores the watcher's findings, a reason and the branch it is about for
+// each, clearing the state if there are none
+func RecordSyncStatus(reasons, branches []string) error {
+	if err := git.SetConfig(checkedAtKey, strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		return err
+	}
+	return writeSyncReasons(reasons, branches)
+}
+
+// ClearSyncStatus drops the reasons about the given branches, e.g. after sync handled them,
+// and keeps those about other branches. Reasons recorded without their branches are all
+// dropped, as they can't be told apart.
+func ClearSyncStatus(synced []string) error {
+	status, err := ReadSyncStatus()
+	if err != nil {
+		return err
+	}
+	if len(status.Branches) != len(status.Reasons) {
+		return writeSyncReasons(nil, nil)
+	}
+
+	done := make(map[string]bool, len(synced))
+	for _, branch := range synced {
+		done[branch] = true
+	}
+	var reasons, branches []string
+	for i, reason := range status.Reasons {
+		if !done[status.Branches[i]] {
+			reasons = append(reasons, reason)
+			branches = append(branches, status.Branches[i])
+		}
+	}
+	return writeSyncReasons(reasons, branches)
+}
+
+// writeSyncReasons stores the reasons the stack needs syncing, or clears them if there are none
+func writeSyncReasons(reasons, branches []string) error {
+	if len(reasons) == 0 {
+		if err := git.UnsetConfig(needsSyncKey); err != nil {
+			return err
+		}
+		return git.UnsetConfig(needsSyncBranchesKey)
+	}
+	if err := git.SetConfig(needsSyncKey, strings.Join(reasons, reasonSep)); err != nil {
+		return err
+	}
+	return git.SetConfig(needsSyncBranchesKey, strings.Join(branches, reasonSep))
+}
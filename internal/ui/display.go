@@ -8,20 +8,21 @@ import (
 	"stacking/internal/stack"
 )
 
-// DisplayStack displays the entire stack in a tree format
-func DisplayStack(s *models.Stack, currentBranch string) {
+// DisplayStack displays the entire stack in a tree format. annotate, if set, returns what to
+// show after each branch.
+func DisplayStack(s *models.Stack, currentBranch string, annotate func(*models.Branch) string) {
 	if len(s.Roots) == 0 {
 		fmt.Println("No stacked branches found.")
 		return
 	}
 
 	for _, root := range s.Roots {
-		displayBranch(root, "", true, currentBranch)
+		displayBranch(root, "", true, currentBranch, annotate)
 	}
 }
 
 // displayBranch recursively displays a branch and its children
-func displayBranch(branch *models.Branch, prefix string, isLast bool, currentBranch string) {
+func displayBranch(branch *models.Branch, prefix string, isLast bool, currentBranch string, annotate func(*models.Branch) string) {
 	// Determine the tree characters
 	var connector string
 	if prefix == "" {
@@ -40,6 +41,11 @@ func displayBranch(branch *models.Branch, prefix string, isLast bool, currentBra
 	if branch.Name == currentBranch {
 		branchDisplay += " *"
 	}
+	if annotate != nil {
+		if annotation := annotate(branch); annotation != "" {
+			branchDisplay += "  " + annotation
+		}
+	}
 
 	fmt.Println(prefix + connector + branchDisplay)
 
@@ -57,7 +63,7 @@ func displayBranch(branch *models.Branch, prefix string, isLast bool, currentBra
 	// Display children
 	for i, child := range branch.Children {
 		isLastChild := i == len(branch.Children)-1
-		displayBranch(child, childPrefix, isLastChild, currentBranch)
+		displayBranch(child, childPrefix, isLastChild, currentBranch, annotate)
 	}
 }
 
@@ -0,0 +1,64 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// PublicKey is the minisign public key releases are signed with, the last line of
+// minisign.pub. Release builds set it with -ldflags "-X stacking/internal/update.PublicKey=...";
+// without it, stak can't verify a release, so upgrade only points to it.
+var PublicKey string
+
+// signatureAsset is the minisign signature of checksumsAsset, made by release.sh and the
+// release workflow
+const signatureAsset = checksumsAsset + ".minisig"
+
+// CanVerify reports whether this build has a key to verify releases with
+func CanVerify() bool {
+	return PublicKey != ""
+}
+
+// verifySignature checks a minisign signature of data, made with "minisign -S -l", against
+// the base64 public key. The trusted comment is checked as well, as minisign does.
+func verifySignature(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != 2+8+ed25519.PublicKeySize || string(key[:2]) != "Ed" {
+		return fmt.Errorf("invalid public key")
+	}
+	keyID, pub := key[2:10], ed25519.PublicKey(key[10:])
+
+	// An untrusted comment, the signature, a trusted comment and the signature of both
+	lines := strings.Split(strings.TrimRight(string(signature), "\r\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("invalid signature file")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature file")
+	}
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		return fmt.Errorf("the signature is prehashed, which stak can't check; sign with minisign -S -l")
+	default:
+		return fmt.Errorf("unknown signature algorithm %q", sig[:2])
+	}
+	if !bytes.Equal(sig[2:10], keyID) {
+		return fmt.Errorf("the signature was made with another key")
+	}
+	if !ed25519.Verify(pub, data, sig[10:]) {
+		return fmt.Errorf("the signature doesn't match")
+	}
+
+	comment := strings.TrimSuffix(strings.TrimPrefix(lines[2], "trusted comment: "), "\r")
+	signed := append(append([]byte{}, sig[10:]...), comment...)
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(pub, signed, global) {
+		return fmt.Errorf("the trusted comment's signature doesn't match")
+	}
+	return nil
+}
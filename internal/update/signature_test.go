@@ -0,0 +1,66 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// minisign builds a public key and the signature file "minisign -S -l" would make for data
+func minisign(t *testing.T, data []byte, alg, comment string) (string, []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte("8bytesID")
+	publicKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+
+	sig := ed25519.Sign(priv, data)
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+	file := "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(alg), keyID...), sig...)) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+	return publicKey, []byte(file)
+}
+
+func TestVerifySignature(t *testing.T) {
+	data := []byte("0123abcd  stak-linux-amd64\n")
+	publicKey, signature := minisign(t, data, "Ed", "timestamp:1760000000\tfile:checksums.txt")
+	otherKey, _ := minisign(t, data, "Ed", "")
+	_, prehashed := minisign(t, data, "ED", "")
+
+	tests := []struct {
+		name      string
+		data      []byte
+		signature []byte
+		key       string
+		wantErr   bool
+	}{
+		{"valid", data, signature, publicKey, false},
+		{"tampered data", []byte("ffff  stak-linux-amd64\n"), signature, publicKey, true},
+		{"other key", data, signature, otherKey, true},
+		{"prehashed", data, prehashed, publicKey, true},
+		{"tampered trusted comment", data, []byte(replaceLine(string(signature), 2, "trusted comment: timestamp:0")), publicKey, true},
+		{"truncated", data, signature[:40], publicKey, true},
+		{"invalid key", data, signature, "not a key", true},
+	}
+	for _, tt := range tests {
+		err := verifySignature(tt.data, tt.signature, tt.key)
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: verifySignature succeeded, want an error", tt.name)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("%s: verifySignature failed: %v", tt.name, err)
+		}
+	}
+}
+
+// replaceLine replaces line i of a signature file
+func replaceLine(file string, i int, line string) string {
+	lines := strings.Split(file, "\n")
+	lines[i] = line
+	return strings.Join(lines, "\n")
+}
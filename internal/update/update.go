@@ -0,0 +1,294 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	releasesURL = "https://api.github.com/repos/adi0705/stak/releases"
+	// checksumsAsset is published with every release by release.sh and the release workflow
+	checksumsAsset = "checksums.txt"
+)
+
+// Channels a user can follow
+const (
+	ChannelStable = "stable" // Full releases only
+	ChannelBeta   = "beta"   // Also pre-releases
+)
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// Release is a published stak release
+type Release struct {
+	TagName    string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Draft      bool    `json:"draft"`
+	HTMLURL    string  `json:"html_url"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Asset is a downloadable file attached to a release
+type Asset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// LatestRelease returns the newest release on the given channel
+func LatestRelease(channel string) (*Release, error) {
+	switch channel {
+	case ChannelStable:
+		var release Release
+		if err := getJSON(releasesURL+"/latest", &release); err != nil {
+			return nil, err
+		}
+		return &release, nil
+	case ChannelBeta:
+		var releases []Release
+		if err := getJSON(releasesURL+"?per_page=20", &releases); err != nil {
+			return nil, err
+		}
+		// Releases are returned newest first
+		for i := range releases {
+			if !releases[i].Draft {
+				return &releases[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no releases found")
+	default:
+		return nil, fmt.Errorf("unknown release channel %q (expected %s or %s)", channel, ChannelStable, ChannelBeta)
+	}
+}
+
+// AssetName returns the release asset built for this platform, e.g. stak-darwin-arm64
+func AssetName() string {
+	name := fmt.Sprintf("stak-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Asset returns the named asset of a release, or nil if it has none
+func (r *Release) Asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// IsNewer reports whether version latest is newer than current
+// Development builds ("dev") are never considered out of date
+func IsNewer(latest, current string) bool {
+	if current == "dev" || current == "" {
+		return false
+	}
+	return compareVersions(latest, current) > 0
+}
+
+// compareVersions compares vX.Y.Z[-pre] versions, returning -1, 0 or 1
+func compareVersions(a, b string) int {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+
+	for i := 0; i < 3; i++ {
+		if aCore[i] != bCore[i] {
+			if aCore[i] > bCore[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+
+	// A release is newer than any of its pre-releases
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre > bPre:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// splitVersion parses "v1.2.3-beta.1" into [1 2 3] and "beta.1"
+func splitVersion(v string) ([3]int, string) {
+	var core [3]int
+	v = strings.TrimPrefix(v, "v")
+	pre := ""
+	if i := strings.IndexAny(v, "-+"); i != -1 {
+		pre = v[i+1:]
+		v = v[:i]
+	}
+	for i, part := range strings.SplitN(v, ".", 3) {
+		core[i], _ = strconv.Atoi(part)
+	}
+	return core, pre
+}
+
+// Download fetches an asset and verifies it against the release's checksums.txt, whose
+// minisign signature is first checked against PublicKey. A release on GitHub could be
+// replaced, checksums and all, but not the key built into stak.
+func Download(release *Release, assetName string) ([]byte, error) {
+	if !CanVerify() {
+		return nil, fmt.Errorf("this build of stak has no key to verify releases with")
+	}
+	asset := release.Asset(assetName)
+	if asset == nil {
+		return nil, fmt.Errorf("release %s has no binary for this platform (%s)", release.TagName, assetName)
+	}
+	checksums := release.Asset(checksumsAsset)
+	signature := release.Asset(signatureAsset)
+	if checksums == nil || signature == nil {
+		return nil, fmt.Errorf("release %s has no signed %s, refusing to install an unverified binary", release.TagName, checksumsAsset)
+	}
+
+	sums, err := getBytes(checksums.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := getBytes(signature.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(sums, sig, PublicKey); err != nil {
+		return nil, fmt.Errorf("%s of release %s failed verification, refusing to install it: %w", checksumsAsset, release.TagName, err)
+	}
+	expected, err := findChecksum(sums, assetName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := getBytes(asset.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", assetName, expected, actual)
+	}
+
+	return data, nil
+}
+
+// findChecksum looks up a file's SHA-256 in shasum output ("<hex>  <name>")
+func findChecksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// Executable returns the resolved path of the running stak binary
+func Executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate running binary: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", exe, err)
+	}
+	return resolved, nil
+}
+
+// IsHomebrewInstall reports whether the binary at path is managed by Homebrew
+func IsHomebrewInstall(path string) bool {
+	return strings.Contains(path, "/Cellar/")
+}
+
+// ReplaceExecutable atomically replaces the binary at path with data
+func ReplaceExecutable(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".stak-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("failed to write to %s: %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+
+	// Windows can't overwrite a running executable, but it can rename it
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move old binary aside: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+func getJSON(url string, v interface{}) error {
+	data, err := getBytes(url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse response from %s: %w", url, err)
+	}
+	return nil
+}
+
+func getBytes(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(url, "https://api.github.com/") {
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	return data, nil
+}
@@ -1,10 +1,13 @@
 package models
 
+import "sort"
+
 // Branch represents a branch in the stack
 type Branch struct {
 	Name     string
 	Parent   string
 	PRNumber int
+	Owner    string // GitHub login of the stack owner, empty if unknown
 	Children []*Branch
 }
 
@@ -58,11 +61,19 @@ func (s *Stack) GetBranch(name string) *Branch {
 }
 
 // BuildRelationships builds parent-child relationships between branches
+// Roots and children are ordered by name so traversals are deterministic
 func (s *Stack) BuildRelationships() {
+	names := make([]string, 0, len(s.Branches))
+	for name := range s.Branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	// First, identify roots
 	s.Roots = make([]*Branch, 0)
 
-	for _, branch := range s.Branches {
+	for _, name := range names {
+		branch := s.Branches[name]
 		if branch.Parent == "" {
 			s.Roots = append(s.Roots, branch)
 			continue
@@ -24,28 +24,36 @@ echo "Creating release $VERSION"
 RELEASE_DIR="release/$VERSION"
 mkdir -p "$RELEASE_DIR"
 
+# The key stak upgrade verifies releases with; without it, the binaries only point to new releases
+LDFLAGS="-X main.version=$VERSION"
+if [ -f minisign.pub ]; then
+    LDFLAGS="$LDFLAGS -X stacking/internal/update.PublicKey=$(tail -n 1 minisign.pub)"
+else
+    echo "Warning: no minisign.pub, so stak upgrade in these binaries won't install releases"
+fi
+
 # Build for different platforms
 echo "Building binaries..."
 
 # macOS (Intel)
 echo "  - macOS (Intel)"
-GOOS=darwin GOARCH=amd64 go build -o "$RELEASE_DIR/stak-darwin-amd64" -ldflags "-X main.version=$VERSION"
+GOOS=darwin GOARCH=amd64 go build -o "$RELEASE_DIR/stak-darwin-amd64" -ldflags "$LDFLAGS"
 
 # macOS (Apple Silicon)
 echo "  - macOS (Apple Silicon)"
-GOOS=darwin GOARCH=arm64 go build -o "$RELEASE_DIR/stak-darwin-arm64" -ldflags "-X main.version=$VERSION"
+GOOS=darwin GOARCH=arm64 go build -o "$RELEASE_DIR/stak-darwin-arm64" -ldflags "$LDFLAGS"
 
 # Linux (amd64)
 echo "  - Linux (amd64)"
-GOOS=linux GOARCH=amd64 go build -o "$RELEASE_DIR/stak-linux-amd64" -ldflags "-X main.version=$VERSION"
+GOOS=linux GOARCH=amd64 go build -o "$RELEASE_DIR/stak-linux-amd64" -ldflags "$LDFLAGS"
 
 # Linux (arm64)
 echo "  - Linux (arm64)"
-GOOS=linux GOARCH=arm64 go build -o "$RELEASE_DIR/stak-linux-arm64" -ldflags "-X main.version=$VERSION"
+GOOS=linux GOARCH=arm64 go build -o "$RELEASE_DIR/stak-linux-arm64" -ldflags "$LDFLAGS"
 
 # Windows (amd64)
 echo "  - Windows (amd64)"
-GOOS=windows GOARCH=amd64 go build -o "$RELEASE_DIR/stak-windows-amd64.exe" -ldflags "-X main.version=$VERSION"
+GOOS=windows GOARCH=amd64 go build -o "$RELEASE_DIR/stak-windows-amd64.exe" -ldflags "$LDFLAGS"
 
 # Create checksums
 echo "Generating checksums..."
@@ -53,6 +61,12 @@ cd "$RELEASE_DIR"
 shasum -a 256 stak-* > checksums.txt
 cd ../..
 
+# Sign the checksums in minisign's legacy format, the one stak upgrade checks
+if [ -f minisign.pub ]; then
+    echo "Signing checksums..."
+    minisign -S -l -s "${MINISIGN_KEY:-$HOME/.minisign/minisign.key}" -m "$RELEASE_DIR/checksums.txt"
+fi
+
 echo "✓ Binaries built in $RELEASE_DIR"
 echo ""
 echo "Next steps:"